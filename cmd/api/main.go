@@ -5,14 +5,23 @@ import (
 	"fmt"
 	"log"
 	"lostnfound-api/internal/config"
+	"lostnfound-api/internal/gazetteer"
 	"lostnfound-api/internal/handler"
+	"lostnfound-api/internal/notify"
+	"lostnfound-api/internal/payments"
+	"lostnfound-api/internal/realtime"
 	"lostnfound-api/internal/repository"
 	"lostnfound-api/internal/router"
+	"lostnfound-api/internal/scheduler"
 	"lostnfound-api/internal/service"
+	"lostnfound-api/internal/sms"
+	"lostnfound-api/internal/ussd"
 	"lostnfound-api/internal/util/storage"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
@@ -26,35 +35,344 @@ func main() {
 	}
 
 	// Initialize config
-	cfg, err := config.Load()
+	cfg, err := config.Load(".")
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
 
 	// Set up database
-	db, err := repository.SetupDatabase(cfg)
+	db, err := repository.SetupDatabase(&cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
 	// Initialize Google Cloud Storage
-	gcs, err := storage.NewGoogleCloudStorage(cfg)
+	gcs, err := storage.NewGoogleCloudStorage(&cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize Google Cloud Storage: %v", err)
 	}
 	defer gcs.Close()
 
+	// Load the Kenyan gazetteer
+	gaz, err := gazetteer.Load()
+	if err != nil {
+		log.Fatalf("Failed to load gazetteer: %v", err)
+	}
+
 	// Initialize repositories
 	itemRepo := repository.NewItemRepository(db)
+	imageRepo := repository.NewImageRepository(db)
+	categoryRepo := repository.NewCategoryRepository(db)
+	tagRepo := repository.NewTagRepository(db)
+	assetRepo := repository.NewAssetRepository(db)
+	recoveryTagRepo := repository.NewRecoveryTagRepository(db)
+	claimRepo := repository.NewClaimRepository(db)
+	conversationRepo := repository.NewConversationRepository(db)
+	userRepo := repository.NewUserRepository(db)
+	savedSearchRepo := repository.NewSavedSearchRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	ussdSessionRepo := repository.NewUSSDSessionRepository(db)
+	webhookRepo := repository.NewWebhookRepository(db)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	custodyRepo := repository.NewCustodyRepository(db)
+	handoverRepo := repository.NewHandoverRepository(db)
+	importRepo := repository.NewImportRepository(db)
+	exportRepo := repository.NewExportRepository(db)
+	escrowRepo := repository.NewEscrowRepository(db)
+	riskRepo := repository.NewRiskRepository(db)
+	moderationRepo := repository.NewModerationRepository(db)
 
 	// Initialize services
-	itemService := service.NewItemService(itemRepo)
+	locationService := service.NewLocationService(gaz)
+	categoryService := service.NewCategoryService(categoryRepo)
+	if err := categoryService.SeedDefaults(); err != nil {
+		log.Fatalf("Failed to seed categories: %v", err)
+	}
+	tagService := service.NewTagService(tagRepo, itemRepo)
+	itemService := service.NewItemService(itemRepo, locationService, categoryService, tagService)
+	storageService := service.NewStorageService(gcs, imageRepo)
+	matchService := service.NewMatchService(itemRepo)
+	trashService := service.NewTrashService(itemRepo, storageService, cfg.ItemRetentionDays)
+	assetHashSecret := cfg.AssetHashSecret
+	if assetHashSecret == "" {
+		log.Println("ASSET_HASH_SECRET not set, falling back to JWT_SECRET for asset identifier hashes")
+		assetHashSecret = cfg.JWTSecret
+	}
+	assetService := service.NewAssetService(assetRepo, assetHashSecret)
+	itemService.AfterCreate(assetService.CheckFoundItem)
+	itemService.AfterUpdate(assetService.CheckFoundItem)
+	recoveryTagService := service.NewRecoveryTagService(recoveryTagRepo, assetService, itemService, categoryService, cfg.PublicBaseURL)
+	claimService := service.NewClaimService(claimRepo, itemRepo)
+	riskService := service.NewRiskService(riskRepo, userRepo)
+	itemService.BeforeCreate(riskService.CheckItem)
+	itemService.AfterCreate(riskService.ItemChanged)
+	itemService.AfterUpdate(riskService.ItemChanged)
+	claimService.BeforeCreate(riskService.CheckClaim)
+	claimService.AfterChange(riskService.ClaimChanged)
+	organizationService := service.NewOrganizationService(organizationRepo, userRepo, itemService, locationService)
+	custodyService := service.NewCustodyService(custodyRepo, organizationRepo, organizationService, itemService, claimService, userRepo, storageService, locationService)
+	handoverService := service.NewHandoverService(handoverRepo, claimService, itemService, custodyService, cfg.HandoverCodeHours, cfg.PublicBaseURL)
+	claimService.AfterChange(handoverService.ClaimChanged)
+	importService := service.NewImportService(importRepo, organizationRepo, organizationService, itemService, categoryService, storageService)
+	exportService := service.NewExportService(exportRepo, itemService, organizationService, storageService)
+	messageService := service.NewMessageService(conversationRepo, itemRepo, userRepo, claimService, storageService)
+	moderationService := service.NewModerationService(moderationRepo, itemRepo)
+	itemService.BeforeCreate(moderationService.CheckItem)
+	messageService.AfterReport(moderationService.MessageReported)
+	duplicateService := service.NewDuplicateService(itemRepo, escrowRepo)
+	itemService.FindDuplicatesWith(duplicateService.Find)
+
+	// Real-time events go through Redis when configured so every instance sees them
+	var broker realtime.Broker = realtime.NewMemoryBroker()
+	if cfg.RedisURL != "" {
+		redisBroker, err := realtime.NewRedisBroker(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize realtime broker: %v", err)
+		}
+		broker = redisBroker
+	}
+	defer broker.Close()
+
+	// SMS goes through Africa's Talking when an API key is set
+	var smsProvider sms.Provider = sms.LogProvider{}
+	if cfg.SMSAPIKey != "" {
+		smsProvider = sms.NewAfricasTalking(cfg.SMSAPIURL, cfg.SMSUsername, cfg.SMSAPIKey, cfg.SMSSenderID)
+	}
+	smsService := service.NewSMSService(smsProvider, userRepo, itemService, categoryService, locationService)
+
+	// Rewards are held in escrow through M-Pesa when Daraja credentials are set
+	var paymentProvider payments.Provider
+	if cfg.MPesaConsumerKey != "" {
+		callbackBase := strings.TrimRight(cfg.PublicBaseURL, "/") + "/api/v1/payments/mpesa"
+		callbackQuery := "?token=" + url.QueryEscape(cfg.MPesaCallbackToken)
+		paymentProvider = payments.NewMPesa(payments.MPesaConfig{
+			BaseURL:               cfg.MPesaAPIURL,
+			ConsumerKey:           cfg.MPesaConsumerKey,
+			ConsumerSecret:        cfg.MPesaConsumerSecret,
+			Shortcode:             cfg.MPesaShortcode,
+			Passkey:               cfg.MPesaPasskey,
+			PayoutShortcode:       cfg.MPesaB2CShortcode,
+			InitiatorName:         cfg.MPesaInitiatorName,
+			SecurityCredential:    cfg.MPesaSecurityCredential,
+			CollectionCallbackURL: callbackBase + "/collections" + callbackQuery,
+			PayoutResultURL:       callbackBase + "/payouts" + callbackQuery,
+			PayoutTimeoutURL:      callbackBase + "/payouts/timeout" + callbackQuery,
+		})
+	}
+	escrowService := service.NewEscrowService(escrowRepo, paymentProvider, itemService, claimService, userRepo, cfg.RewardEscrowDays)
+	handoverService.AfterRedeem(escrowService.ItemHandedOver)
+
+	// USSD sessions live in Redis when configured so any instance can serve the next step
+	var ussdStore ussd.Store = ussdSessionRepo
+	if cfg.RedisURL != "" {
+		redisStore, err := ussd.NewRedisStore(cfg.RedisURL)
+		if err != nil {
+			log.Fatalf("Failed to initialize USSD session store: %v", err)
+		}
+		defer redisStore.Close()
+		ussdStore = redisStore
+	}
+	ussdService := service.NewUSSDService(ussdStore, smsProvider, userRepo, itemService, categoryService)
+
+	// Channels without a provider are stand-ins that write to files
+	channels := []notify.Channel{notify.InAppChannel{}}
+	for _, name := range []string{notify.ChannelEmail, notify.ChannelSMS, notify.ChannelPush} {
+		if name == notify.ChannelSMS && cfg.SMSAPIKey != "" {
+			channels = append(channels, notify.NewSMSChannel(smsProvider))
+			continue
+		}
+		channel, err := notify.NewFileChannel(name, cfg.NotificationDir)
+		if err != nil {
+			log.Fatalf("Failed to initialize %s notifications: %v", name, err)
+		}
+		channels = append(channels, channel)
+	}
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, channels...)
+
+	eventService := service.NewEventService(broker, notificationService, matchService, itemService)
+	itemService.AfterCreate(eventService.ItemCreated)
+	claimService.AfterChange(eventService.ClaimChanged)
+	messageService.AfterSend(eventService.MessageSent)
+	assetService.AfterAlert(eventService.AssetFound)
+	recoveryTagService.AfterReport(eventService.RecoveryReported)
+	moderationService.AfterAction(eventService.ModerationActioned)
+	expiryService := service.NewExpiryService(itemRepo, categoryRepo, organizationRepo, eventService, cfg.ItemReminderDays, cfg.ItemArchiveDays, cfg.CustodyDisposalDays)
+	savedSearchService := service.NewSavedSearchService(savedSearchRepo, itemService, eventService, cfg.SavedSearchDays)
+	itemService.AfterCreate(savedSearchService.Enqueue)
+	itemService.AfterUpdate(savedSearchService.Enqueue)
+	webhookService := service.NewWebhookService(webhookRepo, itemService)
+	itemService.AfterCreate(webhookService.ItemCreated)
+	itemService.AfterUpdate(webhookService.ItemUpdated)
+	claimService.AfterChange(webhookService.ClaimChanged)
+	eventService.AfterMatch(webhookService.MatchFound)
 
 	// Initialize handlers
 	itemHandler := handler.NewItemHandler(itemService)
+	trashHandler := handler.NewTrashHandler(trashService)
+	locationHandler := handler.NewLocationHandler(locationService)
+	matchHandler := handler.NewMatchHandler(matchService)
+	categoryHandler := handler.NewCategoryHandler(categoryService)
+	tagHandler := handler.NewTagHandler(tagService, itemService)
+	assetHandler := handler.NewAssetHandler(assetService)
+	recoveryTagHandler := handler.NewRecoveryTagHandler(recoveryTagService)
+	claimHandler := handler.NewClaimHandler(claimService)
+	messageHandler := handler.NewMessageHandler(messageService)
+	eventHandler := handler.NewEventHandler(eventService)
+	savedSearchHandler := handler.NewSavedSearchHandler(savedSearchService)
+	notificationHandler := handler.NewNotificationHandler(notificationService)
+	smsHandler := handler.NewSMSHandler(smsService, cfg.SMSWebhookToken)
+	ussdHandler := handler.NewUSSDHandler(ussdService, cfg.USSDWebhookToken)
+	webhookHandler := handler.NewWebhookHandler(webhookService)
+	organizationHandler := handler.NewOrganizationHandler(organizationService)
+	custodyHandler := handler.NewCustodyHandler(custodyService)
+	handoverHandler := handler.NewHandoverHandler(handoverService)
+	importHandler := handler.NewImportHandler(importService)
+	exportHandler := handler.NewExportHandler(exportService)
+	escrowHandler := handler.NewEscrowHandler(escrowService, cfg.MPesaCallbackToken)
+	riskHandler := handler.NewRiskHandler(riskService)
+	moderationHandler := handler.NewModerationHandler(moderationService)
+	duplicateHandler := handler.NewDuplicateHandler(duplicateService)
+	expiryHandler := handler.NewExpiryHandler(expiryService)
 
 	// Setup router
-	r := router.SetupRouter(cfg, itemHandler)
+	r := router.SetupRouter(
+		&cfg,
+		itemHandler,
+		trashHandler,
+		locationHandler,
+		matchHandler,
+		categoryHandler,
+		tagHandler,
+		assetHandler,
+		recoveryTagHandler,
+		claimHandler,
+		messageHandler,
+		eventHandler,
+		savedSearchHandler,
+		notificationHandler,
+		smsHandler,
+		ussdHandler,
+		webhookHandler,
+		organizationHandler,
+		custodyHandler,
+		handoverHandler,
+		importHandler,
+		exportHandler,
+		escrowHandler,
+		riskHandler,
+		moderationHandler,
+		duplicateHandler,
+		expiryHandler,
+	)
+
+	// Start background jobs
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	jobs := scheduler.NewScheduler()
+	jobs.Register(scheduler.Job{
+		Name:     "purge-deleted-items",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			purged, err := trashService.PurgeExpired(ctx)
+			if purged > 0 {
+				log.Printf("Purged %d deleted items", purged)
+			}
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "saved-search-digests",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			sent, err := savedSearchService.SendDigests(ctx)
+			if sent > 0 {
+				log.Printf("Sent %d saved search digests", sent)
+			}
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "purge-expired-saved-searches",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := savedSearchService.PurgeExpired()
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "purge-old-notifications",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := notificationService.PurgeOld()
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "purge-expired-ussd-sessions",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := ussdSessionRepo.DeleteExpired()
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "purge-old-webhook-deliveries",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) error {
+			_, err := webhookService.PurgeOld()
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "purge-expired-exports",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			purged, err := exportService.PurgeExpired(ctx)
+			if purged > 0 {
+				log.Printf("Purged %d expired exports", purged)
+			}
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "item-reminders",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			sent, err := expiryService.SendReminders(ctx)
+			if sent > 0 {
+				log.Printf("Sent %d item reminders", sent)
+			}
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "archive-stale-items",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			archived, err := expiryService.ArchiveStale(ctx)
+			if archived > 0 {
+				log.Printf("Archived %d unconfirmed items", archived)
+			}
+			return err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "custody-disposal-warnings",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) error {
+			warned, err := expiryService.WarnDisposals(ctx)
+			if warned > 0 {
+				log.Printf("Warned organisations about %d items nearing their disposal deadline", warned)
+			}
+			return err
+		},
+	})
+	jobs.Start(jobCtx)
+	go savedSearchService.Run(jobCtx)
+	go notificationService.Run(jobCtx)
+	go webhookService.Run(jobCtx)
+	go importService.Run(jobCtx)
+	go exportService.Run(jobCtx)
+	go escrowService.Run(jobCtx)
 
 	// Start server
 	srv := &http.Server{
@@ -76,6 +394,10 @@ func main() {
 	<-quit
 	log.Println("Shutting down server...")
 
+	// Stop background jobs
+	stopJobs()
+	jobs.Wait()
+
 	// The context is used to inform the server it has 5 seconds to finish
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
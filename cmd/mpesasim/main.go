@@ -0,0 +1,30 @@
+// Command mpesasim runs a simulated M-Pesa Daraja API for local development.
+//
+// Point the API at it with MPESA_API_URL=http://localhost:8091 and any
+// MPESA_CONSUMER_KEY. Reward payments and payouts are answered after -delay
+// by posting to the API's callbacks: numbers ending in 00 decline, numbers
+// ending in 01 never answer, and every other number succeeds. With
+// -duplicates, every callback is delivered twice. Transactions are listed at
+// GET /transactions.
+package main
+
+import (
+	"flag"
+	"log"
+	"lostnfound-api/internal/payments"
+	"net/http"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8091", "address to listen on")
+	delay := flag.Duration("delay", 3*time.Second, "how long the simulated payer and M-Pesa take to answer")
+	duplicates := flag.Bool("duplicates", false, "deliver every callback twice")
+	flag.Parse()
+
+	simulator := payments.NewSimulator(*delay, *duplicates)
+	log.Printf("Simulated M-Pesa API listening on %s", *addr)
+	if err := http.ListenAndServe(*addr, simulator); err != nil {
+		log.Fatalf("Failed to start simulated M-Pesa API: %v", err)
+	}
+}
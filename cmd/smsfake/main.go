@@ -0,0 +1,67 @@
+// Command smsfake runs a fake Africa's Talking SMS API for local development.
+//
+// Point the API at it with SMS_API_URL=http://localhost:8090 and any
+// SMS_API_KEY. Sent messages are logged and listed at GET /messages. With
+// -webhook set, every line typed on stdin is delivered to the API's inbound
+// SMS webhook as a text from -phone, so whole conversations can be tried out.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"lostnfound-api/internal/sms"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	apiKey := flag.String("api-key", "", "API key clients must send (any key is accepted when empty)")
+	webhook := flag.String("webhook", "", "inbound SMS webhook to deliver stdin lines to, e.g. http://localhost:8080/api/v1/sms/inbound?token=dev")
+	phone := flag.String("phone", "+254700000001", "phone number inbound texts come from")
+	shortcode := flag.String("shortcode", "22384", "shortcode inbound texts are sent to")
+	flag.Parse()
+
+	server := sms.NewFakeServer(*apiKey)
+	go func() {
+		log.Printf("Fake SMS API listening on %s", *addr)
+		if err := http.ListenAndServe(*addr, server); err != nil {
+			log.Fatalf("Failed to start fake SMS API: %v", err)
+		}
+	}()
+
+	if *webhook == "" {
+		select {}
+	}
+
+	fmt.Printf("Type a text message from %s and press enter (Ctrl-D to quit)\n", *phone)
+	scanner := bufio.NewScanner(os.Stdin)
+	for i := 1; scanner.Scan(); i++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		form := url.Values{}
+		form.Set("id", fmt.Sprintf("fake-inbound-%d", i))
+		form.Set("from", *phone)
+		form.Set("to", *shortcode)
+		form.Set("text", text)
+		form.Set("date", time.Now().Format("2006-01-02 15:04:05"))
+
+		resp, err := http.PostForm(*webhook, form)
+		if err != nil {
+			log.Printf("Failed to deliver text: %v", err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("Webhook returned %s", resp.Status)
+		}
+	}
+}
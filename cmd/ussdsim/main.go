@@ -0,0 +1,78 @@
+// Command ussdsim simulates a USSD gateway so the menus can be tried from a
+// terminal. It posts each step to the API's USSD callback the way the
+// gateway does and prints the screens.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+func main() {
+	callback := flag.String("url", "http://localhost:8080/api/v1/ussd", "USSD callback URL")
+	phone := flag.String("phone", "+254700000001", "phone number dialling in")
+	code := flag.String("code", "*384#", "service code dialled")
+	flag.Parse()
+
+	sessionID := fmt.Sprintf("sim-%d", time.Now().UnixNano())
+	stdin := bufio.NewScanner(os.Stdin)
+	var inputs []string
+
+	fmt.Printf("Dialling %s from %s\n\n", *code, *phone)
+	for {
+		screen, err := post(*callback, url.Values{
+			"sessionId":   {sessionID},
+			"serviceCode": {*code},
+			"phoneNumber": {*phone},
+			"text":        {strings.Join(inputs, "*")},
+		})
+		if err != nil {
+			log.Fatalf("USSD request failed: %v", err)
+		}
+
+		switch {
+		case strings.HasPrefix(screen, "END "):
+			fmt.Println(strings.TrimPrefix(screen, "END "))
+			fmt.Println("\n[session ended]")
+			return
+		case strings.HasPrefix(screen, "CON "):
+			fmt.Println(strings.TrimPrefix(screen, "CON "))
+		default:
+			log.Fatalf("Unexpected response: %q", screen)
+		}
+
+		fmt.Print("> ")
+		if !stdin.Scan() {
+			fmt.Println("\n[cancelled]")
+			return
+		}
+		inputs = append(inputs, strings.TrimSpace(stdin.Text()))
+		fmt.Println()
+	}
+}
+
+// post sends one gateway callback and returns the screen
+func post(callback string, form url.Values) (string, error) {
+	resp, err := http.PostForm(callback, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s: %s", resp.Status, body)
+	}
+	return string(body), nil
+}
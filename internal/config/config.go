@@ -3,16 +3,41 @@ package config
 import "github.com/spf13/viper"
 
 type Config struct {
-	Port               int    `mapstructure:"PORT"`
-	Environment        string `mapstructure:"ENVIRONMENT"`
-	LogLevel           string `mapstructure:"LOG_LEVEL"`
-	DatabaseURL        string `mapstructure:"DB_URL"`
-	JWTSecret          string `mapstructure:"JWT_SECRET"`
-	JWTExpiration      int    `mapstructure:"JWT_EXPIRATION"`
-	GCSBucketName      string `mapstructure:"GCS_BUCKETNAME"`
-	GCSProjectID       string `mapstructure:"GCS_PROGECT_ID"`
-	GCSCredentialsFile string `mapstructure:"GCS_CREDENTIALS_FILE"`
-	RedisURL           string `mapstructure:"REDIS_URL"`
+	Port                    int    `mapstructure:"PORT"`
+	Environment             string `mapstructure:"ENVIRONMENT"`
+	LogLevel                string `mapstructure:"LOG_LEVEL"`
+	DatabaseURL             string `mapstructure:"DB_URL"`
+	JWTSecret               string `mapstructure:"JWT_SECRET"`
+	JWTExpiration           int    `mapstructure:"JWT_EXPIRATION"`
+	GCSBucketName           string `mapstructure:"GCS_BUCKETNAME"`
+	GCSProjectID            string `mapstructure:"GCS_PROGECT_ID"`
+	GCSCredentialsFile      string `mapstructure:"GCS_CREDENTIALS_FILE"`
+	RedisURL                string `mapstructure:"REDIS_URL"`
+	ItemRetentionDays       int    `mapstructure:"ITEM_RETENTION_DAYS"`
+	AssetHashSecret         string `mapstructure:"ASSET_HASH_SECRET"`
+	PublicBaseURL           string `mapstructure:"PUBLIC_BASE_URL"`
+	SavedSearchDays         int    `mapstructure:"SAVED_SEARCH_EXPIRY_DAYS"`
+	NotificationDir         string `mapstructure:"NOTIFICATION_OUTBOX_DIR"`
+	SMSAPIURL               string `mapstructure:"SMS_API_URL"`
+	SMSUsername             string `mapstructure:"SMS_USERNAME"`
+	SMSAPIKey               string `mapstructure:"SMS_API_KEY"`
+	SMSSenderID             string `mapstructure:"SMS_SENDER_ID"`
+	SMSWebhookToken         string `mapstructure:"SMS_WEBHOOK_TOKEN"`
+	USSDWebhookToken        string `mapstructure:"USSD_WEBHOOK_TOKEN"`
+	HandoverCodeHours       int    `mapstructure:"HANDOVER_CODE_HOURS"`
+	MPesaAPIURL             string `mapstructure:"MPESA_API_URL"`
+	MPesaConsumerKey        string `mapstructure:"MPESA_CONSUMER_KEY"`
+	MPesaConsumerSecret     string `mapstructure:"MPESA_CONSUMER_SECRET"`
+	MPesaShortcode          string `mapstructure:"MPESA_SHORTCODE"`
+	MPesaPasskey            string `mapstructure:"MPESA_PASSKEY"`
+	MPesaB2CShortcode       string `mapstructure:"MPESA_B2C_SHORTCODE"`
+	MPesaInitiatorName      string `mapstructure:"MPESA_INITIATOR_NAME"`
+	MPesaSecurityCredential string `mapstructure:"MPESA_SECURITY_CREDENTIAL"`
+	MPesaCallbackToken      string `mapstructure:"MPESA_CALLBACK_TOKEN"`
+	RewardEscrowDays        int    `mapstructure:"REWARD_ESCROW_DAYS"`
+	ItemReminderDays        int    `mapstructure:"ITEM_REMINDER_DAYS"`
+	ItemArchiveDays         int    `mapstructure:"ITEM_ARCHIVE_DAYS"`
+	CustodyDisposalDays     int    `mapstructure:"CUSTODY_DISPOSAL_DAYS"`
 }
 
 func Load(path string) (config Config, err error) {
@@ -20,6 +45,20 @@ func Load(path string) (config Config, err error) {
 	viper.SetConfigName("app")
 	viper.SetConfigType("env")
 	viper.AutomaticEnv()
+
+	viper.SetDefault("ITEM_RETENTION_DAYS", 30)
+	viper.SetDefault("PUBLIC_BASE_URL", "http://localhost:8080")
+	viper.SetDefault("SAVED_SEARCH_EXPIRY_DAYS", 30)
+	viper.SetDefault("NOTIFICATION_OUTBOX_DIR", "./var/notifications")
+	viper.SetDefault("SMS_API_URL", "https://api.sandbox.africastalking.com")
+	viper.SetDefault("SMS_USERNAME", "sandbox")
+	viper.SetDefault("HANDOVER_CODE_HOURS", 72)
+	viper.SetDefault("MPESA_API_URL", "https://sandbox.safaricom.co.ke")
+	viper.SetDefault("REWARD_ESCROW_DAYS", 30)
+	viper.SetDefault("ITEM_REMINDER_DAYS", 30)
+	viper.SetDefault("ITEM_ARCHIVE_DAYS", 90)
+	viper.SetDefault("CUSTODY_DISPOSAL_DAYS", 90)
+
 	err = viper.ReadInConfig()
 	if err != nil {
 		return
@@ -0,0 +1,249 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format is a file format items can be exported in
+type Format string
+
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatGeoJSON Format = "geojson"
+)
+
+// ParseFormat reads a format name, accepting "jsonl" for NDJSON
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "csv", "":
+		return FormatCSV, nil
+	case "ndjson", "jsonl":
+		return FormatNDJSON, nil
+	case "geojson":
+		return FormatGeoJSON, nil
+	default:
+		return "", fmt.Errorf("unsupported export format %q; use csv, ndjson or geojson", name)
+	}
+}
+
+// ContentType returns the MIME type of files in the format
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatGeoJSON:
+		return "application/geo+json"
+	default:
+		return "text/csv; charset=utf-8"
+	}
+}
+
+// Extension returns the file name extension for the format
+func (f Format) Extension() string {
+	return "." + string(f)
+}
+
+// Record is one exported item. Fields a viewer may not see are left empty.
+type Record struct {
+	ID               string         `json:"id"`
+	ReferenceCode    string         `json:"reference_code,omitempty"`
+	Title            string         `json:"title"`
+	Description      string         `json:"description,omitempty"`
+	Status           string         `json:"status"`
+	Category         string         `json:"category,omitempty"`
+	Attributes       map[string]any `json:"attributes,omitempty"`
+	Location         string         `json:"location,omitempty"`
+	CountyID         string         `json:"county_id,omitempty"`
+	SubCountyID      string         `json:"sub_county_id,omitempty"`
+	WardID           string         `json:"ward_id,omitempty"`
+	Latitude         *float64       `json:"latitude,omitempty"`
+	Longitude        *float64       `json:"longitude,omitempty"`
+	Precision        int            `json:"precision,omitempty"`
+	Date             time.Time      `json:"date"`
+	CreatedAt        time.Time      `json:"created_at"`
+	Resolved         bool           `json:"resolved"`
+	Source           string         `json:"source,omitempty"`
+	Tags             []string       `json:"tags,omitempty"`
+	Images           []string       `json:"images,omitempty"`
+	OrganizationID   string         `json:"organization_id,omitempty"`
+	OrganizationName string         `json:"organization_name,omitempty"`
+	Contact          string         `json:"contact,omitempty"`
+	ReporterID       string         `json:"reporter_id,omitempty"`
+	CustodyStatus    string         `json:"custody_status,omitempty"`
+	StorageLocation  string         `json:"storage_location,omitempty"`
+}
+
+// csvColumns are the headings of a CSV export, in order
+var csvColumns = []string{
+	"id", "reference_code", "title", "description", "status", "category", "attributes",
+	"location", "county_id", "sub_county_id", "ward_id", "latitude", "longitude", "precision",
+	"date", "created_at", "resolved", "source", "tags", "images",
+	"organization_id", "organization_name", "contact", "reporter_id", "custody_status", "storage_location",
+}
+
+// Writer writes records to a file one at a time
+type Writer interface {
+	Write(record *Record) error
+	// Close finishes the file and flushes it; it does not close the underlying writer
+	Close() error
+}
+
+// NewWriter returns a writer for the format
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	buffered := bufio.NewWriterSize(w, 64*1024)
+	switch format {
+	case FormatCSV:
+		return &csvWriter{buffered: buffered, csv: csv.NewWriter(buffered)}, nil
+	case FormatNDJSON:
+		return &ndjsonWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case FormatGeoJSON:
+		return &geoJSONWriter{buffered: buffered}, nil
+	default:
+		return nil, fmt.Errorf("unsupported export format %q", format)
+	}
+}
+
+// csvWriter writes a heading row and one row per record
+type csvWriter struct {
+	buffered *bufio.Writer
+	csv      *csv.Writer
+	started  bool
+}
+
+func (w *csvWriter) Write(record *Record) error {
+	if !w.started {
+		w.started = true
+		if err := w.csv.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+
+	attributes := ""
+	if len(record.Attributes) > 0 {
+		data, err := json.Marshal(record.Attributes)
+		if err != nil {
+			return err
+		}
+		attributes = string(data)
+	}
+	return w.csv.Write([]string{
+		record.ID, record.ReferenceCode, record.Title, record.Description, record.Status, record.Category, attributes,
+		record.Location, record.CountyID, record.SubCountyID, record.WardID,
+		formatFloat(record.Latitude), formatFloat(record.Longitude), formatInt(record.Precision),
+		record.Date.Format(time.RFC3339), record.CreatedAt.Format(time.RFC3339), strconv.FormatBool(record.Resolved),
+		record.Source, strings.Join(record.Tags, ";"), strings.Join(record.Images, ";"),
+		record.OrganizationID, record.OrganizationName, record.Contact, record.ReporterID,
+		record.CustodyStatus, record.StorageLocation,
+	})
+}
+
+func (w *csvWriter) Close() error {
+	if !w.started {
+		w.started = true
+		if err := w.csv.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+	return w.buffered.Flush()
+}
+
+// ndjsonWriter writes one JSON object per line
+type ndjsonWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(record *Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonWriter) Close() error {
+	return w.buffered.Flush()
+}
+
+// geoJSONWriter writes a FeatureCollection with a Point feature per record.
+// Records without coordinates have a null geometry.
+type geoJSONWriter struct {
+	buffered *bufio.Writer
+	count    int
+}
+
+// geoJSONFeature is a GeoJSON Feature
+type geoJSONFeature struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id"`
+	Geometry   *geoJSONGeometry `json:"geometry"`
+	Properties *Record          `json:"properties"`
+}
+
+// geoJSONGeometry is a GeoJSON Point
+type geoJSONGeometry struct {
+	Type        string    `json:"type"`
+	Coordinates []float64 `json:"coordinates"`
+}
+
+func (w *geoJSONWriter) Write(record *Record) error {
+	prefix := ","
+	if w.count == 0 {
+		prefix = `{"type":"FeatureCollection","features":[`
+	}
+	w.count++
+
+	properties := *record
+	feature := geoJSONFeature{Type: "Feature", ID: record.ID, Properties: &properties}
+	if record.Latitude != nil && record.Longitude != nil {
+		feature.Geometry = &geoJSONGeometry{Type: "Point", Coordinates: []float64{*record.Longitude, *record.Latitude}}
+		properties.Latitude, properties.Longitude = nil, nil
+	}
+
+	data, err := json.Marshal(feature)
+	if err != nil {
+		return err
+	}
+	if _, err := w.buffered.WriteString(prefix); err != nil {
+		return err
+	}
+	if _, err := w.buffered.Write(data); err != nil {
+		return err
+	}
+	return w.buffered.WriteByte('\n')
+}
+
+func (w *geoJSONWriter) Close() error {
+	closing := "]}\n"
+	if w.count == 0 {
+		closing = `{"type":"FeatureCollection","features":[]}` + "\n"
+	}
+	if _, err := w.buffered.WriteString(closing); err != nil {
+		return err
+	}
+	return w.buffered.Flush()
+}
+
+// formatFloat writes an optional number, or nothing
+func formatFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// formatInt writes a number, leaving zero empty
+func formatInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
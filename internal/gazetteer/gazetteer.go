@@ -0,0 +1,466 @@
+package gazetteer
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//go:embed kenya.json
+var kenyaData []byte
+
+// Level identifies the administrative level of a gazetteer entry
+type Level string
+
+const (
+	LevelCounty    Level = "county"
+	LevelSubCounty Level = "sub_county"
+	LevelWard      Level = "ward"
+)
+
+// County is one of the 47 counties of Kenya
+type County struct {
+	ID          string      `json:"id"`
+	Code        int         `json:"code"`
+	Name        string      `json:"name"`
+	SubCounties []SubCounty `json:"sub_counties,omitempty"`
+	// HasWards is false for counties the gazetteer has no ward list for yet
+	HasWards bool `json:"has_wards"`
+}
+
+// SubCounty is an administrative unit within a county
+type SubCounty struct {
+	ID       string `json:"id"`
+	CountyID string `json:"county_id"`
+	Name     string `json:"name"`
+	Wards    []Ward `json:"wards,omitempty"`
+	// HasWards is false for sub-counties the gazetteer has no ward list for yet
+	HasWards bool `json:"has_wards"`
+}
+
+// Ward is the smallest administrative unit, within a sub-county
+type Ward struct {
+	ID          string `json:"id"`
+	CountyID    string `json:"county_id"`
+	SubCountyID string `json:"sub_county_id"`
+	Name        string `json:"name"`
+}
+
+// Match is the result of resolving free text to a gazetteer entry
+type Match struct {
+	Level       Level   `json:"level"`
+	Name        string  `json:"name"`
+	CountyID    string  `json:"county_id"`
+	SubCountyID string  `json:"sub_county_id,omitempty"`
+	WardID      string  `json:"ward_id,omitempty"`
+	Score       float64 `json:"score"`
+}
+
+// Gazetteer holds Kenya's administrative geography and resolves place names against it
+type Gazetteer struct {
+	counties    []County
+	countyByID  map[string]*County
+	subByID     map[string]*SubCounty
+	wardByID    map[string]*Ward
+	names       map[string][]entry
+	bySize      map[int][]string
+	maxNameSize int
+}
+
+// entry is a named place in the lookup index
+type entry struct {
+	level       Level
+	name        string
+	countyID    string
+	subCountyID string
+	wardID      string
+}
+
+// id returns the most specific ID of the entry
+func (e entry) id() string {
+	switch e.level {
+	case LevelWard:
+		return e.wardID
+	case LevelSubCounty:
+		return e.subCountyID
+	default:
+		return e.countyID
+	}
+}
+
+// depth orders levels from county (0) to ward (2)
+func (e entry) depth() int {
+	switch e.level {
+	case LevelWard:
+		return 2
+	case LevelSubCounty:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// contains reports whether other lies within e
+func (e entry) contains(other entry) bool {
+	switch e.level {
+	case LevelCounty:
+		return other.countyID == e.countyID
+	case LevelSubCounty:
+		return other.subCountyID == e.subCountyID
+	default:
+		return other.wardID == e.wardID
+	}
+}
+
+type rawGazetteer struct {
+	Counties []struct {
+		Code        int    `json:"code"`
+		Name        string `json:"name"`
+		SubCounties []struct {
+			Name  string   `json:"name"`
+			Wards []string `json:"wards"`
+		} `json:"sub_counties"`
+	} `json:"counties"`
+	Aliases map[string]string `json:"aliases"`
+}
+
+// Load parses the embedded Kenyan gazetteer
+func Load() (*Gazetteer, error) {
+	var raw rawGazetteer
+	if err := json.Unmarshal(kenyaData, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse gazetteer: %w", err)
+	}
+
+	g := &Gazetteer{
+		countyByID: make(map[string]*County),
+		subByID:    make(map[string]*SubCounty),
+		wardByID:   make(map[string]*Ward),
+		names:      make(map[string][]entry),
+		bySize:     make(map[int][]string),
+	}
+
+	for _, rc := range raw.Counties {
+		county := County{ID: Slug(rc.Name), Code: rc.Code, Name: rc.Name}
+		for _, rs := range rc.SubCounties {
+			sub := SubCounty{ID: county.ID + "." + Slug(rs.Name), CountyID: county.ID, Name: rs.Name}
+			for _, name := range rs.Wards {
+				sub.Wards = append(sub.Wards, Ward{
+					ID:          sub.ID + "." + Slug(name),
+					CountyID:    county.ID,
+					SubCountyID: sub.ID,
+					Name:        name,
+				})
+			}
+			sub.HasWards = len(sub.Wards) > 0
+			county.HasWards = county.HasWards || sub.HasWards
+			county.SubCounties = append(county.SubCounties, sub)
+		}
+		g.counties = append(g.counties, county)
+	}
+
+	// Index after the slices are complete so the pointers stay valid
+	for ci := range g.counties {
+		county := &g.counties[ci]
+		g.countyByID[county.ID] = county
+		g.index(county.Name, entry{level: LevelCounty, name: county.Name, countyID: county.ID})
+
+		for si := range county.SubCounties {
+			sub := &county.SubCounties[si]
+			g.subByID[sub.ID] = sub
+			g.index(sub.Name, entry{level: LevelSubCounty, name: sub.Name, countyID: county.ID, subCountyID: sub.ID})
+
+			for wi := range sub.Wards {
+				ward := &sub.Wards[wi]
+				g.wardByID[ward.ID] = ward
+				g.index(ward.Name, entry{level: LevelWard, name: ward.Name, countyID: county.ID, subCountyID: sub.ID, wardID: ward.ID})
+			}
+		}
+	}
+
+	for alias, id := range raw.Aliases {
+		e, ok := g.entryByID(id)
+		if !ok {
+			return nil, fmt.Errorf("gazetteer alias %q points to unknown id %q", alias, id)
+		}
+		g.index(alias, e)
+	}
+
+	return g, nil
+}
+
+// index registers a name under its normalised form. Names containing a
+// slash (e.g. "Parklands/Highridge") are also indexed by each part.
+func (g *Gazetteer) index(name string, e entry) {
+	keys := []string{normalize(name)}
+	if strings.Contains(name, "/") {
+		for _, part := range strings.Split(name, "/") {
+			keys = append(keys, normalize(part))
+		}
+	}
+
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		size := len(strings.Fields(key))
+		if _, ok := g.names[key]; !ok {
+			g.bySize[size] = append(g.bySize[size], key)
+		}
+		g.names[key] = append(g.names[key], e)
+		if size > g.maxNameSize {
+			g.maxNameSize = size
+		}
+	}
+}
+
+func (g *Gazetteer) entryByID(id string) (entry, bool) {
+	if ward, ok := g.wardByID[id]; ok {
+		return entry{level: LevelWard, name: ward.Name, countyID: ward.CountyID, subCountyID: ward.SubCountyID, wardID: ward.ID}, true
+	}
+	if sub, ok := g.subByID[id]; ok {
+		return entry{level: LevelSubCounty, name: sub.Name, countyID: sub.CountyID, subCountyID: sub.ID}, true
+	}
+	if county, ok := g.countyByID[id]; ok {
+		return entry{level: LevelCounty, name: county.Name, countyID: county.ID}, true
+	}
+	return entry{}, false
+}
+
+// Counties returns all counties ordered by county code, without their sub-counties
+func (g *Gazetteer) Counties() []County {
+	counties := make([]County, 0, len(g.counties))
+	for _, county := range g.counties {
+		county.SubCounties = nil
+		counties = append(counties, county)
+	}
+	return counties
+}
+
+// County retrieves a county with its sub-counties by ID
+func (g *Gazetteer) County(id string) (*County, bool) {
+	county, ok := g.countyByID[id]
+	return county, ok
+}
+
+// SubCounty retrieves a sub-county with its wards by ID
+func (g *Gazetteer) SubCounty(id string) (*SubCounty, bool) {
+	sub, ok := g.subByID[id]
+	return sub, ok
+}
+
+// Ward retrieves a ward by ID
+func (g *Gazetteer) Ward(id string) (*Ward, bool) {
+	ward, ok := g.wardByID[id]
+	return ward, ok
+}
+
+// candidate is a scored entry found in a piece of free text
+type candidate struct {
+	entry
+	phrase string
+	score  float64
+	span   int
+}
+
+const (
+	// minFuzzyScore is the lowest similarity accepted for a non-exact match
+	minFuzzyScore = 0.75
+	// minFuzzyWordScore is the lowest similarity accepted for a non-exact
+	// match of a single word, which is more likely to be another word
+	minFuzzyWordScore = 0.85
+	// maxResolveRunes and maxResolveTokens bound how much text is resolved
+	maxResolveRunes  = 200
+	maxResolveTokens = 12
+)
+
+// streetWords follow a place name used as the name of a street, such as
+// "Mombasa road" or "Kenyatta avenue", which says nothing about where it is
+var streetWords = map[string]bool{
+	"road": true, "rd": true, "street": true, "st": true, "avenue": true, "ave": true,
+	"highway": true, "lane": true, "drive": true, "way": true, "close": true, "crescent": true,
+}
+
+// Resolve maps free text such as "Nbi", "nairobi CBD" or "Kawangware stage"
+// to the most specific gazetteer entry it mentions. Names are matched
+// exactly first and then fuzzily to tolerate typos. Only the start of long
+// text is read, and place names used as street names are ignored.
+func (g *Gazetteer) Resolve(text string) (*Match, bool) {
+	if runes := []rune(text); len(runes) > maxResolveRunes {
+		text = string(runes[:maxResolveRunes])
+	}
+	tokens := strings.Fields(normalize(text))
+	if len(tokens) == 0 {
+		return nil, false
+	}
+	if len(tokens) > maxResolveTokens {
+		tokens = tokens[:maxResolveTokens]
+	}
+
+	var candidates []candidate
+	for start := range tokens {
+		for size := 1; size <= g.maxNameSize && start+size <= len(tokens); size++ {
+			if end := start + size; end < len(tokens) && streetWords[tokens[end]] {
+				continue
+			}
+			phrase := strings.Join(tokens[start:start+size], " ")
+			candidates = append(candidates, g.lookup(phrase, size)...)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+
+	best := g.pick(candidates)
+	return &Match{
+		Level:       best.level,
+		Name:        best.name,
+		CountyID:    best.countyID,
+		SubCountyID: best.subCountyID,
+		WardID:      best.wardID,
+		Score:       best.score,
+	}, true
+}
+
+// lookup finds entries whose name matches the phrase exactly or fuzzily.
+// Fuzzy matches must have as many words, start with the same letter and be
+// close enough in length to reach the minimum score.
+func (g *Gazetteer) lookup(phrase string, span int) []candidate {
+	if entries, ok := g.names[phrase]; ok {
+		return toCandidates(entries, phrase, 1, span)
+	}
+
+	// Very short phrases are too ambiguous to match fuzzily
+	runes := []rune(phrase)
+	if len(runes) < 4 {
+		return nil
+	}
+
+	threshold := minFuzzyScore
+	if span == 1 {
+		threshold = minFuzzyWordScore
+	}
+
+	var found []candidate
+	for _, name := range g.bySize[span] {
+		nameRunes := []rune(name)
+		if nameRunes[0] != runes[0] {
+			continue
+		}
+		longest := max(len(runes), len(nameRunes))
+		if diff := len(runes) - len(nameRunes); float64(max(diff, -diff)) > (1-threshold)*float64(longest) {
+			continue
+		}
+		if score := similarity(phrase, name); score >= threshold {
+			found = append(found, toCandidates(g.names[name], phrase, score, span)...)
+		}
+	}
+	return found
+}
+
+func toCandidates(entries []entry, phrase string, score float64, span int) []candidate {
+	candidates := make([]candidate, 0, len(entries))
+	for _, e := range entries {
+		candidates = append(candidates, candidate{entry: e, phrase: phrase, score: score, span: span})
+	}
+	return candidates
+}
+
+// pick chooses the best candidate. Stronger matches win; among equally
+// strong matches, entries that agree with other places in the text (a ward
+// inside a county that was also named) win, then longer phrases, then more
+// specific levels. When a sub-county shares its county's name, the county wins.
+func (g *Gazetteer) pick(candidates []candidate) candidate {
+	support := func(c candidate) int {
+		n := 0
+		for _, other := range candidates {
+			if other.phrase != c.phrase && other.id() != c.id() && other.contains(c.entry) {
+				n++
+			}
+		}
+		return n
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if sa, sb := support(a), support(b); sa != sb {
+			return sa > sb
+		}
+		if a.span != b.span {
+			return a.span > b.span
+		}
+		if a.name == b.name && (a.contains(b.entry) || b.contains(a.entry)) {
+			return a.depth() < b.depth()
+		}
+		if a.depth() != b.depth() {
+			return a.depth() > b.depth()
+		}
+		return a.id() < b.id()
+	})
+
+	return candidates[0]
+}
+
+// normalize lowercases text and reduces punctuation to single spaces
+func normalize(text string) string {
+	var b strings.Builder
+	space := true
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == '\'' || r == '’':
+			continue
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+			space = false
+		case !space:
+			b.WriteByte(' ')
+			space = true
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// Slug converts a place name into the ID fragment used in gazetteer IDs
+func Slug(name string) string {
+	return strings.ReplaceAll(normalize(name), " ", "-")
+}
+
+// similarity returns a score in [0, 1] based on Levenshtein distance
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// AssetHandler handles HTTP requests for the registered asset registry
+type AssetHandler struct {
+	service *service.AssetService
+}
+
+// NewAssetHandler creates a new AssetHandler
+func NewAssetHandler(service *service.AssetService) *AssetHandler {
+	return &AssetHandler{service: service}
+}
+
+// registerAssetRequest is the body for registering an asset. The identifier
+// is hashed by the service and never stored as given.
+type registerAssetRequest struct {
+	Type           models.AssetType `json:"type" binding:"required"`
+	Identifier     string           `json:"identifier" binding:"required"`
+	IdentifierType string           `json:"identifier_type"`
+	Brand          string           `json:"brand"`
+	ModelName      string           `json:"model_name"`
+	Description    string           `json:"description"`
+}
+
+// assetStatusRequest is the body for changing an asset's status
+type assetStatusRequest struct {
+	Status models.AssetStatus `json:"status" binding:"required"`
+}
+
+// registryCheckRequest is the body for the public registry check
+type registryCheckRequest struct {
+	Identifier string `json:"identifier" binding:"required"`
+}
+
+// Register handles adding an asset to the user's registry
+func (h *AssetHandler) Register(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req registerAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	asset := &models.RegisteredAsset{
+		UserID:         userID,
+		Type:           req.Type,
+		IdentifierType: req.IdentifierType,
+		Brand:          req.Brand,
+		ModelName:      req.ModelName,
+		Description:    req.Description,
+	}
+	err := h.service.Register(asset, req.Identifier)
+	switch {
+	case errors.Is(err, service.ErrAssetAlreadyRegistered):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Asset registered successfully", asset)
+}
+
+// List handles listing the user's registered assets
+func (h *AssetHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	assets, err := h.service.ListForUser(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Assets retrieved successfully", assets)
+}
+
+// UpdateStatus handles reporting an asset lost, stolen or recovered
+func (h *AssetHandler) UpdateStatus(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req assetStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	asset, err := h.service.SetStatus(id, userID, req.Status)
+	switch {
+	case errors.Is(err, service.ErrAssetNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Asset status updated successfully", asset)
+}
+
+// Delete handles removing an asset from the user's registry
+func (h *AssetHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	err = h.service.Delete(id, userID)
+	switch {
+	case errors.Is(err, service.ErrAssetNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Asset deleted successfully", nil)
+}
+
+// ListAlerts handles listing found items that matched the user's assets
+func (h *AssetHandler) ListAlerts(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	alerts, err := h.service.ListAlerts(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Asset alerts retrieved successfully", alerts)
+}
+
+// Check handles the public "check this serial" lookup
+func (h *AssetHandler) Check(c *gin.Context) {
+	var req registryCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	result, err := h.service.Check(req.Identifier)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, result.Message, result)
+}
+
+// Disputed handles listing identifiers registered by more than one user; admins only
+func (h *AssetHandler) Disputed(c *gin.Context) {
+	disputes, err := h.service.Disputed()
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Disputed assets retrieved successfully", disputes)
+}
+
+// ResolveDispute handles settling a disputed identifier in favour of one
+// registration; admins only
+func (h *AssetHandler) ResolveDispute(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	asset, err := h.service.ResolveDispute(id)
+	switch {
+	case errors.Is(err, service.ErrAssetNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Dispute resolved successfully", asset)
+}
@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// CategoryHandler handles HTTP requests for item categories
+type CategoryHandler struct {
+	service *service.CategoryService
+}
+
+// NewCategoryHandler creates a new CategoryHandler
+func NewCategoryHandler(service *service.CategoryService) *CategoryHandler {
+	return &CategoryHandler{service: service}
+}
+
+// Tree handles retrieval of the full category tree
+func (h *CategoryHandler) Tree(c *gin.Context) {
+	tree, err := h.service.Tree()
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Categories retrieved successfully", tree)
+}
+
+// GetByID handles retrieval of a category with its full attribute schema
+func (h *CategoryHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	category, err := h.service.GetByID(id)
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	schema, err := h.service.Schema(id)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	responseData := gin.H{
+		"category": category,
+		"schema":   schema,
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Category retrieved successfully", responseData)
+}
+
+// Create handles the creation of a new category
+func (h *CategoryHandler) Create(c *gin.Context) {
+	var category models.Category
+	if err := c.ShouldBindJSON(&category); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := h.service.Create(&category); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Category created successfully", category)
+}
+
+// Update handles renaming a category or changing its attribute schema or archive period
+func (h *CategoryHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	var input models.Category
+	if err := c.ShouldBindJSON(&input); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	category, err := h.service.Update(id, input.Name, input.Attributes, input.ArchiveAfterDays)
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Category updated successfully", category)
+}
+
+// Delete handles removal of an unused category
+func (h *CategoryHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	err = h.service.Delete(id)
+	switch {
+	case errors.Is(err, service.ErrCategoryNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrCategoryInUse):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Category deleted successfully", nil)
+}
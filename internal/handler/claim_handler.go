@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// ClaimHandler handles HTTP requests for claims on items
+type ClaimHandler struct {
+	service *service.ClaimService
+}
+
+// NewClaimHandler creates a new ClaimHandler
+func NewClaimHandler(service *service.ClaimService) *ClaimHandler {
+	return &ClaimHandler{service: service}
+}
+
+// createClaimRequest is the body for claiming an item
+type createClaimRequest struct {
+	Description string `json:"description" binding:"required"`
+}
+
+// Create handles claiming an item
+func (h *ClaimHandler) Create(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req createClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	claim := &models.Claim{ItemID: itemID, ClaimerID: userID, Description: req.Description}
+	if err := h.service.Create(claim); err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Claim submitted successfully", claim)
+}
+
+// ListForItem handles listing the claims on an item for its owner
+func (h *ClaimHandler) ListForItem(c *gin.Context) {
+	itemID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	claims, err := h.service.ListForItem(itemID, userID, isAdmin(c))
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Claims retrieved successfully", claims)
+}
+
+// ListMine handles listing the claims the user has made
+func (h *ClaimHandler) ListMine(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	claims, total, err := h.service.ListMine(userID, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Claims retrieved successfully", gin.H{
+		"items": claims,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetByID handles retrieving a single claim
+func (h *ClaimHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	claim, err := h.service.GetForViewer(id, userID, isAdmin(c))
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Claim retrieved successfully", claim)
+}
+
+// Approve handles the item owner accepting a claim
+func (h *ClaimHandler) Approve(c *gin.Context) {
+	h.decide(c, h.service.Approve, "Claim approved successfully")
+}
+
+// Reject handles the item owner turning down a claim
+func (h *ClaimHandler) Reject(c *gin.Context) {
+	h.decide(c, h.service.Reject, "Claim rejected successfully")
+}
+
+// Withdraw handles the claimer taking back a pending claim
+func (h *ClaimHandler) Withdraw(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	claim, err := h.service.Withdraw(id, userID)
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Claim withdrawn successfully", claim)
+}
+
+// decide runs an owner decision on the claim in the path
+func (h *ClaimHandler) decide(c *gin.Context, decision func(id, actorID uuid.UUID, isAdmin bool) (*models.Claim, error), message string) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	claim, err := decision(id, userID, isAdmin(c))
+	if err != nil {
+		writeClaimError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, message, claim)
+}
+
+// writeClaimError maps claim errors to responses
+func writeClaimError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrClaimNotFound), errors.Is(err, service.ErrItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrNotAuthorizedItem):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrClaimExists), errors.Is(err, service.ErrClaimNotPending), errors.Is(err, service.ErrItemNotClaimable):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrThrottled):
+		models.ResponseJson(c, http.StatusTooManyRequests, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+)
+
+// currentUserID returns the authenticated user's ID (set by auth middleware)
+func currentUserID(c *gin.Context) (uuid.UUID, bool) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		return uuid.Nil, false
+	}
+	id, ok := userID.(uuid.UUID)
+	return id, ok
+}
+
+// isAdmin reports whether the authenticated user is an administrator
+func isAdmin(c *gin.Context) bool {
+	admin, _ := c.Get("isAdmin")
+	return admin == true
+}
+
+// paginationParams reads the page and limit query parameters
+func paginationParams(c *gin.Context) (int, int) {
+	page := c.DefaultQuery("page", "1")
+	limit := c.DefaultQuery("limit", "10")
+
+	return models.ParseIntOrDefault(page, 1), models.ParseIntOrDefault(limit, 10)
+}
@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// CustodyHandler handles HTTP requests for drop-off points and the chain of
+// custody of items
+type CustodyHandler struct {
+	service *service.CustodyService
+}
+
+// NewCustodyHandler creates a new CustodyHandler
+func NewCustodyHandler(service *service.CustodyService) *CustodyHandler {
+	return &CustodyHandler{service: service}
+}
+
+// dropOffPointRequest is the body for creating or updating a drop-off point
+type dropOffPointRequest struct {
+	Name                   string                `json:"name"`
+	Location               string                `json:"location"`
+	County                 string                `json:"county"`
+	SubCounty              string                `json:"sub_county"`
+	Latitude               *float64              `json:"latitude"`
+	Longitude              *float64              `json:"longitude"`
+	Phone                  string                `json:"phone"`
+	OpeningHours           []models.OpeningHours `json:"opening_hours"`
+	CollectionInstructions string                `json:"collection_instructions"`
+	Active                 *bool                 `json:"active"`
+}
+
+// toModel converts the request into a drop-off point
+func (r *dropOffPointRequest) toModel() *models.DropOffPoint {
+	point := &models.DropOffPoint{
+		Name:                   r.Name,
+		Location:               r.Location,
+		CountyID:               r.County,
+		SubCountyID:            r.SubCounty,
+		Latitude:               r.Latitude,
+		Longitude:              r.Longitude,
+		Phone:                  r.Phone,
+		OpeningHours:           r.OpeningHours,
+		CollectionInstructions: r.CollectionInstructions,
+		Active:                 true,
+	}
+	if r.Active != nil {
+		point.Active = *r.Active
+	}
+	return point
+}
+
+// ListDropOffPoints handles the public list of drop-off points. Filter with
+// county and organization.
+func (h *CustodyHandler) ListDropOffPoints(c *gin.Context) {
+	page, limit := paginationParams(c)
+
+	var organizationID *uuid.UUID
+	if raw := c.Query("organization"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, "invalid organization ID", nil)
+			return
+		}
+		organizationID = &id
+	}
+
+	points, total, err := h.service.ListDropOffPoints(c.Query("county"), organizationID, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Drop-off points retrieved successfully", gin.H{
+		"items": points,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetDropOffPoint handles retrieving a drop-off point
+func (h *CustodyHandler) GetDropOffPoint(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	point, err := h.service.GetDropOffPoint(id)
+	if err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Drop-off point retrieved successfully", point)
+}
+
+// CreateDropOffPoint handles registering a drop-off point for an organisation
+func (h *CustodyHandler) CreateDropOffPoint(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	var req dropOffPointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	point := req.toModel()
+	if err := h.service.CreateDropOffPoint(id, userID, isAdmin(c), point); err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Drop-off point created successfully", point)
+}
+
+// UpdateDropOffPoint handles changing a drop-off point. Send active=false to
+// stop it accepting items.
+func (h *CustodyHandler) UpdateDropOffPoint(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	pointID, err := uuid.Parse(c.Param("point_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid drop-off point ID", nil)
+		return
+	}
+
+	var req dropOffPointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	point, err := h.service.UpdateDropOffPoint(id, pointID, userID, isAdmin(c), req.toModel())
+	if err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Drop-off point updated successfully", point)
+}
+
+// Transfer handles recording a handover. It takes multipart form data with
+// one of to_organization_id, to_drop_off_point_id, to_user_id or to_name,
+// optional notes, and a "signature" and/or "photo" image.
+func (h *CustodyHandler) Transfer(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var input service.CustodyTransferInput
+	for field, target := range map[string]**uuid.UUID{
+		"to_organization_id":   &input.ToOrganizationID,
+		"to_drop_off_point_id": &input.ToDropOffPointID,
+		"to_user_id":           &input.ToUserID,
+	} {
+		raw := c.PostForm(field)
+		if raw == "" {
+			continue
+		}
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, "invalid "+field, nil)
+			return
+		}
+		*target = &parsed
+	}
+	input.ToName = c.PostForm("to_name")
+	input.Notes = c.PostForm("notes")
+
+	var uploads [2]*service.Upload
+	for i, field := range []string{"signature", "photo"} {
+		header, err := c.FormFile(field)
+		if err != nil {
+			continue
+		}
+		file, err := header.Open()
+		if err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, "failed to read "+field, nil)
+			return
+		}
+		defer file.Close()
+		uploads[i] = &service.Upload{File: file, Header: header}
+	}
+
+	transfer, err := h.service.Transfer(c.Request.Context(), id, userID, isAdmin(c), input, uploads[0], uploads[1])
+	if err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Custody transfer recorded successfully", transfer)
+}
+
+// ConfirmTransfer handles the receiving organisation confirming a pending handover
+func (h *CustodyHandler) ConfirmTransfer(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	transfer, err := h.service.ConfirmTransfer(id, userID, isAdmin(c))
+	if err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Custody transfer confirmed successfully", transfer)
+}
+
+// Transfers handles retrieving an item's chain of custody
+func (h *CustodyHandler) Transfers(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	transfers, err := h.service.Transfers(id, userID, isAdmin(c))
+	if err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Custody transfers retrieved successfully", transfers)
+}
+
+// Collection handles showing the approved claimant who holds their item and
+// how to collect it
+func (h *CustodyHandler) Collection(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	collection, err := h.service.Collection(id, userID)
+	if err != nil {
+		writeCustodyError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Collection instructions retrieved successfully", collection)
+}
+
+// itemParams reads the item ID and the current user, writing the error
+// response when either is missing
+func itemParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, userID, true
+}
+
+// writeCustodyError maps custody errors to responses
+func writeCustodyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrDropOffPointNotFound),
+		errors.Is(err, service.ErrNoCustodian), errors.Is(err, service.ErrTransferNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrNotCustodian), errors.Is(err, service.ErrNotVerifiedClaimant),
+		errors.Is(err, service.ErrCustodyNotVisible), errors.Is(err, service.ErrNotReceiver):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrTransferNotPending), errors.Is(err, service.ErrTransferStale):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	default:
+		writeOrganizationError(c, err)
+	}
+}
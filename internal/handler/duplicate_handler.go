@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// DuplicateHandler handles HTTP requests for finding and merging duplicate items
+type DuplicateHandler struct {
+	service *service.DuplicateService
+}
+
+// NewDuplicateHandler creates a new DuplicateHandler
+func NewDuplicateHandler(service *service.DuplicateService) *DuplicateHandler {
+	return &DuplicateHandler{service: service}
+}
+
+// mergeRequest is the body for merging duplicates into an item
+type mergeRequest struct {
+	DuplicateIDs []uuid.UUID `json:"duplicate_ids" binding:"required"`
+	Note         string      `json:"note"`
+}
+
+// List handles listing the possible duplicates of an item; admins only
+func (h *DuplicateHandler) List(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	duplicates, err := h.service.ForItem(id)
+	if err != nil {
+		writeDuplicateError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Possible duplicates retrieved successfully", duplicates)
+}
+
+// Merge handles merging duplicate items into one; admins only
+func (h *DuplicateHandler) Merge(c *gin.Context) {
+	id, moderatorID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var req mergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	item, err := h.service.Merge(id, req.DuplicateIDs, moderatorID, req.Note)
+	if err != nil {
+		writeDuplicateError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Items merged successfully", item)
+}
+
+// writeDuplicateError maps duplicate errors to responses
+func writeDuplicateError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrMergeMismatch), errors.Is(err, service.ErrMergeEscrowed):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrMergeSelf):
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/payments"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// EscrowHandler handles HTTP requests for rewards held in escrow and
+// callbacks from the payment provider
+type EscrowHandler struct {
+	service *service.EscrowService
+	token   string
+}
+
+// NewEscrowHandler creates a new EscrowHandler. When token is set, provider
+// callbacks must carry it as a token query parameter.
+func NewEscrowHandler(service *service.EscrowService, token string) *EscrowHandler {
+	return &EscrowHandler{service: service, token: token}
+}
+
+// depositRequest is the body for paying a reward into escrow
+type depositRequest struct {
+	AmountCents int64  `json:"amount_cents" binding:"required"`
+	Phone       string `json:"phone"`
+}
+
+// Deposit handles paying a reward for an item into escrow
+func (h *EscrowHandler) Deposit(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var req depositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	escrow, err := h.service.Deposit(c.Request.Context(), id, userID, req.AmountCents, req.Phone)
+	if err != nil {
+		writeEscrowError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusAccepted, "Reward payment requested; approve it on your phone", escrow)
+}
+
+// ForItem handles retrieving the reward for an item
+func (h *EscrowHandler) ForItem(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	escrow, err := h.service.ForItem(id, userID, isAdmin(c))
+	if err != nil {
+		writeEscrowError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Reward retrieved successfully", escrow)
+}
+
+// CollectionCallback handles the provider's result for a reward payment
+func (h *EscrowHandler) CollectionCallback(c *gin.Context) {
+	h.callback(c, payments.ParseCollectionCallback, h.service.CollectionResult)
+}
+
+// PayoutCallback handles the provider's result for a reward payout
+func (h *EscrowHandler) PayoutCallback(c *gin.Context) {
+	h.callback(c, payments.ParsePayoutResult, h.service.PayoutResult)
+}
+
+// PayoutTimeout handles the provider reporting that a payout timed out in
+// its queue. The outcome is unknown, so the payout is left for reconciliation.
+func (h *EscrowHandler) PayoutTimeout(c *gin.Context) {
+	if !h.authorized(c) {
+		return
+	}
+	body, _ := io.ReadAll(c.Request.Body)
+	log.Printf("escrow: payout queue timeout: %s", body)
+	acceptCallback(c)
+}
+
+// List handles listing rewards; admins only
+func (h *EscrowHandler) List(c *gin.Context) {
+	page, limit := paginationParams(c)
+	escrows, total, err := h.service.List(c.Query("status"), page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Rewards retrieved successfully", gin.H{
+		"items": escrows,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetByID handles retrieving a reward with its ledger; admins only
+func (h *EscrowHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	escrow, err := h.service.Get(id)
+	if err != nil {
+		writeEscrowError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Reward retrieved successfully", escrow)
+}
+
+// Reconciliation handles comparing the ledger with what rewards hold; admins only
+func (h *EscrowHandler) Reconciliation(c *gin.Context) {
+	report, err := h.service.Reconciliation()
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Reconciliation retrieved successfully", report)
+}
+
+// RetryPayout handles sending a stuck payout again; admins only
+func (h *EscrowHandler) RetryPayout(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	escrow, err := h.service.RetryPayout(id)
+	if err != nil {
+		writeEscrowError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Payout queued again", escrow)
+}
+
+// callback reads a provider callback and applies it. The provider only
+// needs to know the callback arrived, so failures are logged.
+func (h *EscrowHandler) callback(c *gin.Context, parse func([]byte) (*payments.Result, error), apply func(context.Context, *payments.Result) error) {
+	if !h.authorized(c) {
+		return
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	result, err := parse(body)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := apply(c.Request.Context(), result); err != nil {
+		log.Printf("escrow: callback for %s: %v", result.Reference, err)
+	}
+	acceptCallback(c)
+}
+
+// authorized checks a provider callback carries the webhook token. Callbacks
+// are refused until a token is configured.
+func (h *EscrowHandler) authorized(c *gin.Context) bool {
+	if h.token == "" {
+		models.ResponseJson(c, http.StatusServiceUnavailable, "payment callbacks are not configured", nil)
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.token)) != 1 {
+		models.ResponseJson(c, http.StatusUnauthorized, "invalid token", nil)
+		return false
+	}
+	return true
+}
+
+// acceptCallback answers a callback the way M-Pesa expects
+func acceptCallback(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"ResultCode": 0, "ResultDesc": "Accepted"})
+}
+
+// writeEscrowError maps reward errors to responses
+func writeEscrowError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrEscrowNotFound), errors.Is(err, service.ErrItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrRewardNotAllowed), errors.Is(err, service.ErrRewardOrganization):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrRewardExists), errors.Is(err, service.ErrItemAlreadyReturned),
+		errors.Is(err, service.ErrPayoutNotStuck):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrPaymentsUnavailable):
+		models.ResponseJson(c, http.StatusServiceUnavailable, err.Error(), nil)
+	case errors.Is(err, service.ErrDepositFailed):
+		models.ResponseJson(c, http.StatusBadGateway, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
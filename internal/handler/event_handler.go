@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+	"time"
+)
+
+const (
+	// keepAliveInterval is how often idle streams are pinged so proxies keep them open
+	keepAliveInterval = 25 * time.Second
+	// wsWriteTimeout bounds a single WebSocket write
+	wsWriteTimeout = 10 * time.Second
+)
+
+// wsUpgrader upgrades event stream requests to WebSockets. Origins are not
+// restricted, matching the CORS policy; requests are authenticated by token.
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+	CheckOrigin:     func(r *http.Request) bool { return true },
+}
+
+// EventHandler handles real-time event streams
+type EventHandler struct {
+	service *service.EventService
+}
+
+// NewEventHandler creates a new EventHandler
+func NewEventHandler(service *service.EventService) *EventHandler {
+	return &EventHandler{service: service}
+}
+
+// Stream handles a Server-Sent Events stream of the user's events. Clients
+// resume with the standard Last-Event-ID header or a last_event_id query.
+func (h *EventHandler) Stream(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	sub, err := h.service.Subscribe(c.Request.Context(), userID, lastEventID(c))
+	if err != nil {
+		models.ResponseJson(c, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	defer sub.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(c.Writer, ": keep-alive\n\n")
+			c.Writer.Flush()
+		case event, open := <-sub.Events():
+			if !open {
+				return
+			}
+			fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			c.Writer.Flush()
+		}
+	}
+}
+
+// WebSocket handles a WebSocket stream of the user's events, sent as JSON
+// objects. Clients resume with a last_event_id query.
+func (h *EventHandler) WebSocket(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	sub, err := h.service.Subscribe(c.Request.Context(), userID, lastEventID(c))
+	if err != nil {
+		models.ResponseJson(c, http.StatusServiceUnavailable, err.Error(), nil)
+		return
+	}
+	defer sub.Close()
+
+	conn, err := wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		log.Printf("websocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	// The stream is one-way; reading only detects the client going away
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-ticker.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case event, open := <-sub.Events():
+			if !open {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "stream fell behind, resume with last_event_id"),
+					time.Now().Add(wsWriteTimeout))
+				return
+			}
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// lastEventID reads the ID a client wants to resume after
+func lastEventID(c *gin.Context) string {
+	if id := c.GetHeader("Last-Event-ID"); id != "" {
+		return id
+	}
+	return c.Query("last_event_id")
+}
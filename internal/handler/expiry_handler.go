@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// ExpiryHandler handles HTTP requests for confirming items are still open
+type ExpiryHandler struct {
+	service *service.ExpiryService
+}
+
+// NewExpiryHandler creates a new ExpiryHandler
+func NewExpiryHandler(service *service.ExpiryService) *ExpiryHandler {
+	return &ExpiryHandler{service: service}
+}
+
+// confirmRequest is the body for confirming an item
+type confirmRequest struct {
+	Resolved bool `json:"resolved"`
+}
+
+// Confirm handles a reporter confirming their item is still lost or
+// unclaimed, or that it has been resolved
+func (h *ExpiryHandler) Confirm(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional; without one the item is confirmed as still open
+	var req confirmRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	}
+
+	item, err := h.service.Confirm(id, userID, isAdmin(c), req.Resolved)
+	switch {
+	case errors.Is(err, service.ErrItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrNotAuthorizedItem):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrItemResolved):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	if req.Resolved {
+		models.ResponseJson(c, http.StatusOK, "Item marked resolved and archived", item)
+		return
+	}
+	models.ResponseJson(c, http.StatusOK, "Item confirmed", item)
+}
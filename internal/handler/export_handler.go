@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"lostnfound-api/internal/export"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// ExportHandler handles HTTP requests for item exports
+type ExportHandler struct {
+	service *service.ExportService
+}
+
+// NewExportHandler creates a new ExportHandler
+func NewExportHandler(service *service.ExportService) *ExportHandler {
+	return &ExportHandler{service: service}
+}
+
+// StreamAll handles streaming every item matching the filters; admins only
+func (h *ExportHandler) StreamAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	h.stream(c, nil, userID)
+}
+
+// StreamOrganization handles streaming an organisation's items
+func (h *ExportHandler) StreamOrganization(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	h.stream(c, &id, userID)
+}
+
+// QueueAll handles queueing a background export of every item; admins only
+func (h *ExportHandler) QueueAll(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	h.queue(c, nil, userID)
+}
+
+// QueueOrganization handles queueing a background export of an organisation's items
+func (h *ExportHandler) QueueOrganization(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	h.queue(c, &id, userID)
+}
+
+// ListMine handles listing the current user's exports
+func (h *ExportHandler) ListMine(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	jobs, total, err := h.service.ListMine(userID, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Exports retrieved successfully", gin.H{
+		"items": jobs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetByID handles retrieving an export
+func (h *ExportHandler) GetByID(c *gin.Context) {
+	id, userID, ok := exportParams(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.service.Get(id, userID, isAdmin(c))
+	if err != nil {
+		writeExportError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Export retrieved successfully", job)
+}
+
+// Download handles redirecting to a short-lived link to a finished export
+func (h *ExportHandler) Download(c *gin.Context) {
+	id, userID, ok := exportParams(c)
+	if !ok {
+		return
+	}
+
+	url, err := h.service.DownloadURL(id, userID, isAdmin(c))
+	if err != nil {
+		writeExportError(c, err)
+		return
+	}
+
+	c.Redirect(http.StatusFound, url)
+}
+
+// stream writes an export as the response body
+func (h *ExportHandler) stream(c *gin.Context, organizationID *uuid.UUID, userID uuid.UUID) {
+	req, err := exportRequestFromQuery(c, organizationID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	started := false
+	_, err = h.service.Stream(req, userID, isAdmin(c), func(filename string) io.Writer {
+		started = true
+		c.Header("Content-Type", req.Format.ContentType())
+		c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+		c.Status(http.StatusOK)
+		return c.Writer
+	})
+	if err != nil {
+		if started {
+			// The headers are already sent, so the client sees a truncated file
+			log.Printf("exports: streaming failed: %v", err)
+			return
+		}
+		writeExportError(c, err)
+	}
+}
+
+// queue queues a background export and responds with the job
+func (h *ExportHandler) queue(c *gin.Context, organizationID *uuid.UUID, userID uuid.UUID) {
+	req, err := exportRequestFromQuery(c, organizationID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	job, err := h.service.Queue(req, userID, isAdmin(c))
+	if err != nil {
+		writeExportError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusAccepted, "Export queued successfully", job)
+}
+
+// exportRequestFromQuery reads the format, redaction and item filters of an export
+func exportRequestFromQuery(c *gin.Context, organizationID *uuid.UUID) (service.ExportRequest, error) {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return service.ExportRequest{}, err
+	}
+	return service.ExportRequest{
+		Format:         format,
+		Filter:         itemFilterFromQuery(c),
+		OrganizationID: organizationID,
+		Public:         c.Query("redact") == "public",
+	}, nil
+}
+
+// exportParams reads the export ID and current user
+func exportParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, userID, true
+}
+
+// writeExportError maps export errors to responses
+func writeExportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrExportForbidden):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrExportTooLarge):
+		models.ResponseJson(c, http.StatusRequestEntityTooLarge, err.Error(), nil)
+	case errors.Is(err, service.ErrExportNotReady), errors.Is(err, service.ErrExportUnavailable):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrExportExpired):
+		models.ResponseJson(c, http.StatusGone, err.Error(), nil)
+	default:
+		writeOrganizationError(c, err)
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// HandoverHandler handles HTTP requests for handover codes
+type HandoverHandler struct {
+	service *service.HandoverService
+}
+
+// NewHandoverHandler creates a new HandoverHandler
+func NewHandoverHandler(service *service.HandoverService) *HandoverHandler {
+	return &HandoverHandler{service: service}
+}
+
+// redeemHandoverRequest is the body for confirming a handover
+type redeemHandoverRequest struct {
+	Code      string   `json:"code" binding:"required"`
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+}
+
+// Pass handles showing the approved claimant their handover code
+func (h *HandoverHandler) Pass(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	pass, err := h.service.Pass(id, userID)
+	if err != nil {
+		writeHandoverError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Handover code retrieved successfully", pass)
+}
+
+// QRCode handles rendering the claimant's handover code as a PNG or SVG image
+func (h *HandoverHandler) QRCode(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	size := models.ParseIntOrDefault(c.DefaultQuery("size", "512"), 512)
+	data, contentType, err := h.service.QRCode(id, userID, c.DefaultQuery("format", "png"), size)
+	if err != nil {
+		writeHandoverError(c, err)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// Redeem handles the finder or custodian entering or scanning the claimant's
+// code to confirm the item was returned
+func (h *HandoverHandler) Redeem(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var req redeemHandoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	handover, err := h.service.Redeem(id, userID, isAdmin(c), req.Code, req.Latitude, req.Longitude)
+	if err != nil {
+		writeHandoverError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Item marked as returned", handover)
+}
+
+// ListForItem handles listing the handover codes issued for an item
+func (h *HandoverHandler) ListForItem(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	handovers, err := h.service.ListForItem(id, userID, isAdmin(c))
+	if err != nil {
+		writeHandoverError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Handovers retrieved successfully", handovers)
+}
+
+// writeHandoverError maps handover errors to responses
+func writeHandoverError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrClaimNotFound), errors.Is(err, service.ErrItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrHandoverNotAllowed), errors.Is(err, service.ErrNotAuthorizedItem):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrClaimNotApproved), errors.Is(err, service.ErrItemAlreadyReturned):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrHandoverCodeInvalid):
+		models.ResponseJson(c, http.StatusUnprocessableEntity, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+)
+
+// ImportHandler handles HTTP requests for bulk item imports
+type ImportHandler struct {
+	service *service.ImportService
+}
+
+// NewImportHandler creates a new ImportHandler
+func NewImportHandler(service *service.ImportService) *ImportHandler {
+	return &ImportHandler{service: service}
+}
+
+// importRequest is the JSON body for a bulk import
+type importRequest struct {
+	Items []models.ImportRow `json:"items" binding:"required"`
+}
+
+// Create handles queueing a bulk import. Send JSON with an items array, or
+// multipart form data with a CSV or XLSX "file" (or an "items" JSON array)
+// and an optional "images" ZIP whose file names the rows refer to.
+func (h *ImportHandler) Create(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	job := &models.ImportJob{Format: models.ImportFormatJSON}
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		if err := h.readUpload(c, job); err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+	} else {
+		var req importRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		job.Rows = req.Items
+	}
+
+	if err := h.service.Submit(id, userID, isAdmin(c), job); err != nil {
+		writeImportError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusAccepted, "Import queued successfully", job)
+}
+
+// List handles listing an organisation's imports
+func (h *ImportHandler) List(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	page, limit := paginationParams(c)
+	jobs, total, err := h.service.List(id, userID, isAdmin(c), page, limit)
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Imports retrieved successfully", gin.H{
+		"items": jobs,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetByID handles checking an import's status, row errors and results
+func (h *ImportHandler) GetByID(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	importID, err := uuid.Parse(c.Param("import_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid import ID", nil)
+		return
+	}
+
+	job, err := h.service.Get(id, importID, userID, isAdmin(c))
+	if err != nil {
+		writeImportError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Import retrieved successfully", job)
+}
+
+// readUpload reads the rows and image bundle of a multipart import
+func (h *ImportHandler) readUpload(c *gin.Context, job *models.ImportJob) error {
+	if header, err := c.FormFile("file"); err == nil {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".csv":
+			job.Format = models.ImportFormatCSV
+		case ".xlsx":
+			job.Format = models.ImportFormatXLSX
+		default:
+			return service.ErrImportFormat
+		}
+		data, err := readFormFile(header, service.MaxImportFileBytes)
+		if err != nil {
+			return err
+		}
+		if job.Rows, err = h.service.ParseRows(job.Format, data); err != nil {
+			return err
+		}
+		job.FileName = filepath.Base(header.Filename)
+	} else if items := c.PostForm("items"); items != "" {
+		if err := json.Unmarshal([]byte(items), &job.Rows); err != nil {
+			return errors.New("items must be a JSON array")
+		}
+	} else {
+		return errors.New("upload a file or send items")
+	}
+
+	if header, err := c.FormFile("images"); err == nil {
+		if strings.ToLower(filepath.Ext(header.Filename)) != ".zip" {
+			return service.ErrImportBundleFormat
+		}
+		data, err := readFormFile(header, service.MaxImportBundleBytes)
+		if err != nil {
+			return err
+		}
+		job.Bundle, job.BundleName = data, filepath.Base(header.Filename)
+	}
+	return nil
+}
+
+// readFormFile reads an uploaded file, refusing files larger than limit bytes
+func readFormFile(header *multipart.FileHeader, limit int64) ([]byte, error) {
+	if header.Size > limit {
+		return nil, errors.New(header.Filename + " is too large")
+	}
+	file, err := header.Open()
+	if err != nil {
+		return nil, errors.New("failed to read " + header.Filename)
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		return nil, errors.New("failed to read " + header.Filename)
+	}
+	if int64(len(data)) > limit {
+		return nil, errors.New(header.Filename + " is too large")
+	}
+	return data, nil
+}
+
+// writeImportError maps import errors to responses
+func writeImportError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrImportNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	default:
+		writeOrganizationError(c, err)
+	}
+}
@@ -1,11 +1,16 @@
 package handler
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
 	"lostnfound-api/internal/service"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // ItemHandler handles HTTP requests for items
@@ -35,11 +40,32 @@ func (h *ItemHandler) Create(c *gin.Context) {
 
 	item.UserID = userID.(uuid.UUID)
 
+	// Items are posted on behalf of an organisation through its inventory
+	item.OrganizationID, item.Organization, item.Custody = nil, nil, nil
+	item.ExternalReference = ""
+	item.RewardEscrowed = false
+	// Categories are chosen by ID; admins manage the tree itself
+	item.Category = nil
+	// Images are only added by uploading them
+	item.Images = nil
+
 	if err := h.service.Create(&item); err != nil {
+		if errors.Is(err, service.ErrThrottled) {
+			models.ResponseJson(c, http.StatusTooManyRequests, err.Error(), nil)
+			return
+		}
+		if errors.Is(err, service.ErrBanned) {
+			models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+			return
+		}
 		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
+	if len(item.PossibleDuplicates) > 0 {
+		models.ResponseJson(c, http.StatusCreated, "Item created; it may duplicate an existing report", item)
+		return
+	}
 	models.ResponseJson(c, http.StatusCreated, "Item created successfully", item)
 }
 
@@ -58,13 +84,20 @@ func (h *ItemHandler) GetByID(c *gin.Context) {
 		return
 	}
 
+	// Items hidden by moderators are only shown to their reporter
+	viewerID, _ := currentUserID(c)
+	if item.HiddenAt != nil && item.UserID != viewerID && !isAdmin(c) {
+		models.ResponseJson(c, http.StatusNotFound, "item not found", nil)
+		return
+	}
+	service.RedactForViewer(item, viewerID, isAdmin(c))
+
 	models.ResponseJson(c, http.StatusOK, "Item retrieved successfully", item)
 }
 
 // List handles retrieval of items with filtering
 func (h *ItemHandler) List(c *gin.Context) {
-	status := c.Query("status")
-	category := c.Query("category")
+	filter := itemFilterFromQuery(c)
 	page := c.DefaultQuery("page", "1")
 	limit := c.DefaultQuery("limit", "10")
 
@@ -76,12 +109,21 @@ func (h *ItemHandler) List(c *gin.Context) {
 	pageInt = models.ParseIntOrDefault(page, 1)
 	limitInt = models.ParseIntOrDefault(limit, 10)
 
-	items, count, err := h.service.List(status, category, pageInt, limitInt)
+	items, count, err := h.service.List(filter, pageInt, limitInt)
+	if errors.Is(err, service.ErrPrivateAttributeFilter) {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
 	if err != nil {
 		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
 		return
 	}
 
+	viewerID, _ := currentUserID(c)
+	for i := range items {
+		service.RedactForViewer(&items[i], viewerID, isAdmin(c))
+	}
+
 	responseData := gin.H{
 		"items": items,
 		"total": count,
@@ -92,6 +134,148 @@ func (h *ItemHandler) List(c *gin.Context) {
 	models.ResponseJson(c, http.StatusOK, "Items retrieved successfully", responseData)
 }
 
+// Nearby handles searching for items within a radius of a point
+func (h *ItemHandler) Nearby(c *gin.Context) {
+	lat, errLat := strconv.ParseFloat(c.Query("lat"), 64)
+	lng, errLng := strconv.ParseFloat(c.Query("lng"), 64)
+	if errLat != nil || errLng != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "lat and lng are required", nil)
+		return
+	}
+	radiusKm, err := strconv.ParseFloat(c.DefaultQuery("radius_km", "5"), 64)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid radius_km", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	results, count, err := h.service.Nearby(lat, lng, radiusKm, itemFilterFromQuery(c), page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	viewerID, _ := currentUserID(c)
+	nearby := make([]gin.H, 0, len(results))
+	for i := range results {
+		item := &results[i].Item
+		distance := results[i].DistanceKm
+		if viewerID != item.UserID && !isAdmin(c) {
+			// Whole kilometres, so distances can't be used to triangulate the rounded coordinates
+			distance = math.Round(distance)
+		}
+		service.RedactForViewer(item, viewerID, isAdmin(c))
+		nearby = append(nearby, gin.H{"item": item, "distance_km": distance})
+	}
+
+	responseData := gin.H{
+		"items": nearby,
+		"total": count,
+		"page":  page,
+		"limit": limit,
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Items retrieved successfully", responseData)
+}
+
+// WithinBounds handles searching for items inside a bounding box
+func (h *ItemHandler) WithinBounds(c *gin.Context) {
+	var box repository.BoundingBox
+	var errs [4]error
+	box.MinLat, errs[0] = strconv.ParseFloat(c.Query("min_lat"), 64)
+	box.MinLng, errs[1] = strconv.ParseFloat(c.Query("min_lng"), 64)
+	box.MaxLat, errs[2] = strconv.ParseFloat(c.Query("max_lat"), 64)
+	box.MaxLng, errs[3] = strconv.ParseFloat(c.Query("max_lng"), 64)
+	for _, err := range errs {
+		if err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, "min_lat, min_lng, max_lat and max_lng are required", nil)
+			return
+		}
+	}
+
+	page, limit := paginationParams(c)
+	items, count, err := h.service.WithinBounds(box, itemFilterFromQuery(c), page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	viewerID, _ := currentUserID(c)
+	for i := range items {
+		service.RedactForViewer(&items[i], viewerID, isAdmin(c))
+	}
+
+	responseData := gin.H{
+		"items": items,
+		"total": count,
+		"page":  page,
+		"limit": limit,
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Items retrieved successfully", responseData)
+}
+
+// Search handles keyword search across items, including their transit details
+func (h *ItemHandler) Search(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		models.ResponseJson(c, http.StatusBadRequest, "q is required", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	items, count, err := h.service.Search(q, itemFilterFromQuery(c), page, limit)
+	if errors.Is(err, service.ErrPrivateAttributeFilter) {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	viewerID, _ := currentUserID(c)
+	for i := range items {
+		service.RedactForViewer(&items[i], viewerID, isAdmin(c))
+	}
+
+	responseData := gin.H{
+		"items": items,
+		"total": count,
+		"page":  page,
+		"limit": limit,
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Items retrieved successfully", responseData)
+}
+
+// itemFilterFromQuery reads the item list filters from the query string.
+// Category attributes are filtered with attr.<key>=<value>, e.g. attr.brand=Tecno.
+func itemFilterFromQuery(c *gin.Context) repository.ItemFilter {
+	filter := repository.ItemFilter{
+		Status:       c.Query("status"),
+		CategoryPath: c.Query("category"),
+		CountyID:     c.Query("county"),
+		SubCountyID:  c.Query("sub_county"),
+
+		Operator:            c.Query("operator"),
+		RouteNumber:         c.Query("route"),
+		VehicleRegistration: c.Query("vehicle"),
+		Archived:            c.Query("archived"),
+	}
+
+	for key, values := range c.Request.URL.Query() {
+		if name, ok := strings.CutPrefix(key, "attr."); ok && name != "" && len(values) > 0 {
+			if filter.Attributes == nil {
+				filter.Attributes = make(map[string]string)
+			}
+			filter.Attributes[name] = values[0]
+		}
+	}
+
+	return filter
+}
+
 // Update handles updating an existing item
 func (h *ItemHandler) Update(c *gin.Context) {
 	idStr := c.Param("id")
@@ -108,6 +292,8 @@ func (h *ItemHandler) Update(c *gin.Context) {
 	}
 
 	item.ID = id
+	item.Category = nil
+	item.Images = nil
 
 	// Get user ID from context (set by auth middleware)
 	userID, exists := c.Get("userID")
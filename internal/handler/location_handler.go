@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// LocationHandler handles HTTP requests for Kenyan administrative geography
+type LocationHandler struct {
+	service *service.LocationService
+}
+
+// NewLocationHandler creates a new LocationHandler
+func NewLocationHandler(service *service.LocationService) *LocationHandler {
+	return &LocationHandler{service: service}
+}
+
+// ListCounties handles listing all counties
+func (h *LocationHandler) ListCounties(c *gin.Context) {
+	models.ResponseJson(c, http.StatusOK, "Counties retrieved successfully", h.service.Counties())
+}
+
+// GetCounty handles retrieval of a county with its sub-counties
+func (h *LocationHandler) GetCounty(c *gin.Context) {
+	county, err := h.service.County(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "County retrieved successfully", county)
+}
+
+// GetSubCounty handles retrieval of a sub-county with its wards
+func (h *LocationHandler) GetSubCounty(c *gin.Context) {
+	sub, err := h.service.SubCounty(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Sub-county retrieved successfully", sub)
+}
+
+// Resolve handles resolving free-text locations such as "Nbi CBD" to a gazetteer entry
+func (h *LocationHandler) Resolve(c *gin.Context) {
+	q := c.Query("q")
+	if q == "" {
+		models.ResponseJson(c, http.StatusBadRequest, "q is required", nil)
+		return
+	}
+
+	match, ok := h.service.Resolve(q)
+	if !ok {
+		models.ResponseJson(c, http.StatusNotFound, "no matching location", nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Location resolved successfully", match)
+}
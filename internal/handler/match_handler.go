@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// MatchHandler handles HTTP requests for lost/found matches
+type MatchHandler struct {
+	service *service.MatchService
+}
+
+// NewMatchHandler creates a new MatchHandler
+func NewMatchHandler(service *service.MatchService) *MatchHandler {
+	return &MatchHandler{service: service}
+}
+
+// ListForItem handles retrieval of likely counterparts for an item
+func (h *MatchHandler) ListForItem(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	limit := models.ParseIntOrDefault(c.DefaultQuery("limit", "10"), 10)
+	viewerID, _ := currentUserID(c)
+	matches, err := h.service.MatchesFor(id, viewerID, isAdmin(c), limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, "item not found", nil)
+		return
+	}
+
+	for i := range matches {
+		service.RedactForViewer(&matches[i].Item, viewerID, isAdmin(c))
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Matches retrieved successfully", matches)
+}
@@ -0,0 +1,298 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// MessageHandler handles HTTP requests for conversations between users
+type MessageHandler struct {
+	service *service.MessageService
+}
+
+// NewMessageHandler creates a new MessageHandler
+func NewMessageHandler(service *service.MessageService) *MessageHandler {
+	return &MessageHandler{service: service}
+}
+
+// sendMessageRequest is the JSON body for sending a message without an attachment
+type sendMessageRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// reportConversationRequest is the body for reporting a conversation
+type reportConversationRequest struct {
+	MessageID *uuid.UUID                 `json:"message_id"`
+	Reason    models.MessageReportReason `json:"reason" binding:"required"`
+	Details   string                     `json:"details"`
+}
+
+// StartForItem handles opening a conversation with an item's owner
+func (h *MessageHandler) StartForItem(c *gin.Context) {
+	h.start(c, h.service.StartForItem)
+}
+
+// StartForClaim handles opening the conversation about a claim
+func (h *MessageHandler) StartForClaim(c *gin.Context) {
+	h.start(c, h.service.StartForClaim)
+}
+
+// List handles listing the user's conversations
+func (h *MessageHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	conversations, total, err := h.service.List(userID, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Conversations retrieved successfully", gin.H{
+		"items": conversations,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Messages handles listing a conversation's messages, marking them read
+func (h *MessageHandler) Messages(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	page := models.ParseIntOrDefault(c.DefaultQuery("page", "1"), 1)
+	limit := models.ParseIntOrDefault(c.DefaultQuery("limit", "50"), 50)
+	messages, total, err := h.service.Messages(id, userID, page, limit)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Messages retrieved successfully", gin.H{
+		"items": messages,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Send handles posting a message. Attachments are sent as multipart form
+// data with a "body" field and an "attachment" file.
+func (h *MessageHandler) Send(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	var body string
+	var file multipart.File
+	var fileHeader *multipart.FileHeader
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		body = c.PostForm("body")
+		if header, err := c.FormFile("attachment"); err == nil {
+			f, err := header.Open()
+			if err != nil {
+				models.ResponseJson(c, http.StatusBadRequest, "failed to read attachment", nil)
+				return
+			}
+			defer f.Close()
+			file, fileHeader = f, header
+		}
+	} else {
+		var req sendMessageRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		body = req.Body
+	}
+
+	message, err := h.service.Send(c.Request.Context(), id, userID, body, file, fileHeader)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Message sent successfully", message)
+}
+
+// MarkRead handles marking a conversation's messages as read
+func (h *MessageHandler) MarkRead(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.MarkRead(id, userID); err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Conversation marked as read", nil)
+}
+
+// ShareContact handles a party agreeing to share contact details
+func (h *MessageHandler) ShareContact(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	exchange, err := h.service.ShareContact(id, userID)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Contact sharing updated successfully", exchange)
+}
+
+// Contacts handles retrieving the contact exchange for a conversation
+func (h *MessageHandler) Contacts(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	exchange, err := h.service.Contacts(id, userID)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Contact exchange retrieved successfully", exchange)
+}
+
+// Block handles blocking the other party in a conversation
+func (h *MessageHandler) Block(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Block(id, userID); err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "User blocked successfully", nil)
+}
+
+// Unblock handles lifting a block on the other party in a conversation
+func (h *MessageHandler) Unblock(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Unblock(id, userID); err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "User unblocked successfully", nil)
+}
+
+// Report handles reporting the other party in a conversation
+func (h *MessageHandler) Report(c *gin.Context) {
+	id, userID, ok := conversationParams(c)
+	if !ok {
+		return
+	}
+
+	var req reportConversationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	report, err := h.service.Report(id, userID, req.MessageID, req.Reason, req.Details)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Report submitted successfully", report)
+}
+
+// ListReports handles listing message reports for moderators
+func (h *MessageHandler) ListReports(c *gin.Context) {
+	page, limit := paginationParams(c)
+	reports, total, err := h.service.ListReports(page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Message reports retrieved successfully", gin.H{
+		"items": reports,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// start opens a conversation for the item or claim in the path
+func (h *MessageHandler) start(c *gin.Context, open func(id, userID uuid.UUID) (*models.Conversation, error)) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	conversation, err := open(id, userID)
+	if err != nil {
+		writeMessageError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Conversation retrieved successfully", conversation)
+}
+
+// conversationParams reads the conversation ID and the current user, writing
+// the error response if either is missing
+func conversationParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, userID, true
+}
+
+// writeMessageError maps messaging errors to responses
+func writeMessageError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrConversationNotFound), errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrClaimNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrConversationBlocked):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
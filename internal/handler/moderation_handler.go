@@ -0,0 +1,283 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// ModerationHandler handles HTTP requests for abuse reports, the moderation
+// queue and appeals
+type ModerationHandler struct {
+	service *service.ModerationService
+}
+
+// NewModerationHandler creates a new ModerationHandler
+func NewModerationHandler(service *service.ModerationService) *ModerationHandler {
+	return &ModerationHandler{service: service}
+}
+
+// reportRequest is the body for reporting an item
+type reportRequest struct {
+	Reason  models.ReportReason `json:"reason" binding:"required"`
+	Details string              `json:"details"`
+}
+
+// appealRequest is the body for appealing a moderation action
+type appealRequest struct {
+	Statement string `json:"statement" binding:"required"`
+}
+
+// appealDecisionRequest is the body for deciding an appeal
+type appealDecisionRequest struct {
+	Decision models.AppealStatus `json:"decision" binding:"required"`
+	Response string              `json:"response"`
+}
+
+// IsBanned reports whether a user is banned, for the ban middleware
+func (h *ModerationHandler) IsBanned(userID uuid.UUID) bool {
+	return h.service.IsBanned(userID)
+}
+
+// ReportItem handles reporting an item to moderators
+func (h *ModerationHandler) ReportItem(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var req reportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	report, err := h.service.ReportItem(id, userID, req.Reason, req.Details)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Report submitted successfully", report)
+}
+
+// MyActions handles listing the moderation actions taken against the current user
+func (h *ModerationHandler) MyActions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	actions, total, err := h.service.ActionsAgainst(userID, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Moderation actions retrieved successfully", gin.H{
+		"items": actions,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Appeal handles appealing a moderation action taken against the current user
+func (h *ModerationHandler) Appeal(c *gin.Context) {
+	id, userID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var req appealRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	appeal, err := h.service.Appeal(id, userID, req.Statement)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Appeal submitted successfully", appeal)
+}
+
+// MyAppeals handles listing the current user's appeals
+func (h *ModerationHandler) MyAppeals(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+	h.listAppeals(c, &userID)
+}
+
+// Queue handles listing reports, oldest first; admins only
+func (h *ModerationHandler) Queue(c *gin.Context) {
+	filter := repository.ReportFilter{
+		Status:      c.Query("status"),
+		SubjectType: c.Query("subject_type"),
+		Reason:      c.Query("reason"),
+	}
+	var ok bool
+	if filter.SubjectID, ok = uuidQuery(c, "subject_id"); !ok {
+		return
+	}
+	if filter.ReportedUserID, ok = uuidQuery(c, "user"); !ok {
+		return
+	}
+
+	page, limit := paginationParams(c)
+	reports, total, err := h.service.Queue(filter, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Reports retrieved successfully", gin.H{
+		"items": reports,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Act handles hiding, removing, warning, banning or dismissing in bulk; admins only
+func (h *ModerationHandler) Act(c *gin.Context) {
+	moderatorID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req service.ModerationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	actions, err := h.service.Act(moderatorID, req)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Moderation action recorded", actions)
+}
+
+// AuditLog handles listing moderation actions, newest first; admins only
+func (h *ModerationHandler) AuditLog(c *gin.Context) {
+	filter := repository.ModerationActionFilter{
+		Action:      c.Query("action"),
+		SubjectType: c.Query("subject_type"),
+	}
+	var ok bool
+	if filter.SubjectID, ok = uuidQuery(c, "subject_id"); !ok {
+		return
+	}
+	if filter.ModeratorID, ok = uuidQuery(c, "moderator"); !ok {
+		return
+	}
+	if filter.TargetUserID, ok = uuidQuery(c, "user"); !ok {
+		return
+	}
+
+	page, limit := paginationParams(c)
+	actions, total, err := h.service.AuditLog(filter, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Moderation actions retrieved successfully", gin.H{
+		"items": actions,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Appeals handles listing appeals, oldest first; admins only
+func (h *ModerationHandler) Appeals(c *gin.Context) {
+	h.listAppeals(c, nil)
+}
+
+// DecideAppeal handles upholding or overturning an appeal; admins only
+func (h *ModerationHandler) DecideAppeal(c *gin.Context) {
+	id, moderatorID, ok := itemParams(c)
+	if !ok {
+		return
+	}
+
+	var req appealDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	appeal, err := h.service.DecideAppeal(id, moderatorID, req.Decision, req.Response)
+	if err != nil {
+		writeModerationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Appeal decided", appeal)
+}
+
+// listAppeals writes a page of appeals, optionally only one user's
+func (h *ModerationHandler) listAppeals(c *gin.Context, userID *uuid.UUID) {
+	page, limit := paginationParams(c)
+	appeals, total, err := h.service.Appeals(c.Query("status"), userID, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Appeals retrieved successfully", gin.H{
+		"items": appeals,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// uuidQuery reads an optional UUID query parameter
+func uuidQuery(c *gin.Context, name string) (*uuid.UUID, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid "+name, nil)
+		return nil, false
+	}
+	return &id, true
+}
+
+// writeModerationError maps moderation errors to responses
+func writeModerationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrItemNotFound), errors.Is(err, service.ErrModerationReportNotFound),
+		errors.Is(err, service.ErrModerationNotFound), errors.Is(err, service.ErrAppealNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrReportOwnItem), errors.Is(err, service.ErrAppealNotAllowed),
+		errors.Is(err, service.ErrAppealWindowExpired):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrAlreadyReported), errors.Is(err, service.ErrReportClosed),
+		errors.Is(err, service.ErrAppealExists), errors.Is(err, service.ErrAppealAlreadyDecided):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrActionNotApplicable):
+		models.ResponseJson(c, http.StatusUnprocessableEntity, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// NotificationHandler handles HTTP requests for notifications and preferences
+type NotificationHandler struct {
+	service *service.NotificationService
+}
+
+// NewNotificationHandler creates a new NotificationHandler
+func NewNotificationHandler(service *service.NotificationService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// Inbox handles listing the user's in-app notifications. Pass unread=true
+// for unread notifications only.
+func (h *NotificationHandler) Inbox(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	notifications, total, err := h.service.Inbox(userID, c.Query("unread") == "true", page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+	unread, err := h.service.UnreadCount(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notifications retrieved successfully", gin.H{
+		"items":  notifications,
+		"total":  total,
+		"unread": unread,
+		"page":   page,
+		"limit":  limit,
+	})
+}
+
+// MarkRead handles marking a notification as read
+func (h *NotificationHandler) MarkRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	if err := h.service.MarkRead(id, userID); err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notification marked as read", nil)
+}
+
+// MarkAllRead handles marking all of the user's notifications as read
+func (h *NotificationHandler) MarkAllRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	if err := h.service.MarkAllRead(userID); err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notifications marked as read", nil)
+}
+
+// Preferences handles retrieving the user's language and channel choices
+func (h *NotificationHandler) Preferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	preferences, err := h.service.Preferences(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notification preferences retrieved successfully", preferences)
+}
+
+// UpdatePreferences handles changing the user's language and channel choices
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req service.NotificationPreferences
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	preferences, err := h.service.UpdatePreferences(userID, &req)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notification preferences updated successfully", preferences)
+}
+
+// Outbox handles listing outbox entries for administrators, optionally by status
+func (h *NotificationHandler) Outbox(c *gin.Context) {
+	page, limit := paginationParams(c)
+	status := models.NotificationStatus(c.Query("status"))
+	notifications, total, err := h.service.Outbox(status, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notifications retrieved successfully", gin.H{
+		"items": notifications,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Retry handles requeueing a failed notification
+func (h *NotificationHandler) Retry(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	if err := h.service.Retry(id); err != nil {
+		writeNotificationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Notification queued for delivery", nil)
+}
+
+// writeNotificationError maps notification errors to responses
+func writeNotificationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotificationNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
@@ -0,0 +1,431 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// OrganizationHandler handles HTTP requests for organisations, their staff
+// and their inventory
+type OrganizationHandler struct {
+	service *service.OrganizationService
+}
+
+// NewOrganizationHandler creates a new OrganizationHandler
+func NewOrganizationHandler(service *service.OrganizationService) *OrganizationHandler {
+	return &OrganizationHandler{service: service}
+}
+
+// organizationRequest is the body for creating or updating an organisation
+type organizationRequest struct {
+	Name         string                  `json:"name"`
+	Type         models.OrganizationType `json:"type"`
+	Description  string                  `json:"description"`
+	Phone        string                  `json:"phone"`
+	Email        string                  `json:"email"`
+	Location     string                  `json:"location"`
+	County       string                  `json:"county"`
+	SubCounty    string                  `json:"sub_county"`
+	Latitude     *float64                `json:"latitude"`
+	Longitude    *float64                `json:"longitude"`
+	OpeningHours []models.OpeningHours   `json:"opening_hours"`
+}
+
+// toModel converts the request into an organisation
+func (r *organizationRequest) toModel() *models.Organization {
+	return &models.Organization{
+		Name:         r.Name,
+		Type:         r.Type,
+		Description:  r.Description,
+		Phone:        r.Phone,
+		Email:        r.Email,
+		Location:     r.Location,
+		CountyID:     r.County,
+		SubCountyID:  r.SubCounty,
+		Latitude:     r.Latitude,
+		Longitude:    r.Longitude,
+		OpeningHours: r.OpeningHours,
+	}
+}
+
+// memberRequest is the body for adding a member or changing their role
+type memberRequest struct {
+	Email string                  `json:"email"`
+	Role  models.OrganizationRole `json:"role" binding:"required"`
+}
+
+// organizationItemRequest is an item posted on behalf of an organisation,
+// with where the organisation is keeping it
+type organizationItemRequest struct {
+	models.Item
+	StorageLocation string `json:"storage_location"`
+}
+
+// Create handles registering an organisation
+func (h *OrganizationHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req organizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	organization := req.toModel()
+	if err := h.service.Create(organization, userID); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Organization created successfully", organization)
+}
+
+// List handles the public directory of organisations. Filter with type,
+// county, q and verified=true.
+func (h *OrganizationHandler) List(c *gin.Context) {
+	page, limit := paginationParams(c)
+	filter := repository.OrganizationFilter{
+		Type:         models.OrganizationType(c.Query("type")),
+		CountyID:     c.Query("county"),
+		VerifiedOnly: c.Query("verified") == "true",
+		Query:        c.Query("q"),
+	}
+
+	organizations, total, err := h.service.List(filter, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Organizations retrieved successfully", gin.H{
+		"items": organizations,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// GetByID handles retrieving an organisation's public profile
+func (h *OrganizationHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	organization, err := h.service.GetByID(id)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Organization retrieved successfully", organization)
+}
+
+// Mine handles listing the organisations the user belongs to
+func (h *OrganizationHandler) Mine(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	memberships, err := h.service.Memberships(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Memberships retrieved successfully", memberships)
+}
+
+// Update handles changing an organisation's profile
+func (h *OrganizationHandler) Update(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	var req organizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	organization, err := h.service.Update(id, userID, isAdmin(c), req.toModel())
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Organization updated successfully", organization)
+}
+
+// Verify handles granting or withdrawing an organisation's verified badge
+func (h *OrganizationHandler) Verify(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	var req struct {
+		Verified bool `json:"verified"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	organization, err := h.service.SetVerified(id, req.Verified)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Organization verification updated successfully", organization)
+}
+
+// Members handles listing an organisation's staff
+func (h *OrganizationHandler) Members(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	members, err := h.service.Members(id, userID, isAdmin(c))
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Members retrieved successfully", members)
+}
+
+// AddMember handles adding a user to an organisation's staff
+func (h *OrganizationHandler) AddMember(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	var req memberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	member, err := h.service.AddMember(id, userID, isAdmin(c), req.Email, req.Role)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Member added successfully", member)
+}
+
+// UpdateMember handles changing a member's role
+func (h *OrganizationHandler) UpdateMember(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid user ID", nil)
+		return
+	}
+
+	var req memberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := h.service.UpdateMemberRole(id, memberID, userID, isAdmin(c), req.Role); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Member updated successfully", nil)
+}
+
+// RemoveMember handles removing a member, or a member leaving
+func (h *OrganizationHandler) RemoveMember(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	memberID, err := uuid.Parse(c.Param("user_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid user ID", nil)
+		return
+	}
+
+	if err := h.service.RemoveMember(id, memberID, userID, isAdmin(c)); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Member removed successfully", nil)
+}
+
+// Inventory handles listing the items an organisation posted or holds.
+// Filter with custody=held, released or disposed.
+func (h *OrganizationHandler) Inventory(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	page, limit := paginationParams(c)
+	status := models.CustodyStatus(c.Query("custody"))
+	items, total, err := h.service.Inventory(id, userID, isAdmin(c), status, page, limit)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Inventory retrieved successfully", gin.H{
+		"items": items,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// PostItem handles posting an item on behalf of an organisation
+func (h *OrganizationHandler) PostItem(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+
+	var req organizationItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	item := &req.Item
+	if err := h.service.PostItem(id, userID, isAdmin(c), item, req.StorageLocation); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Item created successfully", item)
+}
+
+// UpdateItem handles editing an item in an organisation's inventory
+func (h *OrganizationHandler) UpdateItem(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid item ID", nil)
+		return
+	}
+
+	var item models.Item
+	if err := c.ShouldBindJSON(&item); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	if err := h.service.UpdateItem(id, itemID, userID, isAdmin(c), &item); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Item updated successfully", item)
+}
+
+// RemoveItem handles deleting an item from an organisation's inventory
+func (h *OrganizationHandler) RemoveItem(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid item ID", nil)
+		return
+	}
+
+	if err := h.service.RemoveItem(id, itemID, userID, isAdmin(c)); err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Item deleted successfully", nil)
+}
+
+// UpdateCustody handles recording where an organisation holds an item or
+// that it has let the item go
+func (h *OrganizationHandler) UpdateCustody(c *gin.Context) {
+	id, userID, ok := organizationParams(c)
+	if !ok {
+		return
+	}
+	itemID, err := uuid.Parse(c.Param("item_id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid item ID", nil)
+		return
+	}
+
+	var req service.CustodyUpdate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	custody, err := h.service.UpdateCustody(id, itemID, userID, isAdmin(c), req)
+	if err != nil {
+		writeOrganizationError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Custody updated successfully", custody)
+}
+
+// organizationParams reads the organisation ID and the current user,
+// writing the error response when either is missing
+func organizationParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, userID, true
+}
+
+// writeOrganizationError maps organisation errors to responses
+func writeOrganizationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrMemberNotFound),
+		errors.Is(err, service.ErrNotInInventory):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrNotOrganizationMember), errors.Is(err, service.ErrOrganizationAdminOnly):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrAlreadyMember), errors.Is(err, service.ErrLastOrganizationAdmin):
+		models.ResponseJson(c, http.StatusConflict, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
@@ -0,0 +1,290 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+	"strings"
+)
+
+// RecoveryTagHandler handles HTTP requests for QR recovery tags
+type RecoveryTagHandler struct {
+	service *service.RecoveryTagService
+}
+
+// NewRecoveryTagHandler creates a new RecoveryTagHandler
+func NewRecoveryTagHandler(service *service.RecoveryTagService) *RecoveryTagHandler {
+	return &RecoveryTagHandler{service: service}
+}
+
+// createRecoveryTagRequest is the body for issuing a tag
+type createRecoveryTagRequest struct {
+	AssetID *uuid.UUID `json:"asset_id"`
+	Label   string     `json:"label"`
+}
+
+// finderReportRequest is the body a finder submits after scanning a tag
+type finderReportRequest struct {
+	Message       string `json:"message" binding:"required,max=2000"`
+	Location      string `json:"location" binding:"max=200"`
+	FinderName    string `json:"finder_name" binding:"max=100"`
+	FinderContact string `json:"finder_contact" binding:"max=100"`
+}
+
+// Create handles issuing a new recovery tag
+func (h *RecoveryTagHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req createRecoveryTagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tag, err := h.service.Create(userID, req.AssetID, req.Label)
+	switch {
+	case errors.Is(err, service.ErrAssetNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Recovery tag created successfully", gin.H{
+		"tag":      tag,
+		"scan_url": h.service.ScanURL(tag),
+	})
+}
+
+// List handles listing the user's recovery tags
+func (h *RecoveryTagHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	tags, err := h.service.List(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Recovery tags retrieved successfully", tags)
+}
+
+// Deactivate handles retiring a recovery tag
+func (h *RecoveryTagHandler) Deactivate(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	err = h.service.Deactivate(id, userID)
+	switch {
+	case errors.Is(err, service.ErrRecoveryTagNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Recovery tag deactivated successfully", nil)
+}
+
+// QRCode handles rendering a tag as a PNG or SVG image
+func (h *RecoveryTagHandler) QRCode(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	size := models.ParseIntOrDefault(c.DefaultQuery("size", "512"), 512)
+	data, contentType, err := h.service.QRCode(id, userID, c.DefaultQuery("format", "png"), size)
+	switch {
+	case errors.Is(err, service.ErrRecoveryTagNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	c.Data(http.StatusOK, contentType, data)
+}
+
+// Sheet handles rendering a printable PDF sheet of tags. An optional
+// comma-separated ids query limits the sheet to those tags.
+func (h *RecoveryTagHandler) Sheet(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var ids []uuid.UUID
+	if raw := c.Query("ids"); raw != "" {
+		for _, part := range strings.Split(raw, ",") {
+			id, err := uuid.Parse(strings.TrimSpace(part))
+			if err != nil {
+				models.ResponseJson(c, http.StatusBadRequest, "invalid ID in ids", nil)
+				return
+			}
+			ids = append(ids, id)
+		}
+	}
+
+	data, err := h.service.Sheet(userID, ids)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="recovery-tags.pdf"`)
+	c.Data(http.StatusOK, "application/pdf", data)
+}
+
+// Lookup handles a finder opening a scanned tag
+func (h *RecoveryTagHandler) Lookup(c *gin.Context) {
+	tag, err := h.service.Lookup(c.Param("code"))
+	if err != nil {
+		writeRecoveryTagError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Recovery tag retrieved successfully", tag)
+}
+
+// Report handles a finder reporting a tagged belonging as found
+func (h *RecoveryTagHandler) Report(c *gin.Context) {
+	var req finderReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	report, err := h.service.Report(c.Param("code"), service.FinderReport{
+		Message:       req.Message,
+		Location:      req.Location,
+		FinderName:    req.FinderName,
+		FinderContact: req.FinderContact,
+	})
+	if err != nil {
+		writeRecoveryTagError(c, err)
+		return
+	}
+
+	// The finder only gets a reference, never the owner's details
+	models.ResponseJson(c, http.StatusCreated, "Thank you, the owner has been notified", gin.H{
+		"reference": report.ID,
+	})
+}
+
+// ListReports handles listing the reports finders sent to the user
+func (h *RecoveryTagHandler) ListReports(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	reports, err := h.service.ListReports(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Recovery reports retrieved successfully", reports)
+}
+
+// MarkReportRead handles marking a finder's report as read
+func (h *RecoveryTagHandler) MarkReportRead(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	if err := h.service.MarkReportRead(id, userID); err != nil {
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Recovery report marked as read", nil)
+}
+
+// PublishReport handles the owner listing the found item a finder's report filed
+func (h *RecoveryTagHandler) PublishReport(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	item, err := h.service.PublishReport(id, userID)
+	switch {
+	case errors.Is(err, service.ErrReportNotFound), errors.Is(err, service.ErrItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrThrottled):
+		models.ResponseJson(c, http.StatusTooManyRequests, err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrBanned):
+		models.ResponseJson(c, http.StatusForbidden, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Found item published successfully", item)
+}
+
+// writeRecoveryTagError maps public tag errors to responses
+func writeRecoveryTagError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrRecoveryTagNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrRecoveryTagInactive):
+		models.ResponseJson(c, http.StatusGone, err.Error(), nil)
+	case errors.Is(err, service.ErrTooManyReports):
+		models.ResponseJson(c, http.StatusTooManyRequests, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+	}
+}
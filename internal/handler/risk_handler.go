@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/service"
+	"net/http"
+	"strconv"
+)
+
+// RiskHandler handles HTTP requests for the risk review queue
+type RiskHandler struct {
+	service *service.RiskService
+}
+
+// NewRiskHandler creates a new RiskHandler
+func NewRiskHandler(service *service.RiskService) *RiskHandler {
+	return &RiskHandler{service: service}
+}
+
+// riskDecisionRequest is the body for deciding a risk review
+type riskDecisionRequest struct {
+	Decision models.RiskReviewStatus `json:"decision" binding:"required"`
+	Note     string                  `json:"note"`
+}
+
+// List handles listing risk reviews, highest score first; admins only
+func (h *RiskHandler) List(c *gin.Context) {
+	filter := repository.RiskReviewFilter{
+		Status:      c.Query("status"),
+		SubjectType: c.Query("subject_type"),
+	}
+	if actor := c.Query("actor"); actor != "" {
+		actorID, err := uuid.Parse(actor)
+		if err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, "invalid actor", nil)
+			return
+		}
+		filter.ActorID = &actorID
+	}
+	if minScore := c.Query("min_score"); minScore != "" {
+		score, err := strconv.Atoi(minScore)
+		if err != nil {
+			models.ResponseJson(c, http.StatusBadRequest, "invalid min_score", nil)
+			return
+		}
+		filter.MinScore = score
+	}
+
+	page, limit := paginationParams(c)
+	reviews, total, err := h.service.Reviews(filter, page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Risk reviews retrieved successfully", gin.H{
+		"items": reviews,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Decide handles clearing or confirming a risk review; admins only
+func (h *RiskHandler) Decide(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+	moderatorID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req riskDecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	review, err := h.service.Decide(id, moderatorID, req.Decision, req.Note)
+	if err != nil {
+		if errors.Is(err, service.ErrRiskReviewNotFound) {
+			models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Risk review decided", review)
+}
@@ -0,0 +1,200 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+	"time"
+)
+
+// SavedSearchHandler handles HTTP requests for saved searches
+type SavedSearchHandler struct {
+	service *service.SavedSearchService
+}
+
+// NewSavedSearchHandler creates a new SavedSearchHandler
+func NewSavedSearchHandler(service *service.SavedSearchService) *SavedSearchHandler {
+	return &SavedSearchHandler{service: service}
+}
+
+// savedSearchRequest is the body for creating or updating a saved search
+type savedSearchRequest struct {
+	Name          string                `json:"name"`
+	Keywords      string                `json:"keywords"`
+	Category      string                `json:"category"`
+	County        string                `json:"county"`
+	Status        models.ItemStatus     `json:"status"`
+	DateFrom      *time.Time            `json:"date_from"`
+	DateTo        *time.Time            `json:"date_to"`
+	Frequency     models.AlertFrequency `json:"frequency"`
+	ExpiresInDays int                   `json:"expires_in_days"`
+}
+
+// toModel converts the request into a saved search for the user
+func (r *savedSearchRequest) toModel(userID uuid.UUID) *models.SavedSearch {
+	return &models.SavedSearch{
+		UserID:       userID,
+		Name:         r.Name,
+		Keywords:     r.Keywords,
+		CategoryPath: r.Category,
+		CountyID:     r.County,
+		Status:       r.Status,
+		DateFrom:     r.DateFrom,
+		DateTo:       r.DateTo,
+		Frequency:    r.Frequency,
+	}
+}
+
+// Create handles saving a new search
+func (h *SavedSearchHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req savedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	search := req.toModel(userID)
+	if err := h.service.Create(search, req.ExpiresInDays); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Saved search created successfully", search)
+}
+
+// List handles listing the user's saved searches
+func (h *SavedSearchHandler) List(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	searches, err := h.service.ListForUser(userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Saved searches retrieved successfully", searches)
+}
+
+// GetByID handles retrieving a saved search
+func (h *SavedSearchHandler) GetByID(c *gin.Context) {
+	id, userID, ok := savedSearchParams(c)
+	if !ok {
+		return
+	}
+
+	search, err := h.service.GetOwned(id, userID)
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Saved search retrieved successfully", search)
+}
+
+// Update handles changing a saved search; this also renews its expiry
+func (h *SavedSearchHandler) Update(c *gin.Context) {
+	id, userID, ok := savedSearchParams(c)
+	if !ok {
+		return
+	}
+
+	var req savedSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	search, err := h.service.Update(id, req.toModel(userID), req.ExpiresInDays)
+	switch {
+	case errors.Is(err, service.ErrSavedSearchNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Saved search updated successfully", search)
+}
+
+// Delete handles removing a saved search
+func (h *SavedSearchHandler) Delete(c *gin.Context) {
+	id, userID, ok := savedSearchParams(c)
+	if !ok {
+		return
+	}
+
+	err := h.service.Delete(id, userID)
+	switch {
+	case errors.Is(err, service.ErrSavedSearchNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Saved search deleted successfully", nil)
+}
+
+// Items handles listing the items that matched a saved search
+func (h *SavedSearchHandler) Items(c *gin.Context) {
+	id, userID, ok := savedSearchParams(c)
+	if !ok {
+		return
+	}
+
+	page, limit := paginationParams(c)
+	hits, total, err := h.service.Hits(id, userID, page, limit)
+	switch {
+	case errors.Is(err, service.ErrSavedSearchNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	for i := range hits {
+		if hits[i].Item != nil {
+			service.RedactForViewer(hits[i].Item, userID, isAdmin(c))
+		}
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Saved search items retrieved successfully", gin.H{
+		"items": hits,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// savedSearchParams reads the saved search ID and the current user, writing
+// the error response if either is missing
+func savedSearchParams(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, userID, true
+}
@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"lostnfound-api/internal/sms"
+	"net/http"
+)
+
+// SMSHandler handles callbacks from the SMS provider
+type SMSHandler struct {
+	service *service.SMSService
+	token   string
+}
+
+// NewSMSHandler creates a new SMSHandler. Callbacks must carry token as a
+// token query parameter; without a token every callback is refused, since
+// the sender's number is taken on trust.
+func NewSMSHandler(service *service.SMSService, token string) *SMSHandler {
+	return &SMSHandler{service: service, token: token}
+}
+
+// Inbound handles an incoming text message callback
+func (h *SMSHandler) Inbound(c *gin.Context) {
+	if h.token == "" {
+		models.ResponseJson(c, http.StatusServiceUnavailable, "SMS webhook is not configured", nil)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.token)) != 1 {
+		models.ResponseJson(c, http.StatusUnauthorized, "invalid token", nil)
+		return
+	}
+
+	if err := c.Request.ParseForm(); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+	msg, err := sms.ParseInbound(c.Request.PostForm)
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	// The provider does not use the response, so failures are only logged
+	reply, err := h.service.HandleInbound(c.Request.Context(), msg)
+	if err != nil {
+		log.Printf("sms: inbound message %s: %v", msg.ID, err)
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Message received", gin.H{"reply": reply})
+}
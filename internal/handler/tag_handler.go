@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// TagHandler handles HTTP requests for item tags
+type TagHandler struct {
+	service     *service.TagService
+	itemService *service.ItemService
+}
+
+// NewTagHandler creates a new TagHandler
+func NewTagHandler(service *service.TagService, itemService *service.ItemService) *TagHandler {
+	return &TagHandler{service: service, itemService: itemService}
+}
+
+// tagsRequest is the body for adding tags to an item
+type tagsRequest struct {
+	Tags []string `json:"tags" binding:"required"`
+}
+
+// AddToItem handles attaching tags to an item
+func (h *TagHandler) AddToItem(c *gin.Context) {
+	item, ok := h.ownedItem(c)
+	if !ok {
+		return
+	}
+
+	var req tagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	tags, err := h.service.AddToItem(item.ID, req.Tags)
+	switch {
+	case errors.Is(err, service.ErrTooManyTags):
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Tags added successfully", tags)
+}
+
+// RemoveFromItem handles detaching a tag from an item
+func (h *TagHandler) RemoveFromItem(c *gin.Context) {
+	item, ok := h.ownedItem(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.RemoveFromItem(item.ID, c.Param("tag")); err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Tag removed successfully", nil)
+}
+
+// Suggestions handles suggesting tags for an item from its title and description
+func (h *TagHandler) Suggestions(c *gin.Context) {
+	item, ok := h.ownedItem(c)
+	if !ok {
+		return
+	}
+
+	suggestions, err := h.service.Suggest(item)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Tag suggestions retrieved successfully", suggestions)
+}
+
+// Popular handles listing the most used tags
+func (h *TagHandler) Popular(c *gin.Context) {
+	limit := models.ParseIntOrDefault(c.DefaultQuery("limit", "20"), 20)
+
+	tags, err := h.service.Popular(limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Popular tags retrieved successfully", tags)
+}
+
+// ownedItem loads the item in the path and checks the user owns it or is an
+// admin, writing the error response if not
+func (h *TagHandler) ownedItem(c *gin.Context) (*models.Item, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return nil, false
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return nil, false
+	}
+
+	item, err := h.itemService.GetByID(id)
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, "item not found", nil)
+		return nil, false
+	}
+
+	if item.UserID != userID && !isAdmin(c) {
+		models.ResponseJson(c, http.StatusForbidden, "not authorized to tag this item", nil)
+		return nil, false
+	}
+
+	return item, true
+}
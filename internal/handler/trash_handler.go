@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// TrashHandler handles HTTP requests for deleted items
+type TrashHandler struct {
+	service *service.TrashService
+}
+
+// NewTrashHandler creates a new TrashHandler
+func NewTrashHandler(service *service.TrashService) *TrashHandler {
+	return &TrashHandler{service: service}
+}
+
+// Restore handles an owner restoring their own deleted item within the retention window
+func (h *TrashHandler) Restore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	item, err := h.service.GetDeleted(id)
+	if err != nil {
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	}
+
+	if item.UserID != userID {
+		models.ResponseJson(c, http.StatusForbidden, "not authorized to restore this item", nil)
+		return
+	}
+	if item.HiddenAt != nil {
+		models.ResponseJson(c, http.StatusForbidden, "this item was removed by a moderator; appeal the removal instead", nil)
+		return
+	}
+	if item.MergedIntoID != nil {
+		models.ResponseJson(c, http.StatusConflict, "this item was merged into "+item.MergedIntoID.String(), nil)
+		return
+	}
+
+	h.restore(c, id, true)
+}
+
+// AdminRestore handles an administrator restoring any deleted item that has not been purged
+func (h *TrashHandler) AdminRestore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	h.restore(c, id, false)
+}
+
+// ListDeleted handles the admin trash view
+func (h *TrashHandler) ListDeleted(c *gin.Context) {
+	page, limit := paginationParams(c)
+
+	items, count, err := h.service.ListDeleted(page, limit)
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	viewerID, _ := currentUserID(c)
+	deleted := make([]gin.H, 0, len(items))
+	for i := range items {
+		service.RedactForViewer(&items[i], viewerID, isAdmin(c))
+		deleted = append(deleted, gin.H{
+			"item":     items[i],
+			"purge_at": h.service.PurgeAt(&items[i]),
+		})
+	}
+
+	responseData := gin.H{
+		"items":          deleted,
+		"total":          count,
+		"page":           page,
+		"limit":          limit,
+		"retention_days": h.service.RetentionDays(),
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Deleted items retrieved successfully", responseData)
+}
+
+func (h *TrashHandler) restore(c *gin.Context, id uuid.UUID, enforceWindow bool) {
+	item, err := h.service.Restore(id, enforceWindow)
+	switch {
+	case errors.Is(err, service.ErrDeletedItemNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+		return
+	case errors.Is(err, service.ErrRestoreWindowExpired):
+		models.ResponseJson(c, http.StatusGone, err.Error(), nil)
+		return
+	case err != nil:
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	viewerID, _ := currentUserID(c)
+	service.RedactForViewer(item, viewerID, isAdmin(c))
+	models.ResponseJson(c, http.StatusOK, "Item restored successfully", item)
+}
@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// USSDHandler handles callbacks from the USSD gateway
+type USSDHandler struct {
+	service *service.USSDService
+	token   string
+}
+
+// NewUSSDHandler creates a new USSDHandler. Callbacks must carry token as a
+// token query parameter; without a token every callback is refused, since
+// the caller's number is taken on trust.
+func NewUSSDHandler(service *service.USSDService, token string) *USSDHandler {
+	return &USSDHandler{service: service, token: token}
+}
+
+// Callback handles one step of a USSD session. The gateway expects a plain
+// text screen starting with CON or END.
+func (h *USSDHandler) Callback(c *gin.Context) {
+	if h.token == "" {
+		models.ResponseJson(c, http.StatusServiceUnavailable, "USSD webhook is not configured", nil)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.token)) != 1 {
+		models.ResponseJson(c, http.StatusUnauthorized, "invalid token", nil)
+		return
+	}
+
+	req := service.USSDRequest{
+		SessionID:   c.PostForm("sessionId"),
+		ServiceCode: c.PostForm("serviceCode"),
+		PhoneNumber: c.PostForm("phoneNumber"),
+		Text:        c.PostForm("text"),
+	}
+	if req.SessionID == "" || req.PhoneNumber == "" {
+		models.ResponseJson(c, http.StatusBadRequest, "sessionId and phoneNumber are required", nil)
+		return
+	}
+
+	c.String(http.StatusOK, h.service.Handle(c.Request.Context(), req))
+}
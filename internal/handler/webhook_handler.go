@@ -0,0 +1,208 @@
+package handler
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/service"
+	"net/http"
+)
+
+// WebhookHandler handles HTTP requests for managing partner webhooks
+type WebhookHandler struct {
+	service *service.WebhookService
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+func NewWebhookHandler(service *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// webhookRequest is the body for creating or updating a subscription
+type webhookRequest struct {
+	Name       string   `json:"name"`
+	URL        string   `json:"url"`
+	Secret     string   `json:"secret"`
+	EventTypes []string `json:"event_types"`
+	Counties   []string `json:"counties"`
+	Categories []string `json:"categories"`
+	Active     *bool    `json:"active"`
+}
+
+// toModel converts the request into a subscription. Subscriptions are active
+// unless the request says otherwise.
+func (r *webhookRequest) toModel() *models.WebhookSubscription {
+	active := r.Active == nil || *r.Active
+	return &models.WebhookSubscription{
+		Name:          r.Name,
+		URL:           r.URL,
+		Secret:        r.Secret,
+		EventTypes:    r.EventTypes,
+		CountyIDs:     r.Counties,
+		CategoryPaths: r.Categories,
+		Active:        active,
+	}
+}
+
+// Create handles adding a subscription. The signing secret is only returned here.
+func (h *WebhookHandler) Create(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		models.ResponseJson(c, http.StatusUnauthorized, "unauthorized", nil)
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	subscription := req.toModel()
+	subscription.CreatedByID = userID
+	if err := h.service.Create(subscription); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusCreated, "Webhook created successfully", gin.H{
+		"webhook": subscription,
+		"secret":  subscription.Secret,
+	})
+}
+
+// List handles listing all subscriptions
+func (h *WebhookHandler) List(c *gin.Context) {
+	subscriptions, err := h.service.List()
+	if err != nil {
+		models.ResponseJson(c, http.StatusInternalServerError, err.Error(), nil)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Webhooks retrieved successfully", subscriptions)
+}
+
+// GetByID handles retrieving a subscription
+func (h *WebhookHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	subscription, err := h.service.GetByID(id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Webhook retrieved successfully", subscription)
+}
+
+// Update handles changing a subscription. The secret is changed through RotateSecret.
+func (h *WebhookHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+		return
+	}
+
+	subscription, err := h.service.Update(id, req.toModel())
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Webhook updated successfully", subscription)
+}
+
+// RotateSecret handles replacing a subscription's signing secret
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	secret, err := h.service.RotateSecret(id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Webhook secret rotated successfully", gin.H{"secret": secret})
+}
+
+// Delete handles removing a subscription
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// Deliveries handles listing a subscription's delivery log, optionally by status
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	page, limit := paginationParams(c)
+	status := models.WebhookDeliveryStatus(c.Query("status"))
+	deliveries, total, err := h.service.Deliveries(id, status, page, limit)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusOK, "Webhook deliveries retrieved successfully", gin.H{
+		"items": deliveries,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// Redeliver handles sending a logged delivery again
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		models.ResponseJson(c, http.StatusBadRequest, "invalid ID", nil)
+		return
+	}
+
+	delivery, err := h.service.Redeliver(id)
+	if err != nil {
+		writeWebhookError(c, err)
+		return
+	}
+
+	models.ResponseJson(c, http.StatusAccepted, "Webhook queued for redelivery", delivery)
+}
+
+// writeWebhookError maps webhook errors to responses
+func writeWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		models.ResponseJson(c, http.StatusNotFound, err.Error(), nil)
+	default:
+		models.ResponseJson(c, http.StatusBadRequest, err.Error(), nil)
+	}
+}
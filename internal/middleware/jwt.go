@@ -8,13 +8,14 @@ import (
 
 	"github.com/dgrijalva/jwt-go"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // JWTClaims represents claims in JWT token
 type JWTClaims struct {
-	UserID  uint   `json:"user_id"`
-	Email   string `json:"email"`
-	IsAdmin bool   `json:"is_admin"`
+	UserID  uuid.UUID `json:"user_id"`
+	Email   string    `json:"email"`
+	IsAdmin bool      `json:"is_admin"`
 	jwt.StandardClaims
 }
 
@@ -76,3 +77,49 @@ func JWT(secret string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// QueryToken lets clients that cannot set headers, such as EventSource and
+// browser WebSockets, pass the bearer token as an access_token query
+// parameter. It must be used before the JWT middleware.
+func QueryToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		c.Next()
+	}
+}
+
+// AdminOnly middleware restricts access to administrators.
+// It must be used after the JWT middleware.
+func AdminOnly() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		isAdmin, _ := c.Get("isAdmin")
+		if isAdmin != true {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// NotBanned stops banned users making changes. They can still read, so
+// they can see what moderators did and appeal it through routes outside
+// this middleware. It must be used after the JWT middleware.
+func NotBanned(isBanned func(userID uuid.UUID) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			userID, _ := c.Get("userID")
+			if id, ok := userID.(uuid.UUID); ok && isBanned(id) {
+				c.JSON(http.StatusForbidden, gin.H{"error": "Your account has been banned"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
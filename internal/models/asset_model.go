@@ -0,0 +1,63 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AssetType is the kind of valuable registered by an owner
+type AssetType string
+
+const (
+	AssetTypePhone   AssetType = "phone"
+	AssetTypeLaptop  AssetType = "laptop"
+	AssetTypeTablet  AssetType = "tablet"
+	AssetTypeBicycle AssetType = "bicycle"
+	AssetTypeOther   AssetType = "other"
+)
+
+// AssetStatus tracks whether a registered asset is with its owner
+type AssetStatus string
+
+const (
+	AssetStatusRegistered AssetStatus = "registered"
+	AssetStatusLost       AssetStatus = "lost"
+	AssetStatusStolen     AssetStatus = "stolen"
+	AssetStatusRecovered  AssetStatus = "recovered"
+)
+
+// RegisteredAsset is a valuable an owner registered before losing it.
+// The identifier (IMEI, serial or frame number) is only stored as a keyed
+// hash so it can be looked up but never listed. Several users may register
+// the same identifier; their registrations are then disputed until an admin
+// decides whose it is.
+type RegisteredAsset struct {
+	Model
+	UserID         uuid.UUID `gorm:"index;not null"`
+	Type           AssetType `gorm:"not null"`
+	IdentifierType string    `gorm:"not null"` // imei, serial_number or frame_number
+	IdentifierHash string    `gorm:"index:idx_registered_assets_identifier;not null" json:"-"`
+	IdentifierHint string    // last characters of the identifier, shown to the owner
+	Brand          string
+	ModelName      string
+	Description    string      `gorm:"type:text"`
+	Status         AssetStatus `gorm:"not null;default:'registered'"`
+	ReportedAt     *time.Time
+	// Disputed is set while another user has registered the same identifier.
+	// It is hidden from owners so registering never reveals who else has.
+	Disputed bool `gorm:"not null;default:false;index" json:"-"`
+}
+
+// IsMissing reports whether the owner has reported the asset lost or stolen
+func (a *RegisteredAsset) IsMissing() bool {
+	return a.Status == AssetStatusLost || a.Status == AssetStatusStolen
+}
+
+// AssetAlert records that a found item matched a registered asset
+type AssetAlert struct {
+	Model
+	AssetID uuid.UUID `gorm:"index;not null"`
+	ItemID  uuid.UUID `gorm:"index;not null"`
+	UserID  uuid.UUID `gorm:"index;not null"`
+	ReadAt  *time.Time
+}
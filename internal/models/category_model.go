@@ -0,0 +1,40 @@
+package models
+
+import "github.com/google/uuid"
+
+// AttributeType is the value type of a category attribute
+type AttributeType string
+
+const (
+	AttributeTypeText    AttributeType = "text"
+	AttributeTypeNumber  AttributeType = "number"
+	AttributeTypeBoolean AttributeType = "boolean"
+	AttributeTypeEnum    AttributeType = "enum"
+)
+
+// AttributeDefinition declares one attribute items in a category can carry
+type AttributeDefinition struct {
+	Key      string        `json:"key"`
+	Label    string        `json:"label"`
+	Type     AttributeType `json:"type"`
+	Required bool          `json:"required,omitempty"`
+	Options  []string      `json:"options,omitempty"` // allowed values for enum attributes
+	Pattern  string        `json:"pattern,omitempty"` // regular expression text values must match
+	// Private attributes, such as serial numbers, identify the item to its
+	// owner. Only the reporter and admins see them, and they can't be filtered on.
+	Private bool `json:"private,omitempty"`
+}
+
+// Category is a node in the item category tree, e.g. Electronics > Phone
+type Category struct {
+	Model
+	ParentID   *uuid.UUID            `gorm:"type:uuid;index"`
+	Name       string                `gorm:"not null"`
+	Slug       string                `gorm:"not null"`
+	Path       string                `gorm:"uniqueIndex;not null"` // slugs from the root, e.g. "electronics/phone"
+	Attributes []AttributeDefinition `gorm:"type:jsonb;serializer:json"`
+	// ArchiveAfterDays is how long items stay listed without their reporter
+	// confirming them; 0 inherits from the parent or the platform default
+	ArchiveAfterDays int        `gorm:"not null;default:0"`
+	Children         []Category `gorm:"foreignKey:ParentID" json:",omitempty"`
+}
@@ -0,0 +1,74 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Conversation is a private thread between an item's owner and one other
+// user. Contact details stay hidden until both agree to share them after a
+// claim has been approved.
+type Conversation struct {
+	Model
+	ItemID                   uuid.UUID  `gorm:"uniqueIndex:idx_conversation_item_participant;not null"`
+	ClaimID                  *uuid.UUID `gorm:"type:uuid;index"`
+	OwnerID                  uuid.UUID  `gorm:"index;not null"`
+	ParticipantID            uuid.UUID  `gorm:"uniqueIndex:idx_conversation_item_participant;index;not null"`
+	LastMessageAt            *time.Time `gorm:"index"`
+	OwnerSharesContact       bool       `gorm:"default:false"`
+	ParticipantSharesContact bool       `gorm:"default:false"`
+}
+
+// HasParty reports whether the user is one of the two people in the conversation
+func (c *Conversation) HasParty(userID uuid.UUID) bool {
+	return c.OwnerID == userID || c.ParticipantID == userID
+}
+
+// OtherParty returns the user on the other side of the conversation
+func (c *Conversation) OtherParty(userID uuid.UUID) uuid.UUID {
+	if c.OwnerID == userID {
+		return c.ParticipantID
+	}
+	return c.OwnerID
+}
+
+// Message is a single message in a conversation
+type Message struct {
+	Model
+	ConversationID uuid.UUID `gorm:"index;not null"`
+	SenderID       uuid.UUID `gorm:"not null"`
+	Body           string    `gorm:"type:text"`
+	AttachmentURL  string
+	ReadAt         *time.Time
+	// HiddenAt is set when a moderator hides the message; its body and
+	// attachment are withheld from the recipient
+	HiddenAt *time.Time
+}
+
+// UserBlock records that one user no longer wants messages from another
+type UserBlock struct {
+	Model
+	BlockerID uuid.UUID `gorm:"uniqueIndex:idx_user_block;not null"`
+	BlockedID uuid.UUID `gorm:"uniqueIndex:idx_user_block;not null"`
+}
+
+// MessageReportReason is why a conversation or message was reported
+type MessageReportReason string
+
+const (
+	MessageReportSpam       MessageReportReason = "spam"
+	MessageReportScam       MessageReportReason = "scam"
+	MessageReportHarassment MessageReportReason = "harassment"
+	MessageReportOther      MessageReportReason = "other"
+)
+
+// MessageReport is a user's report about a conversation or one of its messages
+type MessageReport struct {
+	Model
+	ConversationID uuid.UUID           `gorm:"index;not null"`
+	MessageID      *uuid.UUID          `gorm:"type:uuid"`
+	ReporterID     uuid.UUID           `gorm:"not null"`
+	ReportedUserID uuid.UUID           `gorm:"index;not null"`
+	Reason         MessageReportReason `gorm:"not null"`
+	Details        string              `gorm:"type:text"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// DropOffPoint is a desk or partner shop run by an organisation where finders
+// can hand in items and owners collect them
+type DropOffPoint struct {
+	Model
+	OrganizationID uuid.UUID `gorm:"index;not null"`
+	Organization   *Organization
+	Name           string `gorm:"not null"`
+	Location       string
+	CountyID       string `gorm:"index"`
+	SubCountyID    string `gorm:"index"`
+	Latitude       *float64
+	Longitude      *float64
+	Phone          string
+	// OpeningHours default to the organisation's when empty
+	OpeningHours           []OpeningHours `gorm:"type:jsonb;serializer:json"`
+	CollectionInstructions string         `gorm:"type:text"`
+	Active                 bool           `gorm:"not null;default:true;index"`
+}
+
+// CustodyTransfer is one entry in an item's chain of custody: who handed it
+// over, who received it and when, with a signature or photo as evidence.
+// People without an account are recorded by name. A handover to an
+// organisation stays pending until one of its members confirms receiving it.
+type CustodyTransfer struct {
+	Model
+	ItemID             uuid.UUID `gorm:"index;not null"`
+	FromUserID         *uuid.UUID
+	FromOrganizationID *uuid.UUID
+	FromName           string
+	ToUserID           *uuid.UUID
+	ToOrganizationID   *uuid.UUID `gorm:"index"`
+	ToDropOffPointID   *uuid.UUID
+	ToName             string
+	RecordedByID       uuid.UUID `gorm:"not null"`
+	TransferredAt      time.Time `gorm:"not null"`
+	SignatureURL       string
+	PhotoURL           string
+	Notes              string `gorm:"type:text"`
+	Pending            bool   `gorm:"not null;default:false;index"`
+	ConfirmedByID      *uuid.UUID
+	ConfirmedAt        *time.Time
+}
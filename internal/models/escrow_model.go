@@ -0,0 +1,93 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// EscrowStatus is where a reward's money is
+type EscrowStatus string
+
+const (
+	// EscrowStatusPending rewards are waiting for the payer to approve the payment
+	EscrowStatusPending EscrowStatus = "pending"
+	// EscrowStatusHeld rewards have been paid in and are waiting for the handover
+	EscrowStatusHeld EscrowStatus = "held"
+	// EscrowStatusReleasing and EscrowStatusRefunding rewards have a payout in flight
+	EscrowStatusReleasing EscrowStatus = "releasing"
+	EscrowStatusRefunding EscrowStatus = "refunding"
+	EscrowStatusReleased  EscrowStatus = "released"
+	EscrowStatusRefunded  EscrowStatus = "refunded"
+	// EscrowStatusFailed rewards were never paid in
+	EscrowStatusFailed EscrowStatus = "failed"
+)
+
+// EscrowDisbursement is where a held reward is being paid out to
+type EscrowDisbursement string
+
+const (
+	EscrowDisbursementRelease EscrowDisbursement = "release"
+	EscrowDisbursementRefund  EscrowDisbursement = "refund"
+)
+
+// RewardEscrow is a reward paid in by the item's owner and held until the
+// item is handed over, when it goes to the finder, or until it expires, when
+// it goes back to the owner. Amounts are in cents.
+type RewardEscrow struct {
+	Model
+	// ItemID is unique among active rewards, so an item has at most one
+	ItemID      uuid.UUID    `gorm:"type:uuid;index;not null;uniqueIndex:idx_escrow_active_item,where:status <> 'released' AND status <> 'refunded' AND status <> 'failed' AND deleted_at IS NULL"`
+	PayerID     uuid.UUID    `gorm:"type:uuid;index;not null"`
+	PayerPhone  string       `json:"-"`
+	AmountCents int64        `gorm:"not null"`
+	Currency    string       `gorm:"not null;default:'KES'"`
+	Status      EscrowStatus `gorm:"not null;default:'pending';index"`
+	// CheckoutReference is the provider's ID for the payment in
+	CheckoutReference string `gorm:"uniqueIndex:idx_escrow_checkout,where:checkout_reference <> ''" json:"-"`
+	Receipt           string
+	// PayeeID is set once the reward is released or refunded
+	PayeeID      *uuid.UUID `gorm:"type:uuid;index"`
+	PayeePhone   string     `json:"-"`
+	Disbursement EscrowDisbursement
+	// PayoutReference is our ID for the payout in flight; each attempt gets a new one
+	PayoutReference string `gorm:"uniqueIndex:idx_escrow_payout,where:payout_reference <> ''" json:"-"`
+	PayoutReceipt   string
+	PayoutAttempts  int `gorm:"not null;default:0"`
+	LastError       string
+	// ExpiresAt is when a reward still held is refunded
+	ExpiresAt     time.Time `gorm:"index"`
+	HeldAt        *time.Time
+	SettledAt     *time.Time
+	LedgerEntries []LedgerEntry `gorm:"foreignKey:EscrowID" json:",omitempty"`
+}
+
+// Active reports whether the reward is being paid in or is held
+func (e *RewardEscrow) Active() bool {
+	switch e.Status {
+	case EscrowStatusPending, EscrowStatusHeld, EscrowStatusReleasing, EscrowStatusRefunding:
+		return true
+	}
+	return false
+}
+
+// LedgerEntryKind is a movement of money through escrow
+type LedgerEntryKind string
+
+const (
+	LedgerEntryDeposit LedgerEntryKind = "deposit"
+	LedgerEntryRelease LedgerEntryKind = "release"
+	LedgerEntryRefund  LedgerEntryKind = "refund"
+)
+
+// LedgerEntry records money moving into or out of escrow. Deposits are
+// positive and payouts negative, so an escrow's entries sum to what it holds.
+// An escrow has at most one entry of each kind, so a repeated provider
+// callback cannot move money twice.
+type LedgerEntry struct {
+	Model
+	EscrowID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_ledger_escrow_kind"`
+	Kind        LedgerEntryKind `gorm:"not null;uniqueIndex:idx_ledger_escrow_kind"`
+	AmountCents int64           `gorm:"not null"`
+	// Receipt is the provider's transaction ID
+	Receipt string
+}
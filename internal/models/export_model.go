@@ -0,0 +1,54 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ExportStatus is how far a background export has got
+type ExportStatus string
+
+const (
+	ExportStatusQueued    ExportStatus = "queued"
+	ExportStatusRunning   ExportStatus = "running"
+	ExportStatusCompleted ExportStatus = "completed"
+	ExportStatusFailed    ExportStatus = "failed"
+	// ExportStatusExpired exports have been deleted after their download window
+	ExportStatusExpired ExportStatus = "expired"
+)
+
+// ExportRedaction is how much personal data an export carries
+type ExportRedaction string
+
+const (
+	// ExportRedactionNone keeps contact details, reporters and exact coordinates; platform admins only
+	ExportRedactionNone ExportRedaction = "none"
+	// ExportRedactionOrganization keeps an organisation's own contact details,
+	// coordinates and custody records but not who logged each item
+	ExportRedactionOrganization ExportRedaction = "organization"
+	// ExportRedactionPublic keeps only what anyone can see through the API
+	ExportRedactionPublic ExportRedaction = "public"
+)
+
+// ExportJob is an export of items written to storage in the background and
+// downloaded later through a short-lived link
+type ExportJob struct {
+	Model
+	RequestedByID uuid.UUID `gorm:"index;not null"`
+	// OrganizationID is set for an organisation's export and nil for a platform-wide one
+	OrganizationID *uuid.UUID      `gorm:"type:uuid;index"`
+	Format         string          `gorm:"not null"`
+	Redaction      ExportRedaction `gorm:"not null"`
+	Filter         map[string]any  `gorm:"type:jsonb;serializer:json"`
+	Status         ExportStatus    `gorm:"not null;default:'queued';index"`
+	RowCount       int
+	FileName       string
+	ObjectName     string `json:"-"`
+	Error          string `gorm:"type:text"`
+	Attempts       int    `gorm:"not null;default:0"`
+	LeasedUntil    *time.Time
+	StartedAt      *time.Time
+	FinishedAt     *time.Time
+	// ExpiresAt is when the file is deleted and can no longer be downloaded
+	ExpiresAt *time.Time `gorm:"index"`
+}
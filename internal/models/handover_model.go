@@ -0,0 +1,31 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// HandoverCode is a one-time code issued to the claimant when their claim is
+// approved. Whoever holds the item enters or scans it at the moment of return
+// to prove the right person collected it.
+type HandoverCode struct {
+	Model
+	ClaimID   uuid.UUID `gorm:"index;not null"`
+	ItemID    uuid.UUID `gorm:"index;not null"`
+	ClaimerID uuid.UUID `gorm:"not null"`
+	Code      string    `gorm:"not null" json:"-"`
+	ExpiresAt time.Time `gorm:"not null"`
+	// FailedAttempts counts wrong codes entered for the item while this one was active
+	FailedAttempts int `gorm:"not null;default:0"`
+	// RevokedAt is set when the code is replaced or locked after too many wrong attempts
+	RevokedAt    *time.Time
+	RedeemedAt   *time.Time
+	RedeemedByID *uuid.UUID
+	Latitude     *float64 // where the handover happened, when the scanner shared it
+	Longitude    *float64 // where the handover happened, when the scanner shared it
+}
+
+// Active reports whether the code can still be redeemed at the given time
+func (h *HandoverCode) Active(now time.Time) bool {
+	return h.RedeemedAt == nil && h.RevokedAt == nil && now.Before(h.ExpiresAt)
+}
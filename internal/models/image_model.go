@@ -7,4 +7,7 @@ type Image struct {
 	Model
 	URL    string `gorm:"not null"`
 	ItemID uuid.UUID
+	// Hash is the SHA-256 of the file, known when it was uploaded through
+	// the API; it finds the same photo posted twice
+	Hash string `gorm:"index" json:"-"`
 }
@@ -0,0 +1,92 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ImportStatus is how far a bulk import has got
+type ImportStatus string
+
+const (
+	ImportStatusQueued    ImportStatus = "queued"
+	ImportStatusRunning   ImportStatus = "running"
+	ImportStatusCompleted ImportStatus = "completed"
+	ImportStatusFailed    ImportStatus = "failed"
+)
+
+// ImportFormat is how the rows of a bulk import were sent
+type ImportFormat string
+
+const (
+	ImportFormatJSON ImportFormat = "json"
+	ImportFormatCSV  ImportFormat = "csv"
+	ImportFormatXLSX ImportFormat = "xlsx"
+)
+
+// ImportRow is one item in a bulk import, as sent in JSON or read from a
+// spreadsheet row. Category is a path such as "documents/national-id" or an
+// ID; Images are file names in the image bundle.
+type ImportRow struct {
+	Line              int      `json:"line"`
+	ExternalReference string   `json:"external_reference"`
+	Title             string   `json:"title"`
+	Description       string   `json:"description,omitempty"`
+	Status            string   `json:"status,omitempty"`
+	Category          string   `json:"category,omitempty"`
+	Date              string   `json:"date,omitempty"`
+	Location          string   `json:"location,omitempty"`
+	County            string   `json:"county,omitempty"`
+	SubCounty         string   `json:"sub_county,omitempty"`
+	Latitude          *float64 `json:"latitude,omitempty"`
+	Longitude         *float64 `json:"longitude,omitempty"`
+	Contact           string   `json:"contact,omitempty"`
+	StorageLocation   string   `json:"storage_location,omitempty"`
+	Tags              []string `json:"tags,omitempty"`
+	Images            []string `json:"images,omitempty"`
+	// Problems are errors found while reading the row from a spreadsheet
+	Problems []string `json:"problems,omitempty"`
+}
+
+// ImportRowError explains why a row could not be imported
+type ImportRowError struct {
+	Line              int    `json:"line"`
+	ExternalReference string `json:"external_reference,omitempty"`
+	Message           string `json:"message"`
+}
+
+// ImportRowResult is the item a row created, or the item already imported
+// under the same external reference
+type ImportRowResult struct {
+	Line              int       `json:"line"`
+	ExternalReference string    `json:"external_reference"`
+	ItemID            uuid.UUID `json:"item_id"`
+	Existing          bool      `json:"existing"`
+}
+
+// ImportJob is a bulk intake of items for an organisation. Rows are checked
+// first and every new item is created in one transaction, so a job either
+// imports every valid new row or nothing.
+type ImportJob struct {
+	Model
+	OrganizationID uuid.UUID    `gorm:"index;not null"`
+	CreatedByID    uuid.UUID    `gorm:"not null"`
+	Format         ImportFormat `gorm:"not null"`
+	FileName       string
+	Status         ImportStatus `gorm:"not null;default:'queued';index"`
+	Rows           []ImportRow  `gorm:"type:jsonb;serializer:json" json:"-"`
+	// Bundle is the optional ZIP of images, dropped once the job finishes
+	Bundle       []byte `json:"-"`
+	BundleName   string
+	TotalRows    int
+	CreatedCount int
+	SkippedCount int
+	Errors       []ImportRowError  `gorm:"type:jsonb;serializer:json"`
+	Results      []ImportRowResult `gorm:"type:jsonb;serializer:json"`
+	// Error is set when the job as a whole failed rather than individual rows
+	Error       string `gorm:"type:text"`
+	Attempts    int    `gorm:"not null;default:0"`
+	LeasedUntil *time.Time
+	StartedAt   *time.Time
+	FinishedAt  *time.Time
+}
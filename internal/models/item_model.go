@@ -2,6 +2,7 @@ package models
 
 import (
 	"github.com/google/uuid"
+	"math"
 	"time"
 )
 
@@ -15,22 +16,127 @@ const (
 	ItemStatusReturned ItemStatus = "returned"
 )
 
+// ItemSource records the channel an item was reported through
+type ItemSource string
+
+const (
+	ItemSourceApp  ItemSource = "app"
+	ItemSourceSMS  ItemSource = "sms"
+	ItemSourceUSSD ItemSource = "ussd"
+	// ItemSourceImport items were logged by an organisation through a bulk import
+	ItemSourceImport ItemSource = "import"
+	// ItemSourceRecoveryTag items were filed by a finder who scanned the
+	// owner's recovery tag; they stay drafts until the owner publishes them
+	ItemSourceRecoveryTag ItemSource = "recovery_tag"
+)
+
 // Item represents a lost or found item
 type Item struct {
 	Model
-	Title       string `gorm:"not null"`
-	Description string `gorm:"type:text"`
-	Category    string
-	Status      ItemStatus `gorm:"not null;default:'lost'"`
-	Location    string
-	Date        time.Time
-	Images      []Image
-	UserID      uuid.UUID
-	User        User
-	Contact     string
-	IsResolved  bool `gorm:"default:false"`
-	Reward      float64
-	Tags        []Tag `gorm:"many2many:item_tags;"`
+	Title       string     `gorm:"not null"`
+	Description string     `gorm:"type:text"`
+	CategoryID  *uuid.UUID `gorm:"type:uuid;index"`
+	Category    *Category
+	Attributes  map[string]any `gorm:"type:jsonb;serializer:json"`
+	// PrivateAttributes lists the keys of Attributes its category marks private
+	PrivateAttributes []string   `gorm:"type:jsonb;serializer:json" json:"-"`
+	Status            ItemStatus `gorm:"not null;default:'lost'"`
+	Location          string
+	CountyID          string   `gorm:"index"`
+	SubCountyID       string   `gorm:"index"`
+	WardID            string   `gorm:"index"`
+	Latitude          *float64 `gorm:"index:idx_items_coordinates"`
+	Longitude         *float64 `gorm:"index:idx_items_coordinates"`
+	Precision         int      // accuracy of the coordinates in metres
+	Date              time.Time
+	Images            []Image
+	UserID            uuid.UUID
+	User              User
+	Contact           string
+	IsResolved        bool `gorm:"default:false"`
+	// RewardCents is the reward offered for the item's return. It is only
+	// guaranteed when RewardEscrowed is set.
+	RewardCents    int64 `gorm:"not null;default:0"`
+	RewardEscrowed bool  `gorm:"not null;default:false"`
+	Tags           []Tag `gorm:"many2many:item_tags;"`
+	Transit        *TransitContext
+	// OrganizationID is set for items posted on behalf of an organisation
+	OrganizationID *uuid.UUID `gorm:"type:uuid;index;uniqueIndex:idx_item_external_reference,where:external_reference <> ''"`
+	Organization   *Organization
+	// ExternalReference is the organisation's own ID for an item logged
+	// through a bulk import, so re-uploading a sheet does not duplicate it
+	ExternalReference string `gorm:"uniqueIndex:idx_item_external_reference"`
+	// Custody is where an organisation holds the item; it is only shown to
+	// the organisation's members
+	Custody *ItemCustody
+	// ReferenceCode is a short code reporters without the app quote to follow up
+	ReferenceCode *string    `gorm:"uniqueIndex"`
+	Source        ItemSource `gorm:"not null;default:'app'"`
+	// Draft items are incomplete reports that are not listed or matched until published
+	Draft bool `gorm:"not null;default:false;index"`
+	// HiddenAt is set when a moderator hides the item; hidden items are only
+	// shown to their reporter and moderators
+	HiddenAt *time.Time `gorm:"index"`
+	// MergedIntoID is the item a moderator merged this duplicate report into
+	MergedIntoID *uuid.UUID `gorm:"type:uuid;index"`
+	// ConfirmedAt is when the reporter last confirmed the item is still lost
+	// or waiting for its owner. Reminders and archival count from it, or from
+	// when the item was created.
+	ConfirmedAt *time.Time
+	// RemindedAt is when the reporter was last asked to confirm the item
+	RemindedAt *time.Time
+	// ArchivedAt is set when an unconfirmed or resolved item is archived;
+	// archived items are left out of listings unless asked for
+	ArchivedAt *time.Time `gorm:"index"`
+	// RiskScore rates how likely the item is a scam, from 0 to 100; only
+	// moderators see it
+	RiskScore   int          `gorm:"not null;default:0;index" json:"-"`
+	RiskSignals []RiskSignal `gorm:"type:jsonb;serializer:json" json:"-"`
+	// PossibleDuplicates are existing reports that look like the same item.
+	// They are only filled in when the item is created.
+	PossibleDuplicates []DuplicateCandidate `gorm:"-" json:",omitempty"`
+}
+
+// DuplicateCandidate is an existing report that may describe the same item
+// as a new one
+type DuplicateCandidate struct {
+	ItemID  uuid.UUID  `json:"item_id"`
+	Title   string     `json:"title"`
+	Status  ItemStatus `json:"status"`
+	Score   float64    `json:"score"`
+	Reasons []string   `json:"reasons"`
+	// Own is set when the same person reported the candidate
+	Own bool `json:"own"`
+}
+
+// HasCoordinates reports whether the item has a latitude and longitude
+func (i *Item) HasCoordinates() bool {
+	return i.Latitude != nil && i.Longitude != nil
+}
+
+// RoundCoordinates coarsens the item's coordinates to the given number of
+// decimal places so exact finder locations are not exposed
+func (i *Item) RoundCoordinates(decimals int) {
+	if !i.HasCoordinates() {
+		return
+	}
+
+	factor := math.Pow(10, float64(decimals))
+	lat := math.Round(*i.Latitude*factor) / factor
+	lng := math.Round(*i.Longitude*factor) / factor
+	i.Latitude, i.Longitude = &lat, &lng
+
+	// The rounded position is no more precise than half a grid cell (~111 km per degree)
+	if cell := int(111000 / factor / 2); i.Precision < cell {
+		i.Precision = cell
+	}
+}
+
+// RedactPrivateAttributes removes the attributes only the reporter may see
+func (i *Item) RedactPrivateAttributes() {
+	for _, key := range i.PrivateAttributes {
+		delete(i.Attributes, key)
+	}
 }
 
 // Tag represents a keyword associated with an item
@@ -40,19 +146,34 @@ type Tag struct {
 	Items []Item `gorm:"many2many:item_tags;"`
 }
 
+// ClaimStatus represents the progress of a claim
+type ClaimStatus string
+
+const (
+	ClaimStatusPending   ClaimStatus = "pending"
+	ClaimStatusApproved  ClaimStatus = "approved"
+	ClaimStatusRejected  ClaimStatus = "rejected"
+	ClaimStatusWithdrawn ClaimStatus = "withdrawn"
+)
+
 // Claim represents a claim on a found item
 type Claim struct {
 	Model
-	ItemID      uint
+	ItemID      uuid.UUID
 	ClaimerID   uuid.UUID
-	Description string `gorm:"type:text"`
-	Status      string `gorm:"default:'pending'"`
+	Description string      `gorm:"type:text"`
+	Status      ClaimStatus `gorm:"default:'pending'"`
+	DecidedAt   *time.Time
 	ProofImages []ClaimImage
+	// RiskScore rates how likely the claim is fraudulent, from 0 to 100; only
+	// moderators see it
+	RiskScore   int          `gorm:"not null;default:0;index" json:"-"`
+	RiskSignals []RiskSignal `gorm:"type:jsonb;serializer:json" json:"-"`
 }
 
 // ClaimImage represents proof images for a claim
 type ClaimImage struct {
 	Model
 	URL     string `gorm:"not null"`
-	ClaimID uint
+	ClaimID uuid.UUID
 }
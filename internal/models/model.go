@@ -12,6 +12,7 @@ type Model struct {
 	ID        uuid.UUID `gorm:"primary_key;type:uuid;default:gen_random_uuid()"`
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt `gorm:"index"`
 }
 
 // BeforeCreate hook to generate a UUID before saving a new record
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// ReportSubject is what a report or moderation action is about
+type ReportSubject string
+
+const (
+	ReportSubjectItem    ReportSubject = "item"
+	ReportSubjectMessage ReportSubject = "message"
+	// ReportSubjectUser reports are about a person rather than one thing they posted
+	ReportSubjectUser ReportSubject = "user"
+)
+
+// ReportReason is the reason code a report or moderation action gives
+type ReportReason string
+
+const (
+	ReportReasonSpam       ReportReason = "spam"
+	ReportReasonScam       ReportReason = "scam"
+	ReportReasonOffensive  ReportReason = "offensive"
+	ReportReasonHarassment ReportReason = "harassment"
+	// ReportReasonProhibited covers items that may not be listed, such as
+	// weapons, drugs and other people's identity documents offered for sale
+	ReportReasonProhibited ReportReason = "prohibited"
+	ReportReasonOther      ReportReason = "other"
+)
+
+// ReportReasons lists the valid reason codes
+var ReportReasons = []ReportReason{
+	ReportReasonSpam, ReportReasonScam, ReportReasonOffensive,
+	ReportReasonHarassment, ReportReasonProhibited, ReportReasonOther,
+}
+
+// ReportStatus is where a report stands in the moderation queue
+type ReportStatus string
+
+const (
+	ReportStatusOpen      ReportStatus = "open"
+	ReportStatusActioned  ReportStatus = "actioned"
+	ReportStatusDismissed ReportStatus = "dismissed"
+)
+
+// Report is a user's report of an item, message or person, waiting in the
+// moderation queue
+type Report struct {
+	Model
+	SubjectType ReportSubject `gorm:"not null;index:idx_report_subject"`
+	SubjectID   uuid.UUID     `gorm:"type:uuid;not null;index:idx_report_subject"`
+	ReporterID  uuid.UUID     `gorm:"type:uuid;not null;index"`
+	// ReportedUserID is who posted the item or message
+	ReportedUserID uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Reason         ReportReason `gorm:"not null;index"`
+	Details        string       `gorm:"type:text"`
+	Status         ReportStatus `gorm:"not null;default:'open';index"`
+	// ActionID is the moderation action that closed the report
+	ActionID *uuid.UUID `gorm:"type:uuid;index"`
+}
+
+// ModerationActionType is what a moderator did
+type ModerationActionType string
+
+const (
+	// ModerationHide takes an item or message out of view; the owner still sees it
+	ModerationHide ModerationActionType = "hide"
+	// ModerationRemove hides an item and moves it to the trash
+	ModerationRemove ModerationActionType = "remove"
+	ModerationWarn   ModerationActionType = "warn"
+	// ModerationBan stops a user posting, claiming or messaging
+	ModerationBan ModerationActionType = "ban"
+	// ModerationDismiss closes reports without acting on them
+	ModerationDismiss ModerationActionType = "dismiss"
+	// ModerationReinstate undoes an action after a successful appeal
+	ModerationReinstate ModerationActionType = "reinstate"
+	// ModerationUphold records that an appeal was turned down
+	ModerationUphold ModerationActionType = "uphold"
+	// ModerationMerge records that a duplicate item was merged into another
+	ModerationMerge ModerationActionType = "merge"
+)
+
+// ModerationAction is one step a moderator took. Actions are never deleted
+// and only change when an appeal reverses them, so together they are the
+// moderation audit log.
+type ModerationAction struct {
+	Model
+	ModeratorID  uuid.UUID            `gorm:"type:uuid;not null;index"`
+	Action       ModerationActionType `gorm:"not null;index"`
+	SubjectType  ReportSubject        `gorm:"not null;index:idx_moderation_action_subject"`
+	SubjectID    uuid.UUID            `gorm:"type:uuid;not null;index:idx_moderation_action_subject"`
+	TargetUserID uuid.UUID            `gorm:"type:uuid;not null;index"`
+	Reason       ReportReason
+	Note         string `gorm:"type:text"`
+	// ReportIDs are the reports the action closed
+	ReportIDs []uuid.UUID `gorm:"type:jsonb;serializer:json"`
+	// AppealID is set on reinstate and uphold actions
+	AppealID *uuid.UUID `gorm:"type:uuid;index"`
+	// ReversedAt is set when an appeal against the action succeeded
+	ReversedAt *time.Time
+}
+
+// AppealStatus is where an appeal stands
+type AppealStatus string
+
+const (
+	AppealStatusPending    AppealStatus = "pending"
+	AppealStatusUpheld     AppealStatus = "upheld"
+	AppealStatusOverturned AppealStatus = "overturned"
+)
+
+// Appeal is a user asking moderators to reconsider an action against them
+type Appeal struct {
+	Model
+	ActionID    uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex"`
+	UserID      uuid.UUID    `gorm:"type:uuid;not null;index"`
+	Statement   string       `gorm:"type:text;not null"`
+	Status      AppealStatus `gorm:"not null;default:'pending';index"`
+	DecidedByID *uuid.UUID   `gorm:"type:uuid"`
+	DecidedAt   *time.Time
+	Response    string `gorm:"type:text"`
+}
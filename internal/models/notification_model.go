@@ -0,0 +1,44 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// NotificationStatus is the delivery state of a notification
+type NotificationStatus string
+
+const (
+	NotificationStatusPending NotificationStatus = "pending"
+	NotificationStatusSent    NotificationStatus = "sent"
+	NotificationStatusFailed  NotificationStatus = "failed"
+)
+
+// Notification is an outbox entry: one rendered message for one user on one
+// channel. In-app notifications double as the user's inbox.
+type Notification struct {
+	Model
+	UserID        uuid.UUID          `gorm:"index;not null"`
+	EventType     string             `gorm:"not null"`
+	Channel       string             `gorm:"not null"`
+	Locale        string             `gorm:"not null"`
+	Subject       string             `gorm:"not null"`
+	Body          string             `gorm:"type:text;not null"`
+	Payload       map[string]any     `gorm:"type:jsonb;serializer:json"`
+	Status        NotificationStatus `gorm:"index:idx_notification_due;not null;default:'pending'"`
+	Attempts      int                `gorm:"not null;default:0"`
+	NextAttemptAt time.Time          `gorm:"index:idx_notification_due"`
+	LastError     string
+	SentAt        *time.Time
+	ReadAt        *time.Time
+}
+
+// NotificationPreference records whether a user wants an event type on a
+// channel. Missing preferences fall back to the event type's defaults.
+type NotificationPreference struct {
+	Model
+	UserID    uuid.UUID `gorm:"uniqueIndex:idx_notification_preference;not null"`
+	EventType string    `gorm:"uniqueIndex:idx_notification_preference;not null"`
+	Channel   string    `gorm:"uniqueIndex:idx_notification_preference;not null"`
+	Enabled   bool      `gorm:"not null"`
+}
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// OrganizationType is the kind of place that collects found items
+type OrganizationType string
+
+const (
+	OrganizationTypePolice    OrganizationType = "police"
+	OrganizationTypeCampus    OrganizationType = "campus"
+	OrganizationTypeTransport OrganizationType = "transport"
+	OrganizationTypeAirport   OrganizationType = "airport"
+	OrganizationTypeOther     OrganizationType = "other"
+)
+
+// Weekdays are the day names used in opening hours, starting on Sunday like time.Weekday
+var Weekdays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// OpeningHours is when an organisation's lost property desk is open on one
+// day of the week. Times are "HH:MM" in East Africa Time.
+type OpeningHours struct {
+	Day    string `json:"day"`
+	Opens  string `json:"opens"`
+	Closes string `json:"closes"`
+}
+
+// Organization is a police station, campus security office, transport
+// operator or other office that holds found items on behalf of the public
+type Organization struct {
+	Model
+	Name         string           `gorm:"not null"`
+	Type         OrganizationType `gorm:"not null;index"`
+	Description  string           `gorm:"type:text"`
+	Phone        string
+	Email        string
+	Location     string
+	CountyID     string         `gorm:"index"`
+	SubCountyID  string         `gorm:"index"`
+	Latitude     *float64       // where the office is, shown to the public
+	Longitude    *float64       // where the office is, shown to the public
+	OpeningHours []OpeningHours `gorm:"type:jsonb;serializer:json"`
+	// Verified organisations have been checked by a platform administrator
+	Verified    bool `gorm:"not null;default:false;index"`
+	VerifiedAt  *time.Time
+	CreatedByID uuid.UUID
+}
+
+// eastAfricaTime is the zone opening hours are given in
+var eastAfricaTime = time.FixedZone("EAT", 3*60*60)
+
+// OpenAt reports whether the organisation's desk is open at the given time
+func (o *Organization) OpenAt(t time.Time) bool {
+	local := t.In(eastAfricaTime)
+	day := Weekdays[local.Weekday()]
+	now := local.Format("15:04")
+	for _, hours := range o.OpeningHours {
+		if hours.Day == day && hours.Opens <= now && now < hours.Closes {
+			return true
+		}
+	}
+	return false
+}
+
+// OrganizationRole is what a member may do in an organisation
+type OrganizationRole string
+
+const (
+	// OrganizationRoleAdmin members manage the organisation, its staff and its inventory
+	OrganizationRoleAdmin OrganizationRole = "admin"
+	// OrganizationRoleStaff members log items and record custody
+	OrganizationRoleStaff OrganizationRole = "staff"
+)
+
+// OrganizationMember links a user to an organisation they work for
+type OrganizationMember struct {
+	Model
+	OrganizationID uuid.UUID `gorm:"uniqueIndex:idx_organization_member;not null"`
+	Organization   *Organization
+	UserID         uuid.UUID `gorm:"uniqueIndex:idx_organization_member;not null;index"`
+	User           *User
+	Role           OrganizationRole `gorm:"not null;default:'staff'"`
+	AddedByID      uuid.UUID
+}
+
+// CustodyStatus is whether an organisation still holds an item
+type CustodyStatus string
+
+const (
+	CustodyStatusHeld     CustodyStatus = "held"
+	CustodyStatusReleased CustodyStatus = "released"
+	CustodyStatusDisposed CustodyStatus = "disposed"
+)
+
+// ItemCustody records which organisation physically holds an item, at which
+// of its drop-off points, where it is stored and who logged it in and out
+type ItemCustody struct {
+	Model
+	ItemID          uuid.UUID     `gorm:"uniqueIndex"`
+	OrganizationID  uuid.UUID     `gorm:"index;not null"`
+	DropOffPointID  *uuid.UUID    `gorm:"type:uuid;index"`
+	Status          CustodyStatus `gorm:"not null;default:'held';index"`
+	StorageLocation string        // shelf, locker or bin number
+	ReceivedByID    uuid.UUID
+	ReceivedAt      time.Time
+	ReleasedByID    *uuid.UUID
+	ReleasedAt      *time.Time
+	Notes           string `gorm:"type:text"`
+	// DisposalWarnedAt is when the organisation was warned that the item's
+	// legal holding period is ending
+	DisposalWarnedAt *time.Time
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RecoveryTag is a printable QR sticker that lets a finder report a
+// belonging without learning anything about its owner
+type RecoveryTag struct {
+	Model
+	UserID        uuid.UUID  `gorm:"index;not null"`
+	AssetID       *uuid.UUID `gorm:"type:uuid;index"`
+	Asset         *RegisteredAsset
+	Code          string `gorm:"uniqueIndex;not null"`
+	Label         string
+	Active        bool `gorm:"not null;default:true"`
+	ScanCount     int
+	LastScannedAt *time.Time
+}
+
+// RecoveryReport is a finder's message sent by scanning a recovery tag. It is
+// only visible to the tag owner, who decides whether to reply.
+type RecoveryReport struct {
+	Model
+	TagID         uuid.UUID `gorm:"index;not null"`
+	OwnerID       uuid.UUID `gorm:"index;not null"`
+	ItemID        uuid.UUID `gorm:"type:uuid;index"`
+	Message       string    `gorm:"type:text"`
+	Location      string
+	FinderName    string
+	FinderContact string // only shared if the finder chooses to
+	ReadAt        *time.Time
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// RiskSubject is what a risk review is about
+type RiskSubject string
+
+const (
+	RiskSubjectItem  RiskSubject = "item"
+	RiskSubjectClaim RiskSubject = "claim"
+)
+
+// RiskReviewStatus is where a risk review stands
+type RiskReviewStatus string
+
+const (
+	RiskReviewOpen RiskReviewStatus = "open"
+	// RiskReviewCleared reviews were false alarms; the actor is no longer throttled
+	RiskReviewCleared RiskReviewStatus = "cleared"
+	// RiskReviewConfirmed reviews were abuse; the actor stays throttled
+	RiskReviewConfirmed RiskReviewStatus = "confirmed"
+)
+
+// RiskSignal is one reason an item or claim looks risky and the points it adds
+type RiskSignal struct {
+	Code   string `json:"code"`
+	Points int    `json:"points"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// RiskReview puts a high-risk item or claim in front of a moderator
+type RiskReview struct {
+	Model
+	SubjectType RiskSubject `gorm:"not null;index:idx_risk_review_subject"`
+	SubjectID   uuid.UUID   `gorm:"type:uuid;not null;index:idx_risk_review_subject"`
+	// ActorID is who posted the item or filed the claim
+	ActorID      uuid.UUID        `gorm:"type:uuid;not null;index"`
+	Score        int              `gorm:"not null;index"`
+	Signals      []RiskSignal     `gorm:"type:jsonb;serializer:json"`
+	Status       RiskReviewStatus `gorm:"not null;default:'open';index"`
+	ReviewedByID *uuid.UUID       `gorm:"type:uuid"`
+	ReviewedAt   *time.Time
+	Note         string `gorm:"type:text"`
+}
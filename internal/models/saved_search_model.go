@@ -0,0 +1,45 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// AlertFrequency is how often a saved search sends alerts
+type AlertFrequency string
+
+const (
+	AlertFrequencyInstant AlertFrequency = "instant"
+	AlertFrequencyDaily   AlertFrequency = "daily"
+)
+
+// SavedSearch is a search a user wants to be alerted about when new items match
+type SavedSearch struct {
+	Model
+	UserID         uuid.UUID `gorm:"index;not null"`
+	Name           string
+	Keywords       string
+	CategoryPath   string
+	CountyID       string
+	Status         ItemStatus
+	DateFrom       *time.Time
+	DateTo         *time.Time
+	Frequency      AlertFrequency `gorm:"not null;default:'instant'"`
+	ExpiresAt      time.Time      `gorm:"index"`
+	LastNotifiedAt *time.Time
+}
+
+// IsExpired reports whether the saved search has stopped sending alerts
+func (s *SavedSearch) IsExpired(now time.Time) bool {
+	return !s.ExpiresAt.After(now)
+}
+
+// SavedSearchHit records that an item matched a saved search
+type SavedSearchHit struct {
+	Model
+	SearchID   uuid.UUID `gorm:"uniqueIndex:idx_saved_search_hit;not null"`
+	ItemID     uuid.UUID `gorm:"uniqueIndex:idx_saved_search_hit;not null"`
+	Item       *Item
+	UserID     uuid.UUID `gorm:"index;not null"`
+	NotifiedAt *time.Time
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+// TransitMode is the kind of transport an item was lost or found on
+type TransitMode string
+
+const (
+	TransitModeMatatu       TransitMode = "matatu"
+	TransitModeBodaBoda     TransitMode = "boda_boda"
+	TransitModeBus          TransitMode = "bus"
+	TransitModeSGR          TransitMode = "sgr"
+	TransitModeCommuterRail TransitMode = "commuter_rail"
+	TransitModeTaxi         TransitMode = "taxi"
+	TransitModeOther        TransitMode = "other"
+)
+
+// TransitContext describes the journey on which an item was lost or found
+type TransitContext struct {
+	Model
+	ItemID              uuid.UUID `gorm:"uniqueIndex"`
+	Mode                TransitMode
+	Operator            string `gorm:"index"` // SACCO, bus company or rail operator
+	RouteNumber         string `gorm:"index"`
+	VehicleRegistration string `gorm:"index"`
+	BoardingStage       string
+	AlightingStage      string
+	DepartedAt          *time.Time
+	ArrivedAt           *time.Time
+}
+
+// Normalize puts free-text fields into the canonical form used for matching
+func (t *TransitContext) Normalize() {
+	t.Operator = NormalizeOperator(t.Operator)
+	t.RouteNumber = NormalizeRouteNumber(t.RouteNumber)
+	t.VehicleRegistration = NormalizeVehicleRegistration(t.VehicleRegistration)
+	t.BoardingStage = strings.TrimSpace(t.BoardingStage)
+	t.AlightingStage = strings.TrimSpace(t.AlightingStage)
+}
+
+// NormalizeOperator lowercases an operator name and collapses its whitespace
+func NormalizeOperator(operator string) string {
+	return strings.Join(strings.Fields(strings.ToLower(operator)), " ")
+}
+
+// NormalizeRouteNumber turns "Route 46", "rt46" or " 46 " into "46"
+func NormalizeRouteNumber(route string) string {
+	route = strings.ToUpper(strings.TrimSpace(route))
+	for _, prefix := range []string{"ROUTE", "RT", "NO.", "NO"} {
+		route = strings.TrimSpace(strings.TrimPrefix(route, prefix))
+	}
+	return route
+}
+
+// NormalizeVehicleRegistration turns "kca 123a" into "KCA123A"
+func NormalizeVehicleRegistration(reg string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(reg), ""))
+}
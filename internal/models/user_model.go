@@ -1,13 +1,36 @@
 package models
 
+import (
+	"strings"
+	"time"
+)
+
+// phoneAccountDomain marks the placeholder email of accounts created for
+// people who report by SMS or USSD and never signed up
+const phoneAccountDomain = "@phone.invalid"
+
 type User struct {
 	Model
 	Email     string `gorm:"uniqueIndex;not null"`
-	Password  string `gorm:"not null"`
+	Password  string `gorm:"not null" json:"-"`
 	FirstName string
 	LastName  string
-	Phone     string
+	Phone     string `gorm:"index"`
 	City      string
-	IsAdmin   bool `gorm:"default:false"`
-	Items     []Item
+	Locale    string `gorm:"not null;default:'en'"`
+	IsAdmin   bool   `gorm:"default:false"`
+	// BannedAt is set when a moderator bans the user from posting, claiming
+	// and messaging
+	BannedAt *time.Time
+	Items    []Item
+}
+
+// NewPhoneUser creates an account for someone known only by their phone number
+func NewPhoneUser(phone string) *User {
+	return &User{Email: strings.TrimPrefix(phone, "+") + phoneAccountDomain, Phone: phone, Locale: "en"}
+}
+
+// HasEmail reports whether the user has a real email address
+func (u *User) HasEmail() bool {
+	return u.Email != "" && !strings.HasSuffix(u.Email, phoneAccountDomain)
 }
@@ -0,0 +1,11 @@
+package models
+
+// USSDSession stores the state of a USSD dialogue when Redis is not configured
+type USSDSession struct {
+	Model
+	SessionID string            `gorm:"uniqueIndex;not null"`
+	Phone     string            `gorm:"not null"`
+	State     string            `gorm:"not null"`
+	Data      map[string]string `gorm:"type:jsonb;serializer:json"`
+	Inputs    int               `gorm:"not null;default:0"`
+}
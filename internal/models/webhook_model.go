@@ -0,0 +1,71 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"slices"
+	"strings"
+	"time"
+)
+
+// WebhookSubscription is a partner system, e.g. a police station or a
+// university, that wants events posted to its own URL. Empty filters match
+// everything.
+type WebhookSubscription struct {
+	Model
+	Name          string   `gorm:"not null"`
+	URL           string   `gorm:"not null"`
+	Secret        string   `gorm:"not null" json:"-"`
+	EventTypes    []string `gorm:"type:jsonb;serializer:json"`
+	CountyIDs     []string `gorm:"type:jsonb;serializer:json"`
+	CategoryPaths []string `gorm:"type:jsonb;serializer:json"`
+	Active        bool     `gorm:"not null;default:true;index"`
+	CreatedByID   uuid.UUID
+}
+
+// Wants reports whether the subscription is interested in an event about an
+// item in the given county and category. Category filters match descendants.
+func (s *WebhookSubscription) Wants(eventType, countyID, categoryPath string) bool {
+	if !s.Active || !slices.Contains(s.EventTypes, eventType) {
+		return false
+	}
+	if len(s.CountyIDs) > 0 && !slices.Contains(s.CountyIDs, countyID) {
+		return false
+	}
+	if len(s.CategoryPaths) == 0 {
+		return true
+	}
+	for _, path := range s.CategoryPaths {
+		if categoryPath == path || strings.HasPrefix(categoryPath, path+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDeliveryStatus is the state of a webhook delivery
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery is one event queued for one subscription, and the log of
+// attempts to deliver it. Redeliveries are new rows with the same event ID.
+type WebhookDelivery struct {
+	Model
+	SubscriptionID uuid.UUID             `gorm:"index;not null"`
+	EventID        uuid.UUID             `gorm:"index;not null"`
+	EventType      string                `gorm:"not null"`
+	Body           string                `gorm:"type:text;not null"`
+	Status         WebhookDeliveryStatus `gorm:"index:idx_webhook_delivery_due;not null;default:'pending'"`
+	Attempts       int                   `gorm:"not null;default:0"`
+	NextAttemptAt  time.Time             `gorm:"index:idx_webhook_delivery_due"`
+	ResponseStatus int
+	ResponseBody   string `gorm:"type:text"`
+	DurationMs     int64
+	LastError      string
+	DeliveredAt    *time.Time
+	RedeliveryOf   *uuid.UUID `gorm:"type:uuid"`
+}
@@ -0,0 +1,150 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Channel names
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	ChannelInApp = "in_app"
+)
+
+// Channels lists every channel in the order they are shown to users
+var Channels = []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS}
+
+// ErrNoAddress is returned when the recipient has no address for a channel
+var ErrNoAddress = errors.New("recipient has no address for this channel")
+
+// Recipient is the person a notification is delivered to
+type Recipient struct {
+	UserID uuid.UUID `json:"user_id"`
+	Name   string    `json:"name"`
+	Email  string    `json:"email,omitempty"`
+	Phone  string    `json:"phone,omitempty"`
+}
+
+// Message is a rendered notification ready for delivery
+type Message struct {
+	ID        uuid.UUID `json:"id"`
+	EventType string    `json:"event_type"`
+	To        Recipient `json:"to"`
+	Subject   string    `json:"subject"`
+	Body      string    `json:"body"`
+}
+
+// Channel delivers messages over one medium such as email or SMS
+type Channel interface {
+	// Name returns the channel name stored on notifications
+	Name() string
+	// Send delivers a message. Errors are retried with backoff.
+	Send(ctx context.Context, msg Message) error
+}
+
+// Address returns the recipient's address for a channel, or "" if they have none
+func (r Recipient) Address(channel string) string {
+	switch channel {
+	case ChannelEmail:
+		return r.Email
+	case ChannelSMS:
+		return r.Phone
+	default:
+		return r.UserID.String()
+	}
+}
+
+// FileChannel is a local stand-in for a real provider. It appends every
+// message as a JSON line to <dir>/<name>.log so developers can see what
+// would have been sent.
+type FileChannel struct {
+	name string
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileChannel creates a FileChannel writing to dir
+func NewFileChannel(name, dir string) (*FileChannel, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create notification directory: %w", err)
+	}
+	return &FileChannel{name: name, path: filepath.Join(dir, name+".log")}, nil
+}
+
+// Name returns the channel name
+func (c *FileChannel) Name() string {
+	return c.name
+}
+
+// Send appends the message to the channel's log file
+func (c *FileChannel) Send(ctx context.Context, msg Message) error {
+	if msg.To.Address(c.name) == "" {
+		return ErrNoAddress
+	}
+
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// LogChannel is a stand-in that writes messages to the application log
+type LogChannel struct {
+	name string
+}
+
+// NewLogChannel creates a LogChannel
+func NewLogChannel(name string) *LogChannel {
+	return &LogChannel{name: name}
+}
+
+// Name returns the channel name
+func (c *LogChannel) Name() string {
+	return c.name
+}
+
+// Send logs the message
+func (c *LogChannel) Send(ctx context.Context, msg Message) error {
+	if msg.To.Address(c.name) == "" {
+		return ErrNoAddress
+	}
+	log.Printf("notify[%s] to %s: %s - %s", c.name, msg.To.Address(c.name), msg.Subject, msg.Body)
+	return nil
+}
+
+// InAppChannel delivers to the user's in-app inbox. The stored notification
+// is the inbox entry, so there is nothing further to send.
+type InAppChannel struct{}
+
+// Name returns the channel name
+func (InAppChannel) Name() string {
+	return ChannelInApp
+}
+
+// Send accepts the message; it is already in the inbox
+func (InAppChannel) Send(ctx context.Context, msg Message) error {
+	return nil
+}
@@ -0,0 +1,37 @@
+package notify
+
+import (
+	"context"
+	"lostnfound-api/internal/sms"
+)
+
+// maxSMSLength keeps a notification within three SMS parts
+const maxSMSLength = 459
+
+// SMSChannel delivers notifications as text messages through an SMS provider
+type SMSChannel struct {
+	provider sms.Provider
+}
+
+// NewSMSChannel creates an SMSChannel
+func NewSMSChannel(provider sms.Provider) *SMSChannel {
+	return &SMSChannel{provider: provider}
+}
+
+// Name returns the channel name
+func (c *SMSChannel) Name() string {
+	return ChannelSMS
+}
+
+// Send texts the subject and body to the recipient's phone
+func (c *SMSChannel) Send(ctx context.Context, msg Message) error {
+	if msg.To.Phone == "" {
+		return ErrNoAddress
+	}
+
+	text := []rune(msg.Subject + ": " + msg.Body)
+	if len(text) > maxSMSLength {
+		text = append(text[:maxSMSLength-1], '…')
+	}
+	return c.provider.Send(ctx, msg.To.Phone, string(text))
+}
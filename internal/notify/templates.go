@@ -0,0 +1,272 @@
+package notify
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// Event types that only produce notifications. Events that are also streamed
+// in real time use the realtime package's names.
+const (
+	EventAssetFound       = "asset.found"
+	EventRecoveryReport   = "recovery.report"
+	EventModerationAction = "moderation.action"
+	EventItemReminder     = "item.reminder"
+	EventItemArchived     = "item.archived"
+	EventDisposalDue      = "custody.disposal_due"
+)
+
+// Supported locales
+const (
+	LocaleEnglish = "en"
+	LocaleSwahili = "sw"
+)
+
+// DefaultLocale is used when a user has no locale or an unsupported one
+const DefaultLocale = LocaleEnglish
+
+// Locales lists the supported locales
+var Locales = []string{LocaleEnglish, LocaleSwahili}
+
+// source is the text of a template before parsing
+type source struct {
+	subject string
+	body    string
+}
+
+// eventTemplate describes how one event type is notified
+type eventTemplate struct {
+	// channels are enabled for users who have not set a preference
+	channels []string
+	locales  map[string]source
+}
+
+// events holds the templates for every notified event type. Templates are
+// text/template strings executed against the event payload.
+var events = map[string]eventTemplate{
+	"item.match": {
+		channels: []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: "Possible match for your item",
+				body:    `"{{.Title}}" looks like a match for an item you reported. Open the app to compare the two reports.`,
+			},
+			LocaleSwahili: {
+				subject: "Huenda kipengee chako kimepatikana",
+				body:    `"{{.Title}}" kinafanana na kipengee ulichoripoti. Fungua programu kulinganisha ripoti hizi mbili.`,
+			},
+		},
+	},
+	"claim.created": {
+		channels: []string{ChannelInApp, ChannelPush, ChannelEmail},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: "New claim on your item",
+				body:    `Someone has claimed "{{.ItemTitle}}". Review their proof of ownership and approve or reject the claim.`,
+			},
+			LocaleSwahili: {
+				subject: "Dai jipya kwa kipengee chako",
+				body:    `Mtu amedai "{{.ItemTitle}}". Kagua ushahidi wa umiliki kisha ukubali au ukatae dai hilo.`,
+			},
+		},
+	},
+	"claim.status_changed": {
+		channels: []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: "Claim {{.Status}}",
+				body:    `The claim on "{{.ItemTitle}}" was {{.Status}}.`,
+			},
+			LocaleSwahili: {
+				subject: `Dai {{if eq (print .Status) "approved"}}limekubaliwa{{else if eq (print .Status) "rejected"}}limekataliwa{{else}}limeondolewa{{end}}`,
+				body:    `Dai kuhusu "{{.ItemTitle}}" {{if eq (print .Status) "approved"}}limekubaliwa{{else if eq (print .Status) "rejected"}}limekataliwa{{else}}limeondolewa{{end}}.`,
+			},
+		},
+	},
+	"message.created": {
+		channels: []string{ChannelInApp, ChannelPush},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: "New message",
+				body:    `{{.Preview}}`,
+			},
+			LocaleSwahili: {
+				subject: "Ujumbe mpya",
+				body:    `{{.Preview}}`,
+			},
+		},
+	},
+	"saved_search.match": {
+		channels: []string{ChannelInApp, ChannelEmail},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: `{{len .Items}} new {{if eq (len .Items) 1}}item matches{{else}}items match{{end}} "{{.Name}}"`,
+				body:    `{{range .Items}}- {{.Title}} ({{.Status}}){{"\n"}}{{end}}`,
+			},
+			LocaleSwahili: {
+				subject: `Vipengee {{len .Items}} vipya vinalingana na "{{.Name}}"`,
+				body:    `{{range .Items}}- {{.Title}} ({{if eq (print .Status) "lost"}}kimepotea{{else}}kimeokotwa{{end}}){{"\n"}}{{end}}`,
+			},
+		},
+	},
+	EventAssetFound: {
+		channels: []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: "Your registered {{.AssetType}} may have been found",
+				body:    `A found item "{{.ItemTitle}}" matches the identifier of your {{.Brand}} {{.ModelName}}. Open the app to contact the finder.`,
+			},
+			LocaleSwahili: {
+				subject: "Kifaa chako kilichosajiliwa huenda kimepatikana",
+				body:    `Kipengee kilichookotwa "{{.ItemTitle}}" kina nambari ya utambulisho ya {{.Brand}} {{.ModelName}} yako. Fungua programu uwasiliane na aliyekiokota.`,
+			},
+		},
+	},
+	EventRecoveryReport: {
+		channels: []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: `Someone found "{{.Label}}"`,
+				body:    `A finder scanned your recovery tag{{if .Location}} near {{.Location}}{{end}}.{{if .Message}} They said: {{.Message}}{{end}}`,
+			},
+			LocaleSwahili: {
+				subject: `Mtu ameokota "{{.Label}}"`,
+				body:    `Mtu amechanganua lebo yako ya urejeshaji{{if .Location}} karibu na {{.Location}}{{end}}.{{if .Message}} Ujumbe wake: {{.Message}}{{end}}`,
+			},
+		},
+	},
+	EventModerationAction: {
+		channels: []string{ChannelInApp, ChannelEmail},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: "A moderator took action on your account",
+				body:    `Action: {{.Action}}{{if .ItemTitle}} on "{{.ItemTitle}}"{{end}}. Reason: {{.Reason}}. You can appeal from the app.`,
+			},
+			LocaleSwahili: {
+				subject: "Msimamizi amechukua hatua kwenye akaunti yako",
+				body:    `Hatua: {{.Action}}{{if .ItemTitle}} kuhusu "{{.ItemTitle}}"{{end}}. Sababu: {{.Reason}}. Unaweza kukata rufaa kupitia programu.`,
+			},
+		},
+	},
+	EventItemReminder: {
+		channels: []string{ChannelInApp, ChannelPush, ChannelEmail, ChannelSMS},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: `Is "{{.Title}}" still {{if eq (print .Status) "lost"}}missing{{else}}waiting for its owner{{end}}?`,
+				body:    `You reported "{{.Title}}" {{.Days}} days ago. Confirm in the app that it is still {{if eq (print .Status) "lost"}}missing{{else}}unclaimed{{end}}, or mark it resolved. Unconfirmed reports are archived from {{.ArchiveOn}}.`,
+			},
+			LocaleSwahili: {
+				subject: `Je, "{{.Title}}" {{if eq (print .Status) "lost"}}bado kimepotea{{else}}bado kinamsubiri mwenyewe{{end}}?`,
+				body:    `Uliripoti "{{.Title}}" siku {{.Days}} zilizopita. Thibitisha kwenye programu kwamba {{if eq (print .Status) "lost"}}bado kimepotea{{else}}bado hakijadaiwa{{end}}, au uweke alama kwamba kimeshughulikiwa. Ripoti zisizothibitishwa huhifadhiwa kwenye kumbukumbu kuanzia {{.ArchiveOn}}.`,
+			},
+		},
+	},
+	EventItemArchived: {
+		channels: []string{ChannelInApp, ChannelEmail},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: `"{{.Title}}" was archived`,
+				body:    `Your report of "{{.Title}}" was not confirmed, so it no longer appears in listings or matches. Confirm it in the app to list it again.`,
+			},
+			LocaleSwahili: {
+				subject: `"{{.Title}}" kimehifadhiwa kwenye kumbukumbu`,
+				body:    `Ripoti yako ya "{{.Title}}" haikuthibitishwa, kwa hivyo haionekani tena kwenye orodha wala ulinganisho. Ithibitishe kwenye programu ili ionekane tena.`,
+			},
+		},
+	},
+	EventDisposalDue: {
+		channels: []string{ChannelInApp, ChannelEmail},
+		locales: map[string]source{
+			LocaleEnglish: {
+				subject: `{{len .Items}} held {{if eq (len .Items) 1}}item reaches its{{else}}items reach their{{end}} disposal deadline`,
+				body:    `{{.Organization}} must keep these items until the legal holding period ends:{{"\n"}}{{range .Items}}- {{.Title}}{{if .StorageLocation}} ({{.StorageLocation}}){{end}}: until {{.Deadline}}{{"\n"}}{{end}}Return or release them before then, and record any disposal in the app.`,
+			},
+			LocaleSwahili: {
+				subject: `Vipengee {{len .Items}} vinavyohifadhiwa vinafikia muda wa kuondolewa`,
+				body:    `{{.Organization}} lazima ihifadhi vipengee hivi hadi muda wa kisheria wa kuhifadhi uishe:{{"\n"}}{{range .Items}}- {{.Title}}{{if .StorageLocation}} ({{.StorageLocation}}){{end}}: hadi {{.Deadline}}{{"\n"}}{{end}}Virudishe au uvitoe kabla ya hapo, na urekodi uondoaji wowote kwenye programu.`,
+			},
+		},
+	},
+}
+
+// parsed holds the parsed templates keyed by event type and locale
+var parsed = map[string]map[string][2]*template.Template{}
+
+func init() {
+	for eventType, tmpl := range events {
+		parsed[eventType] = map[string][2]*template.Template{}
+		for locale, src := range tmpl.locales {
+			name := eventType + "." + locale
+			parsed[eventType][locale] = [2]*template.Template{
+				template.Must(template.New(name + ".subject").Parse(src.subject)),
+				template.Must(template.New(name + ".body").Parse(src.body)),
+			}
+		}
+	}
+}
+
+// EventTypes lists every event type that produces notifications
+func EventTypes() []string {
+	types := make([]string, 0, len(events))
+	for eventType := range events {
+		types = append(types, eventType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Supported reports whether an event type produces notifications
+func Supported(eventType string) bool {
+	_, ok := events[eventType]
+	return ok
+}
+
+// DefaultEnabled reports whether a channel is on for an event type when the
+// user has not set a preference
+func DefaultEnabled(eventType, channel string) bool {
+	for _, c := range events[eventType].channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeLocale returns the supported locale closest to the given one
+func NormalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "-_"); i > 0 {
+		locale = locale[:i]
+	}
+	for _, l := range Locales {
+		if l == locale {
+			return l
+		}
+	}
+	return DefaultLocale
+}
+
+// Render produces the subject and body of an event in a locale, falling back
+// to the default locale
+func Render(eventType, locale string, data any) (string, string, error) {
+	byLocale, ok := parsed[eventType]
+	if !ok {
+		return "", "", fmt.Errorf("no template for event %q", eventType)
+	}
+	tmpl, ok := byLocale[NormalizeLocale(locale)]
+	if !ok {
+		tmpl = byLocale[DefaultLocale]
+	}
+
+	var subject, body bytes.Buffer
+	if err := tmpl[0].Execute(&subject, data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl[1].Execute(&body, data); err != nil {
+		return "", "", err
+	}
+	return strings.TrimSpace(subject.String()), strings.TrimSpace(body.String()), nil
+}
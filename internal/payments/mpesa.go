@@ -0,0 +1,383 @@
+package payments
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default M-Pesa Daraja API hosts
+const (
+	MPesaLiveURL    = "https://api.safaricom.co.ke"
+	MPesaSandboxURL = "https://sandbox.safaricom.co.ke"
+)
+
+// mpesaProcessingCode is the error code the STK query returns while the
+// payer has not yet answered the prompt
+const mpesaProcessingCode = "500.001.1001"
+
+// MPesaConfig holds the Daraja credentials and callback URLs
+type MPesaConfig struct {
+	BaseURL        string
+	ConsumerKey    string
+	ConsumerSecret string
+	// Shortcode and Passkey are the paybill collections are made to
+	Shortcode string
+	Passkey   string
+	// PayoutShortcode is the B2C shortcode payouts are made from; empty uses Shortcode
+	PayoutShortcode    string
+	InitiatorName      string
+	SecurityCredential string
+	// CollectionCallbackURL receives STK push results
+	CollectionCallbackURL string
+	// PayoutResultURL and PayoutTimeoutURL receive B2C results and queue timeouts
+	PayoutResultURL  string
+	PayoutTimeoutURL string
+}
+
+// MPesa collects payments by STK push and pays out through B2C on the
+// M-Pesa Daraja API
+type MPesa struct {
+	config MPesaConfig
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewMPesa creates an M-Pesa provider
+func NewMPesa(config MPesaConfig) *MPesa {
+	config.BaseURL = strings.TrimSuffix(config.BaseURL, "/")
+	if config.PayoutShortcode == "" {
+		config.PayoutShortcode = config.Shortcode
+	}
+	return &MPesa{config: config, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// mpesaError is the body Daraja returns for a failed request
+type mpesaError struct {
+	RequestID    string `json:"requestId"`
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// Collect sends an STK push to the payer's phone
+func (p *MPesa) Collect(ctx context.Context, collection Collection) (string, error) {
+	amount, ok := Shillings(collection.AmountCents)
+	if !ok {
+		return "", errors.New("m-pesa amounts must be whole shillings")
+	}
+
+	timestamp, password := p.password(time.Now())
+	var resp struct {
+		CheckoutRequestID   string `json:"CheckoutRequestID"`
+		ResponseCode        string `json:"ResponseCode"`
+		ResponseDescription string `json:"ResponseDescription"`
+	}
+	err := p.post(ctx, "/mpesa/stkpush/v1/processrequest", map[string]any{
+		"BusinessShortCode": p.config.Shortcode,
+		"Password":          password,
+		"Timestamp":         timestamp,
+		"TransactionType":   "CustomerPayBillOnline",
+		"Amount":            amount,
+		"PartyA":            MSISDN(collection.Phone),
+		"PartyB":            p.config.Shortcode,
+		"PhoneNumber":       MSISDN(collection.Phone),
+		"CallBackURL":       p.config.CollectionCallbackURL,
+		"AccountReference":  truncate(collection.Reference, 12),
+		"TransactionDesc":   truncate(collection.Description, 13),
+	}, &resp)
+	if err != nil {
+		return "", err
+	}
+	if resp.ResponseCode != "0" || resp.CheckoutRequestID == "" {
+		return "", fmt.Errorf("%w: %s", ErrRejected, resp.ResponseDescription)
+	}
+	return resp.CheckoutRequestID, nil
+}
+
+// CollectionStatus queries the outcome of an STK push
+func (p *MPesa) CollectionStatus(ctx context.Context, reference string, amountCents int64) (*Result, error) {
+	timestamp, password := p.password(time.Now())
+	var resp struct {
+		ResultCode string `json:"ResultCode"`
+		ResultDesc string `json:"ResultDesc"`
+	}
+	err := p.post(ctx, "/mpesa/stkpushquery/v1/query", map[string]any{
+		"BusinessShortCode": p.config.Shortcode,
+		"Password":          password,
+		"Timestamp":         timestamp,
+		"CheckoutRequestID": reference,
+	}, &resp)
+	var apiErr *mpesaAPIError
+	if errors.As(err, &apiErr) && apiErr.Code == mpesaProcessingCode {
+		return &Result{Reference: reference, Status: StatusPending, Description: apiErr.Message}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The query returns neither the amount nor the receipt number. A payer can
+	// only approve an STK push for the amount it asked for, so a success means
+	// that amount was paid in.
+	result := &Result{Reference: reference, Status: StatusFailed, Description: resp.ResultDesc}
+	if resp.ResultCode == "0" {
+		result.Status, result.AmountCents = StatusSucceeded, amountCents
+	}
+	return result, nil
+}
+
+// Pay sends a B2C payment to a phone
+func (p *MPesa) Pay(ctx context.Context, payout Payout) error {
+	amount, ok := Shillings(payout.AmountCents)
+	if !ok {
+		return errors.New("m-pesa amounts must be whole shillings")
+	}
+
+	var resp struct {
+		ResponseCode        string `json:"ResponseCode"`
+		ResponseDescription string `json:"ResponseDescription"`
+	}
+	err := p.post(ctx, "/mpesa/b2c/v3/paymentrequest", map[string]any{
+		"OriginatorConversationID": payout.ID,
+		"InitiatorName":            p.config.InitiatorName,
+		"SecurityCredential":       p.config.SecurityCredential,
+		"CommandID":                "BusinessPayment",
+		"Amount":                   amount,
+		"PartyA":                   p.config.PayoutShortcode,
+		"PartyB":                   MSISDN(payout.Phone),
+		"Remarks":                  truncate(payout.Remarks, 100),
+		"QueueTimeOutURL":          p.config.PayoutTimeoutURL,
+		"ResultURL":                p.config.PayoutResultURL,
+		"Occasion":                 "",
+	}, &resp)
+	if err != nil {
+		return err
+	}
+	if resp.ResponseCode != "0" {
+		return fmt.Errorf("%w: %s", ErrRejected, resp.ResponseDescription)
+	}
+	return nil
+}
+
+// PayoutStatus reports payouts as pending; Daraja only answers transaction
+// status queries through a callback, so the result arrives there
+func (p *MPesa) PayoutStatus(ctx context.Context, id string) (*Result, error) {
+	return &Result{Reference: id, Status: StatusPending}, nil
+}
+
+// ParseCollectionCallback reads the STK push result Daraja posts to the
+// collection callback URL
+func ParseCollectionCallback(body []byte) (*Result, error) {
+	var payload struct {
+		Body struct {
+			STKCallback struct {
+				CheckoutRequestID string `json:"CheckoutRequestID"`
+				ResultCode        int    `json:"ResultCode"`
+				ResultDesc        string `json:"ResultDesc"`
+				CallbackMetadata  struct {
+					Item []struct {
+						Name  string `json:"Name"`
+						Value any    `json:"Value"`
+					} `json:"Item"`
+				} `json:"CallbackMetadata"`
+			} `json:"stkCallback"`
+		} `json:"Body"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	callback := payload.Body.STKCallback
+	if callback.CheckoutRequestID == "" {
+		return nil, errors.New("callback has no CheckoutRequestID")
+	}
+
+	result := &Result{Reference: callback.CheckoutRequestID, Status: StatusFailed, Description: callback.ResultDesc}
+	if callback.ResultCode == 0 {
+		result.Status = StatusSucceeded
+	}
+	for _, item := range callback.CallbackMetadata.Item {
+		switch item.Name {
+		case "Amount":
+			result.AmountCents = centsFrom(item.Value)
+		case "MpesaReceiptNumber":
+			result.Receipt = fmt.Sprint(item.Value)
+		}
+	}
+	return result, nil
+}
+
+// ParsePayoutResult reads the B2C result Daraja posts to the payout result URL
+func ParsePayoutResult(body []byte) (*Result, error) {
+	var payload struct {
+		Result struct {
+			ResultCode               int    `json:"ResultCode"`
+			ResultDesc               string `json:"ResultDesc"`
+			OriginatorConversationID string `json:"OriginatorConversationID"`
+			TransactionID            string `json:"TransactionID"`
+			ResultParameters         struct {
+				ResultParameter []struct {
+					Key   string `json:"Key"`
+					Value any    `json:"Value"`
+				} `json:"ResultParameter"`
+			} `json:"ResultParameters"`
+		} `json:"Result"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	callback := payload.Result
+	if callback.OriginatorConversationID == "" {
+		return nil, errors.New("result has no OriginatorConversationID")
+	}
+
+	result := &Result{
+		Reference:   callback.OriginatorConversationID,
+		Status:      StatusFailed,
+		Receipt:     callback.TransactionID,
+		Description: callback.ResultDesc,
+	}
+	if callback.ResultCode == 0 {
+		result.Status = StatusSucceeded
+	}
+	for _, parameter := range callback.ResultParameters.ResultParameter {
+		if parameter.Key == "TransactionAmount" {
+			result.AmountCents = centsFrom(parameter.Value)
+		}
+	}
+	return result, nil
+}
+
+// mpesaAPIError is a request Daraja refused with an error code
+type mpesaAPIError struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+func (e *mpesaAPIError) Error() string {
+	return fmt.Sprintf("m-pesa returned %d %s: %s", e.Status, e.Code, e.Message)
+}
+
+// Unwrap reports client errors as rejections; the request was not accepted
+// and can safely be sent again
+func (e *mpesaAPIError) Unwrap() error {
+	if e.Status >= 400 && e.Status < 500 {
+		return ErrRejected
+	}
+	return nil
+}
+
+// post sends an authenticated JSON request and decodes the response
+func (p *MPesa) post(ctx context.Context, path string, body, out any) error {
+	token, err := p.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.config.BaseURL+path, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr mpesaError
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return &mpesaAPIError{Status: resp.StatusCode, Code: apiErr.ErrorCode, Message: apiErr.ErrorMessage}
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// accessToken returns a cached OAuth token, fetching a new one shortly before it expires
+func (p *MPesa) accessToken(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.token != "" && time.Now().Before(p.expires) {
+		return p.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.config.BaseURL+"/oauth/v1/generate?grant_type=client_credentials", nil)
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(p.config.ConsumerKey, p.config.ConsumerSecret)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("m-pesa authentication returned %s", resp.Status)
+	}
+
+	var body struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	seconds, _ := strconv.Atoi(body.ExpiresIn)
+	if seconds <= 0 {
+		seconds = 3599
+	}
+	p.token = body.AccessToken
+	p.expires = time.Now().Add(time.Duration(seconds)*time.Second - time.Minute)
+	return p.token, nil
+}
+
+// password returns the timestamp and password an STK request is signed with
+func (p *MPesa) password(at time.Time) (string, string) {
+	timestamp := at.In(nairobi).Format("20060102150405")
+	return timestamp, base64.StdEncoding.EncodeToString([]byte(p.config.Shortcode + p.config.Passkey + timestamp))
+}
+
+// nairobi is the time zone Daraja timestamps are in
+var nairobi = time.FixedZone("EAT", 3*60*60)
+
+// centsFrom converts an amount in shillings from a callback to cents
+func centsFrom(value any) int64 {
+	switch v := value.(type) {
+	case float64:
+		return int64(math.Round(v * 100))
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return 0
+		}
+		return int64(math.Round(f * 100))
+	default:
+		return 0
+	}
+}
+
+// truncate shortens a field to the length Daraja accepts
+func truncate(value string, length int) string {
+	if len(value) > length {
+		return value[:length]
+	}
+	return value
+}
@@ -0,0 +1,77 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// ErrRejected is returned when the provider refuses a request outright
+var ErrRejected = errors.New("payment rejected by provider")
+
+// Status is the outcome of a payment or payout
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Collection asks a payer to approve a payment on their phone
+type Collection struct {
+	// Phone is the payer's number in international format
+	Phone       string
+	AmountCents int64
+	// Reference is shown to the payer, such as an item's reference code
+	Reference   string
+	Description string
+}
+
+// Payout sends money to a phone
+type Payout struct {
+	// ID is our own reference for the payout; the provider echoes it back in its result
+	ID          string
+	Phone       string
+	AmountCents int64
+	Remarks     string
+}
+
+// Result is what the provider reports about a collection or payout, from a
+// callback or a status query
+type Result struct {
+	// Reference is the provider's ID for a collection, or our ID for a payout
+	Reference   string
+	Status      Status
+	Receipt     string
+	AmountCents int64
+	Description string
+}
+
+// Provider moves money through a mobile-money service
+type Provider interface {
+	// Collect starts a collection and returns the provider's reference for it.
+	// The outcome arrives later through a callback.
+	Collect(ctx context.Context, collection Collection) (string, error)
+	// CollectionStatus asks the provider how a collection of amountCents
+	// ended. A successful result carries the amount the provider confirms
+	// was paid in, and its receipt when the provider reports one.
+	CollectionStatus(ctx context.Context, reference string, amountCents int64) (*Result, error)
+	// Pay starts a payout. The outcome arrives later through a callback.
+	Pay(ctx context.Context, payout Payout) error
+	// PayoutStatus asks the provider how a payout ended. Providers that only
+	// answer through a callback return a pending result.
+	PayoutStatus(ctx context.Context, id string) (*Result, error)
+}
+
+// Shillings converts cents to whole shillings, reporting false when the
+// amount has a fractional part. M-Pesa only moves whole shillings.
+func Shillings(cents int64) (int64, bool) {
+	return cents / 100, cents%100 == 0
+}
+
+// MSISDN converts an international phone number such as "+254712345678" to
+// the "254712345678" form M-Pesa expects
+func MSISDN(phone string) string {
+	return strings.TrimPrefix(phone, "+")
+}
@@ -0,0 +1,293 @@
+package payments
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SimulatedTransaction is a collection or payout made through the Simulator
+type SimulatedTransaction struct {
+	Kind        string    `json:"kind"`
+	Reference   string    `json:"reference"`
+	Phone       string    `json:"phone"`
+	Amount      int64     `json:"amount"`
+	Status      Status    `json:"status"`
+	ResultCode  int       `json:"result_code"`
+	ResultDesc  string    `json:"result_desc"`
+	Receipt     string    `json:"receipt,omitempty"`
+	CallbackURL string    `json:"callback_url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Simulator imitates the M-Pesa Daraja endpoints the MPesa provider uses, so
+// rewards can be tried out without a Safaricom account. Results are posted to
+// the callback URLs after a delay:
+//
+//   - numbers ending in 00 decline (the payer cancels, or the payout fails)
+//   - numbers ending in 01 never answer, leaving the result to reconciliation
+//   - every other number succeeds
+//
+// With duplicates set, each callback is delivered twice. Transactions are
+// kept in memory and listed at GET /transactions.
+type Simulator struct {
+	delay      time.Duration
+	duplicates bool
+	client     *http.Client
+
+	mu           sync.Mutex
+	transactions []*SimulatedTransaction
+	receipts     int
+}
+
+// NewSimulator creates a Simulator that answers after delay
+func NewSimulator(delay time.Duration, duplicates bool) *Simulator {
+	return &Simulator{delay: delay, duplicates: duplicates, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// Transactions returns the transactions made so far
+func (s *Simulator) Transactions() []SimulatedTransaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	transactions := make([]SimulatedTransaction, len(s.transactions))
+	for i, transaction := range s.transactions {
+		transactions[i] = *transaction
+	}
+	return transactions
+}
+
+// ServeHTTP handles the Daraja endpoints and the transaction listing
+func (s *Simulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/oauth/v1/generate":
+		writeSimulatorJSON(w, http.StatusOK, map[string]string{"access_token": "simulated-token", "expires_in": "3599"})
+	case r.Method == http.MethodPost && r.URL.Path == "/mpesa/stkpush/v1/processrequest":
+		s.collect(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/mpesa/stkpushquery/v1/query":
+		s.query(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/mpesa/b2c/v3/paymentrequest":
+		s.pay(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/transactions":
+		writeSimulatorJSON(w, http.StatusOK, s.Transactions())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// collect accepts an STK push and answers it after the delay
+func (s *Simulator) collect(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Amount      int64  `json:"Amount"`
+		PhoneNumber string `json:"PhoneNumber"`
+		CallBackURL string `json:"CallBackURL"`
+	}
+	if !s.authorized(w, r) || !decodeSimulatorRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	transaction := &SimulatedTransaction{
+		Kind:        "collection",
+		Reference:   fmt.Sprintf("ws_CO_SIM%08d", len(s.transactions)+1),
+		Phone:       req.PhoneNumber,
+		Amount:      req.Amount,
+		Status:      StatusPending,
+		CallbackURL: req.CallBackURL,
+		CreatedAt:   time.Now(),
+	}
+	s.transactions = append(s.transactions, transaction)
+	s.mu.Unlock()
+
+	log.Printf("simulated STK push of KES %d to %s", req.Amount, req.PhoneNumber)
+	go s.settle(transaction, 1032, "Request cancelled by user")
+
+	writeSimulatorJSON(w, http.StatusOK, map[string]string{
+		"MerchantRequestID":   "SIM-" + transaction.Reference,
+		"CheckoutRequestID":   transaction.Reference,
+		"ResponseCode":        "0",
+		"ResponseDescription": "Success. Request accepted for processing",
+		"CustomerMessage":     "Success. Request accepted for processing",
+	})
+}
+
+// query reports how an STK push ended, or that it is still being processed
+func (s *Simulator) query(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		CheckoutRequestID string `json:"CheckoutRequestID"`
+	}
+	if !s.authorized(w, r) || !decodeSimulatorRequest(w, r, &req) {
+		return
+	}
+
+	transaction := s.find("collection", req.CheckoutRequestID)
+	switch {
+	case transaction == nil:
+		writeSimulatorJSON(w, http.StatusNotFound, mpesaError{ErrorCode: "404.001.03", ErrorMessage: "Invalid CheckoutRequestID"})
+	case transaction.Status == StatusPending:
+		writeSimulatorJSON(w, http.StatusInternalServerError, mpesaError{ErrorCode: mpesaProcessingCode, ErrorMessage: "The transaction is being processed"})
+	default:
+		writeSimulatorJSON(w, http.StatusOK, map[string]string{
+			"ResponseCode":      "0",
+			"CheckoutRequestID": transaction.Reference,
+			"ResultCode":        fmt.Sprint(transaction.ResultCode),
+			"ResultDesc":        transaction.ResultDesc,
+		})
+	}
+}
+
+// pay accepts a B2C payment and posts its result after the delay
+func (s *Simulator) pay(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		OriginatorConversationID string `json:"OriginatorConversationID"`
+		Amount                   int64  `json:"Amount"`
+		PartyB                   string `json:"PartyB"`
+		ResultURL                string `json:"ResultURL"`
+	}
+	if !s.authorized(w, r) || !decodeSimulatorRequest(w, r, &req) {
+		return
+	}
+
+	s.mu.Lock()
+	transaction := &SimulatedTransaction{
+		Kind:        "payout",
+		Reference:   req.OriginatorConversationID,
+		Phone:       req.PartyB,
+		Amount:      req.Amount,
+		Status:      StatusPending,
+		CallbackURL: req.ResultURL,
+		CreatedAt:   time.Now(),
+	}
+	s.transactions = append(s.transactions, transaction)
+	s.mu.Unlock()
+
+	log.Printf("simulated B2C payment of KES %d to %s", req.Amount, req.PartyB)
+	go s.settle(transaction, 2040, "The receiver cannot be paid")
+
+	writeSimulatorJSON(w, http.StatusOK, map[string]string{
+		"ConversationID":           "AG_SIM_" + req.OriginatorConversationID,
+		"OriginatorConversationID": req.OriginatorConversationID,
+		"ResponseCode":             "0",
+		"ResponseDescription":      "Accept the service request successfully.",
+	})
+}
+
+// settle decides a transaction's outcome from the phone number and posts it
+// to the callback URL
+func (s *Simulator) settle(transaction *SimulatedTransaction, declineCode int, declineDesc string) {
+	time.Sleep(s.delay)
+	if strings.HasSuffix(transaction.Phone, "01") {
+		return
+	}
+
+	s.mu.Lock()
+	if strings.HasSuffix(transaction.Phone, "00") {
+		transaction.Status, transaction.ResultCode, transaction.ResultDesc = StatusFailed, declineCode, declineDesc
+	} else {
+		transaction.Status, transaction.ResultDesc = StatusSucceeded, "The service request is processed successfully."
+		s.receipts++
+		transaction.Receipt = fmt.Sprintf("SIM%07d", s.receipts)
+	}
+	body := s.callbackBody(transaction)
+	s.mu.Unlock()
+
+	deliveries := 1
+	if s.duplicates {
+		deliveries = 2
+	}
+	for i := 0; i < deliveries; i++ {
+		resp, err := s.client.Post(transaction.CallbackURL, "application/json", bytes.NewReader(body))
+		if err != nil {
+			log.Printf("simulated callback to %s failed: %v", transaction.CallbackURL, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			log.Printf("simulated callback to %s returned %s", transaction.CallbackURL, resp.Status)
+		}
+	}
+}
+
+// callbackBody builds the callback Daraja would send for a transaction
+func (s *Simulator) callbackBody(transaction *SimulatedTransaction) []byte {
+	var payload any
+	if transaction.Kind == "collection" {
+		callback := map[string]any{
+			"MerchantRequestID": "SIM-" + transaction.Reference,
+			"CheckoutRequestID": transaction.Reference,
+			"ResultCode":        transaction.ResultCode,
+			"ResultDesc":        transaction.ResultDesc,
+		}
+		if transaction.Status == StatusSucceeded {
+			callback["CallbackMetadata"] = map[string]any{"Item": []map[string]any{
+				{"Name": "Amount", "Value": transaction.Amount},
+				{"Name": "MpesaReceiptNumber", "Value": transaction.Receipt},
+				{"Name": "TransactionDate", "Value": transaction.CreatedAt.In(nairobi).Format("20060102150405")},
+				{"Name": "PhoneNumber", "Value": transaction.Phone},
+			}}
+		}
+		payload = map[string]any{"Body": map[string]any{"stkCallback": callback}}
+	} else {
+		result := map[string]any{
+			"ResultType":               0,
+			"ResultCode":               transaction.ResultCode,
+			"ResultDesc":               transaction.ResultDesc,
+			"OriginatorConversationID": transaction.Reference,
+			"ConversationID":           "AG_SIM_" + transaction.Reference,
+			"TransactionID":            transaction.Receipt,
+		}
+		if transaction.Status == StatusSucceeded {
+			result["ResultParameters"] = map[string]any{"ResultParameter": []map[string]any{
+				{"Key": "TransactionAmount", "Value": transaction.Amount},
+				{"Key": "TransactionReceipt", "Value": transaction.Receipt},
+				{"Key": "ReceiverPartyPublicName", "Value": transaction.Phone},
+			}}
+		}
+		payload = map[string]any{"Result": result}
+	}
+
+	data, _ := json.Marshal(payload)
+	return data
+}
+
+// find looks up a transaction by kind and reference
+func (s *Simulator) find(kind, reference string) *SimulatedTransaction {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, transaction := range s.transactions {
+		if transaction.Kind == kind && transaction.Reference == reference {
+			copied := *transaction
+			return &copied
+		}
+	}
+	return nil
+}
+
+// authorized checks a request carries a bearer token, as Daraja requires
+func (s *Simulator) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeSimulatorJSON(w, http.StatusUnauthorized, mpesaError{ErrorCode: "404.001.04", ErrorMessage: "Invalid Authentication passed"})
+		return false
+	}
+	return true
+}
+
+// decodeSimulatorRequest reads a JSON request body
+func decodeSimulatorRequest(w http.ResponseWriter, r *http.Request, out any) bool {
+	if err := json.NewDecoder(r.Body).Decode(out); err != nil {
+		writeSimulatorJSON(w, http.StatusBadRequest, mpesaError{ErrorCode: "400.002.02", ErrorMessage: err.Error()})
+		return false
+	}
+	return true
+}
+
+// writeSimulatorJSON writes a JSON response
+func writeSimulatorJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
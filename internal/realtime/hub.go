@@ -0,0 +1,126 @@
+package realtime
+
+import (
+	"github.com/google/uuid"
+	"sync"
+)
+
+// subscriptionBuffer is how many events a slow client may fall behind before
+// its stream is closed; it can then reconnect and resume from its last ID
+const subscriptionBuffer = historySize + 32
+
+// Subscription is one open stream for a user
+type Subscription struct {
+	UserID uuid.UUID
+
+	hub     *hub
+	ch      chan Event
+	mu      sync.Mutex
+	last    string
+	ready   bool
+	closed  bool
+	pending []Event
+	once    sync.Once
+}
+
+// Events returns the channel of events for the stream. It is closed when the
+// subscription is closed or the client falls too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.ch
+}
+
+// Close ends the subscription
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.hub.remove(s)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if !s.closed {
+			s.closed = true
+			close(s.ch)
+		}
+	})
+}
+
+// send delivers a live event, holding it back while history is replayed
+func (s *Subscription) send(event Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.ready {
+		s.pending = append(s.pending, event)
+		return
+	}
+	s.deliverLocked(event)
+}
+
+// replay delivers missed events followed by any live events that arrived
+// meanwhile, then switches the subscription to live delivery
+func (s *Subscription) replay(missed []Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, event := range missed {
+		s.deliverLocked(event)
+	}
+	for _, event := range s.pending {
+		s.deliverLocked(event)
+	}
+	s.pending = nil
+	s.ready = true
+}
+
+// deliverLocked queues an event unless it was already delivered
+func (s *Subscription) deliverLocked(event Event) {
+	if s.closed || s.last != "" && !after(event.ID, s.last) {
+		return
+	}
+	select {
+	case s.ch <- event:
+		s.last = event.ID
+	default:
+		s.closed = true
+		close(s.ch)
+	}
+}
+
+// hub fans events out to the subscriptions open in this process
+type hub struct {
+	mu   sync.RWMutex
+	subs map[uuid.UUID]map[*Subscription]struct{}
+}
+
+// newHub creates an empty hub
+func newHub() *hub {
+	return &hub{subs: make(map[uuid.UUID]map[*Subscription]struct{})}
+}
+
+// add registers a new subscription that holds live events until replay
+func (h *hub) add(userID uuid.UUID, lastEventID string) *Subscription {
+	sub := &Subscription{UserID: userID, hub: h, ch: make(chan Event, subscriptionBuffer), last: lastEventID}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[*Subscription]struct{})
+	}
+	h.subs[userID][sub] = struct{}{}
+	return sub
+}
+
+// remove unregisters a subscription
+func (h *hub) remove(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.subs[sub.UserID], sub)
+	if len(h.subs[sub.UserID]) == 0 {
+		delete(h.subs, sub.UserID)
+	}
+}
+
+// deliver sends an event to every subscription of the user
+func (h *hub) deliver(userID uuid.UUID, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for sub := range h.subs[userID] {
+		sub.send(event)
+	}
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"sync"
+	"time"
+)
+
+// MemoryBroker delivers events within a single process. It is used when no
+// Redis URL is configured.
+type MemoryBroker struct {
+	hub *hub
+
+	mu      sync.Mutex
+	lastMs  int64
+	seq     int64
+	history map[uuid.UUID][]Event
+}
+
+// NewMemoryBroker creates a new MemoryBroker
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{hub: newHub(), history: make(map[uuid.UUID][]Event)}
+}
+
+// Publish sends an event to the user's open streams and keeps it for resuming
+func (b *MemoryBroker) Publish(ctx context.Context, userID uuid.UUID, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	b.mu.Lock()
+	ms := now.UnixMilli()
+	if ms <= b.lastMs {
+		ms = b.lastMs
+		b.seq++
+	} else {
+		b.lastMs, b.seq = ms, 0
+	}
+	event := Event{ID: fmt.Sprintf("%d-%d", ms, b.seq), Type: eventType, Data: payload, CreatedAt: now}
+
+	history := append(b.history[userID], event)
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	b.history[userID] = history
+	b.mu.Unlock()
+
+	b.hub.deliver(userID, event)
+	return nil
+}
+
+// Subscribe opens a stream for the user, replaying events after lastEventID
+func (b *MemoryBroker) Subscribe(ctx context.Context, userID uuid.UUID, lastEventID string) (*Subscription, error) {
+	sub := b.hub.add(userID, lastEventID)
+
+	var missed []Event
+	if lastEventID != "" {
+		b.mu.Lock()
+		for _, event := range b.history[userID] {
+			if after(event.ID, lastEventID) {
+				missed = append(missed, event)
+			}
+		}
+		b.mu.Unlock()
+	}
+
+	sub.replay(missed)
+	return sub, nil
+}
+
+// Close releases the broker's resources
+func (b *MemoryBroker) Close() error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types sent to users
+const (
+	EventItemMatch          = "item.match"
+	EventClaimCreated       = "claim.created"
+	EventClaimStatusChanged = "claim.status_changed"
+	EventMessageCreated     = "message.created"
+	EventSavedSearchMatch   = "saved_search.match"
+)
+
+// historySize is how many recent events are kept per user for resuming
+const historySize = 100
+
+// Event is a single event delivered to a user. IDs have the form
+// "<unix-millis>-<sequence>" and increase over time, so a client can resume
+// from the last ID it saw.
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// Broker publishes events to users and lets them subscribe to their own stream
+type Broker interface {
+	// Publish sends an event to every open stream of the user
+	Publish(ctx context.Context, userID uuid.UUID, eventType string, data any) error
+	// Subscribe opens a stream for the user, first replaying any events
+	// after lastEventID that are still in the history
+	Subscribe(ctx context.Context, userID uuid.UUID, lastEventID string) (*Subscription, error)
+	// Close releases the broker's resources
+	Close() error
+}
+
+// parseID splits an event ID into its time and sequence parts
+func parseID(id string) (int64, int64, bool) {
+	ms, seq, found := strings.Cut(id, "-")
+	if !found {
+		return 0, 0, false
+	}
+	a, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	b, err := strconv.ParseInt(seq, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return a, b, true
+}
+
+// ValidID reports whether id is a well-formed event ID
+func ValidID(id string) bool {
+	_, _, ok := parseID(id)
+	return ok
+}
+
+// after reports whether event ID a comes after b
+func after(a, b string) bool {
+	am, as, ok := parseID(a)
+	if !ok {
+		return false
+	}
+	bm, bs, ok := parseID(b)
+	if !ok {
+		return true
+	}
+	return am > bm || am == bm && as > bs
+}
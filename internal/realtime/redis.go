@@ -0,0 +1,160 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"time"
+)
+
+const (
+	// redisChannel carries live events between API instances
+	redisChannel = "realtime:events"
+	// redisHistoryTTL is how long a user's event history is kept after their last event
+	redisHistoryTTL = 24 * time.Hour
+)
+
+// redisEnvelope is an event on the Redis channel
+type redisEnvelope struct {
+	UserID uuid.UUID `json:"user_id"`
+	Event  Event     `json:"event"`
+}
+
+// RedisBroker delivers events across API instances. Each user's recent
+// events are kept in a Redis stream for resuming, and live events are fanned
+// out to every instance over Redis pub/sub.
+type RedisBroker struct {
+	client *redis.Client
+	hub    *hub
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedisBroker connects to Redis and starts listening for events
+func NewRedisBroker(url string) (*RedisBroker, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	listenCtx, stop := context.WithCancel(context.Background())
+	b := &RedisBroker{client: client, hub: newHub(), cancel: stop, done: make(chan struct{})}
+	go b.listen(listenCtx)
+	return b, nil
+}
+
+// Publish appends the event to the user's stream and announces it to all instances
+func (b *RedisBroker) Publish(ctx context.Context, userID uuid.UUID, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	key := streamKey(userID)
+	id, err := b.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: key,
+		MaxLen: historySize,
+		Approx: true,
+		Values: map[string]any{"type": eventType, "data": string(payload), "created_at": now.Format(time.RFC3339Nano)},
+	}).Result()
+	if err != nil {
+		return err
+	}
+	b.client.Expire(ctx, key, redisHistoryTTL)
+
+	envelope, err := json.Marshal(redisEnvelope{
+		UserID: userID,
+		Event:  Event{ID: id, Type: eventType, Data: payload, CreatedAt: now},
+	})
+	if err != nil {
+		return err
+	}
+	return b.client.Publish(ctx, redisChannel, envelope).Err()
+}
+
+// Subscribe opens a stream for the user, replaying events after lastEventID
+// from the user's Redis stream
+func (b *RedisBroker) Subscribe(ctx context.Context, userID uuid.UUID, lastEventID string) (*Subscription, error) {
+	sub := b.hub.add(userID, lastEventID)
+
+	var missed []Event
+	if lastEventID != "" {
+		messages, err := b.client.XRange(ctx, streamKey(userID), "("+lastEventID, "+").Result()
+		if err != nil {
+			sub.Close()
+			return nil, err
+		}
+		for _, message := range messages {
+			missed = append(missed, eventFromStream(message))
+		}
+	}
+
+	sub.replay(missed)
+	return sub, nil
+}
+
+// Close stops listening and disconnects from Redis
+func (b *RedisBroker) Close() error {
+	b.cancel()
+	<-b.done
+	return b.client.Close()
+}
+
+// listen forwards events from the Redis channel to local subscriptions
+func (b *RedisBroker) listen(ctx context.Context) {
+	defer close(b.done)
+
+	pubsub := b.client.Subscribe(ctx, redisChannel)
+	defer pubsub.Close()
+
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("realtime: redis receive failed: %v", err)
+			time.Sleep(time.Second)
+			continue
+		}
+
+		var envelope redisEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			log.Printf("realtime: invalid event on %s: %v", redisChannel, err)
+			continue
+		}
+		b.hub.deliver(envelope.UserID, envelope.Event)
+	}
+}
+
+// streamKey is the Redis stream holding a user's recent events
+func streamKey(userID uuid.UUID) string {
+	return "realtime:user:" + userID.String()
+}
+
+// eventFromStream converts a Redis stream entry into an event
+func eventFromStream(message redis.XMessage) Event {
+	event := Event{ID: message.ID}
+	if v, ok := message.Values["type"].(string); ok {
+		event.Type = v
+	}
+	if v, ok := message.Values["data"].(string); ok {
+		event.Data = json.RawMessage(v)
+	}
+	if v, ok := message.Values["created_at"].(string); ok {
+		event.CreatedAt, _ = time.Parse(time.RFC3339Nano, v)
+	}
+	return event
+}
@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+)
+
+// AssetRepository handles database operations for registered assets
+type AssetRepository struct {
+	db *gorm.DB
+}
+
+// NewAssetRepository creates a new AssetRepository
+func NewAssetRepository(db *gorm.DB) *AssetRepository {
+	return &AssetRepository{db: db}
+}
+
+// Create adds a new registered asset to the database
+func (r *AssetRepository) Create(asset *models.RegisteredAsset) error {
+	return r.db.Create(asset).Error
+}
+
+// GetByID retrieves a registered asset by ID
+func (r *AssetRepository) GetByID(id uuid.UUID) (*models.RegisteredAsset, error) {
+	var asset models.RegisteredAsset
+	err := r.db.First(&asset, "id = ?", id).Error
+	return &asset, err
+}
+
+// FindByHashes retrieves the registered assets matching any of the hashes
+func (r *AssetRepository) FindByHashes(hashes []string) ([]models.RegisteredAsset, error) {
+	var assets []models.RegisteredAsset
+	if len(hashes) == 0 {
+		return assets, nil
+	}
+	err := r.db.Where("identifier_hash IN ?", hashes).Find(&assets).Error
+	return assets, err
+}
+
+// SetDisputed marks every registration of an identifier as disputed or not
+func (r *AssetRepository) SetDisputed(hash string, disputed bool) error {
+	return r.db.Model(&models.RegisteredAsset{}).Where("identifier_hash = ?", hash).Update("disputed", disputed).Error
+}
+
+// ListDisputed retrieves disputed registrations, grouped by identifier and
+// oldest first within each
+func (r *AssetRepository) ListDisputed() ([]models.RegisteredAsset, error) {
+	var assets []models.RegisteredAsset
+	err := r.db.Where("disputed = ?", true).Order("identifier_hash, created_at ASC").Find(&assets).Error
+	return assets, err
+}
+
+// ListByUser retrieves a user's registered assets
+func (r *AssetRepository) ListByUser(userID uuid.UUID) ([]models.RegisteredAsset, error) {
+	var assets []models.RegisteredAsset
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&assets).Error
+	return assets, err
+}
+
+// Update updates an existing registered asset
+func (r *AssetRepository) Update(asset *models.RegisteredAsset) error {
+	return r.db.Save(asset).Error
+}
+
+// Delete permanently removes a registered asset so its identifier can be
+// registered again. Recovery tags printed for it stay linked to the owner.
+func (r *AssetRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.RecoveryTag{}).Where("asset_id = ?", id).Update("asset_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.RegisteredAsset{}, "id = ?", id).Error
+	})
+}
+
+// CreateAlert records that a found item matched a registered asset
+func (r *AssetRepository) CreateAlert(alert *models.AssetAlert) error {
+	return r.db.Create(alert).Error
+}
+
+// AlertExists reports whether an alert was already raised for an asset and item
+func (r *AssetRepository) AlertExists(assetID, itemID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.AssetAlert{}).Where("asset_id = ? AND item_id = ?", assetID, itemID).Count(&count).Error
+	return count > 0, err
+}
+
+// ListAlerts retrieves a user's asset alerts, newest first
+func (r *AssetRepository) ListAlerts(userID uuid.UUID) ([]models.AssetAlert, error) {
+	var alerts []models.AssetAlert
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&alerts).Error
+	return alerts, err
+}
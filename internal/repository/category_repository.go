@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+)
+
+// CategoryRepository handles database operations for categories
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+// NewCategoryRepository creates a new CategoryRepository
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	return &CategoryRepository{db: db}
+}
+
+// Create adds a new category to the database
+func (r *CategoryRepository) Create(category *models.Category) error {
+	return r.db.Create(category).Error
+}
+
+// GetByID retrieves a category by ID
+func (r *CategoryRepository) GetByID(id uuid.UUID) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, "id = ?", id).Error
+	return &category, err
+}
+
+// GetByPath retrieves a category by its slug path, e.g. "electronics/phone"
+func (r *CategoryRepository) GetByPath(path string) (*models.Category, error) {
+	var category models.Category
+	err := r.db.First(&category, "path = ?", path).Error
+	return &category, err
+}
+
+// List retrieves all categories ordered by path, so parents precede their children
+func (r *CategoryRepository) List() ([]models.Category, error) {
+	var categories []models.Category
+	err := r.db.Order("path ASC").Find(&categories).Error
+	return categories, err
+}
+
+// Count returns the number of categories
+func (r *CategoryRepository) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).Count(&count).Error
+	return count, err
+}
+
+// Update updates an existing category
+func (r *CategoryRepository) Update(category *models.Category) error {
+	return r.db.Save(category).Error
+}
+
+// Delete removes a category from the database
+func (r *CategoryRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.Category{}, "id = ?", id).Error
+}
+
+// HasChildren reports whether a category has subcategories
+func (r *CategoryRepository) HasChildren(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
+
+// HasItems reports whether any item is filed under a category
+func (r *CategoryRepository) HasItems(id uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Item{}).Where("category_id = ?", id).Count(&count).Error
+	return count > 0, err
+}
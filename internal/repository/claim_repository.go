@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+var (
+	// ErrClaimDecided is returned when a claim was decided by someone else first
+	ErrClaimDecided = errors.New("claim has already been decided")
+	// ErrItemAlreadyClaimed is returned when approving a claim on an item
+	// another claim was approved for first
+	ErrItemAlreadyClaimed = errors.New("item has already been claimed")
+)
+
+// ClaimRepository handles database operations for claims
+type ClaimRepository struct {
+	db *gorm.DB
+}
+
+// NewClaimRepository creates a new ClaimRepository
+func NewClaimRepository(db *gorm.DB) *ClaimRepository {
+	return &ClaimRepository{db: db}
+}
+
+// Create adds a new claim to the database
+func (r *ClaimRepository) Create(claim *models.Claim) error {
+	return r.db.Create(claim).Error
+}
+
+// GetByID retrieves a claim by ID
+func (r *ClaimRepository) GetByID(id uuid.UUID) (*models.Claim, error) {
+	var claim models.Claim
+	err := r.db.Preload("ProofImages").First(&claim, "id = ?", id).Error
+	return &claim, err
+}
+
+// ListByItem retrieves the claims on an item, oldest first
+func (r *ClaimRepository) ListByItem(itemID uuid.UUID) ([]models.Claim, error) {
+	var claims []models.Claim
+	err := r.db.Preload("ProofImages").Where("item_id = ?", itemID).Order("created_at").Find(&claims).Error
+	return claims, err
+}
+
+// ListByClaimer retrieves the claims a user has made, newest first
+func (r *ClaimRepository) ListByClaimer(claimerID uuid.UUID, page, limit int) ([]models.Claim, int64, error) {
+	var claims []models.Claim
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Claim{}).Where("claimer_id = ?", claimerID)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("ProofImages").Order("created_at DESC").Offset(offset).Limit(limit).Find(&claims).Error
+	return claims, count, err
+}
+
+// HasOpenClaim reports whether a user has a pending or approved claim on an item
+func (r *ClaimRepository) HasOpenClaim(itemID, claimerID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Claim{}).
+		Where("item_id = ? AND claimer_id = ? AND status IN ?", itemID, claimerID,
+			[]models.ClaimStatus{models.ClaimStatusPending, models.ClaimStatusApproved}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasApprovedClaim reports whether a user's claim on an item has been approved
+func (r *ClaimRepository) HasApprovedClaim(itemID, claimerID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Claim{}).
+		Where("item_id = ? AND claimer_id = ? AND status = ?", itemID, claimerID, models.ClaimStatusApproved).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateStatus decides a pending claim. It fails with ErrClaimDecided when
+// the claim is no longer pending.
+func (r *ClaimRepository) UpdateStatus(claim *models.Claim, status models.ClaimStatus) error {
+	now := time.Now()
+	result := r.db.Model(&models.Claim{}).Where("id = ? AND status = ?", claim.ID, models.ClaimStatusPending).
+		Updates(map[string]any{"status": status, "decided_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrClaimDecided
+	}
+	claim.Status = status
+	claim.DecidedAt = &now
+	return nil
+}
+
+// Approve approves a claim, marks the item claimed and rejects every other
+// pending claim on the item in one transaction. The rejected claims are
+// returned. It fails with ErrClaimDecided when the claim is no longer
+// pending and with ErrItemAlreadyClaimed when the item was claimed or
+// returned, so of two concurrent approvals on an item only one succeeds.
+func (r *ClaimRepository) Approve(claim *models.Claim) ([]models.Claim, error) {
+	now := time.Now()
+	var rejected []models.Claim
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Claiming the item first locks it, so approvals of different claims queue here
+		result := tx.Model(&models.Item{}).
+			Where("id = ? AND status NOT IN ?", claim.ItemID, []models.ItemStatus{models.ItemStatusClaimed, models.ItemStatusReturned}).
+			Update("status", models.ItemStatusClaimed)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrItemAlreadyClaimed
+		}
+
+		result = tx.Model(&models.Claim{}).Where("id = ? AND status = ?", claim.ID, models.ClaimStatusPending).
+			Updates(map[string]any{"status": models.ClaimStatusApproved, "decided_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrClaimDecided
+		}
+		if err := tx.Where("item_id = ? AND id <> ? AND status = ?", claim.ItemID, claim.ID, models.ClaimStatusPending).
+			Find(&rejected).Error; err != nil {
+			return err
+		}
+		if len(rejected) > 0 {
+			ids := make([]uuid.UUID, len(rejected))
+			for i := range rejected {
+				ids[i] = rejected[i].ID
+				rejected[i].Status = models.ClaimStatusRejected
+				rejected[i].DecidedAt = &now
+			}
+			if err := tx.Model(&models.Claim{}).Where("id IN ? AND status = ?", ids, models.ClaimStatusPending).
+				Updates(map[string]any{"status": models.ClaimStatusRejected, "decided_at": now}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	claim.Status = models.ClaimStatusApproved
+	claim.DecidedAt = &now
+	return rejected, nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// ConversationRepository handles database operations for conversations and messages
+type ConversationRepository struct {
+	db *gorm.DB
+}
+
+// NewConversationRepository creates a new ConversationRepository
+func NewConversationRepository(db *gorm.DB) *ConversationRepository {
+	return &ConversationRepository{db: db}
+}
+
+// Create adds a new conversation to the database
+func (r *ConversationRepository) Create(conversation *models.Conversation) error {
+	return r.db.Create(conversation).Error
+}
+
+// GetByID retrieves a conversation by ID
+func (r *ConversationRepository) GetByID(id uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.First(&conversation, "id = ?", id).Error
+	return &conversation, err
+}
+
+// Find retrieves the conversation between an item's owner and a participant, if any
+func (r *ConversationRepository) Find(itemID, participantID uuid.UUID) (*models.Conversation, error) {
+	var conversation models.Conversation
+	err := r.db.Where("item_id = ? AND participant_id = ?", itemID, participantID).First(&conversation).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	return &conversation, err
+}
+
+// ListForUser retrieves a user's conversations, most recently active first
+func (r *ConversationRepository) ListForUser(userID uuid.UUID, page, limit int) ([]models.Conversation, int64, error) {
+	var conversations []models.Conversation
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Conversation{}).Where("owner_id = ? OR participant_id = ?", userID, userID)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("last_message_at DESC NULLS LAST").Order("created_at DESC").
+		Offset(offset).Limit(limit).Find(&conversations).Error
+	return conversations, count, err
+}
+
+// Update updates an existing conversation
+func (r *ConversationRepository) Update(conversation *models.Conversation) error {
+	return r.db.Save(conversation).Error
+}
+
+// CreateMessage stores a message and bumps the conversation's activity time
+func (r *ConversationRepository) CreateMessage(message *models.Message) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(message).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Conversation{}).Where("id = ?", message.ConversationID).
+			Update("last_message_at", message.CreatedAt).Error
+	})
+}
+
+// ListMessages retrieves a page of a conversation's messages, newest first
+func (r *ConversationRepository) ListMessages(conversationID uuid.UUID, page, limit int) ([]models.Message, int64, error) {
+	var messages []models.Message
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.Message{}).Where("conversation_id = ?", conversationID)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&messages).Error
+	return messages, count, err
+}
+
+// GetMessage retrieves a message by ID
+func (r *ConversationRepository) GetMessage(id uuid.UUID) (*models.Message, error) {
+	var message models.Message
+	err := r.db.First(&message, "id = ?", id).Error
+	return &message, err
+}
+
+// MarkRead sets the read receipt on every message the reader has received in a conversation
+func (r *ConversationRepository) MarkRead(conversationID, readerID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.Message{}).
+		Where("conversation_id = ? AND sender_id <> ? AND read_at IS NULL", conversationID, readerID).
+		Update("read_at", at).Error
+}
+
+// UnreadCounts returns the number of unread messages per conversation for a user
+func (r *ConversationRepository) UnreadCounts(userID uuid.UUID, conversationIDs []uuid.UUID) (map[uuid.UUID]int64, error) {
+	var rows []struct {
+		ConversationID uuid.UUID
+		Count          int64
+	}
+	counts := make(map[uuid.UUID]int64, len(conversationIDs))
+	if len(conversationIDs) == 0 {
+		return counts, nil
+	}
+
+	err := r.db.Model(&models.Message{}).
+		Select("conversation_id, COUNT(*) AS count").
+		Where("conversation_id IN ? AND sender_id <> ? AND read_at IS NULL", conversationIDs, userID).
+		Group("conversation_id").Scan(&rows).Error
+	for _, row := range rows {
+		counts[row.ConversationID] = row.Count
+	}
+	return counts, err
+}
+
+// Block records that blocker no longer wants messages from blocked
+func (r *ConversationRepository) Block(blockerID, blockedID uuid.UUID) error {
+	block := &models.UserBlock{BlockerID: blockerID, BlockedID: blockedID}
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(block).Error
+}
+
+// Unblock removes a block
+func (r *ConversationRepository) Unblock(blockerID, blockedID uuid.UUID) error {
+	return r.db.Unscoped().Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Delete(&models.UserBlock{}).Error
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (r *ConversationRepository) IsBlocked(a, b uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.UserBlock{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", a, b, b, a).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// HasBlocked reports whether blocker has blocked blocked
+func (r *ConversationRepository) HasBlocked(blockerID, blockedID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.UserBlock{}).
+		Where("blocker_id = ? AND blocked_id = ?", blockerID, blockedID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CreateReport stores a report about a conversation or message
+func (r *ConversationRepository) CreateReport(report *models.MessageReport) error {
+	return r.db.Create(report).Error
+}
+
+// ListReports retrieves message reports, newest first
+func (r *ConversationRepository) ListReports(page, limit int) ([]models.MessageReport, int64, error) {
+	var reports []models.MessageReport
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.MessageReport{})
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, count, err
+}
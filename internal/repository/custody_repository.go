@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+)
+
+// ErrTransferConfirmed is returned when a pending transfer was confirmed in the meantime
+var ErrTransferConfirmed = errors.New("custody transfer already confirmed")
+
+// CustodyRepository handles database operations for drop-off points and the
+// chain of custody of items
+type CustodyRepository struct {
+	db *gorm.DB
+}
+
+// NewCustodyRepository creates a new CustodyRepository
+func NewCustodyRepository(db *gorm.DB) *CustodyRepository {
+	return &CustodyRepository{db: db}
+}
+
+// CreateDropOffPoint adds a drop-off point
+func (r *CustodyRepository) CreateDropOffPoint(point *models.DropOffPoint) error {
+	return r.db.Omit("Organization").Create(point).Error
+}
+
+// GetDropOffPoint retrieves a drop-off point with its organisation
+func (r *CustodyRepository) GetDropOffPoint(id uuid.UUID) (*models.DropOffPoint, error) {
+	var point models.DropOffPoint
+	err := r.db.Preload("Organization").First(&point, id).Error
+	return &point, err
+}
+
+// UpdateDropOffPoint saves changes to a drop-off point
+func (r *CustodyRepository) UpdateDropOffPoint(point *models.DropOffPoint) error {
+	return r.db.Omit("Organization").Save(point).Error
+}
+
+// ListDropOffPoints retrieves active drop-off points, optionally for one
+// county or organisation
+func (r *CustodyRepository) ListDropOffPoints(countyID string, organizationID *uuid.UUID, page, limit int) ([]models.DropOffPoint, int64, error) {
+	var points []models.DropOffPoint
+	var count int64
+
+	query := r.db.Model(&models.DropOffPoint{}).Where("active = ?", true)
+	if countyID != "" {
+		query = query.Where("county_id = ?", countyID)
+	}
+	if organizationID != nil {
+		query = query.Where("organization_id = ?", *organizationID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Organization").Order("name").Offset(offset).Limit(limit).Find(&points).Error
+	return points, count, err
+}
+
+// RecordTransfer adds an entry to an item's chain of custody together with
+// the resulting custody record, if any, in one transaction
+func (r *CustodyRepository) RecordTransfer(transfer *models.CustodyTransfer, custody *models.ItemCustody) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(transfer).Error; err != nil {
+			return err
+		}
+		if custody == nil {
+			return nil
+		}
+		return tx.Save(custody).Error
+	})
+}
+
+// GetTransfer retrieves an entry in a chain of custody
+func (r *CustodyRepository) GetTransfer(id uuid.UUID) (*models.CustodyTransfer, error) {
+	var transfer models.CustodyTransfer
+	err := r.db.First(&transfer, id).Error
+	return &transfer, err
+}
+
+// ConfirmTransfer marks a pending transfer received and saves the resulting
+// custody record in one transaction. It fails with ErrTransferConfirmed when
+// the transfer is no longer pending.
+func (r *CustodyRepository) ConfirmTransfer(transfer *models.CustodyTransfer, custody *models.ItemCustody) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.CustodyTransfer{}).
+			Where("id = ? AND pending = ?", transfer.ID, true).
+			Updates(map[string]interface{}{
+				"pending":         false,
+				"confirmed_by_id": transfer.ConfirmedByID,
+				"confirmed_at":    transfer.ConfirmedAt,
+				"transferred_at":  transfer.TransferredAt,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrTransferConfirmed
+		}
+		transfer.Pending = false
+		return tx.Save(custody).Error
+	})
+}
+
+// ListTransfers retrieves an item's chain of custody, oldest first
+func (r *CustodyRepository) ListTransfers(itemID uuid.UUID) ([]models.CustodyTransfer, error) {
+	var transfers []models.CustodyTransfer
+	err := r.db.Where("item_id = ?", itemID).Order("transferred_at, created_at").Find(&transfers).Error
+	return transfers, err
+}
+
+// LatestTransfer retrieves the most recent completed entry in an item's chain
+// of custody, ignoring handovers still waiting to be confirmed
+func (r *CustodyRepository) LatestTransfer(itemID uuid.UUID) (*models.CustodyTransfer, error) {
+	var transfer models.CustodyTransfer
+	err := r.db.Where("item_id = ? AND pending = ?", itemID, false).Order("transferred_at DESC, created_at DESC").First(&transfer).Error
+	return &transfer, err
+}
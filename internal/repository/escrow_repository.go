@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// escrowFields are the columns a status change may write
+var escrowFields = []string{
+	"status", "amount_cents", "receipt", "payee_id", "payee_phone", "disbursement", "payout_reference",
+	"payout_receipt", "payout_attempts", "last_error", "held_at", "settled_at", "updated_at",
+}
+
+// EscrowMismatch is an escrow whose ledger does not add up to what its
+// status says it holds
+type EscrowMismatch struct {
+	EscrowID      uuid.UUID           `json:"escrow_id"`
+	Status        models.EscrowStatus `json:"status"`
+	ExpectedCents int64               `json:"expected_cents"`
+	LedgerCents   int64               `json:"ledger_cents"`
+}
+
+// EscrowReconciliation compares the ledger with the escrows
+type EscrowReconciliation struct {
+	// HeldCents is what escrows that have been paid in and not paid out hold
+	HeldCents int64 `json:"held_cents"`
+	// LedgerCents is the sum of every ledger entry
+	LedgerCents int64            `json:"ledger_cents"`
+	Mismatches  []EscrowMismatch `json:"mismatches"`
+}
+
+// EscrowRepository handles database operations for reward escrows and their ledger
+type EscrowRepository struct {
+	db *gorm.DB
+}
+
+// NewEscrowRepository creates a new EscrowRepository
+func NewEscrowRepository(db *gorm.DB) *EscrowRepository {
+	return &EscrowRepository{db: db}
+}
+
+// ErrEscrowActive is returned when creating an escrow for an item that
+// already has an active one
+var ErrEscrowActive = errors.New("item already has an active escrow")
+
+// Create stores a new escrow. It fails with ErrEscrowActive when the item
+// already has a reward being paid in, held or paid out.
+func (r *EscrowRepository) Create(escrow *models.RewardEscrow) error {
+	err := r.db.Omit("LedgerEntries").Create(escrow).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_escrow_active_item" {
+		return ErrEscrowActive
+	}
+	return err
+}
+
+// GetByID retrieves an escrow with its ledger entries
+func (r *EscrowRepository) GetByID(id uuid.UUID) (*models.RewardEscrow, error) {
+	var escrow models.RewardEscrow
+	err := r.db.Preload("LedgerEntries", func(db *gorm.DB) *gorm.DB {
+		return db.Order("created_at")
+	}).First(&escrow, "id = ?", id).Error
+	return &escrow, err
+}
+
+// GetByCheckout retrieves the escrow a collection was made for
+func (r *EscrowRepository) GetByCheckout(reference string) (*models.RewardEscrow, error) {
+	var escrow models.RewardEscrow
+	err := r.db.First(&escrow, "checkout_reference = ?", reference).Error
+	return &escrow, err
+}
+
+// GetByPayout retrieves the escrow a payout is in flight for
+func (r *EscrowRepository) GetByPayout(reference string) (*models.RewardEscrow, error) {
+	var escrow models.RewardEscrow
+	err := r.db.First(&escrow, "payout_reference = ?", reference).Error
+	return &escrow, err
+}
+
+// LatestForItem retrieves the most recent escrow for an item
+func (r *EscrowRepository) LatestForItem(itemID uuid.UUID) (*models.RewardEscrow, error) {
+	var escrow models.RewardEscrow
+	err := r.db.Where("item_id = ?", itemID).Order("created_at DESC").First(&escrow).Error
+	return &escrow, err
+}
+
+// HasActive reports whether an item has a reward being paid in, held or paid out
+func (r *EscrowRepository) HasActive(itemID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RewardEscrow{}).Where("item_id = ? AND status IN ?", itemID, []models.EscrowStatus{
+		models.EscrowStatusPending, models.EscrowStatusHeld, models.EscrowStatusReleasing, models.EscrowStatusRefunding,
+	}).Count(&count).Error
+	return count > 0, err
+}
+
+// HeldForItem retrieves the reward held for an item, if any
+func (r *EscrowRepository) HeldForItem(itemID uuid.UUID) (*models.RewardEscrow, error) {
+	var escrow models.RewardEscrow
+	err := r.db.Where("item_id = ? AND status = ?", itemID, models.EscrowStatusHeld).Order("created_at").First(&escrow).Error
+	return &escrow, err
+}
+
+// List retrieves escrows, newest first, optionally with one status
+func (r *EscrowRepository) List(status string, page, limit int) ([]models.RewardEscrow, int64, error) {
+	var escrows []models.RewardEscrow
+	var count int64
+
+	query := r.db.Model(&models.RewardEscrow{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&escrows).Error
+	return escrows, count, err
+}
+
+// ListStale retrieves escrows that have had a status since before cutoff
+func (r *EscrowRepository) ListStale(status models.EscrowStatus, cutoff time.Time, limit int) ([]models.RewardEscrow, error) {
+	var escrows []models.RewardEscrow
+	err := r.db.Where("status = ? AND updated_at <= ?", status, cutoff).Order("updated_at").Limit(limit).Find(&escrows).Error
+	return escrows, err
+}
+
+// ListExpired retrieves held rewards past their expiry that are not yet being paid out
+func (r *EscrowRepository) ListExpired(now time.Time, limit int) ([]models.RewardEscrow, error) {
+	var escrows []models.RewardEscrow
+	err := r.db.Where("status = ? AND disbursement = '' AND expires_at <= ?", models.EscrowStatusHeld, now).
+		Order("expires_at").Limit(limit).Find(&escrows).Error
+	return escrows, err
+}
+
+// ListAwaitingPayout retrieves held rewards due to be paid out that have not
+// used up their attempts
+func (r *EscrowRepository) ListAwaitingPayout(maxAttempts, limit int) ([]models.RewardEscrow, error) {
+	var escrows []models.RewardEscrow
+	err := r.db.Where("status = ? AND disbursement <> '' AND payout_attempts < ?", models.EscrowStatusHeld, maxAttempts).
+		Order("updated_at").Limit(limit).Find(&escrows).Error
+	return escrows, err
+}
+
+// SetCheckout stores the provider's reference for a collection
+func (r *EscrowRepository) SetCheckout(escrow *models.RewardEscrow) error {
+	return r.db.Model(escrow).Update("checkout_reference", escrow.CheckoutReference).Error
+}
+
+// Transition moves an escrow on from a status and records a ledger entry
+// with it, in one transaction. It reports false without changing anything
+// when the escrow is no longer in that status or the ledger already has an
+// entry of the same kind, so repeated callbacks are harmless.
+func (r *EscrowRepository) Transition(escrow *models.RewardEscrow, from models.EscrowStatus, entry *models.LedgerEntry) (bool, error) {
+	applied := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(escrow).Where("status = ?", from).Select(escrowFields).Updates(escrow)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if entry != nil {
+			var exists int64
+			if err := tx.Model(&models.LedgerEntry{}).Where("escrow_id = ? AND kind = ?", entry.EscrowID, entry.Kind).Count(&exists).Error; err != nil {
+				return err
+			}
+			if exists > 0 {
+				return errDuplicateEntry
+			}
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+		}
+		applied = true
+		return nil
+	})
+	if errors.Is(err, errDuplicateEntry) {
+		return false, nil
+	}
+	return applied, err
+}
+
+// Reconcile adds up the ledger and lists escrows whose entries do not match
+// their status
+func (r *EscrowRepository) Reconcile() (*EscrowReconciliation, error) {
+	holding := []models.EscrowStatus{models.EscrowStatusHeld, models.EscrowStatusReleasing, models.EscrowStatusRefunding}
+	report := &EscrowReconciliation{Mismatches: []EscrowMismatch{}}
+
+	err := r.db.Model(&models.RewardEscrow{}).Where("status IN ?", holding).
+		Select("COALESCE(SUM(amount_cents), 0)").Scan(&report.HeldCents).Error
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.Model(&models.LedgerEntry{}).Select("COALESCE(SUM(amount_cents), 0)").Scan(&report.LedgerCents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.db.Table("reward_escrows AS e").
+		Select(`e.id AS escrow_id, e.status,
+			CASE WHEN e.status IN ? THEN e.amount_cents ELSE 0 END AS expected_cents,
+			COALESCE(SUM(l.amount_cents), 0) AS ledger_cents`, holding).
+		Joins("LEFT JOIN ledger_entries l ON l.escrow_id = e.id AND l.deleted_at IS NULL").
+		Where("e.deleted_at IS NULL").
+		Group("e.id, e.status, e.amount_cents").
+		Having("COALESCE(SUM(l.amount_cents), 0) <> CASE WHEN e.status IN ? THEN e.amount_cents ELSE 0 END", holding).
+		Limit(100).
+		Scan(&report.Mismatches).Error
+	return report, err
+}
+
+// errDuplicateEntry rolls back a transition whose ledger entry already exists
+var errDuplicateEntry = errors.New("ledger entry already recorded")
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// ExportRepository handles database operations for background exports
+type ExportRepository struct {
+	db *gorm.DB
+}
+
+// NewExportRepository creates a new ExportRepository
+func NewExportRepository(db *gorm.DB) *ExportRepository {
+	return &ExportRepository{db: db}
+}
+
+// Create queues an export job
+func (r *ExportRepository) Create(job *models.ExportJob) error {
+	return r.db.Create(job).Error
+}
+
+// GetByID retrieves an export job
+func (r *ExportRepository) GetByID(id uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	err := r.db.First(&job, "id = ?", id).Error
+	return &job, err
+}
+
+// ListForUser retrieves the exports a user requested, newest first
+func (r *ExportRepository) ListForUser(userID uuid.UUID, page, limit int) ([]models.ExportJob, int64, error) {
+	var jobs []models.ExportJob
+	var count int64
+
+	query := r.db.Model(&models.ExportJob{}).Where("requested_by_id = ?", userID)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+	return jobs, count, err
+}
+
+// Lease claims the oldest export waiting to run, or one whose worker stopped
+// before finishing, and marks it running until the lease expires
+func (r *ExportRepository) Lease(now time.Time, lease time.Duration) (*models.ExportJob, error) {
+	var jobs []models.ExportJob
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND leased_until <= ?)", models.ExportStatusQueued, models.ExportStatusRunning, now).
+			Order("created_at").
+			Limit(1).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		job := &jobs[0]
+		until := now.Add(lease)
+		job.Status, job.LeasedUntil = models.ExportStatusRunning, &until
+		job.Attempts++
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		return tx.Model(job).Select("status", "leased_until", "attempts", "started_at").Updates(job).Error
+	})
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// Finish stores the outcome of an export
+func (r *ExportRepository) Finish(job *models.ExportJob) error {
+	job.LeasedUntil = nil
+	return r.db.Model(job).Select(
+		"status", "row_count", "file_name", "object_name", "error", "leased_until", "finished_at", "expires_at",
+	).Updates(job).Error
+}
+
+// ListExpired retrieves completed exports whose download window has passed
+func (r *ExportRepository) ListExpired(now time.Time, limit int) ([]models.ExportJob, error) {
+	var jobs []models.ExportJob
+	err := r.db.Where("status = ? AND expires_at <= ?", models.ExportStatusCompleted, now).
+		Order("expires_at").Limit(limit).Find(&jobs).Error
+	return jobs, err
+}
+
+// MarkExpired records that an export's file has been deleted
+func (r *ExportRepository) MarkExpired(job *models.ExportJob) error {
+	job.Status, job.ObjectName = models.ExportStatusExpired, ""
+	return r.db.Model(job).Select("status", "object_name").Updates(job).Error
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// HandoverRepository handles database operations for handover codes
+type HandoverRepository struct {
+	db *gorm.DB
+}
+
+// NewHandoverRepository creates a new HandoverRepository
+func NewHandoverRepository(db *gorm.DB) *HandoverRepository {
+	return &HandoverRepository{db: db}
+}
+
+// Issue stores a new handover code for a claim, revoking any earlier code that
+// is still unused so only one can be redeemed
+func (r *HandoverRepository) Issue(code *models.HandoverCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.HandoverCode{}).
+			Where("claim_id = ? AND redeemed_at IS NULL AND revoked_at IS NULL", code.ClaimID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(code).Error
+	})
+}
+
+// LatestForClaim retrieves the most recently issued code for a claim
+func (r *HandoverRepository) LatestForClaim(claimID uuid.UUID) (*models.HandoverCode, error) {
+	var code models.HandoverCode
+	err := r.db.Where("claim_id = ?", claimID).Order("created_at DESC").First(&code).Error
+	return &code, err
+}
+
+// ActiveForItem retrieves the code that can currently be redeemed for an item
+func (r *HandoverRepository) ActiveForItem(itemID uuid.UUID, now time.Time) (*models.HandoverCode, error) {
+	var code models.HandoverCode
+	err := r.db.Where("item_id = ? AND redeemed_at IS NULL AND revoked_at IS NULL AND expires_at > ?", itemID, now).
+		Order("created_at DESC").First(&code).Error
+	return &code, err
+}
+
+// ListForItem retrieves every code issued for an item, newest first
+func (r *HandoverRepository) ListForItem(itemID uuid.UUID) ([]models.HandoverCode, error) {
+	var codes []models.HandoverCode
+	err := r.db.Where("item_id = ?", itemID).Order("created_at DESC").Find(&codes).Error
+	return codes, err
+}
+
+// Redeem marks a code as used. It reports false when the code was redeemed or
+// revoked in the meantime, so a code can only be used once.
+func (r *HandoverRepository) Redeem(code *models.HandoverCode) (bool, error) {
+	result := r.db.Model(&models.HandoverCode{}).
+		Where("id = ? AND redeemed_at IS NULL AND revoked_at IS NULL", code.ID).
+		Updates(map[string]any{
+			"redeemed_at":    code.RedeemedAt,
+			"redeemed_by_id": code.RedeemedByID,
+			"latitude":       code.Latitude,
+			"longitude":      code.Longitude,
+		})
+	return result.RowsAffected == 1, result.Error
+}
+
+// RecordFailure counts a wrong code entered against an active code, revoking
+// it once maxAttempts is reached
+func (r *HandoverRepository) RecordFailure(code *models.HandoverCode, maxAttempts int) error {
+	code.FailedAttempts++
+	updates := map[string]any{"failed_attempts": gorm.Expr("failed_attempts + 1")}
+	if code.FailedAttempts >= maxAttempts {
+		now := time.Now()
+		code.RevokedAt = &now
+		updates["revoked_at"] = now
+	}
+	return r.db.Model(&models.HandoverCode{}).Where("id = ?", code.ID).Updates(updates).Error
+}
@@ -1,6 +1,7 @@
 package repository
 
 import (
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"lostnfound-api/internal/models"
 )
@@ -34,6 +35,14 @@ func (r *ImageRepository) GetByItemID(itemID uint) ([]models.Image, error) {
 	return images, err
 }
 
+// SharedWithOtherItems reports whether an image URL is also used by an item
+// other than the given one, such as a duplicate sharing the same photo
+func (r *ImageRepository) SharedWithOtherItems(url string, itemID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Image{}).Where("url = ? AND item_id <> ?", url, itemID).Count(&count).Error
+	return count > 0, err
+}
+
 // Delete removes an image from the database
 func (r *ImageRepository) Delete(id uint) error {
 	return r.db.Delete(&models.Image{}, id).Error
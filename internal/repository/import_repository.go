@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// ImportRepository handles database operations for bulk import jobs
+type ImportRepository struct {
+	db *gorm.DB
+}
+
+// NewImportRepository creates a new ImportRepository
+func NewImportRepository(db *gorm.DB) *ImportRepository {
+	return &ImportRepository{db: db}
+}
+
+// Create queues an import job
+func (r *ImportRepository) Create(job *models.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+// GetByID retrieves an import job without its rows and images
+func (r *ImportRepository) GetByID(id uuid.UUID) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := r.db.Omit("rows", "bundle").First(&job, "id = ?", id).Error
+	return &job, err
+}
+
+// ListForOrganization retrieves an organisation's import jobs, newest first
+func (r *ImportRepository) ListForOrganization(organizationID uuid.UUID, page, limit int) ([]models.ImportJob, int64, error) {
+	var jobs []models.ImportJob
+	var count int64
+
+	query := r.db.Model(&models.ImportJob{}).Where("organization_id = ?", organizationID)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Omit("rows", "bundle", "errors", "results").Order("created_at DESC").Offset(offset).Limit(limit).Find(&jobs).Error
+	return jobs, count, err
+}
+
+// Lease claims the oldest job waiting to run, or one whose worker stopped
+// before finishing, and marks it running until the lease expires
+func (r *ImportRepository) Lease(now time.Time, lease time.Duration) (*models.ImportJob, error) {
+	var jobs []models.ImportJob
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? OR (status = ? AND leased_until <= ?)", models.ImportStatusQueued, models.ImportStatusRunning, now).
+			Order("created_at").
+			Limit(1).
+			Find(&jobs).Error
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+
+		job := &jobs[0]
+		until := now.Add(lease)
+		job.Status, job.LeasedUntil = models.ImportStatusRunning, &until
+		job.Attempts++
+		if job.StartedAt == nil {
+			job.StartedAt = &now
+		}
+		return tx.Model(job).Select("status", "leased_until", "attempts", "started_at").Updates(job).Error
+	})
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return &jobs[0], nil
+}
+
+// Finish stores the outcome of a job and drops its image bundle
+func (r *ImportRepository) Finish(job *models.ImportJob) error {
+	job.Bundle, job.LeasedUntil = nil, nil
+	return r.db.Model(job).Select(
+		"status", "total_rows", "created_count", "skipped_count", "errors", "results",
+		"error", "bundle", "leased_until", "finished_at",
+	).Updates(job).Error
+}
@@ -1,6 +1,12 @@
 package repository
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 	"lostnfound-api/internal/models"
@@ -8,40 +14,314 @@ import (
 
 // ItemRepository handles database operations for items
 type ItemRepository struct {
-	db *gorm.DB
+	db      *gorm.DB
+	postgis bool
 }
 
 // NewItemRepository creates a new ItemRepository
 func NewItemRepository(db *gorm.DB) *ItemRepository {
-	return &ItemRepository{db: db}
+	return &ItemRepository{db: db, postgis: HasPostGIS(db)}
 }
 
+// itemReferences are records an item refers to but does not own.
+// They are never written through an item, so a client can't create or
+// change a category, user or organisation by nesting it in an item.
+var itemReferences = []string{"Category", "User", "Organization"}
+
 // Create adds a new item to the database
 func (r *ItemRepository) Create(item *models.Item) error {
-	return r.db.Create(item).Error
+	return r.db.Omit(itemReferences...).Create(item).Error
+}
+
+// CreateBatch adds several items in one transaction, so either all of them
+// are saved or none are
+func (r *ItemRepository) CreateBatch(items []*models.Item) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range items {
+			if err := tx.Omit(itemReferences...).Create(item).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ExternalReferences maps the given external references of an organisation's
+// items to the items already carrying them, including deleted ones
+func (r *ItemRepository) ExternalReferences(organizationID uuid.UUID, references []string) (map[string]uuid.UUID, error) {
+	var rows []struct {
+		ID                uuid.UUID
+		ExternalReference string
+	}
+	existing := make(map[string]uuid.UUID)
+	if len(references) == 0 {
+		return existing, nil
+	}
+
+	err := r.db.Unscoped().Model(&models.Item{}).Select("id", "external_reference").
+		Where("organization_id = ? AND external_reference IN ?", organizationID, references).
+		Scan(&rows).Error
+	for _, row := range rows {
+		existing[row.ExternalReference] = row.ID
+	}
+	return existing, err
 }
 
 // GetByID retrieves an item by ID
 func (r *ItemRepository) GetByID(id uuid.UUID) (*models.Item, error) {
 	var item models.Item
-	err := r.db.Preload("Images").Preload("User").Preload("Tags").First(&item, id).Error
+	err := r.db.Preload("Images").Preload("User").Preload("Tags").Preload("Transit").Preload("Category").Preload("Organization").First(&item, id).Error
 	return &item, err
 }
 
+// ItemFilter holds the optional filters for listing items
+type ItemFilter struct {
+	Status              string
+	CategoryPath        string            // matches the category and all of its descendants
+	Attributes          map[string]string // category attribute values, matched case-insensitively
+	CountyID            string
+	SubCountyID         string
+	Operator            string
+	RouteNumber         string
+	VehicleRegistration string
+	OrganizationID      *uuid.UUID
+	// Archived is "include" to list archived items too, or "only" to list
+	// nothing else; archived items are left out otherwise
+	Archived string
+}
+
+// GetByReference retrieves an item by its reference code
+func (r *ItemRepository) GetByReference(code string) (*models.Item, error) {
+	var item models.Item
+	err := r.db.First(&item, "reference_code = ?", code).Error
+	return &item, err
+}
+
+// ReferenceExists reports whether a reference code is taken, including by deleted items
+func (r *ItemRepository) ReferenceExists(code string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&models.Item{}).Where("reference_code = ?", code).Count(&count).Error
+	return count > 0, err
+}
+
+// FoundDocumentsByName retrieves published found documents whose holder's
+// name contains name, newest first
+func (r *ItemRepository) FoundDocumentsByName(name string, limit int) ([]models.Item, error) {
+	var items []models.Item
+	err := r.db.Where("status = ? AND draft = ? AND hidden_at IS NULL AND archived_at IS NULL", models.ItemStatusFound, false).
+		Where("category_id IN (SELECT id FROM categories WHERE path LIKE ? AND deleted_at IS NULL)", "documents/%").
+		Where("attributes ->> 'name' ILIKE ?", "%"+escapeLike(name)+"%").
+		Order("created_at DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// likeEscaper escapes the characters LIKE treats specially
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes text match itself literally inside a LIKE pattern
+func escapeLike(text string) string {
+	return likeEscaper.Replace(text)
+}
+
+// applyItemFilter adds the filter's conditions to a query. Drafts and items
+// hidden by moderators are never included.
+func applyItemFilter(query *gorm.DB, filter ItemFilter) *gorm.DB {
+	query = query.Where("draft = ? AND hidden_at IS NULL", false)
+	switch filter.Archived {
+	case "include":
+	case "only":
+		query = query.Where("archived_at IS NOT NULL")
+	default:
+		query = query.Where("archived_at IS NULL")
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.CategoryPath != "" {
+		query = query.Where("category_id IN (SELECT id FROM categories WHERE (path = ? OR path LIKE ?) AND deleted_at IS NULL)",
+			filter.CategoryPath, filter.CategoryPath+"/%")
+	}
+	for key, value := range filter.Attributes {
+		// Items never match on attributes their category marks private
+		query = query.Where("LOWER(attributes ->> ?) = LOWER(?) AND NOT COALESCE(private_attributes, '[]') @> jsonb_build_array(?::text)", key, value, key)
+	}
+	if filter.CountyID != "" {
+		query = query.Where("county_id = ?", filter.CountyID)
+	}
+	if filter.SubCountyID != "" {
+		query = query.Where("sub_county_id = ?", filter.SubCountyID)
+	}
+	if filter.Operator != "" {
+		query = query.Where(transitExists("operator = ?"), filter.Operator)
+	}
+	if filter.RouteNumber != "" {
+		query = query.Where(transitExists("route_number = ?"), filter.RouteNumber)
+	}
+	if filter.VehicleRegistration != "" {
+		query = query.Where(transitExists("vehicle_registration = ?"), filter.VehicleRegistration)
+	}
+	if filter.OrganizationID != nil {
+		query = query.Where("organization_id = ?", *filter.OrganizationID)
+	}
+	return query
+}
+
+// transitExists builds a condition matching items whose transit context satisfies cond
+func transitExists(cond string) string {
+	return "EXISTS (SELECT 1 FROM transit_contexts t WHERE t.item_id = items.id AND t.deleted_at IS NULL AND t." + cond + ")"
+}
+
 // List retrieves items with filtering options
-func (r *ItemRepository) List(status string, category string, page, limit int) ([]models.Item, int64, error) {
+func (r *ItemRepository) List(filter ItemFilter, page, limit int) ([]models.Item, int64, error) {
 	var items []models.Item
 	var count int64
 
 	query := r.db.Model(&models.Item{})
 
 	// Apply filters
-	if status != "" {
-		query = query.Where("status = ?", status)
+	query = applyItemFilter(query, filter)
+
+	// Get total count
+	err := query.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * limit
+	err = query.Preload("Images").Preload("User").Preload("Tags").Preload("Transit").Preload("Organization").Offset(offset).Limit(limit).Order("created_at DESC").Find(&items).Error
+
+	return items, count, err
+}
+
+// Count counts the items matching a filter
+func (r *ItemRepository) Count(filter ItemFilter) (int64, error) {
+	var count int64
+	err := applyItemFilter(r.db.Model(&models.Item{}), filter).Count(&count).Error
+	return count, err
+}
+
+// Each calls fn with successive batches of the items matching a filter, so
+// large result sets are never held in memory at once. Returning an error
+// from fn stops the iteration.
+func (r *ItemRepository) Each(filter ItemFilter, batchSize int, fn func(items []models.Item) error) error {
+	var batch []models.Item
+	query := applyItemFilter(r.db.Model(&models.Item{}), filter).
+		Preload("Images").Preload("Tags").Preload("Category").Preload("Organization").Preload("Custody")
+	return query.FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+// BoundingBox is a latitude/longitude rectangle
+type BoundingBox struct {
+	MinLat, MinLng, MaxLat, MaxLng float64
+}
+
+// ItemDistance is an item together with its distance from a search point
+type ItemDistance struct {
+	Item       models.Item
+	DistanceKm float64
+}
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0
+
+// Nearby retrieves items within radiusKm of a point, nearest first.
+// PostGIS is used when installed; otherwise distances are computed with the
+// haversine formula in SQL after a bounding-box prefilter. When decimals is
+// positive, items are filtered and ordered by their coordinates rounded to
+// that many places, so results reveal no more than the public coordinates.
+func (r *ItemRepository) Nearby(lat, lng, radiusKm float64, decimals int, filter ItemFilter, page, limit int) ([]ItemDistance, int64, error) {
+	var distance string
+	var distanceArgs []interface{}
+	latitude, longitude := coordinateColumns(decimals)
+	query := r.db.Model(&models.Item{}).Where("latitude IS NOT NULL AND longitude IS NOT NULL")
+
+	if r.postgis {
+		point := fmt.Sprintf("geography(ST_SetSRID(ST_MakePoint(%s, %s), 4326))", longitude, latitude)
+		distance = "ST_Distance(" + point + ", geography(ST_SetSRID(ST_MakePoint(?, ?), 4326))) / 1000"
+		distanceArgs = []interface{}{lng, lat}
+		query = query.Where("ST_DWithin("+point+", geography(ST_SetSRID(ST_MakePoint(?, ?), 4326)), ?)",
+			lng, lat, radiusKm*1000)
+	} else {
+		distance = fmt.Sprintf("%f * 2 * ASIN(SQRT(POWER(SIN(RADIANS(%s - ?) / 2), 2) + COS(RADIANS(?)) * COS(RADIANS(%s)) * POWER(SIN(RADIANS(%s - ?) / 2), 2)))",
+			earthRadiusKm, latitude, latitude, longitude)
+		distanceArgs = []interface{}{lat, lat, lng}
+		// The prefilter runs on the stored columns so it can use their index;
+		// it is widened by half a rounding step to keep every rounded match
+		box := boundingBoxAround(lat, lng, radiusKm)
+		if decimals > 0 {
+			half := math.Pow(10, -float64(decimals)) / 2
+			box = BoundingBox{MinLat: box.MinLat - half, MinLng: box.MinLng - half, MaxLat: box.MaxLat + half, MaxLng: box.MaxLng + half}
+		}
+		query = query.
+			Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", box.MinLat, box.MaxLat, box.MinLng, box.MaxLng).
+			Where(distance+" <= ?", lat, lat, lng, radiusKm)
+	}
+	query = applyItemFilter(query, filter)
+
+	// Get total count
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
 	}
-	if category != "" {
-		query = query.Where("category = ?", category)
+
+	// Find the IDs in distance order, then load the full items
+	var rows []struct {
+		ID         uuid.UUID
+		DistanceKm float64
+	}
+	offset := (page - 1) * limit
+	err := query.
+		Select("id, "+distance+" AS distance_km", distanceArgs...).
+		Order("distance_km ASC").
+		Offset(offset).Limit(limit).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.ID)
+	}
+
+	var items []models.Item
+	if err := r.db.Preload("Images").Preload("User").Preload("Tags").Preload("Transit").Preload("Organization").Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[uuid.UUID]models.Item, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	results := make([]ItemDistance, 0, len(rows))
+	for _, row := range rows {
+		if item, ok := byID[row.ID]; ok {
+			results = append(results, ItemDistance{Item: item, DistanceKm: row.DistanceKm})
+		}
+	}
+
+	return results, count, nil
+}
+
+// WithinBounds retrieves items whose coordinates fall inside a bounding box.
+// When decimals is positive, the coordinates are rounded to that many places
+// before they are compared with the box.
+func (r *ItemRepository) WithinBounds(box BoundingBox, decimals int, filter ItemFilter, page, limit int) ([]models.Item, int64, error) {
+	var items []models.Item
+	var count int64
+
+	latitude, longitude := coordinateColumns(decimals)
+	query := r.db.Model(&models.Item{})
+	if r.postgis {
+		query = query.Where(fmt.Sprintf("ST_Intersects(geography(ST_SetSRID(ST_MakePoint(%s, %s), 4326)), geography(ST_MakeEnvelope(?, ?, ?, ?, 4326)))", longitude, latitude),
+			box.MinLng, box.MinLat, box.MaxLng, box.MaxLat)
+	} else {
+		query = query.Where(fmt.Sprintf("%s BETWEEN ? AND ? AND %s BETWEEN ? AND ?", latitude, longitude), box.MinLat, box.MaxLat, box.MinLng, box.MaxLng)
 	}
+	query = applyItemFilter(query, filter)
 
 	// Get total count
 	err := query.Count(&count).Error
@@ -51,29 +331,201 @@ func (r *ItemRepository) List(status string, category string, page, limit int) (
 
 	// Apply pagination
 	offset := (page - 1) * limit
-	err = query.Preload("Images").Preload("User").Offset(offset).Limit(limit).Order("created_at DESC").Find(&items).Error
+	err = query.Preload("Images").Preload("User").Preload("Tags").Preload("Transit").Preload("Organization").Offset(offset).Limit(limit).Order("created_at DESC").Find(&items).Error
 
 	return items, count, err
 }
 
-// Update updates an existing item
+// coordinateColumns returns the latitude and longitude columns, rounded to
+// decimals places when decimals is positive
+func coordinateColumns(decimals int) (string, string) {
+	if decimals <= 0 {
+		return "latitude", "longitude"
+	}
+	return fmt.Sprintf("ROUND(latitude::numeric, %d)::float8", decimals), fmt.Sprintf("ROUND(longitude::numeric, %d)::float8", decimals)
+}
+
+// boundingBoxAround returns a box that contains the circle of radiusKm around a point
+func boundingBoxAround(lat, lng, radiusKm float64) BoundingBox {
+	dLat := radiusKm / earthRadiusKm * 180 / math.Pi
+	dLng := dLat / math.Max(math.Cos(lat*math.Pi/180), 0.01)
+	return BoundingBox{MinLat: lat - dLat, MinLng: lng - dLng, MaxLat: lat + dLat, MaxLng: lng + dLng}
+}
+
+// Update updates an existing item. A nil transit context leaves the stored one
+// unchanged; tags, the organisation and custody are managed separately and
+// are never changed here.
 func (r *ItemRepository) Update(item *models.Item) error {
-	return r.db.Save(item).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit(append([]string{"Transit", "Tags", "Custody"}, itemReferences...)...).Save(item).Error; err != nil {
+			return err
+		}
+		if item.Transit == nil {
+			return nil
+		}
+
+		var existing models.TransitContext
+		err := tx.Where("item_id = ?", item.ID).First(&existing).Error
+		switch {
+		case err == nil:
+			item.Transit.ID = existing.ID
+			item.Transit.CreatedAt = existing.CreatedAt
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		item.Transit.ItemID = item.ID
+		return tx.Save(item.Transit).Error
+	})
+}
+
+// SetReward records the reward held in escrow for an item
+func (r *ItemRepository) SetReward(id uuid.UUID, cents int64, escrowed bool) error {
+	return r.db.Model(&models.Item{}).Where("id = ?", id).
+		UpdateColumns(map[string]any{"reward_cents": cents, "reward_escrowed": escrowed}).Error
 }
 
-// Delete removes an item from the database
+// Delete soft deletes an item, keeping it restorable until it is purged
 func (r *ItemRepository) Delete(id uuid.UUID) error {
 	return r.db.Delete(&models.Item{}, id).Error
 }
 
-// SearchByKeyword searches items by keyword
-func (r *ItemRepository) SearchByKeyword(keyword string, page, limit int) ([]models.Item, int64, error) {
+// GetDeletedByID retrieves a soft deleted item by ID
+func (r *ItemRepository) GetDeletedByID(id uuid.UUID) (*models.Item, error) {
+	var item models.Item
+	err := r.db.Unscoped().
+		Preload("Images").Preload("User").Preload("Tags").
+		Where("deleted_at IS NOT NULL").
+		First(&item, id).Error
+	return &item, err
+}
+
+// ListDeleted retrieves soft deleted items, most recently deleted first
+func (r *ItemRepository) ListDeleted(page, limit int) ([]models.Item, int64, error) {
 	var items []models.Item
 	var count int64
 
+	query := r.db.Unscoped().Model(&models.Item{}).Where("deleted_at IS NOT NULL")
+
+	// Get total count
+	err := query.Count(&count).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * limit
+	err = query.Preload("Images").Preload("User").Offset(offset).Limit(limit).Order("deleted_at DESC").Find(&items).Error
+
+	return items, count, err
+}
+
+// ListDeletedBefore retrieves items that were soft deleted before the cutoff,
+// leaving out those with a reward still in escrow
+func (r *ItemRepository) ListDeletedBefore(cutoff time.Time, limit int) ([]models.Item, error) {
+	var items []models.Item
+	err := r.db.Unscoped().
+		Preload("Images").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Where("id NOT IN (?)", r.db.Model(&models.RewardEscrow{}).Select("item_id").Where("status IN ?", activeEscrowStatuses)).
+		Order("deleted_at ASC").
+		Limit(limit).
+		Find(&items).Error
+	return items, err
+}
+
+// Restore clears the deletion mark on a soft deleted item
+func (r *ItemRepository) Restore(id uuid.UUID) error {
+	return r.db.Unscoped().Model(&models.Item{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil).Error
+}
+
+// activeEscrowStatuses are the reward states that still have money moving
+var activeEscrowStatuses = []models.EscrowStatus{
+	models.EscrowStatusPending, models.EscrowStatusHeld, models.EscrowStatusReleasing, models.EscrowStatusRefunding,
+}
+
+// ErrPurgeEscrowActive is returned when purging an item whose reward is still
+// being paid in, held or paid out
+var ErrPurgeEscrowActive = errors.New("item has a reward in escrow")
+
+// Purge permanently removes an item together with everything recorded about
+// it: images, tags, claims, conversations, custody and handover records,
+// settled rewards and their ledger, abuse and risk reports, finder reports
+// and asset alerts. Moderation actions are kept as the audit log. Items with
+// a reward still in escrow are left alone.
+func (r *ItemRepository) Purge(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := tx.Model(&models.RewardEscrow{}).Where("item_id = ? AND status IN ?", id, activeEscrowStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrPurgeEscrowActive
+		}
+
+		claimIDs := tx.Unscoped().Model(&models.Claim{}).Select("id").Where("item_id = ?", id)
+		if err := tx.Unscoped().Where("claim_id IN (?)", claimIDs).Delete(&models.ClaimImage{}).Error; err != nil {
+			return err
+		}
+		conversationIDs := tx.Unscoped().Model(&models.Conversation{}).Select("id").Where("item_id = ?", id)
+		if err := tx.Unscoped().Where("conversation_id IN (?)", conversationIDs).Delete(&models.MessageReport{}).Error; err != nil {
+			return err
+		}
+		messageIDs := tx.Unscoped().Model(&models.Message{}).Select("id").Where("conversation_id IN (?)", conversationIDs)
+		if err := tx.Unscoped().Where("subject_type = ? AND subject_id IN (?)", models.ReportSubjectMessage, messageIDs).
+			Delete(&models.Report{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("conversation_id IN (?)", conversationIDs).Delete(&models.Message{}).Error; err != nil {
+			return err
+		}
+		escrowIDs := tx.Unscoped().Model(&models.RewardEscrow{}).Select("id").Where("item_id = ?", id)
+		if err := tx.Unscoped().Where("escrow_id IN (?)", escrowIDs).Delete(&models.LedgerEntry{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("subject_type = ? AND subject_id = ?", models.ReportSubjectItem, id).
+			Delete(&models.Report{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("(subject_type = ? AND subject_id = ?) OR (subject_type = ? AND subject_id IN (?))",
+			models.RiskSubjectItem, id, models.RiskSubjectClaim, claimIDs).Delete(&models.RiskReview{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM item_tags WHERE item_id = ?", id).Error; err != nil {
+			return err
+		}
+
+		// Tables that reference the item directly, children before the
+		// records they point at
+		for _, model := range []any{
+			&models.HandoverCode{}, &models.Conversation{}, &models.Claim{}, &models.Image{},
+			&models.TransitContext{}, &models.SavedSearchHit{}, &models.ItemCustody{},
+			&models.CustodyTransfer{}, &models.RewardEscrow{}, &models.RecoveryReport{}, &models.AssetAlert{},
+		} {
+			if err := tx.Unscoped().Where("item_id = ?", id).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Unscoped().Delete(&models.Item{}, id).Error
+	})
+}
+
+// SearchByKeyword searches items by keyword across the title, description
+// and transit details such as operator and stages
+func (r *ItemRepository) SearchByKeyword(keyword string, filter ItemFilter, page, limit int) ([]models.Item, int64, error) {
+	var items []models.Item
+	var count int64
+
+	pattern := "%" + keyword + "%"
 	query := r.db.Model(&models.Item{}).
-		Where("title ILIKE ?", "%"+keyword+"%").
-		Or("description ILIKE ?", "%"+keyword+"%")
+		Where(r.db.Where("title ILIKE ?", pattern).
+			Or("description ILIKE ?", pattern).
+			Or(transitExists("(operator ILIKE ? OR route_number ILIKE ? OR boarding_stage ILIKE ? OR alighting_stage ILIKE ?)"),
+				pattern, pattern, pattern, pattern))
+	query = applyItemFilter(query, filter)
 
 	// Get total count
 	err := query.Count(&count).Error
@@ -83,7 +535,218 @@ func (r *ItemRepository) SearchByKeyword(keyword string, page, limit int) ([]mod
 
 	// Apply pagination
 	offset := (page - 1) * limit
-	err = query.Preload("Images").Preload("User").Offset(offset).Limit(limit).Order("created_at DESC").Find(&items).Error
+	err = query.Preload("Images").Preload("User").Preload("Tags").Preload("Transit").Preload("Organization").Offset(offset).Limit(limit).Order("created_at DESC").Find(&items).Error
 
 	return items, count, err
 }
+
+// MatchCandidates retrieves items of the opposite status to item that were
+// reported within window of its date and share its category, county or
+// transit route
+func (r *ItemRepository) MatchCandidates(item *models.Item, window time.Duration, limit int) ([]models.Item, error) {
+	var items []models.Item
+
+	opposite := models.ItemStatusFound
+	if item.Status == models.ItemStatusFound {
+		opposite = models.ItemStatusLost
+	}
+
+	related := r.db.Where("1 = 0")
+	hasRelated := item.CategoryID != nil || item.CountyID != "" || item.Transit != nil
+	if item.CategoryID != nil {
+		related = related.Or("category_id = ?", *item.CategoryID)
+	}
+	if item.CountyID != "" {
+		related = related.Or("county_id = ?", item.CountyID)
+	}
+	if t := item.Transit; t != nil {
+		if t.RouteNumber != "" {
+			related = related.Or(transitExists("route_number = ?"), t.RouteNumber)
+		}
+		if t.Operator != "" {
+			related = related.Or(transitExists("operator = ?"), t.Operator)
+		}
+		if t.VehicleRegistration != "" {
+			related = related.Or(transitExists("vehicle_registration = ?"), t.VehicleRegistration)
+		}
+	}
+
+	query := r.db.Preload("Images").Preload("Transit").Preload("Category").
+		Where("id <> ? AND status = ? AND is_resolved = ? AND draft = ? AND hidden_at IS NULL AND archived_at IS NULL", item.ID, opposite, false, false).
+		Where("date BETWEEN ? AND ?", item.Date.Add(-window), item.Date.Add(window))
+	if hasRelated {
+		query = query.Where(related)
+	}
+
+	err := query.Order("created_at DESC").Limit(limit).Find(&items).Error
+
+	return items, err
+}
+
+// DuplicateCandidates retrieves listed items of the same status as item that
+// may be the same report: those dated between from and to that share its
+// reporter, category or county, and those showing one of its photos
+func (r *ItemRepository) DuplicateCandidates(item *models.Item, imageURLs, imageHashes []string, from, to time.Time, limit int) ([]models.Item, error) {
+	nearby := r.db.Where("user_id = ?", item.UserID)
+	if item.CategoryID != nil {
+		nearby = nearby.Or("category_id = ?", *item.CategoryID)
+	}
+	if item.CountyID != "" {
+		nearby = nearby.Or("county_id = ?", item.CountyID)
+	}
+	related := r.db.Where(r.db.Where("date BETWEEN ? AND ?", from, to).Where(nearby))
+	if len(imageURLs) > 0 {
+		related = related.Or("id IN (?)", r.db.Model(&models.Image{}).Select("item_id").Where("url IN ?", imageURLs))
+	}
+	if len(imageHashes) > 0 {
+		related = related.Or("id IN (?)", r.db.Model(&models.Image{}).Select("item_id").Where("hash IN ?", imageHashes))
+	}
+
+	var items []models.Item
+	err := r.db.Preload("Images").Preload("Category").
+		Where("id <> ? AND status = ? AND is_resolved = ? AND draft = ? AND hidden_at IS NULL AND archived_at IS NULL", item.ID, item.Status, false, false).
+		Where(related).
+		Order("created_at DESC").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// ImageHashes retrieves the known file hashes of the images stored under URLs
+func (r *ItemRepository) ImageHashes(urls []string) ([]string, error) {
+	var hashes []string
+	if len(urls) == 0 {
+		return hashes, nil
+	}
+	err := r.db.Model(&models.Image{}).Distinct("hash").Where("url IN ? AND hash <> ''", urls).Pluck("hash", &hashes).Error
+	return hashes, err
+}
+
+// ErrMergeEscrowActive is returned when merging away an item whose reward is
+// still being paid in, held or paid out
+var ErrMergeEscrowActive = errors.New("a duplicate has a reward in escrow")
+
+// Merge moves the images, tags, claims and conversations of duplicate items
+// to the surviving item and moves the duplicates to the trash, marked as
+// merged, recording the audit actions in the same transaction. A claimer
+// left with several pending claims keeps the oldest; the rest are withdrawn,
+// as are pending claims by the survivor's reporter. Conversations only move
+// when the same person reported both items and the survivor has none with
+// that participant yet. Duplicates with a reward in escrow are left alone.
+func (r *ItemRepository) Merge(survivorID uuid.UUID, duplicateIDs []uuid.UUID, actions []models.ModerationAction, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var active int64
+		if err := tx.Model(&models.RewardEscrow{}).Where("item_id IN ? AND status IN ?", duplicateIDs, activeEscrowStatuses).
+			Count(&active).Error; err != nil {
+			return err
+		}
+		if active > 0 {
+			return ErrMergeEscrowActive
+		}
+
+		if err := tx.Model(&models.Image{}).Where("item_id IN ?", duplicateIDs).Update("item_id", survivorID).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("INSERT INTO item_tags (item_id, tag_id) SELECT ?, tag_id FROM item_tags WHERE item_id IN ? ON CONFLICT DO NOTHING",
+			survivorID, duplicateIDs).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec("DELETE FROM item_tags WHERE item_id IN ?", duplicateIDs).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Claim{}).Where("item_id IN ?", duplicateIDs).Update("item_id", survivorID).Error; err != nil {
+			return err
+		}
+		err := tx.Exec(`UPDATE claims SET status = ?, decided_at = ?, updated_at = ?
+			WHERE item_id = ? AND status = ? AND deleted_at IS NULL AND (
+				claimer_id = (SELECT user_id FROM items WHERE id = ?) OR id NOT IN (
+					SELECT DISTINCT ON (claimer_id) id FROM claims
+					WHERE item_id = ? AND status = ? AND deleted_at IS NULL
+					ORDER BY claimer_id, created_at))`,
+			models.ClaimStatusWithdrawn, at, at, survivorID, models.ClaimStatusPending,
+			survivorID, survivorID, models.ClaimStatusPending).Error
+		if err != nil {
+			return err
+		}
+
+		for _, id := range duplicateIDs {
+			err := tx.Exec(`UPDATE conversations SET item_id = ?
+				WHERE item_id = ? AND owner_id = (SELECT user_id FROM items WHERE id = ?)
+				AND participant_id NOT IN (SELECT participant_id FROM conversations WHERE item_id = ?)`,
+				survivorID, id, survivorID, survivorID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&models.Item{}).Where("id IN ?", duplicateIDs).
+			Updates(map[string]any{"merged_into_id": survivorID, "deleted_at": at, "updated_at": at}).Error
+		if err != nil || len(actions) == 0 {
+			return err
+		}
+		return tx.Create(&actions).Error
+	})
+}
+
+// expiring restricts a query to listed, unresolved lost and found items
+// reported by members of the public, whose reporters are asked to confirm them
+func expiring(query *gorm.DB) *gorm.DB {
+	return query.Where("status IN ? AND is_resolved = ? AND draft = ? AND hidden_at IS NULL AND archived_at IS NULL AND organization_id IS NULL",
+		[]models.ItemStatus{models.ItemStatusLost, models.ItemStatusFound}, false, false)
+}
+
+// DueForReminder retrieves items not confirmed since before whose reporter
+// has not been reminded since, oldest first
+func (r *ItemRepository) DueForReminder(before time.Time, limit int) ([]models.Item, error) {
+	var items []models.Item
+	err := expiring(r.db).
+		Where("COALESCE(confirmed_at, created_at) < ?", before).
+		Where("reminded_at IS NULL OR reminded_at < COALESCE(confirmed_at, created_at)").
+		Order("created_at").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// MarkReminded records that the reporters of items were asked to confirm them
+func (r *ItemRepository) MarkReminded(ids []uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.Item{}).Where("id IN ?", ids).
+		Updates(map[string]any{"reminded_at": at, "updated_at": at}).Error
+}
+
+// DueForArchive retrieves items not confirmed since before whose reporter was
+// reminded before remindedBefore and has not confirmed them since, oldest
+// first. With others unset only items in categoryIDs are considered;
+// otherwise only items outside them, including those with no category.
+func (r *ItemRepository) DueForArchive(categoryIDs []uuid.UUID, others bool, before, remindedBefore time.Time, limit int) ([]models.Item, error) {
+	query := expiring(r.db).
+		Where("COALESCE(confirmed_at, created_at) < ?", before).
+		Where("reminded_at < ? AND reminded_at >= COALESCE(confirmed_at, created_at)", remindedBefore)
+	switch {
+	case !others:
+		query = query.Where("category_id IN ?", categoryIDs)
+	case len(categoryIDs) > 0:
+		query = query.Where("category_id IS NULL OR category_id NOT IN ?", categoryIDs)
+	}
+
+	var items []models.Item
+	err := query.Order("created_at").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// Archive takes items out of listings, skipping those already archived, and
+// returns how many were archived
+func (r *ItemRepository) Archive(ids []uuid.UUID, at time.Time) (int64, error) {
+	result := r.db.Model(&models.Item{}).Where("id IN ? AND archived_at IS NULL", ids).
+		Updates(map[string]any{"archived_at": at, "updated_at": at})
+	return result.RowsAffected, result.Error
+}
+
+// Confirm records that an item's reporter confirmed it at the given time.
+// A confirmed item is listed again if it was archived; a resolved one is
+// marked resolved and archived.
+func (r *ItemRepository) Confirm(id uuid.UUID, at time.Time, resolved bool) error {
+	updates := map[string]any{"confirmed_at": at, "archived_at": nil, "updated_at": at}
+	if resolved {
+		updates["is_resolved"] = true
+		updates["archived_at"] = at
+	}
+	return r.db.Model(&models.Item{}).Where("id = ?", id).Updates(updates).Error
+}
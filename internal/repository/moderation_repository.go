@@ -0,0 +1,284 @@
+package repository
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+)
+
+// ErrAppealDecided is returned when an appeal was decided by someone else first
+var ErrAppealDecided = errors.New("appeal has already been decided")
+
+// ErrReportsClosed is returned when a report was closed by someone else first
+var ErrReportsClosed = errors.New("report has already been closed")
+
+// ReportFilter narrows the moderation queue
+type ReportFilter struct {
+	Status         string
+	SubjectType    string
+	SubjectID      *uuid.UUID
+	Reason         string
+	ReportedUserID *uuid.UUID
+}
+
+// ModerationActionFilter narrows the moderation audit log
+type ModerationActionFilter struct {
+	Action       string
+	SubjectType  string
+	SubjectID    *uuid.UUID
+	ModeratorID  *uuid.UUID
+	TargetUserID *uuid.UUID
+}
+
+// ModerationRepository handles database operations for reports, moderation
+// actions and appeals, and applies actions to the items, messages and users
+// they are about
+type ModerationRepository struct {
+	db *gorm.DB
+}
+
+// NewModerationRepository creates a new ModerationRepository
+func NewModerationRepository(db *gorm.DB) *ModerationRepository {
+	return &ModerationRepository{db: db}
+}
+
+// CreateReport adds a report to the queue
+func (r *ModerationRepository) CreateReport(report *models.Report) error {
+	return r.db.Create(report).Error
+}
+
+// HasOpenReport reports whether a user already has an open report about something
+func (r *ModerationRepository) HasOpenReport(subject models.ReportSubject, subjectID, reporterID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Report{}).
+		Where("subject_type = ? AND subject_id = ? AND reporter_id = ? AND status = ?", subject, subjectID, reporterID, models.ReportStatusOpen).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// GetReports retrieves reports by ID
+func (r *ModerationRepository) GetReports(ids []uuid.UUID) ([]models.Report, error) {
+	var reports []models.Report
+	err := r.db.Where("id IN ?", ids).Order("created_at").Find(&reports).Error
+	return reports, err
+}
+
+// ListReports retrieves the moderation queue, oldest first
+func (r *ModerationRepository) ListReports(filter ReportFilter, page, limit int) ([]models.Report, int64, error) {
+	var reports []models.Report
+	var count int64
+
+	query := r.db.Model(&models.Report{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SubjectType != "" {
+		query = query.Where("subject_type = ?", filter.SubjectType)
+	}
+	if filter.SubjectID != nil {
+		query = query.Where("subject_id = ?", *filter.SubjectID)
+	}
+	if filter.Reason != "" {
+		query = query.Where("reason = ?", filter.Reason)
+	}
+	if filter.ReportedUserID != nil {
+		query = query.Where("reported_user_id = ?", *filter.ReportedUserID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at").Offset(offset).Limit(limit).Find(&reports).Error
+	return reports, count, err
+}
+
+// RecordAction stores a moderation action, closes the open reports it
+// covers with the given status and applies it to its subject, all in one
+// transaction. It fails with ErrReportsClosed when any of those reports was
+// closed in the meantime.
+func (r *ModerationRepository) RecordAction(action *models.ModerationAction, status models.ReportStatus) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(action).Error; err != nil {
+			return err
+		}
+		if len(action.ReportIDs) > 0 {
+			result := tx.Model(&models.Report{}).
+				Where("id IN ? AND status = ?", action.ReportIDs, models.ReportStatusOpen).
+				Updates(map[string]any{"status": status, "action_id": action.ID, "updated_at": action.CreatedAt})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected != int64(len(action.ReportIDs)) {
+				return ErrReportsClosed
+			}
+		}
+		return applyAction(tx, action, action.CreatedAt)
+	})
+}
+
+// Reinstate reverses an action after its appeal succeeded: the subject is
+// shown again, or the user unbanned, and the reversal is recorded. It fails
+// with ErrAppealDecided when the appeal is no longer pending.
+func (r *ModerationRepository) Reinstate(original, reinstate *models.ModerationAction, appeal *models.Appeal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := decideAppeal(tx, appeal); err != nil {
+			return err
+		}
+		if err := tx.Create(reinstate).Error; err != nil {
+			return err
+		}
+		original.ReversedAt = &reinstate.CreatedAt
+		if err := tx.Model(original).Select("reversed_at", "updated_at").Updates(original).Error; err != nil {
+			return err
+		}
+		return applyAction(tx, original, nil)
+	})
+}
+
+// Uphold records that an appeal was turned down. It fails with
+// ErrAppealDecided when the appeal is no longer pending.
+func (r *ModerationRepository) Uphold(uphold *models.ModerationAction, appeal *models.Appeal) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := decideAppeal(tx, appeal); err != nil {
+			return err
+		}
+		return tx.Create(uphold).Error
+	})
+}
+
+// decideAppeal stores an appeal's decision if it is still pending
+func decideAppeal(tx *gorm.DB, appeal *models.Appeal) error {
+	result := tx.Model(appeal).Where("status = ?", models.AppealStatusPending).
+		Select("status", "decided_by_id", "decided_at", "response", "updated_at").Updates(appeal)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAppealDecided
+	}
+	return nil
+}
+
+// applyAction hides, removes or bans what an action is about, or undoes it
+// when at is nil. Warnings and other actions change nothing.
+func applyAction(tx *gorm.DB, action *models.ModerationAction, at any) error {
+	switch {
+	case action.Action == models.ModerationBan:
+		return tx.Model(&models.User{}).Where("id = ?", action.TargetUserID).Update("banned_at", at).Error
+	case action.Action != models.ModerationHide && action.Action != models.ModerationRemove:
+		return nil
+	case action.SubjectType == models.ReportSubjectMessage:
+		return tx.Model(&models.Message{}).Where("id = ?", action.SubjectID).Update("hidden_at", at).Error
+	case action.SubjectType != models.ReportSubjectItem:
+		return nil
+	}
+
+	updates := map[string]any{"hidden_at": at}
+	if action.Action == models.ModerationRemove {
+		updates["deleted_at"] = at
+	}
+	return tx.Unscoped().Model(&models.Item{}).Where("id = ?", action.SubjectID).Updates(updates).Error
+}
+
+// GetAction retrieves a moderation action
+func (r *ModerationRepository) GetAction(id uuid.UUID) (*models.ModerationAction, error) {
+	var action models.ModerationAction
+	err := r.db.First(&action, "id = ?", id).Error
+	return &action, err
+}
+
+// ListActions retrieves the moderation audit log, newest first
+func (r *ModerationRepository) ListActions(filter ModerationActionFilter, page, limit int) ([]models.ModerationAction, int64, error) {
+	var actions []models.ModerationAction
+	var count int64
+
+	query := r.db.Model(&models.ModerationAction{})
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.SubjectType != "" {
+		query = query.Where("subject_type = ?", filter.SubjectType)
+	}
+	if filter.SubjectID != nil {
+		query = query.Where("subject_id = ?", *filter.SubjectID)
+	}
+	if filter.ModeratorID != nil {
+		query = query.Where("moderator_id = ?", *filter.ModeratorID)
+	}
+	if filter.TargetUserID != nil {
+		query = query.Where("target_user_id = ?", *filter.TargetUserID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&actions).Error
+	return actions, count, err
+}
+
+// ListActionsAgainst retrieves the hides, removals, warnings and bans a user
+// has received, newest first
+func (r *ModerationRepository) ListActionsAgainst(userID uuid.UUID, page, limit int) ([]models.ModerationAction, int64, error) {
+	var actions []models.ModerationAction
+	var count int64
+
+	query := r.db.Model(&models.ModerationAction{}).Where("target_user_id = ? AND action IN ?", userID,
+		[]models.ModerationActionType{models.ModerationHide, models.ModerationRemove, models.ModerationWarn, models.ModerationBan})
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&actions).Error
+	return actions, count, err
+}
+
+// CreateAppeal stores an appeal
+func (r *ModerationRepository) CreateAppeal(appeal *models.Appeal) error {
+	return r.db.Create(appeal).Error
+}
+
+// GetAppeal retrieves an appeal
+func (r *ModerationRepository) GetAppeal(id uuid.UUID) (*models.Appeal, error) {
+	var appeal models.Appeal
+	err := r.db.First(&appeal, "id = ?", id).Error
+	return &appeal, err
+}
+
+// AppealFor retrieves the appeal against an action
+func (r *ModerationRepository) AppealFor(actionID uuid.UUID) (*models.Appeal, error) {
+	var appeal models.Appeal
+	err := r.db.First(&appeal, "action_id = ?", actionID).Error
+	return &appeal, err
+}
+
+// ListAppeals retrieves appeals, oldest first, optionally only one user's
+func (r *ModerationRepository) ListAppeals(status string, userID *uuid.UUID, page, limit int) ([]models.Appeal, int64, error) {
+	var appeals []models.Appeal
+	var count int64
+
+	query := r.db.Model(&models.Appeal{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at").Offset(offset).Limit(limit).Find(&appeals).Error
+	return appeals, count, err
+}
+
+// IsBanned reports whether a user is banned
+func (r *ModerationRepository) IsBanned(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("id = ? AND banned_at IS NOT NULL", userID).Count(&count).Error
+	return count > 0, err
+}
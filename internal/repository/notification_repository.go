@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/notify"
+	"time"
+)
+
+// NotificationRepository handles database operations for the notification
+// outbox and preferences
+type NotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new NotificationRepository
+func NewNotificationRepository(db *gorm.DB) *NotificationRepository {
+	return &NotificationRepository{db: db}
+}
+
+// CreateBatch adds notifications to the outbox
+func (r *NotificationRepository) CreateBatch(notifications []models.Notification) error {
+	if len(notifications) == 0 {
+		return nil
+	}
+	return r.db.Create(&notifications).Error
+}
+
+// Lease claims up to limit due notifications for delivery. Leased rows are
+// pushed back by lease so concurrent dispatchers, including other instances,
+// skip them; a crashed dispatcher's rows become due again afterwards.
+func (r *NotificationRepository) Lease(now time.Time, lease time.Duration, limit int) ([]models.Notification, error) {
+	var notifications []models.Notification
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.NotificationStatusPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&notifications).Error
+		if err != nil || len(notifications) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(notifications))
+		for i := range notifications {
+			ids[i] = notifications[i].ID
+		}
+		return tx.Model(&models.Notification{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return notifications, err
+}
+
+// MarkSent records a successful delivery
+func (r *NotificationRepository) MarkSent(id uuid.UUID, attempts int, now time.Time) error {
+	return r.db.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]any{
+		"status":     models.NotificationStatusSent,
+		"attempts":   attempts,
+		"sent_at":    now,
+		"last_error": "",
+	}).Error
+}
+
+// MarkRetry records a failed delivery and when to try again
+func (r *NotificationRepository) MarkRetry(id uuid.UUID, attempts int, next time.Time, lastError string) error {
+	return r.db.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]any{
+		"attempts":        attempts,
+		"next_attempt_at": next,
+		"last_error":      lastError,
+	}).Error
+}
+
+// MarkFailed records that a notification will not be retried
+func (r *NotificationRepository) MarkFailed(id uuid.UUID, attempts int, lastError string) error {
+	return r.db.Model(&models.Notification{}).Where("id = ?", id).Updates(map[string]any{
+		"status":     models.NotificationStatusFailed,
+		"attempts":   attempts,
+		"last_error": lastError,
+	}).Error
+}
+
+// Retry puts a failed notification back in the queue
+func (r *NotificationRepository) Retry(id uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND status = ?", id, models.NotificationStatusFailed).
+		Updates(map[string]any{
+			"status":          models.NotificationStatusPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListInbox retrieves a user's in-app notifications, newest first
+func (r *NotificationRepository) ListInbox(userID uuid.UUID, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var count int64
+
+	query := r.db.Model(&models.Notification{}).Where("user_id = ? AND channel = ?", userID, notify.ChannelInApp)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	return notifications, count, err
+}
+
+// CountUnread counts a user's unread in-app notifications
+func (r *NotificationRepository) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND channel = ? AND read_at IS NULL", userID, notify.ChannelInApp).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead marks one of a user's in-app notifications as read
+func (r *NotificationRepository) MarkRead(id, userID uuid.UUID, now time.Time) (bool, error) {
+	result := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND channel = ?", id, userID, notify.ChannelInApp).
+		Where("read_at IS NULL").
+		Update("read_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	// Already read notifications still exist
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("id = ? AND user_id = ? AND channel = ?", id, userID, notify.ChannelInApp).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// MarkAllRead marks all of a user's in-app notifications as read
+func (r *NotificationRepository) MarkAllRead(userID uuid.UUID, now time.Time) error {
+	return r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND channel = ? AND read_at IS NULL", userID, notify.ChannelInApp).
+		Update("read_at", now).Error
+}
+
+// ListByStatus retrieves outbox entries by status for administrators
+func (r *NotificationRepository) ListByStatus(status models.NotificationStatus, page, limit int) ([]models.Notification, int64, error) {
+	var notifications []models.Notification
+	var count int64
+
+	query := r.db.Model(&models.Notification{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&notifications).Error
+	return notifications, count, err
+}
+
+// DeleteBefore removes delivered and failed notifications older than cutoff.
+// Unread in-app notifications are kept.
+func (r *NotificationRepository) DeleteBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("created_at < ? AND status <> ?", cutoff, models.NotificationStatusPending).
+		Where("channel <> ? OR read_at IS NOT NULL", notify.ChannelInApp).
+		Delete(&models.Notification{})
+	return result.RowsAffected, result.Error
+}
+
+// Preferences retrieves a user's notification preferences
+func (r *NotificationRepository) Preferences(userID uuid.UUID) ([]models.NotificationPreference, error) {
+	var preferences []models.NotificationPreference
+	err := r.db.Where("user_id = ?", userID).Find(&preferences).Error
+	return preferences, err
+}
+
+// SavePreferences creates or updates a user's notification preferences
+func (r *NotificationRepository) SavePreferences(preferences []models.NotificationPreference) error {
+	if len(preferences) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "event_type"}, {Name: "channel"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "updated_at"}),
+	}).Create(&preferences).Error
+}
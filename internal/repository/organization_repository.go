@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// OrganizationRepository handles database operations for organisations,
+// their members and the items they hold
+type OrganizationRepository struct {
+	db *gorm.DB
+}
+
+// NewOrganizationRepository creates a new OrganizationRepository
+func NewOrganizationRepository(db *gorm.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// OrganizationFilter holds the optional filters for listing organisations
+type OrganizationFilter struct {
+	Type         models.OrganizationType
+	CountyID     string
+	VerifiedOnly bool
+	Query        string // matched against the name and location
+}
+
+// Create adds an organisation together with its first member
+func (r *OrganizationRepository) Create(organization *models.Organization, founder *models.OrganizationMember) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(organization).Error; err != nil {
+			return err
+		}
+		founder.OrganizationID = organization.ID
+		return tx.Omit("Organization", "User").Create(founder).Error
+	})
+}
+
+// GetByID retrieves an organisation by ID
+func (r *OrganizationRepository) GetByID(id uuid.UUID) (*models.Organization, error) {
+	var organization models.Organization
+	err := r.db.First(&organization, id).Error
+	return &organization, err
+}
+
+// List retrieves organisations, verified ones first
+func (r *OrganizationRepository) List(filter OrganizationFilter, page, limit int) ([]models.Organization, int64, error) {
+	var organizations []models.Organization
+	var count int64
+
+	query := r.db.Model(&models.Organization{})
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.CountyID != "" {
+		query = query.Where("county_id = ?", filter.CountyID)
+	}
+	if filter.VerifiedOnly {
+		query = query.Where("verified = ?", true)
+	}
+	if filter.Query != "" {
+		query = query.Where("name ILIKE ? OR location ILIKE ?", "%"+filter.Query+"%", "%"+filter.Query+"%")
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("verified DESC, name").Offset(offset).Limit(limit).Find(&organizations).Error
+	return organizations, count, err
+}
+
+// Update saves changes to an organisation's profile
+func (r *OrganizationRepository) Update(organization *models.Organization) error {
+	return r.db.Save(organization).Error
+}
+
+// SetVerified grants or withdraws an organisation's verified badge
+func (r *OrganizationRepository) SetVerified(id uuid.UUID, verified bool, at *time.Time) error {
+	return r.db.Model(&models.Organization{}).Where("id = ?", id).Updates(map[string]any{
+		"verified":    verified,
+		"verified_at": at,
+	}).Error
+}
+
+// Memberships retrieves the organisations a user belongs to
+func (r *OrganizationRepository) Memberships(userID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Preload("Organization").Where("user_id = ?", userID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// GetMember retrieves a user's membership of an organisation
+func (r *OrganizationRepository) GetMember(organizationID, userID uuid.UUID) (*models.OrganizationMember, error) {
+	var member models.OrganizationMember
+	err := r.db.Where("organization_id = ? AND user_id = ?", organizationID, userID).First(&member).Error
+	return &member, err
+}
+
+// ListMembers retrieves an organisation's members with their accounts
+func (r *OrganizationRepository) ListMembers(organizationID uuid.UUID) ([]models.OrganizationMember, error) {
+	var members []models.OrganizationMember
+	err := r.db.Preload("User").Where("organization_id = ?", organizationID).Order("created_at").Find(&members).Error
+	return members, err
+}
+
+// AddMember adds a user to an organisation
+func (r *OrganizationRepository) AddMember(member *models.OrganizationMember) error {
+	return r.db.Omit("Organization", "User").Create(member).Error
+}
+
+// UpdateMemberRole changes a member's role
+func (r *OrganizationRepository) UpdateMemberRole(organizationID, userID uuid.UUID, role models.OrganizationRole) error {
+	return r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Update("role", role).Error
+}
+
+// RemoveMember removes a user from an organisation. The row is deleted
+// outright so the user can be added again later.
+func (r *OrganizationRepository) RemoveMember(organizationID, userID uuid.UUID) error {
+	return r.db.Unscoped().
+		Where("organization_id = ? AND user_id = ?", organizationID, userID).
+		Delete(&models.OrganizationMember{}).Error
+}
+
+// CountAdmins counts an organisation's admins
+func (r *OrganizationRepository) CountAdmins(organizationID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.OrganizationMember{}).
+		Where("organization_id = ? AND role = ?", organizationID, models.OrganizationRoleAdmin).
+		Count(&count).Error
+	return count, err
+}
+
+// Inventory retrieves the items an organisation posted or holds, newest
+// first, optionally by custody status
+func (r *OrganizationRepository) Inventory(organizationID uuid.UUID, status models.CustodyStatus, page, limit int) ([]models.Item, int64, error) {
+	var items []models.Item
+	var count int64
+
+	held := r.db.Model(&models.ItemCustody{}).Select("item_id").Where("organization_id = ?", organizationID)
+	query := r.db.Model(&models.Item{}).Where("organization_id = ? OR id IN (?)", organizationID, held)
+	if status != "" {
+		query = query.Where("id IN (?)", r.db.Model(&models.ItemCustody{}).Select("item_id").
+			Where("organization_id = ? AND status = ?", organizationID, status))
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Preload("Images").Preload("Category").Preload("Custody").
+		Order("created_at DESC").Offset(offset).Limit(limit).Find(&items).Error
+	return items, count, err
+}
+
+// GetCustody retrieves an item's custody record
+func (r *OrganizationRepository) GetCustody(itemID uuid.UUID) (*models.ItemCustody, error) {
+	var custody models.ItemCustody
+	err := r.db.Where("item_id = ?", itemID).First(&custody).Error
+	return &custody, err
+}
+
+// SaveCustody creates or updates an item's custody record
+func (r *OrganizationRepository) SaveCustody(custody *models.ItemCustody) error {
+	return r.db.Save(custody).Error
+}
+
+// DueForDisposal retrieves items held since before whose holders have not
+// been warned that the legal holding period is ending, with their custody
+// records, oldest first
+func (r *OrganizationRepository) DueForDisposal(before time.Time, limit int) ([]models.Item, error) {
+	held := r.db.Model(&models.ItemCustody{}).Select("item_id").
+		Where("status = ? AND disposal_warned_at IS NULL AND received_at < ?", models.CustodyStatusHeld, before)
+
+	var items []models.Item
+	err := r.db.Preload("Custody").Where("id IN (?)", held).Order("created_at").Limit(limit).Find(&items).Error
+	return items, err
+}
+
+// MarkDisposalWarned records that the holders of items were warned about
+// their disposal deadlines
+func (r *OrganizationRepository) MarkDisposalWarned(itemIDs []uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.ItemCustody{}).Where("item_id IN ?", itemIDs).
+		Updates(map[string]any{"disposal_warned_at": at, "updated_at": at}).Error
+}
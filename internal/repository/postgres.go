@@ -26,10 +26,62 @@ func SetupDatabase(cfg *config.Config) (*gorm.DB, error) {
 		&models.Tag{},
 		&models.Claim{},
 		&models.ClaimImage{},
+		&models.TransitContext{},
+		&models.Category{},
+		&models.RegisteredAsset{},
+		&models.AssetAlert{},
+		&models.RecoveryTag{},
+		&models.RecoveryReport{},
+		&models.Conversation{},
+		&models.Message{},
+		&models.UserBlock{},
+		&models.MessageReport{},
+		&models.SavedSearch{},
+		&models.SavedSearchHit{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.USSDSession{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.Organization{},
+		&models.OrganizationMember{},
+		&models.ItemCustody{},
+		&models.DropOffPoint{},
+		&models.CustodyTransfer{},
+		&models.HandoverCode{},
+		&models.ImportJob{},
+		&models.ExportJob{},
+		&models.RewardEscrow{},
+		&models.LedgerEntry{},
+		&models.RiskReview{},
+		&models.Report{},
+		&models.ModerationAction{},
+		&models.Appeal{},
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	// Identifier hashes were unique before disputed registrations were allowed
+	if err := db.Exec("DROP INDEX IF EXISTS idx_registered_assets_identifier_hash").Error; err != nil {
+		return nil, fmt.Errorf("failed to drop unique asset identifier index: %w", err)
+	}
+
+	// Use a spatial index when PostGIS is installed
+	if HasPostGIS(db) {
+		err = db.Exec(`CREATE INDEX IF NOT EXISTS idx_items_geography ON items
+			USING GIST (geography(ST_SetSRID(ST_MakePoint(longitude, latitude), 4326)))`).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to create spatial index: %w", err)
+		}
+	}
+
 	return db, nil
 }
+
+// HasPostGIS reports whether the PostGIS extension is installed in the database
+func HasPostGIS(db *gorm.DB) bool {
+	var installed bool
+	err := db.Raw("SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = 'postgis')").Scan(&installed).Error
+	return err == nil && installed
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// RecoveryTagRepository handles database operations for QR recovery tags
+type RecoveryTagRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryTagRepository creates a new RecoveryTagRepository
+func NewRecoveryTagRepository(db *gorm.DB) *RecoveryTagRepository {
+	return &RecoveryTagRepository{db: db}
+}
+
+// Create adds a new recovery tag to the database
+func (r *RecoveryTagRepository) Create(tag *models.RecoveryTag) error {
+	return r.db.Create(tag).Error
+}
+
+// GetByID retrieves a recovery tag by ID
+func (r *RecoveryTagRepository) GetByID(id uuid.UUID) (*models.RecoveryTag, error) {
+	var tag models.RecoveryTag
+	err := r.db.Preload("Asset").First(&tag, "id = ?", id).Error
+	return &tag, err
+}
+
+// GetByCode retrieves a recovery tag by the code printed on it
+func (r *RecoveryTagRepository) GetByCode(code string) (*models.RecoveryTag, error) {
+	var tag models.RecoveryTag
+	err := r.db.Preload("Asset").First(&tag, "code = ?", code).Error
+	return &tag, err
+}
+
+// ListByUser retrieves a user's recovery tags, optionally limited to the given IDs
+func (r *RecoveryTagRepository) ListByUser(userID uuid.UUID, ids []uuid.UUID) ([]models.RecoveryTag, error) {
+	var tags []models.RecoveryTag
+	query := r.db.Preload("Asset").Where("user_id = ?", userID)
+	if len(ids) > 0 {
+		query = query.Where("id IN ?", ids)
+	}
+	err := query.Order("created_at").Find(&tags).Error
+	return tags, err
+}
+
+// Update updates an existing recovery tag
+func (r *RecoveryTagRepository) Update(tag *models.RecoveryTag) error {
+	return r.db.Omit("Asset").Save(tag).Error
+}
+
+// RecordScan increments a tag's scan counter
+func (r *RecoveryTagRepository) RecordScan(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.RecoveryTag{}).Where("id = ?", id).Updates(map[string]any{
+		"scan_count":      gorm.Expr("scan_count + 1"),
+		"last_scanned_at": at,
+	}).Error
+}
+
+// CreateReport stores a finder's report
+func (r *RecoveryTagRepository) CreateReport(report *models.RecoveryReport) error {
+	return r.db.Create(report).Error
+}
+
+// GetReport retrieves a finder's report by ID
+func (r *RecoveryTagRepository) GetReport(id uuid.UUID) (*models.RecoveryReport, error) {
+	var report models.RecoveryReport
+	err := r.db.First(&report, "id = ?", id).Error
+	return &report, err
+}
+
+// ListReports retrieves the reports sent to an owner, newest first
+func (r *RecoveryTagRepository) ListReports(ownerID uuid.UUID) ([]models.RecoveryReport, error) {
+	var reports []models.RecoveryReport
+	err := r.db.Where("owner_id = ?", ownerID).Order("created_at DESC").Find(&reports).Error
+	return reports, err
+}
+
+// MarkReportRead records when the owner read a report
+func (r *RecoveryTagRepository) MarkReportRead(id uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.RecoveryReport{}).Where("id = ? AND read_at IS NULL", id).Update("read_at", at).Error
+}
+
+// CountReportsSince counts the reports made through a tag since the given time
+func (r *RecoveryTagRepository) CountReportsSince(tagID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.RecoveryReport{}).Where("tag_id = ? AND created_at >= ?", tagID, since).Count(&count).Error
+	return count, err
+}
@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// RiskReviewFilter narrows the risk review queue
+type RiskReviewFilter struct {
+	Status      string
+	SubjectType string
+	ActorID     *uuid.UUID
+	MinScore    int
+}
+
+// RiskRepository handles database operations for risk scores and reviews,
+// and the activity counts risk signals are built from
+type RiskRepository struct {
+	db *gorm.DB
+}
+
+// NewRiskRepository creates a new RiskRepository
+func NewRiskRepository(db *gorm.DB) *RiskRepository {
+	return &RiskRepository{db: db}
+}
+
+// SetItemRisk stores an item's risk score and signals
+func (r *RiskRepository) SetItemRisk(id uuid.UUID, score int, signals []models.RiskSignal) error {
+	return r.db.Model(&models.Item{Model: models.Model{ID: id}}).
+		Select("risk_score", "risk_signals").
+		UpdateColumns(&models.Item{RiskScore: score, RiskSignals: signals}).Error
+}
+
+// SetClaimRisk stores a claim's risk score and signals
+func (r *RiskRepository) SetClaimRisk(id uuid.UUID, score int, signals []models.RiskSignal) error {
+	return r.db.Model(&models.Claim{Model: models.Model{ID: id}}).
+		Select("risk_score", "risk_signals").
+		UpdateColumns(&models.Claim{RiskScore: score, RiskSignals: signals}).Error
+}
+
+// CreateReview adds a review to the queue
+func (r *RiskRepository) CreateReview(review *models.RiskReview) error {
+	return r.db.Create(review).Error
+}
+
+// GetReview retrieves a review
+func (r *RiskRepository) GetReview(id uuid.UUID) (*models.RiskReview, error) {
+	var review models.RiskReview
+	err := r.db.First(&review, "id = ?", id).Error
+	return &review, err
+}
+
+// OpenReviewFor retrieves the open review of an item or claim
+func (r *RiskRepository) OpenReviewFor(subject models.RiskSubject, subjectID uuid.UUID) (*models.RiskReview, error) {
+	var review models.RiskReview
+	err := r.db.Where("subject_type = ? AND subject_id = ? AND status = ?", subject, subjectID, models.RiskReviewOpen).
+		First(&review).Error
+	return &review, err
+}
+
+// UpdateReview saves a review's score, signals and decision
+func (r *RiskRepository) UpdateReview(review *models.RiskReview) error {
+	return r.db.Model(review).
+		Select("score", "signals", "status", "reviewed_by_id", "reviewed_at", "note", "updated_at").
+		Updates(review).Error
+}
+
+// ListReviews retrieves the review queue, highest score first
+func (r *RiskRepository) ListReviews(filter RiskReviewFilter, page, limit int) ([]models.RiskReview, int64, error) {
+	var reviews []models.RiskReview
+	var count int64
+
+	query := r.db.Model(&models.RiskReview{})
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.SubjectType != "" {
+		query = query.Where("subject_type = ?", filter.SubjectType)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.MinScore > 0 {
+		query = query.Where("score >= ?", filter.MinScore)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("score DESC, created_at").Offset(offset).Limit(limit).Find(&reviews).Error
+	return reviews, count, err
+}
+
+// IsFlagged reports whether an actor has a review since a time that is open
+// or was confirmed
+func (r *RiskRepository) IsFlagged(actorID uuid.UUID, since time.Time) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RiskReview{}).
+		Where("actor_id = ? AND created_at >= ? AND status IN ?", actorID, since,
+			[]models.RiskReviewStatus{models.RiskReviewOpen, models.RiskReviewConfirmed}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// CountClaimsSince counts the claims a user has filed since a time
+func (r *RiskRepository) CountClaimsSince(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Claim{}).Where("claimer_id = ? AND created_at >= ?", userID, since).Count(&count).Error
+	return count, err
+}
+
+// CountClaimedItemsSince counts the different items a user has claimed since a time
+func (r *RiskRepository) CountClaimedItemsSince(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Claim{}).Where("claimer_id = ? AND created_at >= ?", userID, since).
+		Distinct("item_id").Count(&count).Error
+	return count, err
+}
+
+// CountItemsSince counts the items a user has posted for themselves since a
+// time. Items finders filed through the user's recovery tags don't count.
+func (r *RiskRepository) CountItemsSince(userID uuid.UUID, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Item{}).
+		Where("user_id = ? AND organization_id IS NULL AND source <> ? AND created_at >= ?", userID, models.ItemSourceRecoveryTag, since).
+		Count(&count).Error
+	return count, err
+}
+
+// CountAccountsWithPhone counts other accounts registered with a phone number
+func (r *RiskRepository) CountAccountsWithPhone(phone string, excludeUserID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("phone = ? AND id <> ?", phone, excludeUserID).Count(&count).Error
+	return count, err
+}
+
+// CountAccountsUsingNumber counts other accounts that registered a phone
+// number or gave it as contact details on an item. digits are the last nine
+// digits of the number, so any way of writing it matches.
+func (r *RiskRepository) CountAccountsUsingNumber(digits string, excludeUserID uuid.UUID) (int64, error) {
+	pattern := "%" + digits + "%"
+	var count int64
+	err := r.db.Raw(`
+		SELECT COUNT(DISTINCT user_id) FROM (
+			SELECT id AS user_id FROM users
+			WHERE deleted_at IS NULL AND regexp_replace(phone, '\D', '', 'g') LIKE ?
+			UNION
+			SELECT user_id FROM items
+			WHERE deleted_at IS NULL AND regexp_replace(contact, '\D', '', 'g') LIKE ?
+		) AS accounts WHERE user_id <> ?`, pattern, pattern, excludeUserID).Scan(&count).Error
+	return count, err
+}
@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// SavedSearchRepository handles database operations for saved searches
+type SavedSearchRepository struct {
+	db *gorm.DB
+}
+
+// NewSavedSearchRepository creates a new SavedSearchRepository
+func NewSavedSearchRepository(db *gorm.DB) *SavedSearchRepository {
+	return &SavedSearchRepository{db: db}
+}
+
+// Create adds a new saved search to the database
+func (r *SavedSearchRepository) Create(search *models.SavedSearch) error {
+	return r.db.Create(search).Error
+}
+
+// GetByID retrieves a saved search by ID
+func (r *SavedSearchRepository) GetByID(id uuid.UUID) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	err := r.db.First(&search, "id = ?", id).Error
+	return &search, err
+}
+
+// ListByUser retrieves a user's saved searches, newest first
+func (r *SavedSearchRepository) ListByUser(userID uuid.UUID) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&searches).Error
+	return searches, err
+}
+
+// CountActiveByUser counts a user's saved searches that have not expired
+func (r *SavedSearchRepository) CountActiveByUser(userID uuid.UUID, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SavedSearch{}).Where("user_id = ? AND expires_at > ?", userID, now).Count(&count).Error
+	return count, err
+}
+
+// Update updates an existing saved search
+func (r *SavedSearchRepository) Update(search *models.SavedSearch) error {
+	return r.db.Save(search).Error
+}
+
+// Delete removes a saved search and its hits
+func (r *SavedSearchRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("search_id = ?", id).Delete(&models.SavedSearchHit{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.SavedSearch{}, "id = ?", id).Error
+	})
+}
+
+// Candidates retrieves the active saved searches whose structured filters
+// (status, county, category, date range) accept the item. Keywords are
+// checked by the caller.
+func (r *SavedSearchRepository) Candidates(item *models.Item, categoryPath string, now time.Time) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	query := r.db.Where("expires_at > ? AND user_id <> ?", now, item.UserID).
+		Where("status = '' OR status IS NULL OR status = ?", item.Status).
+		Where("county_id = '' OR county_id IS NULL OR county_id = ?", item.CountyID).
+		Where("date_from IS NULL OR date_from <= ?", item.Date).
+		Where("date_to IS NULL OR date_to >= ?", item.Date)
+
+	if categoryPath == "" {
+		query = query.Where("category_path = '' OR category_path IS NULL")
+	} else {
+		query = query.Where("category_path = '' OR category_path IS NULL OR category_path = ? OR ? LIKE category_path || '/%'",
+			categoryPath, categoryPath)
+	}
+
+	err := query.Find(&searches).Error
+	return searches, err
+}
+
+// CreateHit records that an item matched a saved search. It reports false if
+// the item had already matched the search.
+func (r *SavedSearchRepository) CreateHit(hit *models.SavedSearchHit) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(hit)
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListHits retrieves the items that matched a saved search, newest first
+func (r *SavedSearchRepository) ListHits(searchID uuid.UUID, page, limit int) ([]models.SavedSearchHit, int64, error) {
+	var hits []models.SavedSearchHit
+	var count int64
+
+	offset := (page - 1) * limit
+	query := r.db.Model(&models.SavedSearchHit{}).Where("search_id = ?", searchID)
+
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Preload("Item").Preload("Item.Images").Order("created_at DESC").
+		Offset(offset).Limit(limit).Find(&hits).Error
+	return hits, count, err
+}
+
+// PendingDigests retrieves daily searches with unsent hits that have not
+// been notified since the given time
+func (r *SavedSearchRepository) PendingDigests(notifiedBefore, now time.Time) ([]models.SavedSearch, error) {
+	var searches []models.SavedSearch
+	hits := r.db.Model(&models.SavedSearchHit{}).Select("search_id").Where("notified_at IS NULL")
+	err := r.db.Where("frequency = ? AND expires_at > ?", models.AlertFrequencyDaily, now).
+		Where("last_notified_at IS NULL OR last_notified_at <= ?", notifiedBefore).
+		Where("id IN (?)", hits).
+		Find(&searches).Error
+	return searches, err
+}
+
+// UnnotifiedHits retrieves the hits of a search that have not been sent yet
+func (r *SavedSearchRepository) UnnotifiedHits(searchID uuid.UUID) ([]models.SavedSearchHit, error) {
+	var hits []models.SavedSearchHit
+	err := r.db.Preload("Item").Where("search_id = ? AND notified_at IS NULL", searchID).
+		Order("created_at").Find(&hits).Error
+	return hits, err
+}
+
+// MarkNotified records that hits were sent and when the search last alerted
+func (r *SavedSearchRepository) MarkNotified(searchID uuid.UUID, hitIDs []uuid.UUID, at time.Time) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(hitIDs) > 0 {
+			if err := tx.Model(&models.SavedSearchHit{}).Where("id IN ?", hitIDs).Update("notified_at", at).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&models.SavedSearch{}).Where("id = ?", searchID).Update("last_notified_at", at).Error
+	})
+}
+
+// DeleteExpiredBefore removes searches that expired before the cutoff
+func (r *SavedSearchRepository) DeleteExpiredBefore(cutoff time.Time) (int64, error) {
+	expired := r.db.Model(&models.SavedSearch{}).Select("id").Where("expires_at <= ?", cutoff)
+	if err := r.db.Unscoped().Where("search_id IN (?)", expired).Delete(&models.SavedSearchHit{}).Error; err != nil {
+		return 0, err
+	}
+	result := r.db.Where("expires_at <= ?", cutoff).Delete(&models.SavedSearch{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+)
+
+// TagCount is a tag together with the number of items carrying it
+type TagCount struct {
+	Name  string `json:"name"`
+	Count int64  `json:"count"`
+}
+
+// TagRepository handles database operations for tags
+type TagRepository struct {
+	db *gorm.DB
+}
+
+// NewTagRepository creates a new TagRepository
+func NewTagRepository(db *gorm.DB) *TagRepository {
+	return &TagRepository{db: db}
+}
+
+// FindOrCreate returns the tags with the given names, creating any that don't exist
+func (r *TagRepository) FindOrCreate(names []string) ([]models.Tag, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, models.Tag{Name: name})
+	}
+	err := r.db.Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "name"}}, DoNothing: true}).Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload so tags that already existed carry their stored IDs
+	var stored []models.Tag
+	err = r.db.Where("name IN ?", names).Find(&stored).Error
+	return stored, err
+}
+
+// FindExisting returns the subset of names that are already tags
+func (r *TagRepository) FindExisting(names []string) (map[string]bool, error) {
+	existing := make(map[string]bool)
+	if len(names) == 0 {
+		return existing, nil
+	}
+
+	var found []string
+	if err := r.db.Model(&models.Tag{}).Where("name IN ?", names).Pluck("name", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, name := range found {
+		existing[name] = true
+	}
+	return existing, nil
+}
+
+// AddToItem attaches tags to an item
+func (r *TagRepository) AddToItem(itemID uuid.UUID, tags []models.Tag) error {
+	item := models.Item{Model: models.Model{ID: itemID}}
+	return r.db.Model(&item).Association("Tags").Append(tags)
+}
+
+// RemoveFromItem detaches a tag from an item
+func (r *TagRepository) RemoveFromItem(itemID uuid.UUID, name string) error {
+	return r.db.Exec(
+		"DELETE FROM item_tags WHERE item_id = ? AND tag_id IN (SELECT id FROM tags WHERE name = ?)",
+		itemID, name,
+	).Error
+}
+
+// Popular returns the most used tags on listed items: not deleted, drafts,
+// hidden by moderators or archived
+func (r *TagRepository) Popular(limit int) ([]TagCount, error) {
+	var counts []TagCount
+	err := r.db.Table("tags").
+		Select("tags.name, COUNT(*) AS count").
+		Joins("JOIN item_tags ON item_tags.tag_id = tags.id").
+		Joins("JOIN items ON items.id = item_tags.item_id AND items.deleted_at IS NULL").
+		Where("items.draft = ? AND items.hidden_at IS NULL AND items.archived_at IS NULL", false).
+		Where("tags.deleted_at IS NULL").
+		Group("tags.name").
+		Order("count DESC, tags.name ASC").
+		Limit(limit).
+		Scan(&counts).Error
+	return counts, err
+}
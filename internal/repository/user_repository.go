@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"lostnfound-api/internal/models"
+)
+
+// UserRepository handles database operations for users
+type UserRepository struct {
+	db *gorm.DB
+}
+
+// NewUserRepository creates a new UserRepository
+func NewUserRepository(db *gorm.DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// GetByID retrieves a user by ID
+func (r *UserRepository) GetByID(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	err := r.db.First(&user, "id = ?", id).Error
+	return &user, err
+}
+
+// UpdateLocale sets the language a user receives notifications in
+func (r *UserRepository) UpdateLocale(id uuid.UUID, locale string) error {
+	return r.db.Model(&models.User{}).Where("id = ?", id).Update("locale", locale).Error
+}
+
+// GetByPhone retrieves the earliest account with a phone number
+func (r *UserRepository) GetByPhone(phone string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("phone = ?", phone).Order("created_at").First(&user).Error
+	return &user, err
+}
+
+// Create adds a new user to the database
+func (r *UserRepository) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+// GetByEmail retrieves a user by email address
+func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+	var user models.User
+	err := r.db.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	return &user, err
+}
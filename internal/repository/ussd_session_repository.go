@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/ussd"
+	"time"
+)
+
+// USSDSessionRepository keeps USSD sessions in the database. It implements
+// ussd.Store for deployments without Redis.
+type USSDSessionRepository struct {
+	db *gorm.DB
+}
+
+// NewUSSDSessionRepository creates a new USSDSessionRepository
+func NewUSSDSessionRepository(db *gorm.DB) *USSDSessionRepository {
+	return &USSDSessionRepository{db: db}
+}
+
+// Get returns a session, or nil if it does not exist or has expired
+func (r *USSDSessionRepository) Get(ctx context.Context, id string) (*ussd.Session, error) {
+	var row models.USSDSession
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND updated_at > ?", id, time.Now().Add(-ussd.SessionTTL)).
+		First(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ussd.Session{
+		ID:        row.SessionID,
+		Phone:     row.Phone,
+		State:     row.State,
+		Data:      row.Data,
+		Inputs:    row.Inputs,
+		UpdatedAt: row.UpdatedAt,
+	}, nil
+}
+
+// Save creates or replaces a session
+func (r *USSDSessionRepository) Save(ctx context.Context, session *ussd.Session) error {
+	session.UpdatedAt = time.Now()
+	row := models.USSDSession{
+		SessionID: session.ID,
+		Phone:     session.Phone,
+		State:     session.State,
+		Data:      session.Data,
+		Inputs:    session.Inputs,
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "session_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"state", "data", "inputs", "updated_at"}),
+	}).Create(&row).Error
+}
+
+// Delete removes a session
+func (r *USSDSessionRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Unscoped().Where("session_id = ?", id).Delete(&models.USSDSession{}).Error
+}
+
+// DeleteExpired removes sessions idle for longer than the session TTL
+func (r *USSDSessionRepository) DeleteExpired() (int64, error) {
+	result := r.db.Unscoped().Where("updated_at < ?", time.Now().Add(-ussd.SessionTTL)).Delete(&models.USSDSession{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"lostnfound-api/internal/models"
+	"time"
+)
+
+// WebhookRepository handles database operations for webhook subscriptions
+// and their delivery log
+type WebhookRepository struct {
+	db *gorm.DB
+}
+
+// NewWebhookRepository creates a new WebhookRepository
+func NewWebhookRepository(db *gorm.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// Create adds a new subscription
+func (r *WebhookRepository) Create(subscription *models.WebhookSubscription) error {
+	return r.db.Create(subscription).Error
+}
+
+// GetByID retrieves a subscription by ID
+func (r *WebhookRepository) GetByID(id uuid.UUID) (*models.WebhookSubscription, error) {
+	var subscription models.WebhookSubscription
+	err := r.db.First(&subscription, id).Error
+	return &subscription, err
+}
+
+// List retrieves all subscriptions, newest first
+func (r *WebhookRepository) List() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.Order("created_at DESC").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// ListActive retrieves the subscriptions that receive events
+func (r *WebhookRepository) ListActive() ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	err := r.db.Where("active = ?", true).Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+// Update saves changes to a subscription
+func (r *WebhookRepository) Update(subscription *models.WebhookSubscription) error {
+	return r.db.Save(subscription).Error
+}
+
+// Delete removes a subscription and stops its pending deliveries
+func (r *WebhookRepository) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.WebhookDelivery{}).
+			Where("subscription_id = ? AND status = ?", id, models.WebhookDeliveryPending).
+			Updates(map[string]any{"status": models.WebhookDeliveryFailed, "last_error": "subscription deleted"}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Delete(&models.WebhookSubscription{}, id).Error
+	})
+}
+
+// CreateDeliveries queues deliveries
+func (r *WebhookRepository) CreateDeliveries(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Create(&deliveries).Error
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *WebhookRepository) GetDelivery(id uuid.UUID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := r.db.First(&delivery, id).Error
+	return &delivery, err
+}
+
+// LeaseDeliveries claims up to limit due deliveries. As with the notification
+// outbox, leased rows are pushed back so other dispatchers skip them.
+func (r *WebhookRepository) LeaseDeliveries(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.WebhookDeliveryPending, now).
+			Order("next_attempt_at").
+			Limit(limit).
+			Find(&deliveries).Error
+		if err != nil || len(deliveries) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, len(deliveries))
+		for i := range deliveries {
+			ids[i] = deliveries[i].ID
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN ?", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	return deliveries, err
+}
+
+// RecordAttempt stores the outcome of a delivery attempt
+func (r *WebhookRepository) RecordAttempt(delivery *models.WebhookDelivery) error {
+	return r.db.Model(delivery).Select(
+		"status", "attempts", "next_attempt_at", "response_status", "response_body",
+		"duration_ms", "last_error", "delivered_at",
+	).Updates(delivery).Error
+}
+
+// ListDeliveries retrieves a subscription's delivery log, newest first,
+// optionally by status
+func (r *WebhookRepository) ListDeliveries(subscriptionID uuid.UUID, status models.WebhookDeliveryStatus, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	var deliveries []models.WebhookDelivery
+	var count int64
+
+	query := r.db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", subscriptionID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * limit
+	err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, count, err
+}
+
+// DeleteDeliveriesBefore removes finished deliveries older than cutoff
+func (r *WebhookRepository) DeleteDeliveriesBefore(cutoff time.Time) (int64, error) {
+	result := r.db.Unscoped().
+		Where("created_at < ? AND status <> ?", cutoff, models.WebhookDeliveryPending).
+		Delete(&models.WebhookDelivery{})
+	return result.RowsAffected, result.Error
+}
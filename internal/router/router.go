@@ -12,6 +12,31 @@ func SetupRouter(
 	cfg *config.Config,
 
 	itemHandler *handler.ItemHandler,
+	trashHandler *handler.TrashHandler,
+	locationHandler *handler.LocationHandler,
+	matchHandler *handler.MatchHandler,
+	categoryHandler *handler.CategoryHandler,
+	tagHandler *handler.TagHandler,
+	assetHandler *handler.AssetHandler,
+	recoveryTagHandler *handler.RecoveryTagHandler,
+	claimHandler *handler.ClaimHandler,
+	messageHandler *handler.MessageHandler,
+	eventHandler *handler.EventHandler,
+	savedSearchHandler *handler.SavedSearchHandler,
+	notificationHandler *handler.NotificationHandler,
+	smsHandler *handler.SMSHandler,
+	ussdHandler *handler.USSDHandler,
+	webhookHandler *handler.WebhookHandler,
+	organizationHandler *handler.OrganizationHandler,
+	custodyHandler *handler.CustodyHandler,
+	handoverHandler *handler.HandoverHandler,
+	importHandler *handler.ImportHandler,
+	exportHandler *handler.ExportHandler,
+	escrowHandler *handler.EscrowHandler,
+	riskHandler *handler.RiskHandler,
+	moderationHandler *handler.ModerationHandler,
+	duplicateHandler *handler.DuplicateHandler,
+	expiryHandler *handler.ExpiryHandler,
 
 ) *gin.Engine {
 	router := gin.Default()
@@ -32,6 +57,41 @@ func SetupRouter(
 		//api.POST("/register", authHandler.Register)
 		//api.POST("/login", authHandler.Login)
 
+		// Location public routes
+		api.GET("/locations/counties", locationHandler.ListCounties)
+		api.GET("/locations/counties/:id", locationHandler.GetCounty)
+		api.GET("/locations/sub-counties/:id", locationHandler.GetSubCounty)
+		api.GET("/locations/resolve", locationHandler.Resolve)
+
+		// Category public routes
+		api.GET("/categories", categoryHandler.Tree)
+		api.GET("/categories/:id", categoryHandler.GetByID)
+
+		// Tag public routes
+		api.GET("/tags/popular", tagHandler.Popular)
+
+		// SMS and USSD gateway callbacks
+		api.POST("/sms/inbound", smsHandler.Inbound)
+		api.POST("/ussd", ussdHandler.Callback)
+
+		// Payment provider callbacks
+		api.POST("/payments/mpesa/collections", escrowHandler.CollectionCallback)
+		api.POST("/payments/mpesa/payouts", escrowHandler.PayoutCallback)
+		api.POST("/payments/mpesa/payouts/timeout", escrowHandler.PayoutTimeout)
+
+		// Organization public routes
+		api.GET("/organizations", organizationHandler.List)
+		api.GET("/organizations/:id", organizationHandler.GetByID)
+		api.GET("/drop-off-points", custodyHandler.ListDropOffPoints)
+		api.GET("/drop-off-points/:id", custodyHandler.GetDropOffPoint)
+
+		// Asset registry public routes
+		api.POST("/registry/check", assetHandler.Check)
+
+		// Recovery tag public routes, opened by finders scanning a QR sticker
+		api.GET("/recover/:code", recoveryTagHandler.Lookup)
+		api.POST("/recover/:code", recoveryTagHandler.Report)
+
 		// Item public routes
 
 		/// TODO
@@ -39,16 +99,138 @@ func SetupRouter(
 		//api.GET("/items/public/:id", itemHandler.GetPublicByID)
 		//api.GET("/items/search", itemHandler.Search)
 
-		// Protected routes
+		// Event stream routes accept the token as a query parameter because
+		// EventSource and browser WebSockets cannot set headers
+		events := api.Group("/events")
+		events.Use(middleware.QueryToken(), middleware.JWT(cfg.JWTSecret))
+		{
+			events.GET("", eventHandler.Stream)
+			events.GET("/ws", eventHandler.WebSocket)
+		}
+
+		// Appeal routes stay open to banned users
+		appeals := api.Group("/moderation")
+		appeals.Use(middleware.JWT(cfg.JWTSecret))
+		{
+			appeals.GET("/actions", moderationHandler.MyActions)
+			appeals.POST("/actions/:id/appeal", moderationHandler.Appeal)
+			appeals.GET("/appeals", moderationHandler.MyAppeals)
+		}
+
+		// Protected routes; banned users can only read
 		protected := api.Group("/")
-		protected.Use(middleware.JWT(cfg.JWTSecret))
+		protected.Use(middleware.JWT(cfg.JWTSecret), middleware.NotBanned(moderationHandler.IsBanned))
 		{
 			// Item routes
 			protected.POST("/items", itemHandler.Create)
 			protected.GET("/items", itemHandler.List)
+			protected.GET("/items/nearby", itemHandler.Nearby)
+			protected.GET("/items/bounds", itemHandler.WithinBounds)
+			protected.GET("/items/search", itemHandler.Search)
 			protected.GET("/items/:id", itemHandler.GetByID)
 			protected.PUT("/items/:id", itemHandler.Update)
 			protected.DELETE("/items/:id", itemHandler.Delete)
+			protected.POST("/items/:id/restore", trashHandler.Restore)
+			protected.POST("/items/:id/confirm", expiryHandler.Confirm)
+			protected.GET("/items/:id/matches", matchHandler.ListForItem)
+			protected.POST("/items/:id/tags", tagHandler.AddToItem)
+			protected.DELETE("/items/:id/tags/:tag", tagHandler.RemoveFromItem)
+			protected.GET("/items/:id/tags/suggestions", tagHandler.Suggestions)
+			protected.POST("/items/:id/claims", claimHandler.Create)
+			protected.GET("/items/:id/claims", claimHandler.ListForItem)
+			protected.POST("/items/:id/conversations", messageHandler.StartForItem)
+			protected.POST("/items/:id/report", moderationHandler.ReportItem)
+
+			// Claim routes
+			protected.GET("/claims", claimHandler.ListMine)
+			protected.GET("/claims/:id", claimHandler.GetByID)
+			protected.POST("/claims/:id/approve", claimHandler.Approve)
+			protected.POST("/claims/:id/reject", claimHandler.Reject)
+			protected.POST("/claims/:id/withdraw", claimHandler.Withdraw)
+			protected.POST("/claims/:id/conversation", messageHandler.StartForClaim)
+			protected.GET("/claims/:id/handover-code", handoverHandler.Pass)
+			protected.GET("/claims/:id/handover-code/qr", handoverHandler.QRCode)
+			protected.POST("/items/:id/handover", handoverHandler.Redeem)
+			protected.GET("/items/:id/handovers", handoverHandler.ListForItem)
+
+			// Reward escrow routes
+			protected.POST("/items/:id/reward", escrowHandler.Deposit)
+			protected.GET("/items/:id/reward", escrowHandler.ForItem)
+
+			// Conversation routes
+			protected.GET("/conversations", messageHandler.List)
+			protected.GET("/conversations/:id/messages", messageHandler.Messages)
+			protected.POST("/conversations/:id/messages", messageHandler.Send)
+			protected.POST("/conversations/:id/read", messageHandler.MarkRead)
+			protected.POST("/conversations/:id/share-contact", messageHandler.ShareContact)
+			protected.GET("/conversations/:id/contact", messageHandler.Contacts)
+			protected.POST("/conversations/:id/block", messageHandler.Block)
+			protected.DELETE("/conversations/:id/block", messageHandler.Unblock)
+			protected.POST("/conversations/:id/report", messageHandler.Report)
+
+			// Saved search routes
+			protected.POST("/saved-searches", savedSearchHandler.Create)
+			protected.GET("/saved-searches", savedSearchHandler.List)
+			protected.GET("/saved-searches/:id", savedSearchHandler.GetByID)
+			protected.PUT("/saved-searches/:id", savedSearchHandler.Update)
+			protected.DELETE("/saved-searches/:id", savedSearchHandler.Delete)
+			protected.GET("/saved-searches/:id/items", savedSearchHandler.Items)
+
+			// Notification routes
+			protected.GET("/notifications", notificationHandler.Inbox)
+			protected.POST("/notifications/read-all", notificationHandler.MarkAllRead)
+			protected.POST("/notifications/:id/read", notificationHandler.MarkRead)
+			protected.GET("/notifications/preferences", notificationHandler.Preferences)
+			protected.PUT("/notifications/preferences", notificationHandler.UpdatePreferences)
+
+			// Organization routes
+			protected.POST("/organizations", organizationHandler.Create)
+			protected.GET("/organizations/mine", organizationHandler.Mine)
+			protected.PUT("/organizations/:id", organizationHandler.Update)
+			protected.GET("/organizations/:id/members", organizationHandler.Members)
+			protected.POST("/organizations/:id/members", organizationHandler.AddMember)
+			protected.PUT("/organizations/:id/members/:user_id", organizationHandler.UpdateMember)
+			protected.DELETE("/organizations/:id/members/:user_id", organizationHandler.RemoveMember)
+			protected.GET("/organizations/:id/items", organizationHandler.Inventory)
+			protected.POST("/organizations/:id/items", organizationHandler.PostItem)
+			protected.PUT("/organizations/:id/items/:item_id", organizationHandler.UpdateItem)
+			protected.DELETE("/organizations/:id/items/:item_id", organizationHandler.RemoveItem)
+			protected.PUT("/organizations/:id/items/:item_id/custody", organizationHandler.UpdateCustody)
+			protected.POST("/organizations/:id/imports", importHandler.Create)
+			protected.GET("/organizations/:id/imports", importHandler.List)
+			protected.GET("/organizations/:id/imports/:import_id", importHandler.GetByID)
+			protected.GET("/organizations/:id/exports/items", exportHandler.StreamOrganization)
+			protected.POST("/organizations/:id/exports", exportHandler.QueueOrganization)
+
+			// Export job routes
+			protected.GET("/exports", exportHandler.ListMine)
+			protected.GET("/exports/:id", exportHandler.GetByID)
+			protected.GET("/exports/:id/download", exportHandler.Download)
+
+			// Drop-off point and chain of custody routes
+			protected.POST("/organizations/:id/drop-off-points", custodyHandler.CreateDropOffPoint)
+			protected.PUT("/organizations/:id/drop-off-points/:point_id", custodyHandler.UpdateDropOffPoint)
+			protected.POST("/items/:id/custody-transfers", custodyHandler.Transfer)
+			protected.GET("/items/:id/custody-transfers", custodyHandler.Transfers)
+			protected.POST("/custody-transfers/:id/confirm", custodyHandler.ConfirmTransfer)
+			protected.GET("/items/:id/collection", custodyHandler.Collection)
+
+			// Asset registry routes
+			protected.POST("/assets", assetHandler.Register)
+			protected.GET("/assets", assetHandler.List)
+			protected.GET("/assets/alerts", assetHandler.ListAlerts)
+			protected.PUT("/assets/:id/status", assetHandler.UpdateStatus)
+			protected.DELETE("/assets/:id", assetHandler.Delete)
+
+			// Recovery tag routes
+			protected.POST("/recovery-tags", recoveryTagHandler.Create)
+			protected.GET("/recovery-tags", recoveryTagHandler.List)
+			protected.GET("/recovery-tags/sheet", recoveryTagHandler.Sheet)
+			protected.GET("/recovery-tags/:id/qr", recoveryTagHandler.QRCode)
+			protected.DELETE("/recovery-tags/:id", recoveryTagHandler.Deactivate)
+			protected.GET("/recovery-reports", recoveryTagHandler.ListReports)
+			protected.POST("/recovery-reports/:id/read", recoveryTagHandler.MarkReportRead)
+			protected.POST("/recovery-reports/:id/publish", recoveryTagHandler.PublishReport)
 
 			/// TODO
 
@@ -61,9 +243,44 @@ func SetupRouter(
 			/// TODO
 
 			// Admin routes
-			//admin := protected.Group("/admin")
-			//admin.Use(middleware.AdminOnly())
+			admin := protected.Group("/admin")
+			admin.Use(middleware.AdminOnly())
 			{
+				admin.GET("/items/deleted", trashHandler.ListDeleted)
+				admin.POST("/items/:id/restore", trashHandler.AdminRestore)
+				admin.GET("/items/:id/duplicates", duplicateHandler.List)
+				admin.POST("/items/:id/merge", duplicateHandler.Merge)
+				admin.GET("/assets/disputed", assetHandler.Disputed)
+				admin.POST("/assets/:id/resolve", assetHandler.ResolveDispute)
+				admin.POST("/categories", categoryHandler.Create)
+				admin.PUT("/categories/:id", categoryHandler.Update)
+				admin.DELETE("/categories/:id", categoryHandler.Delete)
+				admin.GET("/message-reports", messageHandler.ListReports)
+				admin.GET("/notifications", notificationHandler.Outbox)
+				admin.POST("/notifications/:id/retry", notificationHandler.Retry)
+				admin.POST("/organizations/:id/verify", organizationHandler.Verify)
+				admin.POST("/webhooks", webhookHandler.Create)
+				admin.GET("/webhooks", webhookHandler.List)
+				admin.GET("/webhooks/:id", webhookHandler.GetByID)
+				admin.PUT("/webhooks/:id", webhookHandler.Update)
+				admin.DELETE("/webhooks/:id", webhookHandler.Delete)
+				admin.POST("/webhooks/:id/rotate-secret", webhookHandler.RotateSecret)
+				admin.GET("/webhooks/:id/deliveries", webhookHandler.Deliveries)
+				admin.POST("/webhook-deliveries/:id/redeliver", webhookHandler.Redeliver)
+				admin.GET("/exports/items", exportHandler.StreamAll)
+				admin.POST("/exports", exportHandler.QueueAll)
+				admin.GET("/escrows", escrowHandler.List)
+				admin.GET("/escrows/reconciliation", escrowHandler.Reconciliation)
+				admin.GET("/escrows/:id", escrowHandler.GetByID)
+				admin.POST("/escrows/:id/retry-payout", escrowHandler.RetryPayout)
+				admin.GET("/risk-reviews", riskHandler.List)
+				admin.POST("/risk-reviews/:id/decision", riskHandler.Decide)
+				admin.GET("/moderation/reports", moderationHandler.Queue)
+				admin.POST("/moderation/actions", moderationHandler.Act)
+				admin.GET("/moderation/actions", moderationHandler.AuditLog)
+				admin.GET("/moderation/appeals", moderationHandler.Appeals)
+				admin.POST("/moderation/appeals/:id/decision", moderationHandler.DecideAppeal)
+
 				//admin.GET("/users", userHandler.ListUsers)
 				//admin.PUT("/users/:id", userHandler.UpdateUser)
 				//admin.DELETE("/users/:id", userHandler.DeleteUser)
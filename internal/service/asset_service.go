@@ -0,0 +1,343 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// assetIdentifierAttributes are the item attributes that can hold a device identifier
+var assetIdentifierAttributes = []string{"imei", "serial_number", "frame_number"}
+
+// imeiPattern finds IMEI-like numbers in free text
+var imeiPattern = regexp.MustCompile(`\b[0-9]{15}\b`)
+
+var (
+	ErrAssetNotFound          = errors.New("asset not found")
+	ErrAssetAlreadyRegistered = errors.New("you have already registered this identifier")
+	ErrInvalidIdentifier      = errors.New("identifier must be at least 5 letters or digits")
+)
+
+// RegistryCheckResult is the public answer to "is this serial reported?"
+type RegistryCheckResult struct {
+	Reported bool   `json:"reported"`
+	Message  string `json:"message"`
+}
+
+// DisputedIdentifier is an identifier registered by more than one user,
+// with the registrations an admin has to choose between
+type DisputedIdentifier struct {
+	IdentifierHint string                   `json:"identifier_hint"`
+	Registrations  []models.RegisteredAsset `json:"registrations"`
+}
+
+// AssetAlertHook is called after an owner is alerted that a found item
+// matches one of their registered assets
+type AssetAlertHook func(asset *models.RegisteredAsset, item *models.Item)
+
+// AssetService manages the registry of owners' valuables
+type AssetService struct {
+	repo       *repository.AssetRepository
+	secret     []byte
+	afterAlert []AssetAlertHook
+}
+
+// NewAssetService creates a new AssetService. The secret keys the identifier
+// hashes and must stay the same for lookups to keep working.
+func NewAssetService(repo *repository.AssetRepository, secret string) *AssetService {
+	return &AssetService{repo: repo, secret: []byte(secret)}
+}
+
+// AfterAlert registers a hook that runs after an asset alert is created
+func (s *AssetService) AfterAlert(hook AssetAlertHook) {
+	s.afterAlert = append(s.afterAlert, hook)
+}
+
+// Register adds a valuable to the owner's registry. An identifier another
+// user registered is accepted without saying so, so the registry can't be
+// probed or blocked by registering other people's serials first; both
+// registrations are disputed until an admin decides whose it is.
+func (s *AssetService) Register(asset *models.RegisteredAsset, identifier string) error {
+	normalized := normalizeIdentifier(identifier)
+	if len(normalized) < 5 {
+		return ErrInvalidIdentifier
+	}
+	switch asset.Type {
+	case models.AssetTypePhone, models.AssetTypeLaptop, models.AssetTypeTablet, models.AssetTypeBicycle, models.AssetTypeOther:
+	default:
+		return fmt.Errorf("unknown asset type %q", asset.Type)
+	}
+
+	asset.IdentifierHash = s.hash(normalized)
+	asset.IdentifierHint = normalized[len(normalized)-4:]
+	asset.Status = models.AssetStatusRegistered
+	if asset.IdentifierType == "" {
+		asset.IdentifierType = defaultIdentifierType(asset.Type)
+	}
+
+	existing, err := s.repo.FindByHashes([]string{asset.IdentifierHash})
+	if err != nil {
+		return err
+	}
+	disputed := false
+	for _, other := range existing {
+		if other.UserID == asset.UserID {
+			return ErrAssetAlreadyRegistered
+		}
+		disputed = true
+	}
+
+	if err := s.repo.Create(asset); err != nil {
+		return err
+	}
+	if disputed {
+		log.Printf("asset %s: identifier already registered by another user; flagged for review", asset.ID)
+		return s.repo.SetDisputed(asset.IdentifierHash, true)
+	}
+	return nil
+}
+
+// ListForUser retrieves a user's registered assets
+func (s *AssetService) ListForUser(userID uuid.UUID) ([]models.RegisteredAsset, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// GetOwned retrieves an asset owned by the user
+func (s *AssetService) GetOwned(id, userID uuid.UUID) (*models.RegisteredAsset, error) {
+	asset, err := s.repo.GetByID(id)
+	if err != nil || asset.UserID != userID {
+		return nil, ErrAssetNotFound
+	}
+	return asset, nil
+}
+
+// SetStatus marks an owner's asset as lost, stolen, recovered or registered
+func (s *AssetService) SetStatus(id, userID uuid.UUID, status models.AssetStatus) (*models.RegisteredAsset, error) {
+	asset, err := s.GetOwned(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case models.AssetStatusLost, models.AssetStatusStolen:
+		now := time.Now()
+		asset.ReportedAt = &now
+	case models.AssetStatusRecovered, models.AssetStatusRegistered:
+		asset.ReportedAt = nil
+	default:
+		return nil, fmt.Errorf("unknown asset status %q", status)
+	}
+	asset.Status = status
+
+	if err := s.repo.Update(asset); err != nil {
+		return nil, err
+	}
+	return asset, nil
+}
+
+// Delete removes an owner's asset from the registry
+func (s *AssetService) Delete(id, userID uuid.UUID) error {
+	asset, err := s.GetOwned(id, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	return s.settleDispute(asset.IdentifierHash)
+}
+
+// Disputed lists identifiers registered by more than one user; admins only
+func (s *AssetService) Disputed() ([]DisputedIdentifier, error) {
+	assets, err := s.repo.ListDisputed()
+	if err != nil {
+		return nil, err
+	}
+
+	disputes := make([]DisputedIdentifier, 0)
+	for i, asset := range assets {
+		if i == 0 || asset.IdentifierHash != assets[i-1].IdentifierHash {
+			disputes = append(disputes, DisputedIdentifier{IdentifierHint: asset.IdentifierHint})
+		}
+		last := &disputes[len(disputes)-1]
+		last.Registrations = append(last.Registrations, asset)
+	}
+	return disputes, nil
+}
+
+// ResolveDispute settles a disputed identifier in favour of one registration
+// and removes the other users' registrations of it; admins only
+func (s *AssetService) ResolveDispute(id uuid.UUID) (*models.RegisteredAsset, error) {
+	asset, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrAssetNotFound
+	}
+
+	others, err := s.repo.FindByHashes([]string{asset.IdentifierHash})
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range others {
+		if other.UserID == asset.UserID {
+			continue
+		}
+		if err := s.repo.Delete(other.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.settleDispute(asset.IdentifierHash); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// settleDispute clears the dispute on an identifier once only one user has
+// registered it
+func (s *AssetService) settleDispute(hash string) error {
+	assets, err := s.repo.FindByHashes([]string{hash})
+	if err != nil {
+		return err
+	}
+	for _, asset := range assets {
+		if asset.UserID != assets[0].UserID {
+			return nil
+		}
+	}
+	return s.repo.SetDisputed(hash, false)
+}
+
+// Check answers the public "check this serial" query. It reveals only
+// whether the identifier has been reported lost or stolen, never who owns
+// it or whether it is registered at all.
+func (s *AssetService) Check(identifier string) (*RegistryCheckResult, error) {
+	normalized := normalizeIdentifier(identifier)
+	if len(normalized) < 5 {
+		return nil, ErrInvalidIdentifier
+	}
+
+	assets, err := s.repo.FindByHashes([]string{s.hash(normalized)})
+	if err != nil {
+		return nil, err
+	}
+	for _, asset := range assets {
+		if asset.IsMissing() {
+			return &RegistryCheckResult{
+				Reported: true,
+				Message:  "This device has been reported lost or stolen. Please contact the platform to return it.",
+			}, nil
+		}
+	}
+
+	return &RegistryCheckResult{
+		Reported: false,
+		Message:  "This device has not been reported lost or stolen.",
+	}, nil
+}
+
+// ListAlerts retrieves a user's alerts about found items matching their assets
+func (s *AssetService) ListAlerts(userID uuid.UUID) ([]models.AssetAlert, error) {
+	return s.repo.ListAlerts(userID)
+}
+
+// CheckFoundItem alerts owners whose registered assets match the identifiers
+// on a newly posted found item. It is registered as an item hook.
+func (s *AssetService) CheckFoundItem(item *models.Item) {
+	if item.Status != models.ItemStatusFound {
+		return
+	}
+
+	identifiers := itemIdentifiers(item)
+	hashes := make([]string, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		hashes = append(hashes, s.hash(identifier))
+	}
+
+	assets, err := s.repo.FindByHashes(hashes)
+	if err != nil {
+		log.Printf("failed to look up registered assets for item %s: %v", item.ID, err)
+		return
+	}
+
+	for _, asset := range assets {
+		// The owner posting their own item is not news to them. Nobody is
+		// alerted about a disputed identifier until an admin settles whose it is.
+		if asset.UserID == item.UserID || asset.Disputed {
+			continue
+		}
+		if exists, err := s.repo.AlertExists(asset.ID, item.ID); err != nil || exists {
+			continue
+		}
+
+		alert := &models.AssetAlert{AssetID: asset.ID, ItemID: item.ID, UserID: asset.UserID}
+		if err := s.repo.CreateAlert(alert); err != nil {
+			log.Printf("failed to create asset alert for item %s: %v", item.ID, err)
+			continue
+		}
+		for _, hook := range s.afterAlert {
+			hook(&asset, item)
+		}
+	}
+}
+
+// hash returns the keyed hash of a normalised identifier
+func (s *AssetService) hash(normalized string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(normalized))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// itemIdentifiers collects the normalised device identifiers on an item
+func itemIdentifiers(item *models.Item) []string {
+	seen := map[string]bool{}
+	var identifiers []string
+	add := func(value string) {
+		normalized := normalizeIdentifier(value)
+		if len(normalized) >= 5 && !seen[normalized] {
+			seen[normalized] = true
+			identifiers = append(identifiers, normalized)
+		}
+	}
+
+	for _, key := range assetIdentifierAttributes {
+		if value, ok := item.Attributes[key].(string); ok {
+			add(value)
+		}
+	}
+	for _, imei := range imeiPattern.FindAllString(item.Title+" "+item.Description, -1) {
+		add(imei)
+	}
+	return identifiers
+}
+
+// normalizeIdentifier uppercases an identifier and strips spaces and punctuation
+func normalizeIdentifier(identifier string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(identifier) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// defaultIdentifierType returns the usual identifier for an asset type
+func defaultIdentifierType(assetType models.AssetType) string {
+	switch assetType {
+	case models.AssetTypePhone:
+		return "imei"
+	case models.AssetTypeBicycle:
+		return "frame_number"
+	default:
+		return "serial_number"
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import "lostnfound-api/internal/models"
+
+// categorySeed describes a category in the default tree
+type categorySeed struct {
+	name       string
+	attributes []models.AttributeDefinition
+	children   []categorySeed
+}
+
+var (
+	colourAttribute = models.AttributeDefinition{Key: "colour", Label: "Colour", Type: models.AttributeTypeText}
+
+	// Documents record the holder's name and only the last digits of the number
+	documentAttributes = []models.AttributeDefinition{
+		{Key: "name", Label: "Name on document", Type: models.AttributeTypeText, Required: true},
+		{Key: "number_suffix", Label: "Last 3-4 digits of the number", Type: models.AttributeTypeText, Pattern: `^[0-9A-Za-z]{3,4}$`, Private: true},
+	}
+)
+
+// defaultCategories is the category tree created on first start
+var defaultCategories = []categorySeed{
+	{
+		name:       "Documents",
+		attributes: documentAttributes,
+		children: []categorySeed{
+			{name: "National ID"},
+			{name: "Passport"},
+			{name: "Driving Licence"},
+			{name: "Birth Certificate"},
+			{name: "Student ID", attributes: []models.AttributeDefinition{
+				{Key: "institution", Label: "Institution", Type: models.AttributeTypeText},
+			}},
+			{name: "Bank Card", attributes: []models.AttributeDefinition{
+				{Key: "bank", Label: "Bank", Type: models.AttributeTypeText},
+			}},
+			{name: "Certificate"},
+		},
+	},
+	{
+		name:       "Electronics",
+		attributes: []models.AttributeDefinition{colourAttribute},
+		children: []categorySeed{
+			{name: "Phone", attributes: []models.AttributeDefinition{
+				{Key: "brand", Label: "Brand", Type: models.AttributeTypeEnum, Options: []string{
+					"Tecno", "Infinix", "Itel", "Samsung", "Apple", "Oppo", "Xiaomi", "Huawei", "Vivo", "Nokia", "Other",
+				}},
+				{Key: "model", Label: "Model", Type: models.AttributeTypeText},
+				{Key: "imei", Label: "IMEI", Type: models.AttributeTypeText, Pattern: `^[0-9]{15}$`, Private: true},
+			}},
+			{name: "Laptop", attributes: []models.AttributeDefinition{
+				{Key: "brand", Label: "Brand", Type: models.AttributeTypeText},
+				{Key: "serial_number", Label: "Serial number", Type: models.AttributeTypeText, Private: true},
+			}},
+			{name: "Tablet", attributes: []models.AttributeDefinition{
+				{Key: "brand", Label: "Brand", Type: models.AttributeTypeText},
+			}},
+			{name: "Headphones"},
+			{name: "Charger"},
+		},
+	},
+	{
+		name: "Bags & Wallets",
+		attributes: []models.AttributeDefinition{
+			colourAttribute,
+			{Key: "material", Label: "Material", Type: models.AttributeTypeEnum, Options: []string{
+				"Leather", "Canvas", "Nylon", "Synthetic", "Other",
+			}},
+		},
+		children: []categorySeed{
+			{name: "Backpack"},
+			{name: "Handbag"},
+			{name: "Wallet"},
+			{name: "Suitcase"},
+		},
+	},
+	{
+		name: "Keys",
+		attributes: []models.AttributeDefinition{
+			{Key: "key_count", Label: "Number of keys", Type: models.AttributeTypeNumber},
+			{Key: "keyholder", Label: "Keyholder", Type: models.AttributeTypeText},
+		},
+	},
+	{
+		name: "Vehicles & Bicycles",
+		children: []categorySeed{
+			{name: "Bicycle", attributes: []models.AttributeDefinition{
+				colourAttribute,
+				{Key: "frame_number", Label: "Frame number", Type: models.AttributeTypeText, Private: true},
+			}},
+			{name: "Number Plate", attributes: []models.AttributeDefinition{
+				{Key: "registration", Label: "Registration", Type: models.AttributeTypeText},
+			}},
+		},
+	},
+	{name: "Clothing", attributes: []models.AttributeDefinition{colourAttribute}},
+	{name: "Jewellery & Watches", attributes: []models.AttributeDefinition{colourAttribute}},
+	{name: "Other"},
+}
@@ -0,0 +1,374 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrCategoryInUse    = errors.New("category has subcategories or items")
+)
+
+// CategoryService manages the item category tree and its attribute schemas
+type CategoryService struct {
+	repo *repository.CategoryRepository
+}
+
+// NewCategoryService creates a new CategoryService
+func NewCategoryService(repo *repository.CategoryRepository) *CategoryService {
+	return &CategoryService{repo: repo}
+}
+
+// Tree returns the full category tree
+func (s *CategoryService) Tree() ([]models.Category, error) {
+	categories, err := s.repo.List()
+	if err != nil {
+		return nil, err
+	}
+	return buildCategoryTree(categories, nil), nil
+}
+
+// GetByID retrieves a category by ID
+func (s *CategoryService) GetByID(id uuid.UUID) (*models.Category, error) {
+	category, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// GetByPath retrieves a category by its slug path
+func (s *CategoryService) GetByPath(path string) (*models.Category, error) {
+	category, err := s.repo.GetByPath(path)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	return category, nil
+}
+
+// Schema returns every attribute an item in the category may carry,
+// including those inherited from parent categories
+func (s *CategoryService) Schema(id uuid.UUID) ([]models.AttributeDefinition, error) {
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema []models.AttributeDefinition
+	segments := strings.Split(category.Path, "/")
+	for i := 1; i <= len(segments); i++ {
+		ancestor, err := s.repo.GetByPath(strings.Join(segments[:i], "/"))
+		if err != nil {
+			return nil, err
+		}
+		schema = append(schema, ancestor.Attributes...)
+	}
+	return schema, nil
+}
+
+// Create adds a new category under its parent
+func (s *CategoryService) Create(category *models.Category) error {
+	if strings.TrimSpace(category.Name) == "" {
+		return errors.New("name is required")
+	}
+	if category.Slug == "" {
+		category.Slug = slugify(category.Name)
+	}
+	if err := validateAttributeDefinitions(category.Attributes); err != nil {
+		return err
+	}
+	if category.ArchiveAfterDays < 0 {
+		return errors.New("archive period cannot be negative")
+	}
+
+	category.Path = category.Slug
+	if category.ParentID != nil {
+		parent, err := s.GetByID(*category.ParentID)
+		if err != nil {
+			return errors.New("parent category not found")
+		}
+		category.Path = parent.Path + "/" + category.Slug
+	}
+
+	if _, err := s.repo.GetByPath(category.Path); err == nil {
+		return fmt.Errorf("category %q already exists", category.Path)
+	}
+
+	return s.repo.Create(category)
+}
+
+// Update changes a category's name, attribute schema and archive period. The
+// slug and parent are fixed once created so that paths stored in filters stay valid.
+func (s *CategoryService) Update(id uuid.UUID, name string, attributes []models.AttributeDefinition, archiveAfterDays int) (*models.Category, error) {
+	category, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := validateAttributeDefinitions(attributes); err != nil {
+		return nil, err
+	}
+	if archiveAfterDays < 0 {
+		return nil, errors.New("archive period cannot be negative")
+	}
+
+	category.Name = name
+	category.Attributes = attributes
+	category.ArchiveAfterDays = archiveAfterDays
+	if err := s.repo.Update(category); err != nil {
+		return nil, err
+	}
+	return category, nil
+}
+
+// Delete removes a category that has no subcategories and no items
+func (s *CategoryService) Delete(id uuid.UUID) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
+	}
+
+	hasChildren, err := s.repo.HasChildren(id)
+	if err != nil {
+		return err
+	}
+	hasItems, err := s.repo.HasItems(id)
+	if err != nil {
+		return err
+	}
+	if hasChildren || hasItems {
+		return ErrCategoryInUse
+	}
+
+	return s.repo.Delete(id)
+}
+
+// ResolvePath turns a category ID or slug path into a slug path for filtering
+func (s *CategoryService) ResolvePath(category string) string {
+	if id, err := uuid.Parse(category); err == nil {
+		if c, err := s.repo.GetByID(id); err == nil {
+			return c.Path
+		}
+	}
+	return strings.ToLower(strings.Trim(category, "/"))
+}
+
+// ValidateItemAttributes checks an item's attributes against its category's
+// schema, normalises enum values to their declared spelling and records
+// which of them are private
+func (s *CategoryService) ValidateItemAttributes(item *models.Item) error {
+	item.PrivateAttributes = nil
+	if item.CategoryID == nil {
+		if len(item.Attributes) > 0 {
+			return errors.New("attributes require a category")
+		}
+		return nil
+	}
+
+	schema, err := s.Schema(*item.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	var problems []string
+	defined := make(map[string]bool, len(schema))
+	for _, def := range schema {
+		defined[def.Key] = true
+
+		value, ok := item.Attributes[def.Key]
+		if !ok || value == nil || value == "" {
+			if def.Required {
+				problems = append(problems, def.Key+" is required")
+			}
+			continue
+		}
+
+		normalized, err := validateAttributeValue(def, value)
+		if err != nil {
+			problems = append(problems, def.Key+": "+err.Error())
+			continue
+		}
+		item.Attributes[def.Key] = normalized
+		if def.Private {
+			item.PrivateAttributes = append(item.PrivateAttributes, def.Key)
+		}
+	}
+
+	for key := range item.Attributes {
+		if !defined[key] {
+			problems = append(problems, key+" is not an attribute of this category")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid attributes: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// IsPrivateAttribute reports whether any category marks an attribute private
+func (s *CategoryService) IsPrivateAttribute(key string) (bool, error) {
+	categories, err := s.repo.List()
+	if err != nil {
+		return false, err
+	}
+	for _, category := range categories {
+		for _, def := range category.Attributes {
+			if def.Key == key && def.Private {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// SeedDefaults creates the default category tree when no categories exist
+func (s *CategoryService) SeedDefaults() error {
+	count, err := s.repo.Count()
+	if err != nil || count > 0 {
+		return err
+	}
+
+	var seed func(parentID *uuid.UUID, nodes []categorySeed) error
+	seed = func(parentID *uuid.UUID, nodes []categorySeed) error {
+		for _, node := range nodes {
+			category := &models.Category{ParentID: parentID, Name: node.name, Attributes: node.attributes}
+			if err := s.Create(category); err != nil {
+				return fmt.Errorf("failed to seed category %q: %w", node.name, err)
+			}
+			if err := seed(&category.ID, node.children); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return seed(nil, defaultCategories)
+}
+
+// validateAttributeValue checks a single attribute value and returns it in canonical form
+func validateAttributeValue(def models.AttributeDefinition, value any) (any, error) {
+	switch def.Type {
+	case models.AttributeTypeNumber:
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return nil, errors.New("must be a number")
+			}
+			return n, nil
+		}
+		return nil, errors.New("must be a number")
+
+	case models.AttributeTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return nil, errors.New("must be true or false")
+			}
+			return b, nil
+		}
+		return nil, errors.New("must be true or false")
+
+	case models.AttributeTypeEnum:
+		text := strings.TrimSpace(fmt.Sprint(value))
+		for _, option := range def.Options {
+			if strings.EqualFold(option, text) {
+				return option, nil
+			}
+		}
+		return nil, fmt.Errorf("must be one of %s", strings.Join(def.Options, ", "))
+
+	default:
+		text, ok := value.(string)
+		if !ok {
+			return nil, errors.New("must be text")
+		}
+		text = strings.TrimSpace(text)
+		if def.Pattern != "" && !regexp.MustCompile(def.Pattern).MatchString(text) {
+			return nil, errors.New("has an invalid format")
+		}
+		return text, nil
+	}
+}
+
+// attributeKeyPattern restricts attribute keys to lowercase snake case
+var attributeKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// validateAttributeDefinitions checks a category's attribute schema
+func validateAttributeDefinitions(defs []models.AttributeDefinition) error {
+	seen := make(map[string]bool, len(defs))
+	for _, def := range defs {
+		if !attributeKeyPattern.MatchString(def.Key) {
+			return fmt.Errorf("attribute key %q must be lowercase snake case", def.Key)
+		}
+		if seen[def.Key] {
+			return fmt.Errorf("attribute %q is declared twice", def.Key)
+		}
+		seen[def.Key] = true
+
+		switch def.Type {
+		case models.AttributeTypeText, models.AttributeTypeNumber, models.AttributeTypeBoolean:
+		case models.AttributeTypeEnum:
+			if len(def.Options) == 0 {
+				return fmt.Errorf("enum attribute %q needs options", def.Key)
+			}
+		default:
+			return fmt.Errorf("attribute %q has unknown type %q", def.Key, def.Type)
+		}
+
+		if def.Pattern != "" {
+			if _, err := regexp.Compile(def.Pattern); err != nil {
+				return fmt.Errorf("attribute %q has an invalid pattern: %w", def.Key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// buildCategoryTree nests categories under their parents
+func buildCategoryTree(categories []models.Category, parentID *uuid.UUID) []models.Category {
+	var nodes []models.Category
+	for _, category := range categories {
+		if (category.ParentID == nil) != (parentID == nil) {
+			continue
+		}
+		if parentID != nil && *category.ParentID != *parentID {
+			continue
+		}
+		category.Children = buildCategoryTree(categories, &category.ID)
+		nodes = append(nodes, category)
+	}
+	return nodes
+}
+
+// slugify converts a name into a URL-friendly slug
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+			dash = false
+		case r == '\'':
+		case !dash && b.Len() > 0:
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
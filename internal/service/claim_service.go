@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"strings"
+)
+
+var (
+	ErrItemNotFound      = errors.New("item not found")
+	ErrClaimNotFound     = errors.New("claim not found")
+	ErrClaimOwnItem      = errors.New("you cannot claim your own item")
+	ErrClaimExists       = errors.New("you already have an open claim on this item")
+	ErrClaimNotPending   = errors.New("claim has already been decided")
+	ErrItemNotClaimable  = errors.New("item is no longer open for claims")
+	ErrNotAuthorizedItem = errors.New("not authorized for this item")
+)
+
+// ClaimHook is called after a claim is created or its status changes
+type ClaimHook func(claim *models.Claim)
+
+// ClaimCheck is called before a claim is created and can refuse it
+type ClaimCheck func(claim *models.Claim) error
+
+// ClaimService provides business logic for claims on items
+type ClaimService struct {
+	repo     *repository.ClaimRepository
+	itemRepo *repository.ItemRepository
+
+	beforeCreate []ClaimCheck
+	afterChange  []ClaimHook
+}
+
+// NewClaimService creates a new ClaimService
+func NewClaimService(repo *repository.ClaimRepository, itemRepo *repository.ItemRepository) *ClaimService {
+	return &ClaimService{repo: repo, itemRepo: itemRepo}
+}
+
+// BeforeCreate registers a check that runs before a claim is created
+func (s *ClaimService) BeforeCreate(check ClaimCheck) {
+	s.beforeCreate = append(s.beforeCreate, check)
+}
+
+// AfterChange registers a hook that runs after a claim is created or its status changes
+func (s *ClaimService) AfterChange(hook ClaimHook) {
+	s.afterChange = append(s.afterChange, hook)
+}
+
+// Create files a claim on an item
+func (s *ClaimService) Create(claim *models.Claim) error {
+	if strings.TrimSpace(claim.Description) == "" {
+		return errors.New("description is required")
+	}
+
+	item, err := s.itemRepo.GetByID(claim.ItemID)
+	if err != nil {
+		return ErrItemNotFound
+	}
+	if item.UserID == claim.ClaimerID {
+		return ErrClaimOwnItem
+	}
+	if item.IsResolved || item.Status == models.ItemStatusClaimed || item.Status == models.ItemStatusReturned {
+		return ErrItemNotClaimable
+	}
+	// Drafts and hidden items are not shown to others, and archived items are
+	// no longer open for claims
+	if item.Draft || item.HiddenAt != nil {
+		return ErrItemNotFound
+	}
+	if item.ArchivedAt != nil {
+		return ErrItemNotClaimable
+	}
+
+	open, err := s.repo.HasOpenClaim(claim.ItemID, claim.ClaimerID)
+	if err != nil {
+		return err
+	}
+	if open {
+		return ErrClaimExists
+	}
+
+	claim.Status = models.ClaimStatusPending
+	claim.DecidedAt = nil
+	for _, check := range s.beforeCreate {
+		if err := check(claim); err != nil {
+			return err
+		}
+	}
+	if err := s.repo.Create(claim); err != nil {
+		return err
+	}
+
+	s.changed(claim)
+	return nil
+}
+
+// GetForViewer retrieves a claim visible to the claimer, the item owner or an admin
+func (s *ClaimService) GetForViewer(id, viewerID uuid.UUID, isAdmin bool) (*models.Claim, error) {
+	claim, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrClaimNotFound
+	}
+	if isAdmin || claim.ClaimerID == viewerID {
+		return claim, nil
+	}
+
+	item, err := s.itemRepo.GetByID(claim.ItemID)
+	if err != nil || item.UserID != viewerID {
+		return nil, ErrClaimNotFound
+	}
+	return claim, nil
+}
+
+// ListForItem retrieves the claims on an item for its owner or an admin
+func (s *ClaimService) ListForItem(itemID, viewerID uuid.UUID, isAdmin bool) ([]models.Claim, error) {
+	if _, err := s.ownedItem(itemID, viewerID, isAdmin); err != nil {
+		return nil, err
+	}
+	return s.repo.ListByItem(itemID)
+}
+
+// ListMine retrieves the claims a user has made
+func (s *ClaimService) ListMine(claimerID uuid.UUID, page, limit int) ([]models.Claim, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListByClaimer(claimerID, page, limit)
+}
+
+// Approve accepts a claim on behalf of the item owner. Other pending claims
+// on the item are rejected and the item is marked claimed.
+func (s *ClaimService) Approve(id, actorID uuid.UUID, isAdmin bool) (*models.Claim, error) {
+	claim, err := s.decidableClaim(id, actorID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	rejected, err := s.repo.Approve(claim)
+	if err != nil {
+		return nil, claimDecisionError(err)
+	}
+
+	s.changed(claim)
+	for i := range rejected {
+		s.changed(&rejected[i])
+	}
+	return claim, nil
+}
+
+// Reject turns down a claim on behalf of the item owner
+func (s *ClaimService) Reject(id, actorID uuid.UUID, isAdmin bool) (*models.Claim, error) {
+	claim, err := s.decidableClaim(id, actorID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.UpdateStatus(claim, models.ClaimStatusRejected); err != nil {
+		return nil, claimDecisionError(err)
+	}
+
+	s.changed(claim)
+	return claim, nil
+}
+
+// Withdraw lets the claimer take back a pending claim
+func (s *ClaimService) Withdraw(id, claimerID uuid.UUID) (*models.Claim, error) {
+	claim, err := s.repo.GetByID(id)
+	if err != nil || claim.ClaimerID != claimerID {
+		return nil, ErrClaimNotFound
+	}
+	if claim.Status != models.ClaimStatusPending {
+		return nil, ErrClaimNotPending
+	}
+	if err := s.repo.UpdateStatus(claim, models.ClaimStatusWithdrawn); err != nil {
+		return nil, claimDecisionError(err)
+	}
+
+	s.changed(claim)
+	return claim, nil
+}
+
+// HasApprovedClaim reports whether a user's claim on an item has been approved
+func (s *ClaimService) HasApprovedClaim(itemID, claimerID uuid.UUID) (bool, error) {
+	return s.repo.HasApprovedClaim(itemID, claimerID)
+}
+
+// claimDecisionError maps a decision that lost a race to the service's errors
+func claimDecisionError(err error) error {
+	switch {
+	case errors.Is(err, repository.ErrClaimDecided):
+		return ErrClaimNotPending
+	case errors.Is(err, repository.ErrItemAlreadyClaimed):
+		return ErrItemNotClaimable
+	}
+	return err
+}
+
+// changed runs the after-change hooks for a claim
+func (s *ClaimService) changed(claim *models.Claim) {
+	for _, hook := range s.afterChange {
+		hook(claim)
+	}
+}
+
+// decidableClaim loads a pending claim the actor may approve or reject
+func (s *ClaimService) decidableClaim(id, actorID uuid.UUID, isAdmin bool) (*models.Claim, error) {
+	claim, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrClaimNotFound
+	}
+	if _, err := s.ownedItem(claim.ItemID, actorID, isAdmin); err != nil {
+		return nil, err
+	}
+	if claim.Status != models.ClaimStatusPending {
+		return nil, ErrClaimNotPending
+	}
+	return claim, nil
+}
+
+// ownedItem loads an item and checks the actor owns it or is an admin
+func (s *ClaimService) ownedItem(itemID, actorID uuid.UUID, isAdmin bool) (*models.Item, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if !isAdmin && item.UserID != actorID {
+		return nil, ErrNotAuthorizedItem
+	}
+	return item, nil
+}
@@ -0,0 +1,599 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"mime/multipart"
+	"strings"
+	"time"
+)
+
+// Kinds of custodian
+const (
+	CustodianOrganization = "organization"
+	CustodianPerson       = "person"
+)
+
+var (
+	ErrDropOffPointNotFound = errors.New("drop-off point not found")
+	ErrDropOffPointInactive = errors.New("this drop-off point is not accepting items")
+	ErrNoCustodian          = errors.New("nobody is recorded as holding this item")
+	ErrNotCustodian         = errors.New("only the current custodian can record a handover")
+	ErrTransferNotFound     = errors.New("custody transfer not found")
+	ErrNotReceiver          = errors.New("only the receiving organization can confirm a handover")
+	ErrTransferNotPending   = errors.New("this handover has already been confirmed")
+	ErrTransferStale        = errors.New("the item has changed hands since this handover was recorded")
+	ErrCustodyEvidence      = errors.New("a handover needs a signature or a photo")
+	ErrNotVerifiedClaimant  = errors.New("collection details are only shown to the approved claimant")
+	ErrCustodyNotVisible    = errors.New("not authorized to view this item's custody")
+)
+
+// Custodian is whoever physically holds an item
+type Custodian struct {
+	Kind           string     `json:"kind"`
+	Name           string     `json:"name"`
+	UserID         *uuid.UUID `json:"user_id,omitempty"`
+	OrganizationID *uuid.UUID `json:"organization_id,omitempty"`
+	DropOffPointID *uuid.UUID `json:"drop_off_point_id,omitempty"`
+}
+
+// CustodyTransferInput says who an item is being handed to. Give an
+// organisation or one of its drop-off points, or a person by account or name.
+type CustodyTransferInput struct {
+	ToOrganizationID *uuid.UUID
+	ToDropOffPointID *uuid.UUID
+	ToUserID         *uuid.UUID
+	ToName           string
+	Notes            string
+}
+
+// Upload is a file sent with a request
+type Upload struct {
+	File   multipart.File
+	Header *multipart.FileHeader
+}
+
+// CollectionInstructions tell an approved claimant where their item is and
+// how to collect it
+type CollectionInstructions struct {
+	ItemID        uuid.UUID             `json:"item_id"`
+	ReferenceCode string                `json:"reference_code,omitempty"`
+	Custodian     Custodian             `json:"custodian"`
+	Verified      bool                  `json:"verified"`
+	Location      string                `json:"location,omitempty"`
+	CountyID      string                `json:"county_id,omitempty"`
+	Latitude      *float64              `json:"latitude,omitempty"`
+	Longitude     *float64              `json:"longitude,omitempty"`
+	Phone         string                `json:"phone,omitempty"`
+	OpeningHours  []models.OpeningHours `json:"opening_hours,omitempty"`
+	OpenNow       bool                  `json:"open_now"`
+	Instructions  string                `json:"instructions"`
+}
+
+// CustodyService manages drop-off points and the chain of custody of found
+// items as they pass between finders, organisations and owners
+type CustodyService struct {
+	repo          *repository.CustodyRepository
+	orgRepo       *repository.OrganizationRepository
+	organizations *OrganizationService
+	items         *ItemService
+	claims        *ClaimService
+	users         *repository.UserRepository
+	storage       *StorageService
+	locations     *LocationService
+}
+
+// NewCustodyService creates a new CustodyService
+func NewCustodyService(repo *repository.CustodyRepository, orgRepo *repository.OrganizationRepository, organizations *OrganizationService, items *ItemService, claims *ClaimService, users *repository.UserRepository, storage *StorageService, locations *LocationService) *CustodyService {
+	return &CustodyService{
+		repo:          repo,
+		orgRepo:       orgRepo,
+		organizations: organizations,
+		items:         items,
+		claims:        claims,
+		users:         users,
+		storage:       storage,
+		locations:     locations,
+	}
+}
+
+// CreateDropOffPoint registers a drop-off point for an organisation. Only its admins may do this.
+func (s *CustodyService) CreateDropOffPoint(organizationID, actorID uuid.UUID, isAdmin bool, point *models.DropOffPoint) error {
+	if err := s.organizations.requireRole(organizationID, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return err
+	}
+	point.OrganizationID = organizationID
+	point.Active = true
+	if err := s.prepareDropOffPoint(point); err != nil {
+		return err
+	}
+	return s.repo.CreateDropOffPoint(point)
+}
+
+// UpdateDropOffPoint changes a drop-off point or takes it out of use. Only
+// the organisation's admins may do this.
+func (s *CustodyService) UpdateDropOffPoint(organizationID, id, actorID uuid.UUID, isAdmin bool, input *models.DropOffPoint) (*models.DropOffPoint, error) {
+	if err := s.organizations.requireRole(organizationID, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return nil, err
+	}
+	point, err := s.repo.GetDropOffPoint(id)
+	if err != nil || point.OrganizationID != organizationID {
+		return nil, ErrDropOffPointNotFound
+	}
+
+	point.Name = input.Name
+	point.Location = input.Location
+	point.CountyID = input.CountyID
+	point.SubCountyID = input.SubCountyID
+	point.Latitude = input.Latitude
+	point.Longitude = input.Longitude
+	point.Phone = input.Phone
+	point.OpeningHours = input.OpeningHours
+	point.CollectionInstructions = input.CollectionInstructions
+	point.Active = input.Active
+	if err := s.prepareDropOffPoint(point); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateDropOffPoint(point); err != nil {
+		return nil, err
+	}
+	return point, nil
+}
+
+// ListDropOffPoints retrieves active drop-off points for the public
+func (s *CustodyService) ListDropOffPoints(county string, organizationID *uuid.UUID, page, limit int) ([]models.DropOffPoint, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	filter := repository.ItemFilter{CountyID: county}
+	s.locations.NormalizeFilter(&filter)
+	return s.repo.ListDropOffPoints(filter.CountyID, organizationID, page, limit)
+}
+
+// GetDropOffPoint retrieves a drop-off point
+func (s *CustodyService) GetDropOffPoint(id uuid.UUID) (*models.DropOffPoint, error) {
+	point, err := s.repo.GetDropOffPoint(id)
+	if err != nil {
+		return nil, ErrDropOffPointNotFound
+	}
+	return point, nil
+}
+
+// Custodian works out who holds an item now: the organisation holding it,
+// else the last person it was handed to, else the finder who reported it
+func (s *CustodyService) Custodian(item *models.Item) (*Custodian, error) {
+	custody, err := s.orgRepo.GetCustody(item.ID)
+	if err == nil && custody.Status == models.CustodyStatusHeld {
+		custodian := &Custodian{Kind: CustodianOrganization, OrganizationID: &custody.OrganizationID, DropOffPointID: custody.DropOffPointID}
+		if organization, err := s.orgRepo.GetByID(custody.OrganizationID); err == nil {
+			custodian.Name = organization.Name
+		}
+		return custodian, nil
+	}
+	if err == nil && custody.Status == models.CustodyStatusDisposed {
+		return nil, ErrNoCustodian
+	}
+
+	latest, err := s.repo.LatestTransfer(item.ID)
+	if err == nil && latest.ToOrganizationID == nil {
+		return &Custodian{Kind: CustodianPerson, Name: latest.ToName, UserID: latest.ToUserID}, nil
+	}
+
+	// Lost items are reported by their owners, who do not have them
+	if item.Status == models.ItemStatusLost {
+		return nil, ErrNoCustodian
+	}
+	return &Custodian{Kind: CustodianPerson, Name: item.User.FirstName, UserID: &item.UserID}, nil
+}
+
+// Transfer records that an item changed hands, with a signature or photo as
+// evidence. It may only be recorded by the current custodian: the person
+// holding it, or a member of the organisation holding it. A handover to an
+// organisation the actor does not belong to stays pending until the
+// organisation confirms it.
+func (s *CustodyService) Transfer(ctx context.Context, itemID, actorID uuid.UUID, isAdmin bool, input CustodyTransferInput, signature, photo *Upload) (*models.CustodyTransfer, error) {
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	current, err := s.Custodian(item)
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := &models.CustodyTransfer{
+		ItemID:        item.ID,
+		RecordedByID:  actorID,
+		TransferredAt: time.Now(),
+		Notes:         strings.TrimSpace(input.Notes),
+	}
+	if current.Kind == CustodianOrganization {
+		transfer.FromOrganizationID, transfer.FromName = current.OrganizationID, current.Name
+	} else {
+		transfer.FromUserID, transfer.FromName = current.UserID, current.Name
+	}
+	if err := s.resolveRecipient(transfer, input); err != nil {
+		return nil, err
+	}
+	if sameCustodian(current, transfer) {
+		return nil, errors.New("the item is already with that custodian")
+	}
+	if !isAdmin && !s.mayHandOver(current, actorID) {
+		return nil, ErrNotCustodian
+	}
+	if transfer.ToOrganizationID != nil && !isAdmin {
+		_, err := s.organizations.Role(*transfer.ToOrganizationID, actorID)
+		transfer.Pending = err != nil
+	}
+
+	if signature == nil && photo == nil {
+		return nil, ErrCustodyEvidence
+	}
+	if signature != nil {
+		if transfer.SignatureURL, err = s.storage.UploadCustodyEvidence(ctx, item.ID, signature.File, signature.Header); err != nil {
+			return nil, err
+		}
+	}
+	if photo != nil {
+		if transfer.PhotoURL, err = s.storage.UploadCustodyEvidence(ctx, item.ID, photo.File, photo.Header); err != nil {
+			return nil, err
+		}
+	}
+
+	var custody *models.ItemCustody
+	if !transfer.Pending {
+		if custody, err = s.nextCustody(item.ID, transfer, actorID); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.repo.RecordTransfer(transfer, custody); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// ConfirmTransfer records that the receiving organisation has the item from a
+// pending handover. Only its members may confirm, and only while whoever
+// handed the item over still holds it.
+func (s *CustodyService) ConfirmTransfer(id, actorID uuid.UUID, isAdmin bool) (*models.CustodyTransfer, error) {
+	transfer, err := s.repo.GetTransfer(id)
+	if err != nil {
+		return nil, ErrTransferNotFound
+	}
+	if transfer.ToOrganizationID == nil {
+		return nil, ErrTransferNotFound
+	}
+	if !isAdmin {
+		if _, err := s.organizations.Role(*transfer.ToOrganizationID, actorID); err != nil {
+			return nil, ErrNotReceiver
+		}
+	}
+	if !transfer.Pending {
+		return nil, ErrTransferNotPending
+	}
+
+	item, err := s.items.GetByID(transfer.ItemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	current, err := s.Custodian(item)
+	if err != nil || !handedOverBy(current, transfer) {
+		return nil, ErrTransferStale
+	}
+
+	now := time.Now()
+	transfer.TransferredAt = now
+	transfer.ConfirmedByID, transfer.ConfirmedAt = &actorID, &now
+	custody, err := s.nextCustody(item.ID, transfer, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ConfirmTransfer(transfer, custody); err != nil {
+		if errors.Is(err, repository.ErrTransferConfirmed) {
+			return nil, ErrTransferNotPending
+		}
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// HoldsItem reports whether the actor holds the item now, in person or as a
+// member of the organisation holding it
+func (s *CustodyService) HoldsItem(item *models.Item, actorID uuid.UUID) bool {
+	current, err := s.Custodian(item)
+	if err != nil {
+		return false
+	}
+	if current.Kind == CustodianPerson {
+		return isUser(current.UserID, actorID)
+	}
+	_, err = s.organizations.Role(*current.OrganizationID, actorID)
+	return err == nil
+}
+
+// RecordReturn closes an item's chain of custody with its handover to the
+// claimant. The verified handover code stands in for a signature.
+func (s *CustodyService) RecordReturn(item *models.Item, claimerID, actorID uuid.UUID, at time.Time) error {
+	transfer := &models.CustodyTransfer{
+		ItemID:        item.ID,
+		ToUserID:      &claimerID,
+		RecordedByID:  actorID,
+		TransferredAt: at,
+		Notes:         "Returned to the claimant with a verified handover code",
+	}
+	if user, err := s.users.GetByID(claimerID); err == nil {
+		transfer.ToName = strings.TrimSpace(user.FirstName + " " + user.LastName)
+	}
+	if current, err := s.Custodian(item); err == nil {
+		if current.Kind == CustodianOrganization {
+			transfer.FromOrganizationID, transfer.FromName = current.OrganizationID, current.Name
+		} else {
+			transfer.FromUserID, transfer.FromName = current.UserID, current.Name
+		}
+	}
+
+	custody, err := s.nextCustody(item.ID, transfer, actorID)
+	if err != nil {
+		return err
+	}
+	return s.repo.RecordTransfer(transfer, custody)
+}
+
+// Transfers retrieves an item's chain of custody. It is shown to the
+// reporter, to anyone in the chain and to members of organisations in it.
+func (s *CustodyService) Transfers(itemID, actorID uuid.UUID, isAdmin bool) ([]models.CustodyTransfer, error) {
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	transfers, err := s.repo.ListTransfers(item.ID)
+	if err != nil {
+		return nil, err
+	}
+	if isAdmin || item.UserID == actorID {
+		return transfers, nil
+	}
+
+	organizations := make(map[uuid.UUID]bool)
+	if item.OrganizationID != nil {
+		organizations[*item.OrganizationID] = true
+	}
+	for _, transfer := range transfers {
+		if isUser(transfer.FromUserID, actorID) || isUser(transfer.ToUserID, actorID) {
+			return transfers, nil
+		}
+		for _, id := range []*uuid.UUID{transfer.FromOrganizationID, transfer.ToOrganizationID} {
+			if id != nil {
+				organizations[*id] = true
+			}
+		}
+	}
+	for id := range organizations {
+		if _, err := s.organizations.Role(id, actorID); err == nil {
+			return transfers, nil
+		}
+	}
+	return nil, ErrCustodyNotVisible
+}
+
+// Collection tells the approved claimant who holds their item and how to
+// collect it. Nobody else can see these details.
+func (s *CustodyService) Collection(itemID, actorID uuid.UUID) (*CollectionInstructions, error) {
+	approved, err := s.claims.HasApprovedClaim(itemID, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if !approved {
+		return nil, ErrNotVerifiedClaimant
+	}
+
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	custodian, err := s.Custodian(item)
+	if err != nil {
+		return nil, err
+	}
+
+	collection := &CollectionInstructions{ItemID: item.ID, Custodian: *custodian}
+	if item.ReferenceCode != nil {
+		collection.ReferenceCode = *item.ReferenceCode
+	}
+
+	if custodian.Kind == CustodianPerson {
+		// Finders are reached through messaging rather than by sharing their details
+		collection.Custodian.UserID = nil
+		collection.Instructions = "Arrange the handover with " + nameOr(custodian.Name, "the finder") +
+			" in the conversation about your claim. Meet in a public place and bring your ID."
+		return collection, nil
+	}
+
+	organization, err := s.orgRepo.GetByID(*custodian.OrganizationID)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	collection.Verified = organization.Verified
+	collection.Location, collection.CountyID = organization.Location, organization.CountyID
+	collection.Latitude, collection.Longitude = organization.Latitude, organization.Longitude
+	collection.Phone = organization.Phone
+	collection.OpeningHours = organization.OpeningHours
+	collection.OpenNow = organization.OpenAt(time.Now())
+	instructions := ""
+
+	if custodian.DropOffPointID != nil {
+		if point, err := s.repo.GetDropOffPoint(*custodian.DropOffPointID); err == nil {
+			collection.Custodian.Name = organization.Name + " - " + point.Name
+			collection.Location, collection.CountyID = point.Location, point.CountyID
+			collection.Latitude, collection.Longitude = point.Latitude, point.Longitude
+			if point.Phone != "" {
+				collection.Phone = point.Phone
+			}
+			if len(point.OpeningHours) > 0 {
+				hours := models.Organization{OpeningHours: point.OpeningHours}
+				collection.OpeningHours = point.OpeningHours
+				collection.OpenNow = hours.OpenAt(time.Now())
+			}
+			instructions = point.CollectionInstructions
+		}
+	}
+
+	if instructions == "" {
+		instructions = "Collect the item from " + collection.Custodian.Name + " during opening hours."
+	}
+	if collection.ReferenceCode != "" {
+		instructions += " Quote reference " + collection.ReferenceCode + " and bring the ID you claimed with."
+	} else {
+		instructions += " Bring the ID you claimed with."
+	}
+	collection.Instructions = instructions
+	return collection, nil
+}
+
+// resolveRecipient fills in who a transfer is to
+func (s *CustodyService) resolveRecipient(transfer *models.CustodyTransfer, input CustodyTransferInput) error {
+	switch {
+	case input.ToDropOffPointID != nil:
+		point, err := s.repo.GetDropOffPoint(*input.ToDropOffPointID)
+		if err != nil {
+			return ErrDropOffPointNotFound
+		}
+		if !point.Active {
+			return ErrDropOffPointInactive
+		}
+		if input.ToOrganizationID != nil && *input.ToOrganizationID != point.OrganizationID {
+			return errors.New("the drop-off point belongs to a different organization")
+		}
+		transfer.ToOrganizationID, transfer.ToDropOffPointID = &point.OrganizationID, &point.ID
+		transfer.ToName = point.Name
+		if point.Organization != nil {
+			transfer.ToName = point.Organization.Name + " - " + point.Name
+		}
+	case input.ToOrganizationID != nil:
+		organization, err := s.orgRepo.GetByID(*input.ToOrganizationID)
+		if err != nil {
+			return ErrOrganizationNotFound
+		}
+		transfer.ToOrganizationID, transfer.ToName = &organization.ID, organization.Name
+	case input.ToUserID != nil:
+		user, err := s.users.GetByID(*input.ToUserID)
+		if err != nil {
+			return errors.New("recipient account not found")
+		}
+		transfer.ToUserID = &user.ID
+		transfer.ToName = nameOr(strings.TrimSpace(input.ToName), strings.TrimSpace(user.FirstName+" "+user.LastName))
+	case strings.TrimSpace(input.ToName) != "":
+		transfer.ToName = strings.TrimSpace(input.ToName)
+	default:
+		return fmt.Errorf("give a recipient: an organization, a drop-off point, a user or a name")
+	}
+	return nil
+}
+
+// mayHandOver reports whether the actor may record a transfer: they hold the
+// item themselves, as the reporter does until it first changes hands, or
+// belong to the organisation holding it
+func (s *CustodyService) mayHandOver(current *Custodian, actorID uuid.UUID) bool {
+	if current.Kind == CustodianPerson {
+		return isUser(current.UserID, actorID)
+	}
+	_, err := s.organizations.Role(*current.OrganizationID, actorID)
+	return err == nil
+}
+
+// nextCustody returns the custody record after a transfer: held by the
+// receiving organisation, released when an organisation hands the item to a
+// person, or nil when it passes between people
+func (s *CustodyService) nextCustody(itemID uuid.UUID, transfer *models.CustodyTransfer, actorID uuid.UUID) (*models.ItemCustody, error) {
+	custody, err := s.orgRepo.GetCustody(itemID)
+	if err != nil {
+		custody = nil
+	}
+	now := transfer.TransferredAt
+
+	if transfer.ToOrganizationID != nil {
+		if custody == nil {
+			custody = &models.ItemCustody{ItemID: itemID}
+		}
+		if custody.OrganizationID != *transfer.ToOrganizationID {
+			custody.StorageLocation = ""
+		}
+		custody.OrganizationID = *transfer.ToOrganizationID
+		custody.DropOffPointID = transfer.ToDropOffPointID
+		custody.Status = models.CustodyStatusHeld
+		custody.ReceivedByID, custody.ReceivedAt = actorID, now
+		custody.ReleasedByID, custody.ReleasedAt = nil, nil
+		custody.DisposalWarnedAt = nil
+		return custody, nil
+	}
+
+	if custody != nil && custody.Status == models.CustodyStatusHeld {
+		custody.Status = models.CustodyStatusReleased
+		custody.ReleasedByID, custody.ReleasedAt = &actorID, &now
+		return custody, nil
+	}
+	return nil, nil
+}
+
+// prepareDropOffPoint validates and normalises a drop-off point
+func (s *CustodyService) prepareDropOffPoint(point *models.DropOffPoint) error {
+	point.Name = strings.TrimSpace(point.Name)
+	if point.Name == "" {
+		return errors.New("name is required")
+	}
+	point.CollectionInstructions = strings.TrimSpace(point.CollectionInstructions)
+
+	countyID, subCountyID, err := normalizePlace(s.locations, point.Location, point.CountyID, point.SubCountyID, point.Latitude, point.Longitude)
+	if err != nil {
+		return err
+	}
+	point.CountyID, point.SubCountyID = countyID, subCountyID
+
+	return validateOpeningHours(point.OpeningHours)
+}
+
+// sameCustodian reports whether a transfer would hand an item to whoever already holds it
+func sameCustodian(current *Custodian, transfer *models.CustodyTransfer) bool {
+	if current.Kind == CustodianOrganization {
+		return transfer.ToOrganizationID != nil && *transfer.ToOrganizationID == *current.OrganizationID &&
+			equalIDs(transfer.ToDropOffPointID, current.DropOffPointID)
+	}
+	return current.UserID != nil && isUser(transfer.ToUserID, *current.UserID)
+}
+
+// handedOverBy reports whether a transfer was recorded from whoever holds the item now
+func handedOverBy(current *Custodian, transfer *models.CustodyTransfer) bool {
+	if current.Kind == CustodianOrganization {
+		return equalIDs(transfer.FromOrganizationID, current.OrganizationID)
+	}
+	return transfer.FromOrganizationID == nil && equalIDs(transfer.FromUserID, current.UserID)
+}
+
+// isUser reports whether an optional user ID is the given user
+func isUser(id *uuid.UUID, userID uuid.UUID) bool {
+	return id != nil && *id == userID
+}
+
+// equalIDs reports whether two optional IDs are the same
+func equalIDs(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// nameOr returns name, or fallback when name is empty
+func nameOr(name, fallback string) string {
+	if name == "" {
+		return fallback
+	}
+	return name
+}
@@ -0,0 +1,284 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// duplicateWindow is how far apart two reports of the same item may be dated
+	duplicateWindow = 7 * 24 * time.Hour
+	// duplicateCandidateLimit bounds how many candidates are scored per item
+	duplicateCandidateLimit = 100
+	// minDuplicateScore is the lowest score reported as a possible duplicate
+	minDuplicateScore = 0.5
+	// maxDuplicates bounds how many possible duplicates are returned
+	maxDuplicates = 5
+	// maxMergeDuplicates bounds how many duplicates one merge takes in
+	maxMergeDuplicates = 20
+)
+
+var (
+	ErrMergeSelf     = errors.New("an item cannot be merged into itself")
+	ErrMergeMismatch = errors.New("only open reports of the same status and organisation can be merged")
+	ErrMergeEscrowed = errors.New("an item with a reward in escrow cannot be merged into another")
+)
+
+// DuplicateService finds reports that describe the same item and lets
+// moderators merge them
+type DuplicateService struct {
+	items   *repository.ItemRepository
+	escrows *repository.EscrowRepository
+}
+
+// NewDuplicateService creates a new DuplicateService
+func NewDuplicateService(items *repository.ItemRepository, escrows *repository.EscrowRepository) *DuplicateService {
+	return &DuplicateService{items: items, escrows: escrows}
+}
+
+// Find returns the existing reports most likely to describe the same item,
+// best first. It is registered as the item service's duplicate finder.
+func (s *DuplicateService) Find(item *models.Item) ([]models.DuplicateCandidate, error) {
+	date := item.Date
+	if date.IsZero() {
+		date = item.CreatedAt
+	}
+
+	var urls []string
+	for _, image := range item.Images {
+		urls = append(urls, image.URL)
+	}
+	hashes, err := s.items.ImageHashes(urls)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := s.items.DuplicateCandidates(item, urls, hashes, date.Add(-duplicateWindow), date.Add(duplicateWindow), duplicateCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]models.DuplicateCandidate, 0)
+	for _, candidate := range candidates {
+		score, reasons := scoreDuplicate(item, &candidate, date, urls, hashes)
+		if score < minDuplicateScore {
+			continue
+		}
+		duplicates = append(duplicates, models.DuplicateCandidate{
+			ItemID:  candidate.ID,
+			Title:   candidate.Title,
+			Status:  candidate.Status,
+			Score:   math.Round(score*100) / 100,
+			Reasons: reasons,
+			Own:     candidate.UserID == item.UserID,
+		})
+	}
+
+	sort.SliceStable(duplicates, func(i, j int) bool {
+		return duplicates[i].Score > duplicates[j].Score
+	})
+	if len(duplicates) > maxDuplicates {
+		duplicates = duplicates[:maxDuplicates]
+	}
+	return duplicates, nil
+}
+
+// ForItem returns the possible duplicates of an existing item, for moderators
+func (s *DuplicateService) ForItem(id uuid.UUID) ([]models.DuplicateCandidate, error) {
+	item, err := s.items.GetByID(id)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	return s.Find(item)
+}
+
+// Merge folds duplicate reports into a surviving item. Their images, tags,
+// claims and conversations move to the survivor, and the duplicates go to
+// the trash marked as merged. Each merge is written to the moderation audit log.
+func (s *DuplicateService) Merge(survivorID uuid.UUID, duplicateIDs []uuid.UUID, moderatorID uuid.UUID, note string) (*models.Item, error) {
+	if len(duplicateIDs) == 0 || len(duplicateIDs) > maxMergeDuplicates {
+		return nil, fmt.Errorf("duplicate_ids must list between 1 and %d items", maxMergeDuplicates)
+	}
+	survivor, err := s.items.GetByID(survivorID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+
+	seen := map[uuid.UUID]bool{}
+	var duplicates []*models.Item
+	for _, id := range duplicateIDs {
+		if id == survivorID {
+			return nil, ErrMergeSelf
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		duplicate, err := s.items.GetByID(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrItemNotFound, id)
+		}
+		if err := s.mergeable(survivor, duplicate); err != nil {
+			return nil, err
+		}
+		duplicates = append(duplicates, duplicate)
+	}
+
+	note = strings.TrimSpace(note)
+	if note == "" {
+		note = "merged into " + survivor.ID.String()
+	}
+	ids := make([]uuid.UUID, 0, len(duplicates))
+	actions := make([]models.ModerationAction, 0, len(duplicates))
+	for _, duplicate := range duplicates {
+		ids = append(ids, duplicate.ID)
+		actions = append(actions, models.ModerationAction{
+			ModeratorID:  moderatorID,
+			Action:       models.ModerationMerge,
+			SubjectType:  models.ReportSubjectItem,
+			SubjectID:    duplicate.ID,
+			TargetUserID: duplicate.UserID,
+			Note:         note,
+		})
+	}
+	if err := s.items.Merge(survivor.ID, ids, actions, time.Now()); err != nil {
+		if errors.Is(err, repository.ErrMergeEscrowActive) {
+			return nil, ErrMergeEscrowed
+		}
+		return nil, err
+	}
+
+	return s.items.GetByID(survivor.ID)
+}
+
+// mergeable checks a duplicate can be folded into a survivor
+func (s *DuplicateService) mergeable(survivor, duplicate *models.Item) error {
+	if duplicate.Status != survivor.Status || duplicate.IsResolved || survivor.IsResolved {
+		return ErrMergeMismatch
+	}
+	if (duplicate.OrganizationID == nil) != (survivor.OrganizationID == nil) ||
+		duplicate.OrganizationID != nil && *duplicate.OrganizationID != *survivor.OrganizationID {
+		return ErrMergeMismatch
+	}
+	if duplicate.RewardEscrowed {
+		return ErrMergeEscrowed
+	}
+	active, err := s.escrows.HasActive(duplicate.ID)
+	if err != nil {
+		return err
+	}
+	if active {
+		return ErrMergeEscrowed
+	}
+	return nil
+}
+
+// scoreDuplicate rates how likely a candidate is another report of the same
+// item, in [0, 1]. date is when the new item was lost or found; urls and
+// hashes identify its photos.
+func scoreDuplicate(item, candidate *models.Item, date time.Time, urls, hashes []string) (float64, []string) {
+	var score float64
+	var reasons []string
+
+	if sharesPhoto(candidate, urls, hashes) {
+		score += 0.5
+		reasons = append(reasons, "same photo")
+	}
+	if candidate.UserID == item.UserID {
+		score += 0.2
+		reasons = append(reasons, "same reporter")
+	}
+
+	if strings.EqualFold(strings.TrimSpace(item.Title), strings.TrimSpace(candidate.Title)) {
+		score += 0.15
+		reasons = append(reasons, "same title")
+	}
+	if overlap := wordOverlap(item.Title+" "+item.Description, candidate.Title+" "+candidate.Description); overlap > 0 {
+		score += 0.4 * overlap
+		if overlap >= 0.3 {
+			reasons = append(reasons, "similar description")
+		}
+	}
+
+	if item.CategoryID != nil && candidate.CategoryID != nil && *item.CategoryID == *candidate.CategoryID {
+		score += 0.1
+		reasons = append(reasons, "same category")
+	}
+	// Private attributes stay out of the score so a warning cannot confirm a hidden value
+	agree, disagree := comparePublicAttributes(item, candidate)
+	if agree > 0 {
+		score += math.Min(0.05*float64(agree), 0.15)
+		reasons = append(reasons, "matching attributes")
+	}
+	score -= 0.15 * float64(disagree)
+
+	switch km, ok := distanceKm(item, candidate); {
+	case ok && km <= 1:
+		score += 0.2
+		reasons = append(reasons, "within 1 km")
+	case ok && km <= 5:
+		score += 0.1
+		reasons = append(reasons, "within 5 km")
+	case ok:
+	case item.WardID != "" && item.WardID == candidate.WardID:
+		score += 0.15
+		reasons = append(reasons, "same ward")
+	case item.SubCountyID != "" && item.SubCountyID == candidate.SubCountyID:
+		score += 0.1
+		reasons = append(reasons, "same sub-county")
+	case item.CountyID != "" && item.CountyID == candidate.CountyID:
+		score += 0.05
+		reasons = append(reasons, "same county")
+	}
+
+	candidateDate := candidate.Date
+	if candidateDate.IsZero() {
+		candidateDate = candidate.CreatedAt
+	}
+	days := math.Abs(date.Sub(candidateDate).Hours()) / 24
+	score += 0.15 * math.Max(0, 1-days/(duplicateWindow.Hours()/24))
+	if sameDay(date, candidateDate) {
+		reasons = append(reasons, "same day")
+	}
+
+	return math.Max(0, math.Min(score, 1)), reasons
+}
+
+// sharesPhoto reports whether a candidate shows one of the given photos
+func sharesPhoto(candidate *models.Item, urls, hashes []string) bool {
+	for _, image := range candidate.Images {
+		for _, url := range urls {
+			if image.URL == url {
+				return true
+			}
+		}
+		for _, hash := range hashes {
+			if image.Hash != "" && image.Hash == hash {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// distanceKm is the great-circle distance between two items, when both have coordinates
+func distanceKm(a, b *models.Item) (float64, bool) {
+	if !a.HasCoordinates() || !b.HasCoordinates() {
+		return 0, false
+	}
+
+	const earthRadiusKm = 6371.0
+	lat1, lat2 := *a.Latitude*math.Pi/180, *b.Latitude*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (*b.Longitude - *a.Longitude) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h))), true
+}
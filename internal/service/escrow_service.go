@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/payments"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/sms"
+	"time"
+)
+
+const (
+	// minRewardCents and maxRewardCents bound a reward; M-Pesa moves whole
+	// shillings up to a per-transaction limit
+	minRewardCents = 10 * 100
+	maxRewardCents = 150000 * 100
+	// collectionQueryAfter is how long to wait for a collection callback before
+	// asking the provider directly
+	collectionQueryAfter = 2 * time.Minute
+	// collectionTimeout is how long a payer has to approve a payment before
+	// the reward is failed
+	collectionTimeout = time.Hour
+	// payoutQueryAfter is how long to wait for a payout result before asking
+	// the provider directly
+	payoutQueryAfter = 10 * time.Minute
+	// maxPayoutAttempts is how many failed payouts are retried before the
+	// reward is left for an administrator
+	maxPayoutAttempts = 5
+	// escrowPollInterval is how often the worker reconciles rewards
+	escrowPollInterval = time.Minute
+	// escrowBatchSize bounds how many rewards a reconciliation step handles
+	escrowBatchSize = 50
+)
+
+var (
+	ErrPaymentsUnavailable = errors.New("reward payments are not configured")
+	ErrEscrowNotFound      = errors.New("reward not found")
+	ErrRewardExists        = errors.New("this item already has a reward in escrow")
+	ErrRewardNotAllowed    = errors.New("only the owner of a lost item, or a claimant approved for a found item, can pay a reward")
+	ErrRewardOrganization  = errors.New("rewards cannot be paid for items held by organisations")
+	ErrRewardAmount        = fmt.Errorf("rewards must be whole shillings between KES %d and KES %d", minRewardCents/100, maxRewardCents/100)
+	ErrRewardPhone         = errors.New("a Kenyan M-Pesa number is required")
+	ErrDepositFailed       = errors.New("the payment could not be started; try again")
+	ErrPayoutNotStuck      = errors.New("only rewards with a payout in flight can be retried")
+)
+
+// EscrowService holds rewards in escrow: the owner pays in by mobile money,
+// and the money is paid out to the finder when the item is handed over or
+// back to the owner when the reward expires
+type EscrowService struct {
+	repo     *repository.EscrowRepository
+	provider payments.Provider
+	items    *ItemService
+	claims   *ClaimService
+	users    *repository.UserRepository
+	ttl      time.Duration
+	wake     chan struct{}
+}
+
+// NewEscrowService creates a new EscrowService. Rewards still held after
+// days are refunded. A nil provider turns rewards off.
+func NewEscrowService(repo *repository.EscrowRepository, provider payments.Provider, items *ItemService, claims *ClaimService, users *repository.UserRepository, days int) *EscrowService {
+	return &EscrowService{
+		repo:     repo,
+		provider: provider,
+		items:    items,
+		claims:   claims,
+		users:    users,
+		ttl:      time.Duration(days) * 24 * time.Hour,
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// Deposit asks the payer to pay a reward into escrow. The payer approves the
+// payment on their phone, and the reward is held once the provider confirms
+// it. An empty phone uses the number on the payer's account.
+func (s *EscrowService) Deposit(ctx context.Context, itemID, payerID uuid.UUID, amountCents int64, phone string) (*models.RewardEscrow, error) {
+	if s.provider == nil {
+		return nil, ErrPaymentsUnavailable
+	}
+	if _, whole := payments.Shillings(amountCents); !whole || amountCents < minRewardCents || amountCents > maxRewardCents {
+		return nil, ErrRewardAmount
+	}
+
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if err := s.mayPay(item, payerID); err != nil {
+		return nil, err
+	}
+	if phone == "" {
+		if payer, err := s.users.GetByID(payerID); err == nil {
+			phone = payer.Phone
+		}
+	}
+	phone, ok := sms.NormalizePhone(phone)
+	if !ok {
+		return nil, ErrRewardPhone
+	}
+
+	active, err := s.repo.HasActive(item.ID)
+	if err != nil {
+		return nil, err
+	}
+	if active {
+		return nil, ErrRewardExists
+	}
+
+	escrow := &models.RewardEscrow{
+		ItemID:      item.ID,
+		PayerID:     payerID,
+		PayerPhone:  phone,
+		AmountCents: amountCents,
+		Currency:    "KES",
+		Status:      models.EscrowStatusPending,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Create(escrow); err != nil {
+		if errors.Is(err, repository.ErrEscrowActive) {
+			return nil, ErrRewardExists
+		}
+		return nil, err
+	}
+
+	reference := escrow.ID.String()[:8]
+	if item.ReferenceCode != nil {
+		reference = *item.ReferenceCode
+	}
+	checkout, err := s.provider.Collect(ctx, payments.Collection{
+		Phone:       phone,
+		AmountCents: amountCents,
+		Reference:   reference,
+		Description: "Item reward",
+	})
+	if err != nil {
+		log.Printf("escrow: collection for reward %s failed: %v", escrow.ID, err)
+		escrow.Status, escrow.LastError = models.EscrowStatusFailed, err.Error()
+		if _, err := s.repo.Transition(escrow, models.EscrowStatusPending, nil); err != nil {
+			return nil, err
+		}
+		return nil, ErrDepositFailed
+	}
+
+	escrow.CheckoutReference = checkout
+	if err := s.repo.SetCheckout(escrow); err != nil {
+		return nil, err
+	}
+	return escrow, nil
+}
+
+// ForItem retrieves the latest reward for an item, for the payer, the item's
+// reporter, an approved claimant or an admin
+func (s *EscrowService) ForItem(itemID, actorID uuid.UUID, isAdmin bool) (*models.RewardEscrow, error) {
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	escrow, err := s.repo.LatestForItem(item.ID)
+	if err != nil {
+		return nil, ErrEscrowNotFound
+	}
+	if isAdmin || escrow.PayerID == actorID || item.UserID == actorID {
+		return escrow, nil
+	}
+	if approved, err := s.claims.HasApprovedClaim(item.ID, actorID); err == nil && approved {
+		return escrow, nil
+	}
+	return nil, ErrEscrowNotFound
+}
+
+// Get retrieves a reward with its ledger entries
+func (s *EscrowService) Get(id uuid.UUID) (*models.RewardEscrow, error) {
+	escrow, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrEscrowNotFound
+	}
+	return escrow, nil
+}
+
+// List retrieves rewards, optionally with one status
+func (s *EscrowService) List(status string, page, limit int) ([]models.RewardEscrow, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.List(status, page, limit)
+}
+
+// Reconciliation compares the ledger with what rewards hold
+func (s *EscrowService) Reconciliation() (*repository.EscrowReconciliation, error) {
+	return s.repo.Reconcile()
+}
+
+// CollectionResult applies a collection callback from the provider.
+// Repeated callbacks are ignored.
+func (s *EscrowService) CollectionResult(ctx context.Context, result *payments.Result) error {
+	escrow, err := s.repo.GetByCheckout(result.Reference)
+	if err != nil {
+		return ErrEscrowNotFound
+	}
+	return s.settleCollection(ctx, escrow, result)
+}
+
+// PayoutResult applies a payout callback from the provider. Repeated
+// callbacks, and results for earlier attempts, are ignored.
+func (s *EscrowService) PayoutResult(ctx context.Context, result *payments.Result) error {
+	escrow, err := s.repo.GetByPayout(result.Reference)
+	if err != nil {
+		return ErrEscrowNotFound
+	}
+	return s.settlePayout(escrow, result)
+}
+
+// ItemHandedOver releases the reward held for an item to the finder. It is
+// registered as a handover hook. The finder is whichever of the reporter and
+// the claimant did not pay.
+func (s *EscrowService) ItemHandedOver(code *models.HandoverCode, item *models.Item) {
+	escrow, err := s.repo.HeldForItem(item.ID)
+	if err != nil || escrow.Disbursement != "" {
+		return
+	}
+
+	payee := item.UserID
+	if escrow.PayerID == item.UserID {
+		payee = code.ClaimerID
+	}
+	escrow.Disbursement, escrow.PayeeID = models.EscrowDisbursementRelease, &payee
+	if _, err := s.repo.Transition(escrow, models.EscrowStatusHeld, nil); err != nil {
+		log.Printf("escrow: failed to release reward %s: %v", escrow.ID, err)
+		return
+	}
+	s.signal()
+}
+
+// RetryPayout returns a reward whose payout never got an answer to the
+// queue, so it is paid out again. Check with the provider that the first
+// payout did not go through before retrying.
+func (s *EscrowService) RetryPayout(id uuid.UUID) (*models.RewardEscrow, error) {
+	escrow, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrEscrowNotFound
+	}
+	from := escrow.Status
+	if from != models.EscrowStatusReleasing && from != models.EscrowStatusRefunding && !(from == models.EscrowStatusHeld && escrow.Disbursement != "") {
+		return nil, ErrPayoutNotStuck
+	}
+
+	escrow.Status, escrow.PayoutReference, escrow.PayoutAttempts = models.EscrowStatusHeld, "", 0
+	escrow.LastError = "payout retried by an administrator"
+	applied, err := s.repo.Transition(escrow, from, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !applied {
+		return nil, ErrPayoutNotStuck
+	}
+	s.signal()
+	return escrow, nil
+}
+
+// Run reconciles rewards until ctx is cancelled
+func (s *EscrowService) Run(ctx context.Context) {
+	if s.provider == nil {
+		return
+	}
+	ticker := time.NewTicker(escrowPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Reconcile(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("escrow: reconciliation failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Reconcile asks the provider about collections and payouts whose callbacks
+// have not arrived, refunds expired rewards and pays out rewards waiting to
+// be paid. It returns how many rewards it moved on.
+func (s *EscrowService) Reconcile(ctx context.Context) (int, error) {
+	if s.provider == nil {
+		return 0, nil
+	}
+	now := time.Now()
+	moved := 0
+
+	pending, err := s.repo.ListStale(models.EscrowStatusPending, now.Add(-collectionQueryAfter), escrowBatchSize)
+	if err != nil {
+		return moved, err
+	}
+	for i := range pending {
+		escrow := &pending[i]
+		result, err := s.collectionStatus(ctx, escrow, now)
+		if err != nil {
+			log.Printf("escrow: status of collection for reward %s: %v", escrow.ID, err)
+			continue
+		}
+		if result.Status != payments.StatusPending {
+			if err := s.settleCollection(ctx, escrow, result); err != nil {
+				return moved, err
+			}
+			moved++
+		}
+	}
+
+	for _, status := range []models.EscrowStatus{models.EscrowStatusReleasing, models.EscrowStatusRefunding} {
+		inFlight, err := s.repo.ListStale(status, now.Add(-payoutQueryAfter), escrowBatchSize)
+		if err != nil {
+			return moved, err
+		}
+		for i := range inFlight {
+			escrow := &inFlight[i]
+			result, err := s.provider.PayoutStatus(ctx, escrow.PayoutReference)
+			if err != nil {
+				log.Printf("escrow: status of payout for reward %s: %v", escrow.ID, err)
+				continue
+			}
+			if result.Status != payments.StatusPending {
+				if err := s.settlePayout(escrow, result); err != nil {
+					return moved, err
+				}
+				moved++
+			}
+		}
+	}
+
+	expired, err := s.repo.ListExpired(now, escrowBatchSize)
+	if err != nil {
+		return moved, err
+	}
+	for i := range expired {
+		escrow := &expired[i]
+		escrow.Disbursement, escrow.PayeeID = models.EscrowDisbursementRefund, &escrow.PayerID
+		if _, err := s.repo.Transition(escrow, models.EscrowStatusHeld, nil); err != nil {
+			return moved, err
+		}
+	}
+
+	due, err := s.repo.ListAwaitingPayout(maxPayoutAttempts, escrowBatchSize)
+	if err != nil {
+		return moved, err
+	}
+	for i := range due {
+		if ctx.Err() != nil {
+			return moved, ctx.Err()
+		}
+		if err := s.disburse(ctx, &due[i]); err != nil {
+			return moved, err
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// collectionStatus asks the provider how a collection ended, failing it when
+// the payer has had long enough to answer
+func (s *EscrowService) collectionStatus(ctx context.Context, escrow *models.RewardEscrow, now time.Time) (*payments.Result, error) {
+	expired := now.Sub(escrow.CreatedAt) > collectionTimeout
+	if escrow.CheckoutReference == "" {
+		if !expired {
+			return &payments.Result{Status: payments.StatusPending}, nil
+		}
+		return &payments.Result{Status: payments.StatusFailed, Description: "the payment was never started"}, nil
+	}
+
+	result, err := s.provider.CollectionStatus(ctx, escrow.CheckoutReference, escrow.AmountCents)
+	if err != nil {
+		return nil, err
+	}
+	if result.Status == payments.StatusPending && expired {
+		result.Status, result.Description = payments.StatusFailed, "the payment was not confirmed in time"
+	}
+	return result, nil
+}
+
+// settleCollection holds a reward once it is paid in, or fails it. A
+// success is only believed once the provider confirms it. A payment of the
+// wrong amount fails the reward and what arrived is refunded to the payer.
+func (s *EscrowService) settleCollection(ctx context.Context, escrow *models.RewardEscrow, result *payments.Result) error {
+	if escrow.Status != models.EscrowStatusPending {
+		return nil
+	}
+
+	switch result.Status {
+	case payments.StatusSucceeded:
+		// Only what the provider confirms is recorded; the callback just says
+		// when to ask
+		confirmed, err := s.provider.CollectionStatus(ctx, escrow.CheckoutReference, escrow.AmountCents)
+		if err != nil {
+			return err
+		}
+		if confirmed.Status != payments.StatusSucceeded {
+			// Reconciliation settles it once the provider has an answer
+			log.Printf("escrow: provider reports collection for reward %s as %s", escrow.ID, confirmed.Status)
+			return nil
+		}
+		if confirmed.AmountCents <= 0 {
+			// Without a confirmed amount there is nothing safe to hold or refund
+			log.Printf("escrow: provider did not confirm the amount paid in for reward %s", escrow.ID)
+			escrow.Status, escrow.LastError = models.EscrowStatusFailed, "the provider did not confirm the amount paid in; check with the provider"
+			_, err := s.repo.Transition(escrow, models.EscrowStatusPending, nil)
+			return err
+		}
+
+		now := time.Now()
+		escrow.Status, escrow.Receipt, escrow.HeldAt = models.EscrowStatusHeld, confirmed.Receipt, &now
+		if confirmed.AmountCents != escrow.AmountCents {
+			escrow.LastError = fmt.Sprintf("provider reported %d cents paid in instead of %d; refunding", confirmed.AmountCents, escrow.AmountCents)
+			escrow.AmountCents = confirmed.AmountCents
+			escrow.Disbursement, escrow.PayeeID = models.EscrowDisbursementRefund, &escrow.PayerID
+		}
+		entry := &models.LedgerEntry{EscrowID: escrow.ID, Kind: models.LedgerEntryDeposit, AmountCents: escrow.AmountCents, Receipt: confirmed.Receipt}
+		applied, err := s.repo.Transition(escrow, models.EscrowStatusPending, entry)
+		if err != nil || !applied {
+			return err
+		}
+		if escrow.Disbursement == models.EscrowDisbursementRefund {
+			s.signal()
+			return nil
+		}
+		if err := s.items.SetReward(escrow.ItemID, escrow.AmountCents, true); err != nil {
+			log.Printf("escrow: failed to show reward %s on item %s: %v", escrow.ID, escrow.ItemID, err)
+		}
+		return nil
+	case payments.StatusFailed:
+		escrow.Status, escrow.LastError = models.EscrowStatusFailed, result.Description
+		_, err := s.repo.Transition(escrow, models.EscrowStatusPending, nil)
+		return err
+	}
+	return nil
+}
+
+// settlePayout completes a release or refund once it is paid out. A failed
+// payout goes back to held so it is tried again.
+func (s *EscrowService) settlePayout(escrow *models.RewardEscrow, result *payments.Result) error {
+	from := escrow.Status
+	if from != models.EscrowStatusReleasing && from != models.EscrowStatusRefunding {
+		return nil
+	}
+
+	switch result.Status {
+	case payments.StatusSucceeded:
+		now := time.Now()
+		status, kind := models.EscrowStatusReleased, models.LedgerEntryRelease
+		if from == models.EscrowStatusRefunding {
+			status, kind = models.EscrowStatusRefunded, models.LedgerEntryRefund
+		}
+		escrow.Status, escrow.PayoutReceipt, escrow.SettledAt, escrow.LastError = status, result.Receipt, &now, ""
+		entry := &models.LedgerEntry{EscrowID: escrow.ID, Kind: kind, AmountCents: -escrow.AmountCents, Receipt: result.Receipt}
+		applied, err := s.repo.Transition(escrow, from, entry)
+		if err != nil || !applied {
+			return err
+		}
+		if status == models.EscrowStatusRefunded {
+			if err := s.items.SetReward(escrow.ItemID, escrow.AmountCents, false); err != nil {
+				log.Printf("escrow: failed to clear reward %s from item %s: %v", escrow.ID, escrow.ItemID, err)
+			}
+		}
+		return nil
+	case payments.StatusFailed:
+		escrow.Status, escrow.PayoutReference, escrow.LastError = models.EscrowStatusHeld, "", result.Description
+		applied, err := s.repo.Transition(escrow, from, nil)
+		if err == nil && applied {
+			s.signal()
+		}
+		return err
+	}
+	return nil
+}
+
+// disburse starts the payout of a held reward. A payout the provider refuses
+// stays held to be tried again; one whose outcome is unknown stays in flight
+// until the provider answers, so the money is never sent twice.
+func (s *EscrowService) disburse(ctx context.Context, escrow *models.RewardEscrow) error {
+	phone := escrow.PayerPhone
+	if escrow.Disbursement == models.EscrowDisbursementRelease {
+		phone = ""
+		if escrow.PayeeID != nil {
+			if payee, err := s.users.GetByID(*escrow.PayeeID); err == nil {
+				phone, _ = sms.NormalizePhone(payee.Phone)
+			}
+		}
+		if phone == "" {
+			// Wait for the finder to add an M-Pesa number to their account
+			if escrow.LastError != "the finder has no M-Pesa number" {
+				escrow.LastError = "the finder has no M-Pesa number"
+				_, err := s.repo.Transition(escrow, models.EscrowStatusHeld, nil)
+				return err
+			}
+			return nil
+		}
+	}
+
+	status := models.EscrowStatusReleasing
+	remarks := "Reward for returning a lost item"
+	if escrow.Disbursement == models.EscrowDisbursementRefund {
+		status, remarks = models.EscrowStatusRefunding, "Refund of an unclaimed item reward"
+	}
+	escrow.Status, escrow.PayeePhone = status, phone
+	escrow.PayoutReference = uuid.NewString()
+	escrow.PayoutAttempts++
+	applied, err := s.repo.Transition(escrow, models.EscrowStatusHeld, nil)
+	if err != nil || !applied {
+		return err
+	}
+
+	err = s.provider.Pay(ctx, payments.Payout{
+		ID:          escrow.PayoutReference,
+		Phone:       phone,
+		AmountCents: escrow.AmountCents,
+		Remarks:     remarks,
+	})
+	if err == nil {
+		return nil
+	}
+
+	log.Printf("escrow: payout for reward %s failed: %v", escrow.ID, err)
+	if !errors.Is(err, payments.ErrRejected) {
+		// The provider may still have the request; wait for its result
+		return nil
+	}
+	escrow.Status, escrow.PayoutReference, escrow.LastError = models.EscrowStatusHeld, "", err.Error()
+	_, err = s.repo.Transition(escrow, status, nil)
+	return err
+}
+
+// mayPay checks the payer owns the item: its reporter unless they reported
+// finding it, or an approved claimant. Approving a claim marks the item
+// claimed, so either may pay once a claim is approved.
+func (s *EscrowService) mayPay(item *models.Item, payerID uuid.UUID) error {
+	if item.OrganizationID != nil {
+		return ErrRewardOrganization
+	}
+	if item.IsResolved || item.Status == models.ItemStatusReturned {
+		return ErrItemAlreadyReturned
+	}
+	if item.UserID == payerID {
+		if item.Status == models.ItemStatusFound {
+			return ErrRewardNotAllowed
+		}
+		return nil
+	}
+	approved, err := s.claims.HasApprovedClaim(item.ID, payerID)
+	if err != nil {
+		return err
+	}
+	if !approved {
+		return ErrRewardNotAllowed
+	}
+	return nil
+}
+
+// signal wakes the worker without blocking
+func (s *EscrowService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
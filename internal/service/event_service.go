@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/notify"
+	"lostnfound-api/internal/realtime"
+	"time"
+)
+
+const (
+	// minLiveMatchScore is the lowest match score announced in real time
+	minLiveMatchScore = 0.5
+	// maxLiveMatches bounds how many matches are announced for a new item
+	maxLiveMatches = 5
+)
+
+// matchEvent is the payload of an item.match event
+type matchEvent struct {
+	ItemID        uuid.UUID `json:"item_id"`
+	MatchedItemID uuid.UUID `json:"matched_item_id"`
+	Title         string    `json:"title"`
+	Score         float64   `json:"score"`
+	Reasons       []string  `json:"reasons"`
+}
+
+// claimEvent is the payload of the claim events
+type claimEvent struct {
+	ClaimID   uuid.UUID          `json:"claim_id"`
+	ItemID    uuid.UUID          `json:"item_id"`
+	ItemTitle string             `json:"item_title"`
+	Status    models.ClaimStatus `json:"status"`
+}
+
+// messageEvent is the payload of a message.created event
+type messageEvent struct {
+	ConversationID uuid.UUID `json:"conversation_id"`
+	MessageID      uuid.UUID `json:"message_id"`
+	SenderID       uuid.UUID `json:"sender_id"`
+	Preview        string    `json:"preview"`
+}
+
+// savedSearchEvent is the payload of a saved_search.match event
+type savedSearchEvent struct {
+	SearchID uuid.UUID          `json:"search_id"`
+	Name     string             `json:"name"`
+	Digest   bool               `json:"digest"`
+	Items    []savedSearchMatch `json:"items"`
+}
+
+// savedSearchMatch summarises an item in a saved search alert
+type savedSearchMatch struct {
+	ItemID   uuid.UUID         `json:"item_id"`
+	Title    string            `json:"title"`
+	Status   models.ItemStatus `json:"status"`
+	CountyID string            `json:"county_id,omitempty"`
+}
+
+// assetFoundEvent is the payload of an asset.found notification
+type assetFoundEvent struct {
+	AssetID   uuid.UUID        `json:"asset_id"`
+	AssetType models.AssetType `json:"asset_type"`
+	Brand     string           `json:"brand"`
+	ModelName string           `json:"model_name"`
+	ItemID    uuid.UUID        `json:"item_id"`
+	ItemTitle string           `json:"item_title"`
+}
+
+// moderationEvent is the payload of a moderation.action notification
+type moderationEvent struct {
+	ActionID  uuid.UUID                   `json:"action_id"`
+	Action    models.ModerationActionType `json:"action"`
+	Reason    models.ReportReason         `json:"reason"`
+	ItemID    *uuid.UUID                  `json:"item_id,omitempty"`
+	ItemTitle string                      `json:"item_title,omitempty"`
+}
+
+// recoveryReportEvent is the payload of a recovery.report notification
+type recoveryReportEvent struct {
+	TagID    uuid.UUID `json:"tag_id"`
+	ReportID uuid.UUID `json:"report_id"`
+	ItemID   uuid.UUID `json:"item_id"`
+	Label    string    `json:"label"`
+	Message  string    `json:"message"`
+	Location string    `json:"location"`
+}
+
+// itemReminderEvent is the payload of an item.reminder notification
+type itemReminderEvent struct {
+	ItemID    uuid.UUID         `json:"item_id"`
+	Title     string            `json:"title"`
+	Status    models.ItemStatus `json:"status"`
+	Days      int               `json:"days"`
+	ArchiveOn string            `json:"archive_on"`
+}
+
+// itemArchivedEvent is the payload of an item.archived notification
+type itemArchivedEvent struct {
+	ItemID uuid.UUID `json:"item_id"`
+	Title  string    `json:"title"`
+}
+
+// disposalDueEvent is the payload of a custody.disposal_due notification
+type disposalDueEvent struct {
+	OrganizationID uuid.UUID         `json:"organization_id"`
+	Organization   string            `json:"organization"`
+	Items          []disposalDueItem `json:"items"`
+}
+
+// disposalDueItem is a held item in a custody.disposal_due notification
+type disposalDueItem struct {
+	ItemID          uuid.UUID `json:"item_id"`
+	Title           string    `json:"title"`
+	StorageLocation string    `json:"storage_location,omitempty"`
+	Deadline        string    `json:"deadline"`
+}
+
+// noticeDateFormat is how dates are written in notifications
+const noticeDateFormat = "2 Jan 2006"
+
+// noticeZone is the time zone dates in notifications are given in
+var noticeZone = time.FixedZone("EAT", 3*60*60)
+
+// MatchHook is called for each likely match announced for a new item
+type MatchHook func(itemID uuid.UUID, match Match)
+
+// EventService turns item, claim and message changes into real-time events
+// and notifications for the users they concern
+type EventService struct {
+	broker        realtime.Broker
+	notifications *NotificationService
+	matches       *MatchService
+	items         *ItemService
+	afterMatch    []MatchHook
+}
+
+// NewEventService creates a new EventService
+func NewEventService(broker realtime.Broker, notifications *NotificationService, matches *MatchService, items *ItemService) *EventService {
+	return &EventService{broker: broker, notifications: notifications, matches: matches, items: items}
+}
+
+// AfterMatch registers a hook that runs for every match announced for a new item
+func (s *EventService) AfterMatch(hook MatchHook) {
+	s.afterMatch = append(s.afterMatch, hook)
+}
+
+// Subscribe opens a user's event stream, resuming after lastEventID if given
+func (s *EventService) Subscribe(ctx context.Context, userID uuid.UUID, lastEventID string) (*realtime.Subscription, error) {
+	if !realtime.ValidID(lastEventID) {
+		lastEventID = ""
+	}
+	return s.broker.Subscribe(ctx, userID, lastEventID)
+}
+
+// ItemCreated announces likely matches for a new item to its reporter and to
+// the reporters of the matching items. Matching runs in the background so
+// saving the item is not slowed down.
+func (s *EventService) ItemCreated(item *models.Item) {
+	go func(itemID, ownerID uuid.UUID, title string) {
+		matches, err := s.matches.FindMatches(itemID, maxLiveMatches)
+		if err != nil {
+			log.Printf("realtime: failed to find matches for item %s: %v", itemID, err)
+			return
+		}
+
+		for _, match := range matches {
+			if match.Score < minLiveMatchScore {
+				continue
+			}
+			s.publish(ownerID, realtime.EventItemMatch, matchEvent{
+				ItemID:        itemID,
+				MatchedItemID: match.Item.ID,
+				Title:         match.Item.Title,
+				Score:         match.Score,
+				Reasons:       match.Reasons,
+			})
+			s.publish(match.Item.UserID, realtime.EventItemMatch, matchEvent{
+				ItemID:        match.Item.ID,
+				MatchedItemID: itemID,
+				Title:         title,
+				Score:         match.Score,
+				Reasons:       match.Reasons,
+			})
+			for _, hook := range s.afterMatch {
+				hook(itemID, match)
+			}
+		}
+	}(item.ID, item.UserID, item.Title)
+}
+
+// ClaimChanged tells the item owner about new claims and the claimer about decisions
+func (s *EventService) ClaimChanged(claim *models.Claim) {
+	item, err := s.items.GetByID(claim.ItemID)
+	if err != nil {
+		return
+	}
+
+	payload := claimEvent{ClaimID: claim.ID, ItemID: claim.ItemID, ItemTitle: item.Title, Status: claim.Status}
+	if claim.Status != models.ClaimStatusPending {
+		s.publish(claim.ClaimerID, realtime.EventClaimStatusChanged, payload)
+	}
+	switch claim.Status {
+	case models.ClaimStatusPending:
+		s.publish(item.UserID, realtime.EventClaimCreated, payload)
+	case models.ClaimStatusWithdrawn:
+		s.publish(item.UserID, realtime.EventClaimStatusChanged, payload)
+	}
+}
+
+// MessageSent tells the recipient about a new message
+func (s *EventService) MessageSent(conversation *models.Conversation, message *models.Message) {
+	s.publish(conversation.OtherParty(message.SenderID), realtime.EventMessageCreated, messageEvent{
+		ConversationID: conversation.ID,
+		MessageID:      message.ID,
+		SenderID:       message.SenderID,
+		Preview:        truncate(message.Body, 80),
+	})
+}
+
+// SavedSearchMatched alerts a user that items matched one of their saved
+// searches, either as they arrive or as a daily digest
+func (s *EventService) SavedSearchMatched(search *models.SavedSearch, items []models.Item, digest bool) {
+	payload := savedSearchEvent{SearchID: search.ID, Name: search.Name, Digest: digest}
+	for _, item := range items {
+		payload.Items = append(payload.Items, savedSearchMatch{
+			ItemID:   item.ID,
+			Title:    item.Title,
+			Status:   item.Status,
+			CountyID: item.CountyID,
+		})
+	}
+	s.publish(search.UserID, realtime.EventSavedSearchMatch, payload)
+}
+
+// AssetFound notifies an owner that a found item matches a registered asset
+func (s *EventService) AssetFound(asset *models.RegisteredAsset, item *models.Item) {
+	s.notifications.Notify(asset.UserID, notify.EventAssetFound, assetFoundEvent{
+		AssetID:   asset.ID,
+		AssetType: asset.Type,
+		Brand:     asset.Brand,
+		ModelName: asset.ModelName,
+		ItemID:    item.ID,
+		ItemTitle: item.Title,
+	})
+}
+
+// RecoveryReported notifies an owner that a finder scanned one of their tags
+func (s *EventService) RecoveryReported(tag *models.RecoveryTag, report *models.RecoveryReport) {
+	s.notifications.Notify(tag.UserID, notify.EventRecoveryReport, recoveryReportEvent{
+		TagID:    tag.ID,
+		ReportID: report.ID,
+		ItemID:   report.ItemID,
+		Label:    tagTitle(tag),
+		Message:  truncate(report.Message, 160),
+		Location: report.Location,
+	})
+}
+
+// ModerationActioned tells a user a moderator hid or removed something they
+// posted, warned them or banned them
+func (s *EventService) ModerationActioned(action *models.ModerationAction, item *models.Item) {
+	event := moderationEvent{
+		ActionID: action.ID,
+		Action:   action.Action,
+		Reason:   action.Reason,
+	}
+	if item != nil {
+		event.ItemID, event.ItemTitle = &item.ID, item.Title
+	}
+	s.notifications.Notify(action.TargetUserID, notify.EventModerationAction, event)
+}
+
+// ItemReminder asks a reporter to confirm an item is still lost or unclaimed.
+// days is how long ago it was reported or last confirmed; archiveOn is when
+// it will be archived if they do not.
+func (s *EventService) ItemReminder(item *models.Item, days int, archiveOn time.Time) {
+	s.notifications.Notify(item.UserID, notify.EventItemReminder, itemReminderEvent{
+		ItemID:    item.ID,
+		Title:     item.Title,
+		Status:    item.Status,
+		Days:      days,
+		ArchiveOn: archiveOn.In(noticeZone).Format(noticeDateFormat),
+	})
+}
+
+// ItemArchived tells a reporter their unconfirmed item was archived
+func (s *EventService) ItemArchived(item *models.Item) {
+	s.notifications.Notify(item.UserID, notify.EventItemArchived, itemArchivedEvent{ItemID: item.ID, Title: item.Title})
+}
+
+// DisposalDue warns an organisation's admins that the legal holding period
+// of items it holds is ending. Items must have their custody loaded; the
+// period runs for holdFor from when each was received.
+func (s *EventService) DisposalDue(organization *models.Organization, admins []uuid.UUID, items []models.Item, holdFor time.Duration) {
+	event := disposalDueEvent{OrganizationID: organization.ID, Organization: organization.Name}
+	for _, item := range items {
+		event.Items = append(event.Items, disposalDueItem{
+			ItemID:          item.ID,
+			Title:           item.Title,
+			StorageLocation: item.Custody.StorageLocation,
+			Deadline:        item.Custody.ReceivedAt.Add(holdFor).In(noticeZone).Format(noticeDateFormat),
+		})
+	}
+	for _, userID := range admins {
+		s.notifications.Notify(userID, notify.EventDisposalDue, event)
+	}
+}
+
+// publish sends an event to the user's stream and queues its notifications,
+// logging failures rather than failing the caller
+func (s *EventService) publish(userID uuid.UUID, eventType string, data any) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.broker.Publish(ctx, userID, eventType, data); err != nil {
+		log.Printf("realtime: failed to publish %s to user %s: %v", eventType, userID, err)
+	}
+	s.notifications.Notify(userID, eventType, data)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"time"
+)
+
+const (
+	// expiryBatchSize bounds how many items each run reminds, archives or warns about
+	expiryBatchSize = 200
+	// archiveNotice is the least time between reminding a reporter and
+	// archiving their item
+	archiveNotice = 7 * 24 * time.Hour
+	// disposalNotice is how long before the end of the legal holding period
+	// organisations are warned
+	disposalNotice = 14 * 24 * time.Hour
+)
+
+// ErrItemResolved is returned when confirming an item that was already resolved
+var ErrItemResolved = errors.New("item has already been resolved")
+
+// ExpiryService keeps listings current. It asks reporters to confirm their
+// items are still lost or unclaimed, archives the items they do not confirm
+// and warns organisations before the legal holding period of items they hold
+// ends.
+type ExpiryService struct {
+	items         *repository.ItemRepository
+	categories    *repository.CategoryRepository
+	organizations *repository.OrganizationRepository
+	events        *EventService
+	remindAfter   time.Duration
+	archiveDays   int
+	holdFor       time.Duration
+}
+
+// NewExpiryService creates a new ExpiryService. Reporters are reminded after
+// reminderDays and items archived after archiveDays unless their category
+// sets its own period; organisations must hold items for disposalDays.
+func NewExpiryService(items *repository.ItemRepository, categories *repository.CategoryRepository, organizations *repository.OrganizationRepository, events *EventService, reminderDays, archiveDays, disposalDays int) *ExpiryService {
+	return &ExpiryService{
+		items:         items,
+		categories:    categories,
+		organizations: organizations,
+		events:        events,
+		remindAfter:   time.Duration(reminderDays) * 24 * time.Hour,
+		archiveDays:   archiveDays,
+		holdFor:       time.Duration(disposalDays) * 24 * time.Hour,
+	}
+}
+
+// Confirm records that an item's reporter confirmed it is still lost or
+// unclaimed, which lists it again if it was archived. With resolved set the
+// reporter says it no longer is: the item is marked resolved and archived.
+func (s *ExpiryService) Confirm(id, userID uuid.UUID, isAdmin, resolved bool) (*models.Item, error) {
+	item, err := s.items.GetByID(id)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if item.UserID != userID && !isAdmin {
+		return nil, ErrNotAuthorizedItem
+	}
+	if item.IsResolved {
+		return nil, ErrItemResolved
+	}
+
+	if err := s.items.Confirm(id, time.Now(), resolved); err != nil {
+		return nil, err
+	}
+	return s.items.GetByID(id)
+}
+
+// SendReminders asks the reporters of items unconfirmed for the reminder
+// period whether they are still lost or unclaimed, and returns how many
+// reminders were sent
+func (s *ExpiryService) SendReminders(ctx context.Context) (int, error) {
+	now := time.Now()
+	items, err := s.items.DueForReminder(now.Add(-s.remindAfter), expiryBatchSize)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+	periods, err := s.archivePeriods()
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for i := range items {
+		if ctx.Err() != nil {
+			break
+		}
+		since := confirmedAt(&items[i])
+		archiveOn := since.AddDate(0, 0, s.archivePeriod(periods, items[i].CategoryID))
+		if earliest := now.Add(archiveNotice); archiveOn.Before(earliest) {
+			archiveOn = earliest
+		}
+		s.events.ItemReminder(&items[i], int(now.Sub(since).Hours()/24), archiveOn)
+		ids = append(ids, items[i].ID)
+	}
+
+	if len(ids) == 0 {
+		return 0, ctx.Err()
+	}
+	return len(ids), s.items.MarkReminded(ids, now)
+}
+
+// ArchiveStale archives items unconfirmed for their category's archive
+// period whose reporters were reminded and did not answer, and returns how
+// many were archived
+func (s *ExpiryService) ArchiveStale(ctx context.Context) (int, error) {
+	periods, err := s.archivePeriods()
+	if err != nil {
+		return 0, err
+	}
+
+	// Categories with their own period are archived group by group; every
+	// other item uses the platform default
+	groups := map[int][]uuid.UUID{}
+	var own []uuid.UUID
+	for id, days := range periods {
+		if days != s.archiveDays {
+			groups[days] = append(groups[days], id)
+			own = append(own, id)
+		}
+	}
+
+	archived := 0
+	for days, categoryIDs := range groups {
+		n, err := s.archive(categoryIDs, false, days)
+		archived += n
+		if err != nil {
+			return archived, err
+		}
+		if ctx.Err() != nil {
+			return archived, ctx.Err()
+		}
+	}
+	n, err := s.archive(own, true, s.archiveDays)
+	return archived + n, err
+}
+
+// archive archives one group of due items and tells their reporters
+func (s *ExpiryService) archive(categoryIDs []uuid.UUID, others bool, days int) (int, error) {
+	now := time.Now()
+	items, err := s.items.DueForArchive(categoryIDs, others, now.AddDate(0, 0, -days), now.Add(-archiveNotice), expiryBatchSize)
+	if err != nil || len(items) == 0 {
+		return 0, err
+	}
+
+	ids := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	archived, err := s.items.Archive(ids, now)
+	if err != nil {
+		return 0, err
+	}
+	for i := range items {
+		s.events.ItemArchived(&items[i])
+	}
+	return int(archived), nil
+}
+
+// WarnDisposals tells organisations which of the items they hold reach the
+// end of their legal holding period within the notice period, and returns
+// how many items they were warned about
+func (s *ExpiryService) WarnDisposals(ctx context.Context) (int, error) {
+	now := time.Now()
+	items, err := s.organizations.DueForDisposal(now.Add(disposalNotice-s.holdFor), expiryBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	byOrganization := map[uuid.UUID][]models.Item{}
+	for _, item := range items {
+		byOrganization[item.Custody.OrganizationID] = append(byOrganization[item.Custody.OrganizationID], item)
+	}
+
+	warned := 0
+	for organizationID, held := range byOrganization {
+		if ctx.Err() != nil {
+			return warned, ctx.Err()
+		}
+
+		organization, err := s.organizations.GetByID(organizationID)
+		if err != nil {
+			log.Printf("expiry: organisation %s holding items not found: %v", organizationID, err)
+			continue
+		}
+		members, err := s.organizations.ListMembers(organizationID)
+		if err != nil {
+			return warned, err
+		}
+		var admins []uuid.UUID
+		for _, member := range members {
+			if member.Role == models.OrganizationRoleAdmin {
+				admins = append(admins, member.UserID)
+			}
+		}
+		s.events.DisposalDue(organization, admins, held, s.holdFor)
+
+		ids := make([]uuid.UUID, 0, len(held))
+		for _, item := range held {
+			ids = append(ids, item.ID)
+		}
+		if err := s.organizations.MarkDisposalWarned(ids, now); err != nil {
+			return warned, err
+		}
+		warned += len(held)
+	}
+	return warned, nil
+}
+
+// archivePeriods returns the archive period in days of every category. A
+// category without its own period inherits its parent's, and top-level
+// categories fall back to the platform default.
+func (s *ExpiryService) archivePeriods() (map[uuid.UUID]int, error) {
+	// Categories come ordered by path, so parents are resolved before their children
+	categories, err := s.categories.List()
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make(map[uuid.UUID]int, len(categories))
+	for _, category := range categories {
+		days := category.ArchiveAfterDays
+		if days <= 0 {
+			days = s.archiveDays
+			if category.ParentID != nil {
+				if inherited, ok := periods[*category.ParentID]; ok {
+					days = inherited
+				}
+			}
+		}
+		periods[category.ID] = days
+	}
+	return periods, nil
+}
+
+// archivePeriod returns the archive period in days of items in a category
+func (s *ExpiryService) archivePeriod(periods map[uuid.UUID]int, categoryID *uuid.UUID) int {
+	if categoryID != nil {
+		if days, ok := periods[*categoryID]; ok {
+			return days
+		}
+	}
+	return s.archiveDays
+}
+
+// confirmedAt is when an item's reporter last confirmed it, or when it was created
+func confirmedAt(item *models.Item) time.Time {
+	if item.ConfirmedAt != nil {
+		return *item.ConfirmedAt
+	}
+	return item.CreatedAt
+}
@@ -0,0 +1,423 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"lostnfound-api/internal/export"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"time"
+)
+
+const (
+	// exportBatchSize is how many items are loaded from the database at a time
+	exportBatchSize = 500
+	// maxStreamedExportRows bounds exports streamed straight to the client;
+	// larger ones are queued as background jobs
+	maxStreamedExportRows = 10000
+	// exportRetention is how long a finished export can be downloaded
+	exportRetention = 7 * 24 * time.Hour
+	// exportLinkLifetime is how long a download link works
+	exportLinkLifetime = 15 * time.Minute
+	// exportLease is how long a worker may spend on an export before another
+	// worker assumes it stopped and picks the export up again
+	exportLease = 30 * time.Minute
+	// maxExportAttempts is how many times an export is picked up before it is failed
+	maxExportAttempts = 3
+	// exportPollInterval is how often the worker looks for queued exports
+	exportPollInterval = 10 * time.Second
+)
+
+var (
+	ErrExportNotFound    = errors.New("export not found")
+	ErrExportForbidden   = errors.New("only administrators can export every item")
+	ErrExportTooLarge    = fmt.Errorf("exports of more than %d items must be queued as a background export", maxStreamedExportRows)
+	ErrExportNotReady    = errors.New("the export has not finished")
+	ErrExportExpired     = errors.New("the export has expired; queue it again")
+	ErrExportUnavailable = errors.New("the export failed; queue it again")
+)
+
+// ExportRequest describes an export of items
+type ExportRequest struct {
+	Format export.Format
+	Filter repository.ItemFilter
+	// OrganizationID limits the export to an organisation's items; nil exports every item
+	OrganizationID *uuid.UUID
+	// Public redacts the export as for the public even when the role allows more
+	Public bool
+}
+
+// ExportService exports items as CSV, NDJSON or GeoJSON, streamed to the
+// client or written to storage in the background
+type ExportService struct {
+	repo          *repository.ExportRepository
+	items         *ItemService
+	organizations *OrganizationService
+	storage       *StorageService
+	wake          chan struct{}
+}
+
+// NewExportService creates a new ExportService
+func NewExportService(repo *repository.ExportRepository, items *ItemService, organizations *OrganizationService, storage *StorageService) *ExportService {
+	return &ExportService{
+		repo:          repo,
+		items:         items,
+		organizations: organizations,
+		storage:       storage,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// Stream writes an export straight to the client. open is called once the
+// request is authorised, to set the response headers and return the body.
+func (s *ExportService) Stream(req ExportRequest, actorID uuid.UUID, isAdmin bool, open func(filename string) io.Writer) (int, error) {
+	redaction, err := s.authorize(&req, actorID, isAdmin)
+	if err != nil {
+		return 0, err
+	}
+	count, err := s.items.Count(req.Filter)
+	if err != nil {
+		return 0, err
+	}
+	if count > maxStreamedExportRows {
+		return 0, ErrExportTooLarge
+	}
+
+	return s.write(open(exportFileName(req, time.Now())), req, redaction)
+}
+
+// Queue starts a background export
+func (s *ExportService) Queue(req ExportRequest, actorID uuid.UUID, isAdmin bool) (*models.ExportJob, error) {
+	redaction, err := s.authorize(&req, actorID, isAdmin)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := filterToMap(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &models.ExportJob{
+		RequestedByID:  actorID,
+		OrganizationID: req.OrganizationID,
+		Format:         string(req.Format),
+		Redaction:      redaction,
+		Filter:         filter,
+		Status:         models.ExportStatusQueued,
+	}
+	if err := s.repo.Create(job); err != nil {
+		return nil, err
+	}
+
+	s.signal()
+	return job, nil
+}
+
+// Get retrieves an export for whoever requested it, members of its
+// organisation or an administrator
+func (s *ExportService) Get(id, actorID uuid.UUID, isAdmin bool) (*models.ExportJob, error) {
+	job, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrExportNotFound
+	}
+	if isAdmin || job.RequestedByID == actorID {
+		return job, nil
+	}
+	if job.OrganizationID != nil {
+		if err := s.organizations.requireRole(*job.OrganizationID, actorID, false, models.OrganizationRoleStaff); err == nil {
+			return job, nil
+		}
+	}
+	return nil, ErrExportNotFound
+}
+
+// ListMine retrieves the exports a user requested
+func (s *ExportService) ListMine(actorID uuid.UUID, page, limit int) ([]models.ExportJob, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListForUser(actorID, page, limit)
+}
+
+// DownloadURL returns a short-lived link to a finished export's file
+func (s *ExportService) DownloadURL(id, actorID uuid.UUID, isAdmin bool) (string, error) {
+	job, err := s.Get(id, actorID, isAdmin)
+	if err != nil {
+		return "", err
+	}
+	switch job.Status {
+	case models.ExportStatusCompleted:
+	case models.ExportStatusExpired:
+		return "", ErrExportExpired
+	case models.ExportStatusFailed:
+		return "", ErrExportUnavailable
+	default:
+		return "", ErrExportNotReady
+	}
+	if job.ExpiresAt != nil && time.Now().After(*job.ExpiresAt) {
+		return "", ErrExportExpired
+	}
+	return s.storage.ExportDownloadURL(job.ObjectName, job.FileName)
+}
+
+// Run processes queued exports until ctx is cancelled
+func (s *ExportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(exportPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Process(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("exports: processing failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Process runs every queued export and returns how many finished
+func (s *ExportService) Process(ctx context.Context) (int, error) {
+	finished := 0
+	for ctx.Err() == nil {
+		job, err := s.repo.Lease(time.Now(), exportLease)
+		if err != nil || job == nil {
+			return finished, err
+		}
+
+		if job.Attempts > maxExportAttempts {
+			job.Status, job.Error = models.ExportStatusFailed, "the export stopped before finishing too many times"
+		} else if err := s.process(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down; the export is picked up again once its lease expires
+				return finished, ctx.Err()
+			}
+			job.Status, job.Error = models.ExportStatusFailed, err.Error()
+		}
+		now := time.Now()
+		job.FinishedAt = &now
+		if err := s.repo.Finish(job); err != nil {
+			return finished, err
+		}
+		finished++
+	}
+	return finished, ctx.Err()
+}
+
+// PurgeExpired deletes the files of exports past their download window and
+// returns how many were removed
+func (s *ExportService) PurgeExpired(ctx context.Context) (int, error) {
+	jobs, err := s.repo.ListExpired(time.Now(), 100)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for i := range jobs {
+		if ctx.Err() != nil {
+			return purged, ctx.Err()
+		}
+		if err := s.storage.DeleteExport(ctx, jobs[i].ObjectName); err != nil {
+			log.Printf("exports: failed to delete %s: %v", jobs[i].ObjectName, err)
+			continue
+		}
+		if err := s.repo.MarkExpired(&jobs[i]); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// process writes an export to storage. The file is streamed to storage as
+// it is written, so it is never held in memory.
+func (s *ExportService) process(ctx context.Context, job *models.ExportJob) error {
+	format, err := export.ParseFormat(job.Format)
+	if err != nil {
+		return err
+	}
+	req := ExportRequest{Format: format, OrganizationID: job.OrganizationID}
+	if err := mapToFilter(job.Filter, &req.Filter); err != nil {
+		return err
+	}
+	req.Filter.OrganizationID = job.OrganizationID
+
+	reader, writer := io.Pipe()
+	written := make(chan int, 1)
+	go func() {
+		count, err := s.write(writer, req, job.Redaction)
+		written <- count
+		writer.CloseWithError(err)
+	}()
+
+	filename := exportFileName(req, job.CreatedAt)
+	objectName, err := s.storage.UploadExport(ctx, job.ID, filename, reader, format.ContentType())
+	// Unblock the writer if the upload stopped reading early
+	reader.CloseWithError(errors.New("upload stopped"))
+	count := <-written
+	if err != nil {
+		return err
+	}
+
+	expires := time.Now().Add(exportRetention)
+	job.Status = models.ExportStatusCompleted
+	job.RowCount = count
+	job.FileName = filename
+	job.ObjectName = objectName
+	job.ExpiresAt = &expires
+	job.Error = ""
+	return nil
+}
+
+// write exports the items a request matches and returns how many were written
+func (s *ExportService) write(w io.Writer, req ExportRequest, redaction models.ExportRedaction) (int, error) {
+	writer, err := export.NewWriter(req.Format, w)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = s.items.Each(req.Filter, exportBatchSize, func(items []models.Item) error {
+		for i := range items {
+			if err := writer.Write(exportRecord(&items[i], redaction)); err != nil {
+				return err
+			}
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return count, err
+	}
+	return count, writer.Close()
+}
+
+// authorize checks the actor may run an export and returns the redaction
+// their role gets. Platform-wide exports are for administrators; members of
+// an organisation may export its items.
+func (s *ExportService) authorize(req *ExportRequest, actorID uuid.UUID, isAdmin bool) (models.ExportRedaction, error) {
+	redaction := models.ExportRedactionNone
+	if req.OrganizationID == nil {
+		if !isAdmin {
+			return "", ErrExportForbidden
+		}
+	} else {
+		if err := s.organizations.requireRole(*req.OrganizationID, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+			return "", err
+		}
+		if !isAdmin {
+			redaction = models.ExportRedactionOrganization
+		}
+	}
+	if req.Public {
+		redaction = models.ExportRedactionPublic
+	}
+
+	req.Filter.OrganizationID = req.OrganizationID
+	return redaction, nil
+}
+
+// signal wakes the worker without blocking
+func (s *ExportService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// exportRecord converts an item to an export record with the fields the
+// redaction allows
+func exportRecord(item *models.Item, redaction models.ExportRedaction) *export.Record {
+	record := &export.Record{
+		ID:          item.ID.String(),
+		Title:       item.Title,
+		Description: item.Description,
+		Status:      string(item.Status),
+		Attributes:  item.Attributes,
+		Location:    item.Location,
+		CountyID:    item.CountyID,
+		SubCountyID: item.SubCountyID,
+		WardID:      item.WardID,
+		Date:        item.Date,
+		CreatedAt:   item.CreatedAt,
+		Resolved:    item.IsResolved,
+		Source:      string(item.Source),
+	}
+	if item.ReferenceCode != nil {
+		record.ReferenceCode = *item.ReferenceCode
+	}
+	if item.Category != nil {
+		record.Category = item.Category.Path
+	}
+	for _, tag := range item.Tags {
+		record.Tags = append(record.Tags, tag.Name)
+	}
+	for _, image := range item.Images {
+		record.Images = append(record.Images, image.URL)
+	}
+	if item.Organization != nil {
+		record.OrganizationID, record.OrganizationName = item.Organization.ID.String(), item.Organization.Name
+	}
+
+	if redaction == models.ExportRedactionPublic {
+		item.RoundCoordinates(publicCoordinateDecimals)
+		item.RedactPrivateAttributes()
+	} else {
+		record.Contact = item.Contact
+		if item.Custody != nil {
+			record.CustodyStatus, record.StorageLocation = string(item.Custody.Status), item.Custody.StorageLocation
+		}
+	}
+	if redaction == models.ExportRedactionNone {
+		record.ReporterID = item.UserID.String()
+	}
+	record.Latitude, record.Longitude, record.Precision = item.Latitude, item.Longitude, item.Precision
+	return record
+}
+
+// exportFileName names an export file after its scope and date
+func exportFileName(req ExportRequest, at time.Time) string {
+	scope := "items"
+	if req.OrganizationID != nil {
+		scope = "organization-" + req.OrganizationID.String()[:8] + "-items"
+	}
+	return scope + "-" + at.UTC().Format("20060102-150405") + req.Format.Extension()
+}
+
+// filterToMap stores an item filter on an export job, leaving out empty fields
+func filterToMap(filter repository.ItemFilter) (map[string]any, error) {
+	filter.OrganizationID = nil
+	data, err := json.Marshal(filter)
+	if err != nil {
+		return nil, err
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	for key, value := range values {
+		if value == nil || value == "" {
+			delete(values, key)
+		}
+	}
+	return values, nil
+}
+
+// mapToFilter reads an item filter stored on an export job
+func mapToFilter(values map[string]any, filter *repository.ItemFilter) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, filter)
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/util/qrcode"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// handoverCodeLength is the number of characters in a handover code
+	handoverCodeLength = 8
+	// maxHandoverAttempts is how many wrong codes lock the active code for an
+	// item, so the claimant has to open a fresh one
+	maxHandoverAttempts = 5
+)
+
+var (
+	ErrHandoverCodeInvalid = errors.New("handover code is incorrect or has expired")
+	ErrHandoverNotAllowed  = errors.New("only the finder or whoever holds the item can confirm a handover")
+	ErrClaimNotApproved    = errors.New("this claim has not been approved")
+	ErrItemAlreadyReturned = errors.New("this item has already been returned")
+)
+
+// HandoverHook is called after an item is handed over with a redeemed code
+type HandoverHook func(code *models.HandoverCode, item *models.Item)
+
+// HandoverPass is what the claimant shows at collection: the code to read out
+// and the URL encoded in its QR code
+type HandoverPass struct {
+	ClaimID    uuid.UUID  `json:"claim_id"`
+	ItemID     uuid.UUID  `json:"item_id"`
+	Code       string     `json:"code,omitempty"`
+	ScanURL    string     `json:"scan_url,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RedeemedAt *time.Time `json:"redeemed_at,omitempty"`
+}
+
+// HandoverService issues one-time handover codes to approved claimants and
+// verifies them when an item is returned
+type HandoverService struct {
+	repo    *repository.HandoverRepository
+	claims  *ClaimService
+	items   *ItemService
+	custody *CustodyService
+	ttl     time.Duration
+	baseURL string
+
+	afterRedeem []HandoverHook
+}
+
+// NewHandoverService creates a new HandoverService. Codes expire after
+// codeHours and scan URLs are built from baseURL.
+func NewHandoverService(repo *repository.HandoverRepository, claims *ClaimService, items *ItemService, custody *CustodyService, codeHours int, baseURL string) *HandoverService {
+	return &HandoverService{
+		repo:    repo,
+		claims:  claims,
+		items:   items,
+		custody: custody,
+		ttl:     time.Duration(codeHours) * time.Hour,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+// AfterRedeem registers a hook that runs after an item is handed over
+func (s *HandoverService) AfterRedeem(hook HandoverHook) {
+	s.afterRedeem = append(s.afterRedeem, hook)
+}
+
+// ClaimChanged issues a handover code when a claim is approved. It is
+// registered as a claim hook.
+func (s *HandoverService) ClaimChanged(claim *models.Claim) {
+	if claim.Status != models.ClaimStatusApproved {
+		return
+	}
+	if _, err := s.issue(claim); err != nil {
+		log.Printf("Failed to issue handover code for claim %s: %v", claim.ID, err)
+	}
+}
+
+// Pass retrieves the claimant's handover code, issuing a new one when the
+// last has expired or been locked
+func (s *HandoverService) Pass(claimID, claimerID uuid.UUID) (*HandoverPass, error) {
+	claim, err := s.claims.GetForViewer(claimID, claimerID, false)
+	if err != nil || claim.ClaimerID != claimerID {
+		return nil, ErrClaimNotFound
+	}
+	if claim.Status != models.ClaimStatusApproved {
+		return nil, ErrClaimNotApproved
+	}
+
+	code, err := s.repo.LatestForClaim(claim.ID)
+	if err == nil && code.RedeemedAt != nil {
+		return &HandoverPass{ClaimID: claim.ID, ItemID: claim.ItemID, ExpiresAt: code.ExpiresAt, RedeemedAt: code.RedeemedAt}, nil
+	}
+	if err != nil || !code.Active(time.Now()) {
+		if code, err = s.issue(claim); err != nil {
+			return nil, err
+		}
+	}
+
+	return &HandoverPass{
+		ClaimID:   claim.ID,
+		ItemID:    claim.ItemID,
+		Code:      code.Code,
+		ScanURL:   s.scanURL(code),
+		ExpiresAt: code.ExpiresAt,
+	}, nil
+}
+
+// QRCode renders the claimant's handover code as a PNG or SVG image
+func (s *HandoverService) QRCode(claimID, claimerID uuid.UUID, format string, size int) ([]byte, string, error) {
+	pass, err := s.Pass(claimID, claimerID)
+	if err != nil {
+		return nil, "", err
+	}
+	if pass.RedeemedAt != nil {
+		return nil, "", ErrItemAlreadyReturned
+	}
+
+	switch format {
+	case "svg":
+		data, err := qrcode.SVG(pass.ScanURL)
+		return data, "image/svg+xml", err
+	case "png", "":
+		if size < 128 || size > 2048 {
+			size = 512
+		}
+		data, err := qrcode.PNG(pass.ScanURL, size)
+		return data, "image/png", err
+	default:
+		return nil, "", fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// Redeem checks a handover code entered or scanned by the finder or the
+// custodian and marks the item returned. The location is optional.
+func (s *HandoverService) Redeem(itemID, actorID uuid.UUID, isAdmin bool, code string, lat, lng *float64) (*models.HandoverCode, error) {
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if item.Status == models.ItemStatusReturned {
+		return nil, ErrItemAlreadyReturned
+	}
+	if !isAdmin && item.UserID != actorID && !s.custody.HoldsItem(item, actorID) {
+		return nil, ErrHandoverNotAllowed
+	}
+	if (lat == nil) != (lng == nil) {
+		return nil, errors.New("latitude and longitude must be given together")
+	}
+	if lat != nil {
+		if err := validateCoordinates(*lat, *lng); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	active, err := s.repo.ActiveForItem(item.ID, now)
+	if err != nil {
+		return nil, ErrHandoverCodeInvalid
+	}
+	if active.ClaimerID == actorID {
+		return nil, ErrHandoverNotAllowed
+	}
+	if subtle.ConstantTimeCompare([]byte(normalizeHandoverCode(code)), []byte(active.Code)) != 1 {
+		if err := s.repo.RecordFailure(active, maxHandoverAttempts); err != nil {
+			return nil, err
+		}
+		return nil, ErrHandoverCodeInvalid
+	}
+
+	active.RedeemedAt, active.RedeemedByID = &now, &actorID
+	active.Latitude, active.Longitude = lat, lng
+	redeemed, err := s.repo.Redeem(active)
+	if err != nil {
+		return nil, err
+	}
+	if !redeemed {
+		return nil, ErrHandoverCodeInvalid
+	}
+
+	item.Status = models.ItemStatusReturned
+	item.IsResolved = true
+	if err := s.items.Update(item); err != nil {
+		return nil, err
+	}
+	if err := s.custody.RecordReturn(item, active.ClaimerID, actorID, now); err != nil {
+		log.Printf("Failed to record return of item %s in its chain of custody: %v", item.ID, err)
+	}
+	for _, hook := range s.afterRedeem {
+		hook(active, item)
+	}
+	return active, nil
+}
+
+// ListForItem retrieves the handover codes issued for an item, without the
+// codes themselves, for the reporter, the custodian or an admin
+func (s *HandoverService) ListForItem(itemID, actorID uuid.UUID, isAdmin bool) ([]models.HandoverCode, error) {
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if !isAdmin && item.UserID != actorID && !s.custody.HoldsItem(item, actorID) {
+		return nil, ErrNotAuthorizedItem
+	}
+	return s.repo.ListForItem(item.ID)
+}
+
+// issue creates a fresh code for an approved claim
+func (s *HandoverService) issue(claim *models.Claim) (*models.HandoverCode, error) {
+	value, err := randomCode(handoverCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	code := &models.HandoverCode{
+		ClaimID:   claim.ID,
+		ItemID:    claim.ItemID,
+		ClaimerID: claim.ClaimerID,
+		Code:      value,
+		ExpiresAt: time.Now().Add(s.ttl),
+	}
+	if err := s.repo.Issue(code); err != nil {
+		return nil, err
+	}
+	return code, nil
+}
+
+// scanURL returns the URL encoded in a handover QR code. It opens the
+// handover page for the item with the code filled in.
+func (s *HandoverService) scanURL(code *models.HandoverCode) string {
+	return s.baseURL + "/handover/" + code.ItemID.String() + "?code=" + url.QueryEscape(code.Code)
+}
+
+// normalizeHandoverCode tidies a code typed by hand
+func normalizeHandoverCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
@@ -0,0 +1,554 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/util/spreadsheet"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxImportRows bounds the number of items in one import
+	maxImportRows = 1000
+	// MaxImportFileBytes bounds the size of an uploaded CSV or XLSX file
+	MaxImportFileBytes = 10 << 20
+	// MaxImportBundleBytes bounds the size of an uploaded image ZIP
+	MaxImportBundleBytes = 100 << 20
+	// maxImportImageBytes bounds the size of a single image in a bundle
+	maxImportImageBytes = 10 << 20
+	// importLease is how long a worker may spend on a job before another
+	// worker assumes it stopped and picks the job up again
+	importLease = 15 * time.Minute
+	// maxImportAttempts is how many times a job is picked up before it is failed
+	maxImportAttempts = 3
+	// importPollInterval is how often the worker looks for queued jobs
+	importPollInterval = 5 * time.Second
+)
+
+// importColumns maps the accepted spreadsheet headings to the row field they fill
+var importColumns = map[string]string{
+	"external_reference": "external_reference",
+	"external_id":        "external_reference",
+	"reference":          "external_reference",
+	"title":              "title",
+	"description":        "description",
+	"status":             "status",
+	"category":           "category",
+	"date":               "date",
+	"location":           "location",
+	"county":             "county",
+	"sub_county":         "sub_county",
+	"latitude":           "latitude",
+	"lat":                "latitude",
+	"longitude":          "longitude",
+	"lng":                "longitude",
+	"contact":            "contact",
+	"storage_location":   "storage_location",
+	"tags":               "tags",
+	"images":             "images",
+}
+
+var (
+	ErrImportNotFound     = errors.New("import not found")
+	ErrImportEmpty        = errors.New("the import has no rows")
+	ErrTooManyImportRows  = fmt.Errorf("an import can have at most %d rows", maxImportRows)
+	ErrImportFormat       = errors.New("upload a .csv or .xlsx file")
+	ErrImportBundleFormat = errors.New("images must be uploaded as a .zip file")
+)
+
+// ImportService takes bulk intakes of items from organisations. Jobs are
+// queued by the API and processed in the background.
+type ImportService struct {
+	repo          *repository.ImportRepository
+	orgRepo       *repository.OrganizationRepository
+	organizations *OrganizationService
+	items         *ItemService
+	categories    *CategoryService
+	storage       *StorageService
+	wake          chan struct{}
+}
+
+// NewImportService creates a new ImportService
+func NewImportService(repo *repository.ImportRepository, orgRepo *repository.OrganizationRepository, organizations *OrganizationService, items *ItemService, categories *CategoryService, storage *StorageService) *ImportService {
+	return &ImportService{
+		repo:          repo,
+		orgRepo:       orgRepo,
+		organizations: organizations,
+		items:         items,
+		categories:    categories,
+		storage:       storage,
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// ParseRows reads the rows of a CSV or XLSX file. The first row holds the
+// column headings. Values that cannot be read are kept as row problems and
+// reported when the job runs.
+func (s *ImportService) ParseRows(format models.ImportFormat, data []byte) ([]models.ImportRow, error) {
+	var records [][]string
+	var err error
+	switch format {
+	case models.ImportFormatCSV:
+		records, err = spreadsheet.ReadCSV(bytes.NewReader(data))
+	case models.ImportFormatXLSX:
+		records, err = spreadsheet.ReadXLSX(data)
+	default:
+		return nil, ErrImportFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read the file: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, ErrImportEmpty
+	}
+
+	columns := make([]string, len(records[0]))
+	found := make(map[string]bool)
+	for i, heading := range records[0] {
+		key := strings.NewReplacer(" ", "_", "-", "_").Replace(strings.ToLower(strings.TrimSpace(heading)))
+		columns[i] = importColumns[key]
+		found[columns[i]] = true
+	}
+	for _, required := range []string{"external_reference", "title"} {
+		if !found[required] {
+			return nil, fmt.Errorf("the file needs a %s column", required)
+		}
+	}
+
+	var rows []models.ImportRow
+	for i, record := range records[1:] {
+		if blankRecord(record) {
+			continue
+		}
+		row := models.ImportRow{Line: i + 2}
+		for j, value := range record {
+			if j < len(columns) && columns[j] != "" {
+				setImportField(&row, columns[j], strings.TrimSpace(value))
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Submit queues an import of rows for an organisation, with an optional ZIP
+// of images. Any member may import.
+func (s *ImportService) Submit(organizationID, actorID uuid.UUID, isAdmin bool, job *models.ImportJob) error {
+	if err := s.organizations.requireRole(organizationID, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return err
+	}
+	if len(job.Rows) == 0 {
+		return ErrImportEmpty
+	}
+	if len(job.Rows) > maxImportRows {
+		return ErrTooManyImportRows
+	}
+	if len(job.Bundle) > 0 {
+		if _, err := zip.NewReader(bytes.NewReader(job.Bundle), int64(len(job.Bundle))); err != nil {
+			return ErrImportBundleFormat
+		}
+	}
+	for i := range job.Rows {
+		if job.Format == models.ImportFormatJSON {
+			job.Rows[i].Line = i + 1
+			job.Rows[i].Problems = nil
+		}
+	}
+
+	job.OrganizationID = organizationID
+	job.CreatedByID = actorID
+	job.Status = models.ImportStatusQueued
+	job.TotalRows = len(job.Rows)
+	if err := s.repo.Create(job); err != nil {
+		return err
+	}
+	job.Rows, job.Bundle = nil, nil
+
+	s.signal()
+	return nil
+}
+
+// Get retrieves an import job with its row errors and results
+func (s *ImportService) Get(organizationID, id, actorID uuid.UUID, isAdmin bool) (*models.ImportJob, error) {
+	if err := s.organizations.requireRole(organizationID, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return nil, err
+	}
+	job, err := s.repo.GetByID(id)
+	if err != nil || job.OrganizationID != organizationID {
+		return nil, ErrImportNotFound
+	}
+	return job, nil
+}
+
+// List retrieves an organisation's import jobs
+func (s *ImportService) List(organizationID, actorID uuid.UUID, isAdmin bool, page, limit int) ([]models.ImportJob, int64, error) {
+	if err := s.organizations.requireRole(organizationID, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListForOrganization(organizationID, page, limit)
+}
+
+// Run processes queued imports until ctx is cancelled
+func (s *ImportService) Run(ctx context.Context) {
+	ticker := time.NewTicker(importPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Process(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("imports: processing failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Process runs every queued import and returns how many finished
+func (s *ImportService) Process(ctx context.Context) (int, error) {
+	finished := 0
+	for ctx.Err() == nil {
+		job, err := s.repo.Lease(time.Now(), importLease)
+		if err != nil || job == nil {
+			return finished, err
+		}
+
+		if job.Attempts > maxImportAttempts {
+			job.Status, job.Error = models.ImportStatusFailed, "the import stopped before finishing too many times"
+		} else if err := s.process(ctx, job); err != nil {
+			if ctx.Err() != nil {
+				// Shutting down; the job is picked up again once its lease expires
+				return finished, ctx.Err()
+			}
+			job.Status, job.Error = models.ImportStatusFailed, err.Error()
+		}
+		now := time.Now()
+		job.FinishedAt = &now
+		if err := s.repo.Finish(job); err != nil {
+			return finished, err
+		}
+		finished++
+	}
+	return finished, ctx.Err()
+}
+
+// process checks every row of a job and, when all are valid, creates the new
+// items in one transaction. Rows whose external reference was imported before
+// are skipped. Row errors are recorded on the job; the returned error is for
+// failures of the job as a whole.
+func (s *ImportService) process(ctx context.Context, job *models.ImportJob) error {
+	organization, err := s.orgRepo.GetByID(job.OrganizationID)
+	if err != nil {
+		return ErrOrganizationNotFound
+	}
+	images, err := bundleIndex(job.Bundle)
+	if err != nil {
+		return err
+	}
+
+	references := make([]string, 0, len(job.Rows))
+	for _, row := range job.Rows {
+		if ref := strings.TrimSpace(row.ExternalReference); ref != "" {
+			references = append(references, ref)
+		}
+	}
+	existing, err := s.items.ExternalReferences(organization.ID, references)
+	if err != nil {
+		return err
+	}
+
+	job.Errors, job.Results = nil, nil
+	var items []*models.Item
+	var rows []models.ImportRow
+	seen := make(map[string]int)
+	for _, row := range job.Rows {
+		ref := strings.TrimSpace(row.ExternalReference)
+		fail := func(message string) {
+			job.Errors = append(job.Errors, models.ImportRowError{Line: row.Line, ExternalReference: ref, Message: message})
+		}
+
+		if len(row.Problems) > 0 {
+			for _, problem := range row.Problems {
+				fail(problem)
+			}
+			continue
+		}
+		if ref == "" {
+			fail("external_reference is required")
+			continue
+		}
+		if line, repeated := seen[ref]; repeated {
+			fail(fmt.Sprintf("external_reference is already used on line %d", line))
+			continue
+		}
+		seen[ref] = row.Line
+		if id, ok := existing[ref]; ok {
+			job.Results = append(job.Results, models.ImportRowResult{Line: row.Line, ExternalReference: ref, ItemID: id, Existing: true})
+			continue
+		}
+
+		item, err := s.rowItem(organization, job.CreatedByID, row)
+		if err == nil {
+			err = checkBundleImages(row.Images, images)
+		}
+		if err == nil {
+			err = s.items.Validate(item)
+		}
+		if err != nil {
+			fail(err.Error())
+			continue
+		}
+		items = append(items, item)
+		rows = append(rows, row)
+	}
+
+	job.SkippedCount = len(job.Results)
+	if len(job.Errors) > 0 {
+		job.Status = models.ImportStatusFailed
+		job.Error = fmt.Sprintf("%d rows have errors; nothing was imported", countLines(job.Errors))
+		job.Results = nil
+		return nil
+	}
+
+	var uploaded []string
+	for i, row := range rows {
+		for _, name := range row.Images {
+			url, hash, err := s.uploadBundleImage(ctx, organization.ID, images[bundleKey(name)])
+			if err != nil {
+				s.removeUploads(ctx, uploaded)
+				return fmt.Errorf("line %d: %w", row.Line, err)
+			}
+			uploaded = append(uploaded, url)
+			items[i].Images = append(items[i].Images, models.Image{URL: url, Hash: hash})
+		}
+	}
+
+	if err := s.items.CreateBatch(items, models.ItemSourceImport); err != nil {
+		s.removeUploads(ctx, uploaded)
+		return err
+	}
+
+	for i, item := range items {
+		job.Results = append(job.Results, models.ImportRowResult{Line: rows[i].Line, ExternalReference: item.ExternalReference, ItemID: item.ID})
+	}
+	sort.Slice(job.Results, func(i, j int) bool { return job.Results[i].Line < job.Results[j].Line })
+	job.CreatedCount = len(items)
+	job.Status = models.ImportStatusCompleted
+	job.Error = ""
+	return nil
+}
+
+// rowItem builds the item a row describes
+func (s *ImportService) rowItem(organization *models.Organization, actorID uuid.UUID, row models.ImportRow) (*models.Item, error) {
+	item := &models.Item{
+		Title:             strings.TrimSpace(row.Title),
+		Description:       strings.TrimSpace(row.Description),
+		Status:            models.ItemStatus(strings.ToLower(strings.TrimSpace(row.Status))),
+		Location:          strings.TrimSpace(row.Location),
+		CountyID:          strings.TrimSpace(row.County),
+		SubCountyID:       strings.TrimSpace(row.SubCounty),
+		Latitude:          row.Latitude,
+		Longitude:         row.Longitude,
+		Contact:           strings.TrimSpace(row.Contact),
+		ExternalReference: strings.TrimSpace(row.ExternalReference),
+	}
+	if item.Status != "" && item.Status != models.ItemStatusFound && item.Status != models.ItemStatusLost {
+		return nil, errors.New("status must be found or lost")
+	}
+	if row.Category != "" {
+		category, err := s.categories.GetByPath(s.categories.ResolvePath(row.Category))
+		if err != nil {
+			return nil, fmt.Errorf("unknown category %q", row.Category)
+		}
+		item.CategoryID = &category.ID
+	}
+	if row.Date != "" {
+		date, err := spreadsheet.ParseDate(row.Date)
+		if err != nil {
+			return nil, err
+		}
+		item.Date = date
+	}
+	for _, tag := range row.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			item.Tags = append(item.Tags, models.Tag{Name: tag})
+		}
+	}
+
+	organizationItem(organization, actorID, item, row.StorageLocation)
+	return item, nil
+}
+
+// uploadBundleImage stores one image from a bundle and returns its URL and content hash
+func (s *ImportService) uploadBundleImage(ctx context.Context, organizationID uuid.UUID, file *zip.File) (string, string, error) {
+	reader, err := file.Open()
+	if err != nil {
+		return "", "", fmt.Errorf("could not read %s from the bundle: %w", file.Name, err)
+	}
+	defer reader.Close()
+
+	hash := sha256.New()
+	url, err := s.storage.UploadImportImage(ctx, organizationID, path.Base(file.Name), io.TeeReader(reader, hash))
+	if err != nil {
+		return "", "", err
+	}
+	return url, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// removeUploads deletes images uploaded for an import that did not go through
+func (s *ImportService) removeUploads(ctx context.Context, urls []string) {
+	for _, url := range urls {
+		if err := s.storage.DeleteFileByURL(ctx, url); err != nil {
+			log.Printf("imports: failed to remove %s: %v", url, err)
+		}
+	}
+}
+
+// signal wakes the worker without blocking
+func (s *ImportService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// setImportField stores a spreadsheet value in the row field its column fills
+func setImportField(row *models.ImportRow, field, value string) {
+	if value == "" {
+		return
+	}
+	switch field {
+	case "external_reference":
+		row.ExternalReference = value
+	case "title":
+		row.Title = value
+	case "description":
+		row.Description = value
+	case "status":
+		row.Status = value
+	case "category":
+		row.Category = value
+	case "date":
+		row.Date = value
+	case "location":
+		row.Location = value
+	case "county":
+		row.County = value
+	case "sub_county":
+		row.SubCounty = value
+	case "latitude", "longitude":
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			row.Problems = append(row.Problems, fmt.Sprintf("%s %q is not a number", field, value))
+			return
+		}
+		if field == "latitude" {
+			row.Latitude = &number
+		} else {
+			row.Longitude = &number
+		}
+	case "contact":
+		row.Contact = value
+	case "storage_location":
+		row.StorageLocation = value
+	case "tags":
+		row.Tags = splitList(value)
+	case "images":
+		row.Images = splitList(value)
+	}
+}
+
+// splitList splits a cell holding several values separated by semicolons or commas
+func splitList(value string) []string {
+	var values []string
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == ',' }) {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// blankRecord reports whether every cell of a spreadsheet row is empty
+func blankRecord(record []string) bool {
+	for _, value := range record {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// bundleIndex lists the files in an image bundle by lower-case file name,
+// ignoring folders and the metadata some archivers add
+func bundleIndex(bundle []byte) (map[string]*zip.File, error) {
+	images := make(map[string]*zip.File)
+	if len(bundle) == 0 {
+		return images, nil
+	}
+	archive, err := zip.NewReader(bytes.NewReader(bundle), int64(len(bundle)))
+	if err != nil {
+		return nil, ErrImportBundleFormat
+	}
+	for _, file := range archive.File {
+		name := path.Base(file.Name)
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") || strings.HasPrefix(name, ".") {
+			continue
+		}
+		images[bundleKey(name)] = file
+	}
+	return images, nil
+}
+
+// checkBundleImages checks that every image a row names is an image in the bundle
+func checkBundleImages(names []string, images map[string]*zip.File) error {
+	for _, name := range names {
+		file, ok := images[bundleKey(name)]
+		if !ok {
+			return fmt.Errorf("image %q is not in the image bundle", name)
+		}
+		if !strings.HasPrefix(getContentTypeFromFileName(name), "image/") {
+			return fmt.Errorf("%q is not an image", name)
+		}
+		if file.UncompressedSize64 > maxImportImageBytes {
+			return fmt.Errorf("image %q is larger than %d MB", name, maxImportImageBytes>>20)
+		}
+	}
+	return nil
+}
+
+// bundleKey is how rows refer to images in a bundle: by file name, ignoring case
+func bundleKey(name string) string {
+	return strings.ToLower(path.Base(strings.ReplaceAll(strings.TrimSpace(name), "\\", "/")))
+}
+
+// countLines counts the distinct rows with errors
+func countLines(errs []models.ImportRowError) int {
+	lines := make(map[int]bool)
+	for _, err := range errs {
+		lines[err.Line] = true
+	}
+	return len(lines)
+}
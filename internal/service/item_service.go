@@ -2,29 +2,226 @@ package service
 
 import (
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"log"
 	"lostnfound-api/internal/models"
 	"lostnfound-api/internal/repository"
+	"math"
+	"strings"
+	"time"
 )
 
+// referenceCodeLength is the number of characters in an item reference code
+const referenceCodeLength = 7
+
+// ErrPrivateAttributeFilter is returned when filtering on a private attribute
+var ErrPrivateAttributeFilter = errors.New("private attributes cannot be used as filters")
+
+// ItemHook is called after an item has been saved. Hooks do not run for drafts
+// until they are published.
+type ItemHook func(item *models.Item)
+
+// ItemCheck is called before an item is created and can refuse it
+type ItemCheck func(item *models.Item) error
+
+// DuplicateFinder looks for existing reports of the same item as a new one
+type DuplicateFinder func(item *models.Item) ([]models.DuplicateCandidate, error)
+
 // ItemService provides business logic for items
 type ItemService struct {
-	repo *repository.ItemRepository
+	repo       *repository.ItemRepository
+	locations  *LocationService
+	categories *CategoryService
+	tags       *TagService
+
+	beforeCreate []ItemCheck
+	afterCreate  []ItemHook
+	afterUpdate  []ItemHook
+	duplicates   DuplicateFinder
 }
 
 // NewItemService creates a new ItemService
-func NewItemService(repo *repository.ItemRepository) *ItemService {
-	return &ItemService{repo: repo}
+func NewItemService(repo *repository.ItemRepository, locations *LocationService, categories *CategoryService, tags *TagService) *ItemService {
+	return &ItemService{repo: repo, locations: locations, categories: categories, tags: tags}
+}
+
+// BeforeCreate registers a check that runs before an item is created. Bulk
+// imports are not checked.
+func (s *ItemService) BeforeCreate(check ItemCheck) {
+	s.beforeCreate = append(s.beforeCreate, check)
+}
+
+// FindDuplicatesWith sets how new items are checked for duplicates. Only
+// items created one at a time and not as drafts are checked.
+func (s *ItemService) FindDuplicatesWith(finder DuplicateFinder) {
+	s.duplicates = finder
 }
 
-// Create adds a new item
+// AfterCreate registers a hook that runs after every item is created
+func (s *ItemService) AfterCreate(hook ItemHook) {
+	s.afterCreate = append(s.afterCreate, hook)
+}
+
+// AfterUpdate registers a hook that runs after every item is updated
+func (s *ItemService) AfterUpdate(hook ItemHook) {
+	s.afterUpdate = append(s.afterUpdate, hook)
+}
+
+// Create adds a new item reported through the app
 func (s *ItemService) Create(item *models.Item) error {
-	// Validate item
+	return s.CreateFrom(item, models.ItemSourceApp)
+}
+
+// CreateFrom adds a new item reported through the given channel
+func (s *ItemService) CreateFrom(item *models.Item, source models.ItemSource) error {
+	if err := s.Validate(item); err != nil {
+		return err
+	}
+	if err := s.prepareNew(item, source); err != nil {
+		return err
+	}
+	for _, check := range s.beforeCreate {
+		if err := check(item); err != nil {
+			return err
+		}
+	}
+
+	if err := s.repo.Create(item); err != nil {
+		return err
+	}
+
+	// A possible duplicate is only a warning; the item is saved either way
+	if s.duplicates != nil && !item.Draft {
+		duplicates, err := s.duplicates(item)
+		if err != nil {
+			log.Printf("failed to check item %s for duplicates: %v", item.ID, err)
+		}
+		item.PossibleDuplicates = duplicates
+	}
+
+	s.created(item)
+	return nil
+}
+
+// CreateDraft saves an unlisted draft filed on a user's behalf. The
+// before-create checks wait until the user publishes it with PublishChecked.
+func (s *ItemService) CreateDraft(item *models.Item, source models.ItemSource) error {
+	if err := s.Validate(item); err != nil {
+		return err
+	}
+	if err := s.prepareNew(item, source); err != nil {
+		return err
+	}
+	item.Draft = true
+	return s.repo.Create(item)
+}
+
+// CreateBatch adds several items reported through the same channel in one
+// transaction. Either every item is saved or none are.
+func (s *ItemService) CreateBatch(items []*models.Item, source models.ItemSource) error {
+	for i, item := range items {
+		if err := s.Validate(item); err != nil {
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+		if err := s.prepareNew(item, source); err != nil {
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+	}
+
+	if err := s.repo.CreateBatch(items); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		s.created(item)
+	}
+	return nil
+}
+
+// Validate checks a new item and normalises its location, coordinates and
+// attributes without saving it
+func (s *ItemService) Validate(item *models.Item) error {
 	if item.Title == "" {
 		return errors.New("title is required")
 	}
+	if item.RewardCents < 0 {
+		return errors.New("reward cannot be negative")
+	}
+	if item.Date.IsZero() {
+		item.Date = time.Now()
+	}
 
-	return s.repo.Create(item)
+	if err := s.locations.NormalizeItem(item); err != nil {
+		return err
+	}
+	if err := validateItemCoordinates(item); err != nil {
+		return err
+	}
+	if item.Transit != nil {
+		item.Transit.Normalize()
+	}
+	return s.categories.ValidateItemAttributes(item)
+}
+
+// SetReward records the reward held in escrow for an item
+func (s *ItemService) SetReward(id uuid.UUID, cents int64, escrowed bool) error {
+	return s.repo.SetReward(id, cents, escrowed)
+}
+
+// Count counts the items matching a filter
+func (s *ItemService) Count(filter repository.ItemFilter) (int64, error) {
+	s.NormalizeFilter(&filter)
+	return s.repo.Count(filter)
+}
+
+// Each calls fn with successive batches of the items matching a filter
+func (s *ItemService) Each(filter repository.ItemFilter, batchSize int, fn func(items []models.Item) error) error {
+	s.NormalizeFilter(&filter)
+	return s.repo.Each(filter, batchSize, fn)
+}
+
+// ExternalReferences maps an organisation's external references to the items
+// already imported under them
+func (s *ItemService) ExternalReferences(organizationID uuid.UUID, references []string) (map[string]uuid.UUID, error) {
+	return s.repo.ExternalReferences(organizationID, references)
+}
+
+// GetByReference retrieves an item by its reference code
+func (s *ItemService) GetByReference(code string) (*models.Item, error) {
+	return s.repo.GetByReference(strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// FoundDocumentsByName retrieves found documents bearing a name
+func (s *ItemService) FoundDocumentsByName(name string, limit int) ([]models.Item, error) {
+	return s.repo.FoundDocumentsByName(strings.TrimSpace(name), limit)
+}
+
+// PublishChecked runs the before-create checks on a draft filed with
+// CreateDraft and then publishes it
+func (s *ItemService) PublishChecked(item *models.Item) error {
+	for _, check := range s.beforeCreate {
+		if err := check(item); err != nil {
+			return err
+		}
+	}
+	return s.Publish(item)
+}
+
+// Publish lists a draft item and runs the hooks a new item gets
+func (s *ItemService) Publish(item *models.Item) error {
+	if !item.Draft {
+		return nil
+	}
+	item.Draft = false
+	if err := s.repo.Update(item); err != nil {
+		return err
+	}
+
+	for _, hook := range s.afterCreate {
+		hook(item)
+	}
+	return nil
 }
 
 // GetByID retrieves an item by ID
@@ -33,7 +230,79 @@ func (s *ItemService) GetByID(id uuid.UUID) (*models.Item, error) {
 }
 
 // List retrieves items with filtering options
-func (s *ItemService) List(status string, category string, page, limit int) ([]models.Item, int64, error) {
+func (s *ItemService) List(filter repository.ItemFilter, page, limit int) ([]models.Item, int64, error) {
+	// Default pagination values
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	if err := s.checkAttributeFilters(filter); err != nil {
+		return nil, 0, err
+	}
+	s.NormalizeFilter(&filter)
+
+	return s.repo.List(filter, page, limit)
+}
+
+// publicCoordinateDecimals is how finely coordinates are shown to users other
+// than the reporter; two decimal places is roughly one kilometre
+const publicCoordinateDecimals = 2
+
+// minNearbyRadiusKm and maxNearbyRadiusKm bound radius searches. The minimum
+// is wider than a rounded coordinate cell so searches can't locate items
+// more precisely than their public coordinates.
+const (
+	minNearbyRadiusKm = 2
+	maxNearbyRadiusKm = 100
+)
+
+// Nearby retrieves items within radiusKm of a point, nearest first. The point
+// is snapped to the public coordinate grid and the radius to whole kilometres,
+// and items are matched and ordered by their public coordinates, so repeated
+// searches can't be used to narrow down an item's position.
+func (s *ItemService) Nearby(lat, lng, radiusKm float64, filter repository.ItemFilter, page, limit int) ([]repository.ItemDistance, int64, error) {
+	if err := validateCoordinates(lat, lng); err != nil {
+		return nil, 0, err
+	}
+	if radiusKm < minNearbyRadiusKm || radiusKm > maxNearbyRadiusKm {
+		return nil, 0, fmt.Errorf("radius_km must be between %d and %d", minNearbyRadiusKm, maxNearbyRadiusKm)
+	}
+	lat, lng = roundCoordinate(lat), roundCoordinate(lng)
+	radiusKm = math.Ceil(radiusKm)
+
+	// Default pagination values
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	if err := s.checkAttributeFilters(filter); err != nil {
+		return nil, 0, err
+	}
+	s.NormalizeFilter(&filter)
+
+	return s.repo.Nearby(lat, lng, radiusKm, publicCoordinateDecimals, filter, page, limit)
+}
+
+// WithinBounds retrieves items inside a bounding box, widened to whole
+// rounded coordinate cells
+func (s *ItemService) WithinBounds(box repository.BoundingBox, filter repository.ItemFilter, page, limit int) ([]models.Item, int64, error) {
+	if err := validateCoordinates(box.MinLat, box.MinLng); err != nil {
+		return nil, 0, err
+	}
+	if err := validateCoordinates(box.MaxLat, box.MaxLng); err != nil {
+		return nil, 0, err
+	}
+	if box.MinLat > box.MaxLat || box.MinLng > box.MaxLng {
+		return nil, 0, errors.New("bounding box minimums must not exceed maximums")
+	}
+	box = snapToCells(box)
+
 	// Default pagination values
 	if page <= 0 {
 		page = 1
@@ -42,7 +311,56 @@ func (s *ItemService) List(status string, category string, page, limit int) ([]m
 		limit = 10
 	}
 
-	return s.repo.List(status, category, page, limit)
+	if err := s.checkAttributeFilters(filter); err != nil {
+		return nil, 0, err
+	}
+	s.NormalizeFilter(&filter)
+
+	return s.repo.WithinBounds(box, publicCoordinateDecimals, filter, page, limit)
+}
+
+// RedactForViewer hides an item's contact details and private attributes and
+// rounds its coordinates unless the viewer reported the item or is an administrator. Other users
+// reach the reporter through in-app messaging instead.
+func RedactForViewer(item *models.Item, viewerID uuid.UUID, isAdmin bool) {
+	item.User.Password = ""
+	if isAdmin || item.UserID == viewerID {
+		return
+	}
+	item.RoundCoordinates(publicCoordinateDecimals)
+	item.RedactPrivateAttributes()
+	item.Contact = ""
+	item.User = models.User{Model: item.User.Model, FirstName: item.User.FirstName}
+}
+
+// roundCoordinate rounds a coordinate to the public coordinate grid
+func roundCoordinate(v float64) float64 {
+	scale := math.Pow(10, publicCoordinateDecimals)
+	return math.Round(v*scale) / scale
+}
+
+// snapToCells widens a bounding box to the edges of the rounded coordinate
+// cells it touches. An item is then found exactly when its public
+// coordinates fall inside the box, however small the box is.
+func snapToCells(box repository.BoundingBox) repository.BoundingBox {
+	cell := math.Pow(10, -publicCoordinateDecimals)
+	return repository.BoundingBox{
+		MinLat: roundCoordinate(box.MinLat) - cell/2,
+		MinLng: roundCoordinate(box.MinLng) - cell/2,
+		MaxLat: roundCoordinate(box.MaxLat) + cell/2,
+		MaxLng: roundCoordinate(box.MaxLng) + cell/2,
+	}
+}
+
+// validateCoordinates checks that a latitude and longitude are in range
+func validateCoordinates(lat, lng float64) error {
+	if lat < -90 || lat > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if lng < -180 || lng > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	return nil
 }
 
 // Update updates an existing item
@@ -53,12 +371,53 @@ func (s *ItemService) Update(item *models.Item) error {
 	}
 
 	// Check if item exists
-	_, err := s.repo.GetByID(item.ID)
+	existing, err := s.repo.GetByID(item.ID)
 	if err != nil {
 		return errors.New("item not found")
 	}
 
-	return s.repo.Update(item)
+	// Ownership, creation time and how the item was reported can't be changed
+	// by an update; drafts are only listed through Publish
+	item.UserID = existing.UserID
+	item.OrganizationID = existing.OrganizationID
+	item.ExternalReference = existing.ExternalReference
+	item.CreatedAt = existing.CreatedAt
+	item.ReferenceCode = existing.ReferenceCode
+	item.Source = existing.Source
+	item.Draft = existing.Draft
+	item.HiddenAt = existing.HiddenAt
+	item.MergedIntoID = existing.MergedIntoID
+	item.ConfirmedAt, item.RemindedAt, item.ArchivedAt = existing.ConfirmedAt, existing.RemindedAt, existing.ArchivedAt
+	item.RewardEscrowed = existing.RewardEscrowed
+	item.RiskScore, item.RiskSignals = existing.RiskScore, existing.RiskSignals
+	if existing.RewardEscrowed {
+		item.RewardCents = existing.RewardCents
+	}
+
+	if err := s.locations.NormalizeItem(item); err != nil {
+		return err
+	}
+	if err := validateItemCoordinates(item); err != nil {
+		return err
+	}
+	if item.Transit != nil {
+		item.Transit.Normalize()
+	}
+	if err := s.categories.ValidateItemAttributes(item); err != nil {
+		return err
+	}
+
+	if err := s.repo.Update(item); err != nil {
+		return err
+	}
+
+	// Hidden items are kept out of matching and alerts until reinstated
+	if !item.Draft && item.HiddenAt == nil {
+		for _, hook := range s.afterUpdate {
+			hook(item)
+		}
+	}
+	return nil
 }
 
 // Delete removes an item
@@ -73,7 +432,7 @@ func (s *ItemService) Delete(id uuid.UUID) error {
 }
 
 // Search searches items by keyword
-func (s *ItemService) Search(keyword string, page, limit int) ([]models.Item, int64, error) {
+func (s *ItemService) Search(keyword string, filter repository.ItemFilter, page, limit int) ([]models.Item, int64, error) {
 	// Default pagination values
 	if page <= 0 {
 		page = 1
@@ -82,5 +441,123 @@ func (s *ItemService) Search(keyword string, page, limit int) ([]models.Item, in
 		limit = 10
 	}
 
-	return s.repo.SearchByKeyword(keyword, page, limit)
+	if err := s.checkAttributeFilters(filter); err != nil {
+		return nil, 0, err
+	}
+	s.NormalizeFilter(&filter)
+
+	return s.repo.SearchByKeyword(keyword, filter, page, limit)
+}
+
+// newReferenceCode generates a reference code no other item uses
+func (s *ItemService) newReferenceCode() (string, error) {
+	for range 5 {
+		code, err := randomCode(referenceCodeLength)
+		if err != nil {
+			return "", err
+		}
+		exists, err := s.repo.ReferenceExists(code)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return code, nil
+		}
+	}
+	return "", errors.New("failed to generate a unique reference code")
+}
+
+// validateItemCoordinates checks that an item has both coordinates or neither
+func validateItemCoordinates(item *models.Item) error {
+	if (item.Latitude == nil) != (item.Longitude == nil) {
+		return errors.New("latitude and longitude must be provided together")
+	}
+	if !item.HasCoordinates() {
+		return nil
+	}
+	if item.Precision < 0 {
+		return errors.New("precision must not be negative")
+	}
+	return validateCoordinates(*item.Latitude, *item.Longitude)
+}
+
+// prepareNew gives a validated new item its tags, source and reference code
+func (s *ItemService) prepareNew(item *models.Item, source models.ItemSource) error {
+	// A new item starts its life afresh, whatever the caller sent
+	item.Source = source
+	item.Draft = false
+	item.HiddenAt, item.MergedIntoID = nil, nil
+	item.ConfirmedAt, item.RemindedAt, item.ArchivedAt = nil, nil, nil
+	item.RewardEscrowed = false
+	item.RiskScore, item.RiskSignals = 0, nil
+
+	if err := s.applyTags(item); err != nil {
+		return err
+	}
+	code, err := s.newReferenceCode()
+	if err != nil {
+		return err
+	}
+	item.ReferenceCode = &code
+	return nil
+}
+
+// created runs the after-create hooks for a new item that is not a draft
+func (s *ItemService) created(item *models.Item) {
+	if item.Draft {
+		return
+	}
+	for _, hook := range s.afterCreate {
+		hook(item)
+	}
+}
+
+// applyTags normalises the tags supplied with a new item, or suggests tags
+// from its title and description when none were supplied
+func (s *ItemService) applyTags(item *models.Item) error {
+	names := make([]string, 0, len(item.Tags))
+	for _, tag := range item.Tags {
+		names = append(names, tag.Name)
+	}
+
+	if len(names) == 0 {
+		suggested, err := s.tags.Suggest(item)
+		if err != nil {
+			return err
+		}
+		names = suggested
+	}
+
+	tags, err := s.tags.Resolve(names)
+	if err != nil {
+		return err
+	}
+	item.Tags = tags
+	return nil
+}
+
+// checkAttributeFilters rejects filters on private attributes, which would
+// reveal whether an item with a given serial number exists
+func (s *ItemService) checkAttributeFilters(filter repository.ItemFilter) error {
+	for key := range filter.Attributes {
+		private, err := s.categories.IsPrivateAttribute(key)
+		if err != nil {
+			return err
+		}
+		if private {
+			return fmt.Errorf("%w: attr.%s", ErrPrivateAttributeFilter, key)
+		}
+	}
+	return nil
+}
+
+// NormalizeFilter puts filters given by the client into the form items are stored in
+func (s *ItemService) NormalizeFilter(filter *repository.ItemFilter) {
+	s.locations.NormalizeFilter(filter)
+	if filter.CategoryPath != "" {
+		filter.CategoryPath = s.categories.ResolvePath(filter.CategoryPath)
+	}
+	filter.Operator = models.NormalizeOperator(filter.Operator)
+	filter.RouteNumber = models.NormalizeRouteNumber(filter.RouteNumber)
+	filter.VehicleRegistration = models.NormalizeVehicleRegistration(filter.VehicleRegistration)
 }
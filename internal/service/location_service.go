@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"lostnfound-api/internal/gazetteer"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+)
+
+var (
+	ErrUnknownCounty    = errors.New("unknown county")
+	ErrUnknownSubCounty = errors.New("unknown sub-county")
+	ErrUnknownWard      = errors.New("unknown ward")
+)
+
+// LocationService provides Kenyan administrative geography lookups
+type LocationService struct {
+	gazetteer *gazetteer.Gazetteer
+}
+
+// NewLocationService creates a new LocationService
+func NewLocationService(g *gazetteer.Gazetteer) *LocationService {
+	return &LocationService{gazetteer: g}
+}
+
+// Counties returns all 47 counties
+func (s *LocationService) Counties() []gazetteer.County {
+	return s.gazetteer.Counties()
+}
+
+// County retrieves a county with its sub-counties
+func (s *LocationService) County(id string) (*gazetteer.County, error) {
+	county, ok := s.gazetteer.County(id)
+	if !ok {
+		return nil, ErrUnknownCounty
+	}
+	return county, nil
+}
+
+// SubCounty retrieves a sub-county with its wards
+func (s *LocationService) SubCounty(id string) (*gazetteer.SubCounty, error) {
+	sub, ok := s.gazetteer.SubCounty(id)
+	if !ok {
+		return nil, ErrUnknownSubCounty
+	}
+	return sub, nil
+}
+
+// Resolve maps free text to the gazetteer entry it most likely refers to
+func (s *LocationService) Resolve(text string) (*gazetteer.Match, bool) {
+	return s.gazetteer.Resolve(text)
+}
+
+// NormalizeItem fills in an item's structured location. Explicit IDs are
+// validated and their parents filled in; otherwise the free-text Location
+// is resolved against the gazetteer. Unresolvable text is left as is.
+func (s *LocationService) NormalizeItem(item *models.Item) error {
+	switch {
+	case item.WardID != "":
+		ward, ok := s.gazetteer.Ward(item.WardID)
+		if !ok {
+			return ErrUnknownWard
+		}
+		item.SubCountyID, item.CountyID = ward.SubCountyID, ward.CountyID
+	case item.SubCountyID != "":
+		sub, ok := s.gazetteer.SubCounty(item.SubCountyID)
+		if !ok {
+			return ErrUnknownSubCounty
+		}
+		item.CountyID = sub.CountyID
+	case item.CountyID != "":
+		if _, ok := s.gazetteer.County(item.CountyID); !ok {
+			return ErrUnknownCounty
+		}
+	case item.Location != "":
+		if match, ok := s.gazetteer.Resolve(item.Location); ok {
+			item.CountyID, item.SubCountyID, item.WardID = match.CountyID, match.SubCountyID, match.WardID
+		}
+	}
+
+	return nil
+}
+
+// NormalizeFilter turns county and sub-county filters given as names
+// ("Nbi", "nairobi") into gazetteer IDs
+func (s *LocationService) NormalizeFilter(filter *repository.ItemFilter) {
+	if filter.CountyID != "" {
+		if _, ok := s.gazetteer.County(filter.CountyID); !ok {
+			if match, ok := s.gazetteer.Resolve(filter.CountyID); ok {
+				filter.CountyID = match.CountyID
+			}
+		}
+	}
+	if filter.SubCountyID != "" {
+		if _, ok := s.gazetteer.SubCounty(filter.SubCountyID); !ok {
+			if match, ok := s.gazetteer.Resolve(filter.SubCountyID); ok && match.SubCountyID != "" {
+				filter.SubCountyID = match.SubCountyID
+			}
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package service
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// matchWindow is how far apart a lost and a found report may be dated
+	matchWindow = 14 * 24 * time.Hour
+	// matchCandidateLimit bounds how many candidates are scored per item
+	matchCandidateLimit = 200
+	// minMatchScore is the lowest score returned as a match
+	minMatchScore = 0.3
+)
+
+// Match is a candidate counterpart for an item with the reasons it was suggested
+type Match struct {
+	Item    models.Item `json:"item"`
+	Score   float64     `json:"score"`
+	Reasons []string    `json:"reasons"`
+}
+
+// MatchService pairs lost items with found items
+type MatchService struct {
+	repo *repository.ItemRepository
+}
+
+// NewMatchService creates a new MatchService
+func NewMatchService(repo *repository.ItemRepository) *MatchService {
+	return &MatchService{repo: repo}
+}
+
+// FindMatches returns the items most likely to be the counterpart of the
+// given item, best first
+func (s *MatchService) FindMatches(id uuid.UUID, limit int) ([]Match, error) {
+	item, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.match(item, limit)
+}
+
+// MatchesFor returns an item's matches to a viewer. Drafts and items hidden
+// by moderators are only matched for their reporter and admins; anyone else
+// gets ErrItemNotFound, as when viewing the item.
+func (s *MatchService) MatchesFor(id, viewerID uuid.UUID, isAdmin bool, limit int) ([]Match, error) {
+	item, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if (item.Draft || item.HiddenAt != nil) && item.UserID != viewerID && !isAdmin {
+		return nil, ErrItemNotFound
+	}
+	return s.match(item, limit)
+}
+
+// match scores the candidates for an item, best first
+func (s *MatchService) match(item *models.Item, limit int) ([]Match, error) {
+	if item.Date.IsZero() {
+		item.Date = item.CreatedAt
+	}
+
+	candidates, err := s.repo.MatchCandidates(item, matchWindow, matchCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]Match, 0, len(candidates))
+	for _, candidate := range candidates {
+		score, reasons := scoreMatch(item, &candidate)
+		if score >= minMatchScore {
+			matches = append(matches, Match{Item: candidate, Score: score, Reasons: reasons})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Score > matches[j].Score
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+
+	return matches, nil
+}
+
+// scoreMatch rates how likely two items are the same object, in [0, 1]
+func scoreMatch(a, b *models.Item) (float64, []string) {
+	var score float64
+	var reasons []string
+
+	switch {
+	case a.CategoryID != nil && b.CategoryID != nil && *a.CategoryID == *b.CategoryID:
+		score += 0.2
+		reasons = append(reasons, "same category")
+	case a.Category != nil && b.Category != nil && a.Category.ParentID != nil &&
+		b.Category.ParentID != nil && *a.Category.ParentID == *b.Category.ParentID:
+		score += 0.1
+		reasons = append(reasons, "related category")
+	}
+
+	// Agreeing attributes (brand, colour, ...) add confidence; contradicting
+	// ones remove it. Private ones are left out so scores can't confirm an IMEI.
+	agree, disagree := comparePublicAttributes(a, b)
+	if agree > 0 {
+		score += math.Min(0.05*float64(agree), 0.2)
+		reasons = append(reasons, "matching attributes")
+	}
+	score -= 0.1 * float64(disagree)
+
+	switch {
+	case a.WardID != "" && a.WardID == b.WardID:
+		score += 0.2
+		reasons = append(reasons, "same ward")
+	case a.SubCountyID != "" && a.SubCountyID == b.SubCountyID:
+		score += 0.15
+		reasons = append(reasons, "same sub-county")
+	case a.CountyID != "" && a.CountyID == b.CountyID:
+		score += 0.1
+		reasons = append(reasons, "same county")
+	}
+
+	if overlap := wordOverlap(a.Title+" "+a.Description, b.Title+" "+b.Description); overlap > 0 {
+		score += 0.2 * overlap
+		reasons = append(reasons, "similar description")
+	}
+
+	transitScore, transitReasons := scoreTransit(a, b)
+	score += transitScore
+	reasons = append(reasons, transitReasons...)
+
+	// Closer dates score higher
+	days := math.Abs(a.Date.Sub(b.Date).Hours()) / 24
+	windowDays := matchWindow.Hours() / 24
+	score += 0.1 * math.Max(0, 1-days/windowDays)
+
+	return math.Max(0, math.Min(score, 1)), reasons
+}
+
+// comparePublicAttributes counts the attributes two items share with equal
+// and with different values, skipping any either item keeps private
+func comparePublicAttributes(a, b *models.Item) (agree, disagree int) {
+	private := make(map[string]bool, len(a.PrivateAttributes)+len(b.PrivateAttributes))
+	for _, keys := range [][]string{a.PrivateAttributes, b.PrivateAttributes} {
+		for _, key := range keys {
+			private[key] = true
+		}
+	}
+	for key, va := range a.Attributes {
+		vb, ok := b.Attributes[key]
+		if !ok || private[key] {
+			continue
+		}
+		if strings.EqualFold(fmt.Sprint(va), fmt.Sprint(vb)) {
+			agree++
+		} else {
+			disagree++
+		}
+	}
+	return agree, disagree
+}
+
+// scoreTransit rates how well two items' journeys line up
+func scoreTransit(a, b *models.Item) (float64, []string) {
+	ta, tb := a.Transit, b.Transit
+	if ta == nil || tb == nil {
+		return 0, nil
+	}
+
+	var score float64
+	var reasons []string
+
+	if ta.VehicleRegistration != "" && ta.VehicleRegistration == tb.VehicleRegistration {
+		score += 0.4
+		reasons = append(reasons, "same vehicle")
+	}
+	if ta.RouteNumber != "" && ta.RouteNumber == tb.RouteNumber {
+		score += 0.25
+		reasons = append(reasons, "same route")
+	}
+	if ta.Operator != "" && ta.Operator == tb.Operator {
+		score += 0.15
+		reasons = append(reasons, "same operator")
+	}
+	if sharesStage(ta, tb) {
+		score += 0.1
+		reasons = append(reasons, "shared stage")
+	}
+
+	// A found item handed in at an operator's office rarely has exact times,
+	// so reports from the same day count as well as overlapping journeys
+	switch {
+	case journeysOverlap(ta, tb):
+		score += 0.2
+		reasons = append(reasons, "overlapping travel times")
+	case sameDay(journeyTime(a), journeyTime(b)):
+		score += 0.15
+		reasons = append(reasons, "same day")
+	}
+
+	return score, reasons
+}
+
+// journeyTime is when an item's journey started, falling back to its reported date
+func journeyTime(item *models.Item) time.Time {
+	if item.Transit != nil && item.Transit.DepartedAt != nil {
+		return *item.Transit.DepartedAt
+	}
+	return item.Date
+}
+
+// journeysOverlap reports whether two travel windows intersect
+func journeysOverlap(a, b *models.TransitContext) bool {
+	if a.DepartedAt == nil || b.DepartedAt == nil {
+		return false
+	}
+
+	endA, endB := *a.DepartedAt, *b.DepartedAt
+	if a.ArrivedAt != nil {
+		endA = *a.ArrivedAt
+	}
+	if b.ArrivedAt != nil {
+		endB = *b.ArrivedAt
+	}
+
+	// Allow an hour either side for imprecise reports
+	slack := time.Hour
+	return !a.DepartedAt.After(endB.Add(slack)) && !b.DepartedAt.After(endA.Add(slack))
+}
+
+// sharesStage reports whether two journeys share a boarding or alighting stage
+func sharesStage(a, b *models.TransitContext) bool {
+	stages := map[string]bool{}
+	for _, stage := range []string{a.BoardingStage, a.AlightingStage} {
+		if stage != "" {
+			stages[strings.ToLower(stage)] = true
+		}
+	}
+	for _, stage := range []string{b.BoardingStage, b.AlightingStage} {
+		if stage != "" && stages[strings.ToLower(stage)] {
+			return true
+		}
+	}
+	return false
+}
+
+func sameDay(a, b time.Time) bool {
+	ya, ma, da := a.Date()
+	yb, mb, db := b.Date()
+	return !a.IsZero() && ya == yb && ma == mb && da == db
+}
+
+// wordOverlap returns the Jaccard similarity of the words of two texts
+func wordOverlap(a, b string) float64 {
+	wordsA, wordsB := wordSet(a), wordSet(b)
+	if len(wordsA) == 0 || len(wordsB) == 0 {
+		return 0
+	}
+
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(wordsA)+len(wordsB)-shared)
+}
+
+// wordSet returns the distinct lowercase words of at least three letters in text
+func wordSet(text string) map[string]bool {
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}) {
+		if len(word) >= 3 {
+			words[word] = true
+		}
+	}
+	return words
+}
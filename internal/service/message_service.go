@@ -0,0 +1,429 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"mime/multipart"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxMessageLength bounds the length of a message body
+const maxMessageLength = 4000
+
+var (
+	// phonePattern finds Kenyan and international phone numbers in message text
+	phonePattern = regexp.MustCompile(`(\+?254|0)[\s-]?[17][0-9](?:[\s-]?[0-9]){7}\b`)
+	// emailPattern finds email addresses in message text
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+)
+
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrConversationBlocked  = errors.New("messages between these users are blocked")
+	ErrOwnItemConversation  = errors.New("you cannot start a conversation about your own item")
+	ErrEmptyMessage         = errors.New("message needs text or an attachment")
+)
+
+// ContactDetails are a user's contact details, revealed once both parties agree
+type ContactDetails struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// ConversationSummary is a conversation with its unread count for the viewer
+type ConversationSummary struct {
+	models.Conversation
+	Unread int64 `json:"unread"`
+}
+
+// ContactExchange reports the state of the contact exchange in a conversation
+type ContactExchange struct {
+	ClaimApproved     bool            `json:"claim_approved"`
+	OwnerAgreed       bool            `json:"owner_agreed"`
+	ParticipantAgreed bool            `json:"participant_agreed"`
+	Owner             *ContactDetails `json:"owner,omitempty"`
+	Participant       *ContactDetails `json:"participant,omitempty"`
+}
+
+// MessageHook is called after a message has been sent
+type MessageHook func(conversation *models.Conversation, message *models.Message)
+
+// MessageReportHook is called after a conversation or message has been reported
+type MessageReportHook func(report *models.MessageReport)
+
+// MessageService provides masked messaging between item owners and other users
+type MessageService struct {
+	repo     *repository.ConversationRepository
+	itemRepo *repository.ItemRepository
+	userRepo *repository.UserRepository
+	claims   *ClaimService
+	storage  *StorageService
+
+	afterSend   []MessageHook
+	afterReport []MessageReportHook
+}
+
+// NewMessageService creates a new MessageService
+func NewMessageService(repo *repository.ConversationRepository, itemRepo *repository.ItemRepository, userRepo *repository.UserRepository, claims *ClaimService, storage *StorageService) *MessageService {
+	return &MessageService{repo: repo, itemRepo: itemRepo, userRepo: userRepo, claims: claims, storage: storage}
+}
+
+// AfterSend registers a hook that runs after every message is sent
+func (s *MessageService) AfterSend(hook MessageHook) {
+	s.afterSend = append(s.afterSend, hook)
+}
+
+// AfterReport registers a hook that runs after every report
+func (s *MessageService) AfterReport(hook MessageReportHook) {
+	s.afterReport = append(s.afterReport, hook)
+}
+
+// StartForItem opens (or reopens) the conversation between a user and an item's owner
+func (s *MessageService) StartForItem(itemID, userID uuid.UUID) (*models.Conversation, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if item.UserID == userID {
+		return nil, ErrOwnItemConversation
+	}
+	return s.findOrCreate(item, userID, nil)
+}
+
+// StartForClaim opens the conversation about a claim. Either the claimer or
+// the item owner may start it.
+func (s *MessageService) StartForClaim(claimID, userID uuid.UUID) (*models.Conversation, error) {
+	claim, err := s.claims.GetForViewer(claimID, userID, false)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.itemRepo.GetByID(claim.ItemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	return s.findOrCreate(item, claim.ClaimerID, &claim.ID)
+}
+
+// List retrieves a user's conversations with unread counts
+func (s *MessageService) List(userID uuid.UUID, page, limit int) ([]ConversationSummary, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	conversations, total, err := s.repo.ListForUser(userID, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]uuid.UUID, len(conversations))
+	for i, conversation := range conversations {
+		ids[i] = conversation.ID
+	}
+	unread, err := s.repo.UnreadCounts(userID, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	summaries := make([]ConversationSummary, len(conversations))
+	for i, conversation := range conversations {
+		summaries[i] = ConversationSummary{Conversation: conversation, Unread: unread[conversation.ID]}
+	}
+	return summaries, total, nil
+}
+
+// Get retrieves a conversation the user takes part in
+func (s *MessageService) Get(id, userID uuid.UUID) (*models.Conversation, error) {
+	conversation, err := s.repo.GetByID(id)
+	if err != nil || !conversation.HasParty(userID) {
+		return nil, ErrConversationNotFound
+	}
+	return conversation, nil
+}
+
+// Messages retrieves a page of messages and marks those received as read
+func (s *MessageService) Messages(id, userID uuid.UUID, page, limit int) ([]models.Message, int64, error) {
+	if _, err := s.Get(id, userID); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	messages, total, err := s.repo.ListMessages(id, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := s.repo.MarkRead(id, userID, time.Now()); err != nil {
+		return nil, 0, err
+	}
+
+	// Messages hidden by moderators are only shown to their sender
+	for i := range messages {
+		if messages[i].HiddenAt != nil && messages[i].SenderID != userID {
+			messages[i].Body, messages[i].AttachmentURL = "", ""
+		}
+	}
+	return messages, total, nil
+}
+
+// MarkRead marks every message the user has received in a conversation as read
+func (s *MessageService) MarkRead(id, userID uuid.UUID) error {
+	if _, err := s.Get(id, userID); err != nil {
+		return err
+	}
+	return s.repo.MarkRead(id, userID, time.Now())
+}
+
+// Send posts a message, optionally with an attachment. Phone numbers and
+// email addresses are masked until the parties have exchanged contacts.
+func (s *MessageService) Send(ctx context.Context, id, senderID uuid.UUID, body string, file multipart.File, fileHeader *multipart.FileHeader) (*models.Message, error) {
+	conversation, err := s.Get(id, senderID)
+	if err != nil {
+		return nil, err
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" && file == nil {
+		return nil, ErrEmptyMessage
+	}
+	if len(body) > maxMessageLength {
+		return nil, fmt.Errorf("message must be at most %d characters", maxMessageLength)
+	}
+
+	blocked, err := s.repo.IsBlocked(conversation.OwnerID, conversation.ParticipantID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrConversationBlocked
+	}
+
+	exchange, err := s.contactExchange(conversation)
+	if err != nil {
+		return nil, err
+	}
+	if !exchange.revealed() {
+		body = MaskContactDetails(body)
+	}
+
+	message := &models.Message{ConversationID: conversation.ID, SenderID: senderID, Body: body}
+	if file != nil {
+		url, err := s.storage.UploadMessageAttachment(ctx, conversation.ID, file, fileHeader)
+		if err != nil {
+			return nil, err
+		}
+		message.AttachmentURL = url
+	}
+
+	if err := s.repo.CreateMessage(message); err != nil {
+		return nil, err
+	}
+
+	for _, hook := range s.afterSend {
+		hook(conversation, message)
+	}
+	return message, nil
+}
+
+// ShareContact records that the user agrees to share contact details
+func (s *MessageService) ShareContact(id, userID uuid.UUID) (*ContactExchange, error) {
+	conversation, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if conversation.OwnerID == userID {
+		conversation.OwnerSharesContact = true
+	} else {
+		conversation.ParticipantSharesContact = true
+	}
+	if err := s.repo.Update(conversation); err != nil {
+		return nil, err
+	}
+
+	return s.Contacts(id, userID)
+}
+
+// Contacts returns the contact exchange state, including both parties'
+// details once a claim is approved and both have agreed
+func (s *MessageService) Contacts(id, userID uuid.UUID) (*ContactExchange, error) {
+	conversation, err := s.Get(id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange, err := s.contactExchange(conversation)
+	if err != nil || !exchange.revealed() {
+		return exchange, err
+	}
+
+	item, err := s.itemRepo.GetByID(conversation.ItemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	owner, err := s.userRepo.GetByID(conversation.OwnerID)
+	if err != nil {
+		return nil, err
+	}
+	participant, err := s.userRepo.GetByID(conversation.ParticipantID)
+	if err != nil {
+		return nil, err
+	}
+
+	exchange.Owner = contactDetails(owner)
+	if item.Contact != "" {
+		exchange.Owner.Phone = item.Contact
+	}
+	exchange.Participant = contactDetails(participant)
+	return exchange, nil
+}
+
+// Block stops the other party from messaging the user
+func (s *MessageService) Block(id, userID uuid.UUID) error {
+	conversation, err := s.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Block(userID, conversation.OtherParty(userID))
+}
+
+// Unblock lifts a block on the other party
+func (s *MessageService) Unblock(id, userID uuid.UUID) error {
+	conversation, err := s.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	return s.repo.Unblock(userID, conversation.OtherParty(userID))
+}
+
+// Report flags the other party, optionally pointing at one of their messages
+func (s *MessageService) Report(id, reporterID uuid.UUID, messageID *uuid.UUID, reason models.MessageReportReason, details string) (*models.MessageReport, error) {
+	conversation, err := s.Get(id, reporterID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch reason {
+	case models.MessageReportSpam, models.MessageReportScam, models.MessageReportHarassment, models.MessageReportOther:
+	default:
+		return nil, fmt.Errorf("unknown report reason %q", reason)
+	}
+
+	if messageID != nil {
+		message, err := s.repo.GetMessage(*messageID)
+		if err != nil || message.ConversationID != conversation.ID || message.SenderID == reporterID {
+			return nil, errors.New("message not found in this conversation")
+		}
+	}
+
+	report := &models.MessageReport{
+		ConversationID: conversation.ID,
+		MessageID:      messageID,
+		ReporterID:     reporterID,
+		ReportedUserID: conversation.OtherParty(reporterID),
+		Reason:         reason,
+		Details:        strings.TrimSpace(details),
+	}
+	if err := s.repo.CreateReport(report); err != nil {
+		return nil, err
+	}
+
+	for _, hook := range s.afterReport {
+		hook(report)
+	}
+	return report, nil
+}
+
+// ListReports retrieves message reports for moderators
+func (s *MessageService) ListReports(page, limit int) ([]models.MessageReport, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListReports(page, limit)
+}
+
+// MaskContactDetails hides phone numbers and email addresses in text
+func MaskContactDetails(text string) string {
+	text = phonePattern.ReplaceAllString(text, "[phone hidden]")
+	return emailPattern.ReplaceAllString(text, "[email hidden]")
+}
+
+// findOrCreate returns the conversation between an item's owner and a
+// participant, creating it if needed and linking the claim if given
+func (s *MessageService) findOrCreate(item *models.Item, participantID uuid.UUID, claimID *uuid.UUID) (*models.Conversation, error) {
+	blocked, err := s.repo.IsBlocked(item.UserID, participantID)
+	if err != nil {
+		return nil, err
+	}
+	if blocked {
+		return nil, ErrConversationBlocked
+	}
+
+	conversation, err := s.repo.Find(item.ID, participantID)
+	if err != nil {
+		return nil, err
+	}
+	if conversation != nil {
+		if claimID != nil && (conversation.ClaimID == nil || *conversation.ClaimID != *claimID) {
+			conversation.ClaimID = claimID
+			if err := s.repo.Update(conversation); err != nil {
+				return nil, err
+			}
+		}
+		return conversation, nil
+	}
+
+	conversation = &models.Conversation{
+		ItemID:        item.ID,
+		ClaimID:       claimID,
+		OwnerID:       item.UserID,
+		ParticipantID: participantID,
+	}
+	if err := s.repo.Create(conversation); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// contactExchange works out whether contacts may be revealed in a conversation
+func (s *MessageService) contactExchange(conversation *models.Conversation) (*ContactExchange, error) {
+	approved, err := s.claims.HasApprovedClaim(conversation.ItemID, conversation.ParticipantID)
+	if err != nil {
+		return nil, err
+	}
+	return &ContactExchange{
+		ClaimApproved:     approved,
+		OwnerAgreed:       conversation.OwnerSharesContact,
+		ParticipantAgreed: conversation.ParticipantSharesContact,
+	}, nil
+}
+
+// revealed reports whether contact details may be shown
+func (e *ContactExchange) revealed() bool {
+	return e.ClaimApproved && e.OwnerAgreed && e.ParticipantAgreed
+}
+
+// contactDetails extracts the shareable contact details of a user
+func contactDetails(user *models.User) *ContactDetails {
+	return &ContactDetails{
+		Name:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Phone: user.Phone,
+		Email: user.Email,
+	}
+}
@@ -0,0 +1,400 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// maxBulkReports bounds how many reports one moderation request covers
+	maxBulkReports = 100
+	// appealWindow is how long after an action its target can appeal it
+	appealWindow = 30 * 24 * time.Hour
+)
+
+var (
+	ErrModerationReportNotFound = errors.New("report not found")
+	ErrReportClosed             = errors.New("report has already been closed")
+	ErrAlreadyReported          = errors.New("you have already reported this")
+	ErrReportOwnItem            = errors.New("you cannot report your own item")
+	ErrActionNotApplicable      = errors.New("action does not apply to what was reported")
+	ErrModerationNotFound       = errors.New("moderation action not found")
+	ErrAppealNotFound           = errors.New("appeal not found")
+	ErrAppealExists             = errors.New("this action has already been appealed")
+	ErrAppealNotAllowed         = errors.New("this action cannot be appealed")
+	ErrAppealWindowExpired      = errors.New("the time to appeal this action has passed")
+	ErrAppealAlreadyDecided     = errors.New("appeal has already been decided")
+	ErrBanned                   = errors.New("your account has been banned; you can appeal from the app")
+)
+
+// ModerationHook is called after a moderator hides, removes, warns or bans.
+// item is the item the action is about, if any.
+type ModerationHook func(action *models.ModerationAction, item *models.Item)
+
+// ModerationRequest is a moderator acting on reports from the queue
+type ModerationRequest struct {
+	ReportIDs []uuid.UUID                 `json:"report_ids" binding:"required"`
+	Action    models.ModerationActionType `json:"action" binding:"required"`
+	// Reason overrides the reason code given in the reports
+	Reason models.ReportReason `json:"reason"`
+	Note   string              `json:"note"`
+}
+
+// ModerationService runs the moderation queue: users report items, messages
+// and people, moderators act on the reports, and the people acted against
+// can appeal
+type ModerationService struct {
+	repo     *repository.ModerationRepository
+	itemRepo *repository.ItemRepository
+
+	afterAction []ModerationHook
+}
+
+// NewModerationService creates a new ModerationService
+func NewModerationService(repo *repository.ModerationRepository, itemRepo *repository.ItemRepository) *ModerationService {
+	return &ModerationService{repo: repo, itemRepo: itemRepo}
+}
+
+// AfterAction registers a hook that runs after every hide, removal, warning or ban
+func (s *ModerationService) AfterAction(hook ModerationHook) {
+	s.afterAction = append(s.afterAction, hook)
+}
+
+// ReportItem files a report about an item
+func (s *ModerationService) ReportItem(itemID, reporterID uuid.UUID, reason models.ReportReason, details string) (*models.Report, error) {
+	if !slices.Contains(models.ReportReasons, reason) {
+		return nil, fmt.Errorf("unknown report reason %q", reason)
+	}
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil || item.Draft {
+		return nil, ErrItemNotFound
+	}
+	if item.UserID == reporterID {
+		return nil, ErrReportOwnItem
+	}
+
+	open, err := s.repo.HasOpenReport(models.ReportSubjectItem, itemID, reporterID)
+	if err != nil {
+		return nil, err
+	}
+	if open {
+		return nil, ErrAlreadyReported
+	}
+
+	report := &models.Report{
+		SubjectType:    models.ReportSubjectItem,
+		SubjectID:      itemID,
+		ReporterID:     reporterID,
+		ReportedUserID: item.UserID,
+		Reason:         reason,
+		Details:        strings.TrimSpace(details),
+		Status:         models.ReportStatusOpen,
+	}
+	if err := s.repo.CreateReport(report); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// MessageReported adds a report from a conversation to the queue. A report
+// pointing at a message is about that message; otherwise it is about the
+// other person. It is registered as a message report hook.
+func (s *ModerationService) MessageReported(report *models.MessageReport) {
+	queued := &models.Report{
+		SubjectType:    models.ReportSubjectUser,
+		SubjectID:      report.ReportedUserID,
+		ReporterID:     report.ReporterID,
+		ReportedUserID: report.ReportedUserID,
+		Reason:         models.ReportReason(report.Reason),
+		Details:        report.Details,
+		Status:         models.ReportStatusOpen,
+	}
+	if report.MessageID != nil {
+		queued.SubjectType, queued.SubjectID = models.ReportSubjectMessage, *report.MessageID
+	}
+	if err := s.repo.CreateReport(queued); err != nil {
+		log.Printf("moderation: failed to queue message report %s: %v", report.ID, err)
+	}
+}
+
+// Queue retrieves reports for moderators
+func (s *ModerationService) Queue(filter repository.ReportFilter, page, limit int) ([]models.Report, int64, error) {
+	page, limit = moderationPage(page, limit)
+	return s.repo.ListReports(filter, page, limit)
+}
+
+// Act applies one action to every report in a request. Hide and remove act
+// on what was reported, once per item or message. Warn and ban act on the
+// people reported, once per person. Dismiss closes the reports without
+// acting. Every report is checked before anything is done, so a request
+// with a closed report or an action that does not fit changes nothing.
+func (s *ModerationService) Act(moderatorID uuid.UUID, req ModerationRequest) ([]models.ModerationAction, error) {
+	if len(req.ReportIDs) == 0 || len(req.ReportIDs) > maxBulkReports {
+		return nil, fmt.Errorf("report_ids must list between 1 and %d reports", maxBulkReports)
+	}
+	if req.Reason != "" && !slices.Contains(models.ReportReasons, req.Reason) {
+		return nil, fmt.Errorf("unknown reason %q", req.Reason)
+	}
+
+	reports, err := s.repo.GetReports(req.ReportIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) != len(slices.Compact(sortedIDs(req.ReportIDs))) {
+		return nil, ErrModerationReportNotFound
+	}
+	for _, report := range reports {
+		if report.Status != models.ReportStatusOpen {
+			return nil, fmt.Errorf("%w: %s", ErrReportClosed, report.ID)
+		}
+		if err := actionApplies(req.Action, report.SubjectType); err != nil {
+			return nil, err
+		}
+	}
+
+	status := models.ReportStatusActioned
+	if req.Action == models.ModerationDismiss {
+		status = models.ReportStatusDismissed
+	}
+
+	var actions []models.ModerationAction
+	for _, group := range groupReports(req.Action, reports) {
+		first := group[0]
+		action := &models.ModerationAction{
+			ModeratorID:  moderatorID,
+			Action:       req.Action,
+			SubjectType:  first.SubjectType,
+			SubjectID:    first.SubjectID,
+			TargetUserID: first.ReportedUserID,
+			Reason:       req.Reason,
+			Note:         strings.TrimSpace(req.Note),
+		}
+		if req.Action == models.ModerationWarn || req.Action == models.ModerationBan {
+			action.SubjectType, action.SubjectID = models.ReportSubjectUser, first.ReportedUserID
+		}
+		if action.Reason == "" {
+			action.Reason = first.Reason
+		}
+		for _, report := range group {
+			action.ReportIDs = append(action.ReportIDs, report.ID)
+		}
+
+		// The item is loaded first so its title is known after it is removed
+		var item *models.Item
+		if first.SubjectType == models.ReportSubjectItem {
+			if found, err := s.itemRepo.GetByID(first.SubjectID); err == nil {
+				item = found
+			}
+		}
+
+		if err := s.repo.RecordAction(action, status); err != nil {
+			if errors.Is(err, repository.ErrReportsClosed) {
+				return actions, ErrReportClosed
+			}
+			return actions, err
+		}
+		actions = append(actions, *action)
+
+		if req.Action != models.ModerationDismiss {
+			for _, hook := range s.afterAction {
+				hook(action, item)
+			}
+		}
+	}
+	return actions, nil
+}
+
+// AuditLog retrieves moderation actions for moderators
+func (s *ModerationService) AuditLog(filter repository.ModerationActionFilter, page, limit int) ([]models.ModerationAction, int64, error) {
+	page, limit = moderationPage(page, limit)
+	return s.repo.ListActions(filter, page, limit)
+}
+
+// ActionsAgainst retrieves the actions taken against a user, for that user
+func (s *ModerationService) ActionsAgainst(userID uuid.UUID, page, limit int) ([]models.ModerationAction, int64, error) {
+	page, limit = moderationPage(page, limit)
+	return s.repo.ListActionsAgainst(userID, page, limit)
+}
+
+// Appeal asks moderators to reconsider an action taken against a user
+func (s *ModerationService) Appeal(actionID, userID uuid.UUID, statement string) (*models.Appeal, error) {
+	statement = strings.TrimSpace(statement)
+	if statement == "" {
+		return nil, errors.New("statement is required")
+	}
+
+	action, err := s.repo.GetAction(actionID)
+	if err != nil || action.TargetUserID != userID {
+		return nil, ErrModerationNotFound
+	}
+	if !appealable(action.Action) || action.ReversedAt != nil {
+		return nil, ErrAppealNotAllowed
+	}
+	if time.Since(action.CreatedAt) > appealWindow {
+		return nil, ErrAppealWindowExpired
+	}
+	if _, err := s.repo.AppealFor(actionID); err == nil {
+		return nil, ErrAppealExists
+	}
+
+	appeal := &models.Appeal{
+		ActionID:  actionID,
+		UserID:    userID,
+		Statement: statement,
+		Status:    models.AppealStatusPending,
+	}
+	if err := s.repo.CreateAppeal(appeal); err != nil {
+		return nil, err
+	}
+	return appeal, nil
+}
+
+// Appeals retrieves appeals, for moderators or, when userID is set, for the
+// user who made them
+func (s *ModerationService) Appeals(status string, userID *uuid.UUID, page, limit int) ([]models.Appeal, int64, error) {
+	page, limit = moderationPage(page, limit)
+	return s.repo.ListAppeals(status, userID, page, limit)
+}
+
+// DecideAppeal upholds or overturns an appeal. Overturning it reverses the
+// action: hidden items and messages are shown again, removed items come
+// back from the trash and banned users are unbanned. Either way the
+// decision is recorded in the audit log.
+func (s *ModerationService) DecideAppeal(id, moderatorID uuid.UUID, decision models.AppealStatus, response string) (*models.Appeal, error) {
+	if decision != models.AppealStatusUpheld && decision != models.AppealStatusOverturned {
+		return nil, errors.New("decision must be upheld or overturned")
+	}
+	appeal, err := s.repo.GetAppeal(id)
+	if err != nil {
+		return nil, ErrAppealNotFound
+	}
+	if appeal.Status != models.AppealStatusPending {
+		return nil, ErrAppealAlreadyDecided
+	}
+	original, err := s.repo.GetAction(appeal.ActionID)
+	if err != nil {
+		return nil, ErrModerationNotFound
+	}
+
+	now := time.Now()
+	appeal.Status, appeal.Response = decision, strings.TrimSpace(response)
+	appeal.DecidedByID, appeal.DecidedAt = &moderatorID, &now
+	record := &models.ModerationAction{
+		ModeratorID:  moderatorID,
+		Action:       models.ModerationUphold,
+		SubjectType:  original.SubjectType,
+		SubjectID:    original.SubjectID,
+		TargetUserID: original.TargetUserID,
+		Reason:       original.Reason,
+		Note:         appeal.Response,
+		AppealID:     &appeal.ID,
+	}
+
+	if decision == models.AppealStatusOverturned {
+		record.Action = models.ModerationReinstate
+		err = s.repo.Reinstate(original, record, appeal)
+	} else {
+		err = s.repo.Uphold(record, appeal)
+	}
+	if errors.Is(err, repository.ErrAppealDecided) {
+		return nil, ErrAppealAlreadyDecided
+	}
+	if err != nil {
+		return nil, err
+	}
+	return appeal, nil
+}
+
+// IsBanned reports whether a user is banned. Lookups that fail are treated
+// as not banned so an outage does not lock everyone out.
+func (s *ModerationService) IsBanned(userID uuid.UUID) bool {
+	banned, err := s.repo.IsBanned(userID)
+	if err != nil {
+		log.Printf("moderation: failed to check ban of user %s: %v", userID, err)
+		return false
+	}
+	return banned
+}
+
+// CheckItem stops banned users posting items through any channel. It is
+// registered as an item check.
+func (s *ModerationService) CheckItem(item *models.Item) error {
+	if s.IsBanned(item.UserID) {
+		return ErrBanned
+	}
+	return nil
+}
+
+// actionApplies checks an action can be taken on a report about subject
+func actionApplies(action models.ModerationActionType, subject models.ReportSubject) error {
+	switch action {
+	case models.ModerationWarn, models.ModerationBan, models.ModerationDismiss:
+		return nil
+	case models.ModerationHide:
+		if subject == models.ReportSubjectItem || subject == models.ReportSubjectMessage {
+			return nil
+		}
+	case models.ModerationRemove:
+		if subject == models.ReportSubjectItem {
+			return nil
+		}
+	default:
+		return fmt.Errorf("action must be one of hide, remove, warn, ban or dismiss")
+	}
+	return fmt.Errorf("%w: cannot %s a reported %s", ErrActionNotApplicable, action, subject)
+}
+
+// groupReports splits reports into the groups one action each covers: by
+// person for warnings and bans, otherwise by what was reported
+func groupReports(action models.ModerationActionType, reports []models.Report) [][]models.Report {
+	var groups [][]models.Report
+	index := make(map[string]int)
+	for _, report := range reports {
+		key := string(report.SubjectType) + ":" + report.SubjectID.String()
+		if action == models.ModerationWarn || action == models.ModerationBan {
+			key = report.ReportedUserID.String()
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], report)
+	}
+	return groups
+}
+
+// appealable reports whether an action can be appealed
+func appealable(action models.ModerationActionType) bool {
+	switch action {
+	case models.ModerationHide, models.ModerationRemove, models.ModerationWarn, models.ModerationBan:
+		return true
+	}
+	return false
+}
+
+// sortedIDs returns a sorted copy of ids
+func sortedIDs(ids []uuid.UUID) []uuid.UUID {
+	sorted := slices.Clone(ids)
+	slices.SortFunc(sorted, func(a, b uuid.UUID) int { return strings.Compare(a.String(), b.String()) })
+	return sorted
+}
+
+// moderationPage applies the default pagination values
+func moderationPage(page, limit int) (int, int) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return page, limit
+}
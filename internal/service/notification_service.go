@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/notify"
+	"lostnfound-api/internal/repository"
+	"math/rand/v2"
+	"time"
+)
+
+const (
+	// notificationBatchSize bounds how many notifications are leased at once
+	notificationBatchSize = 100
+	// notificationLease is how long a leased notification is hidden from other dispatchers
+	notificationLease = 5 * time.Minute
+	// notificationSendTimeout bounds a single delivery attempt
+	notificationSendTimeout = 15 * time.Second
+	// maxNotificationAttempts is how many times delivery is tried before giving up
+	maxNotificationAttempts = 6
+	// notificationBaseBackoff is the delay before the first retry; it doubles per attempt
+	notificationBaseBackoff = time.Minute
+	// notificationMaxBackoff caps the delay between retries
+	notificationMaxBackoff = 6 * time.Hour
+	// notificationPollInterval is how often the dispatcher checks for due retries
+	notificationPollInterval = 30 * time.Second
+	// notificationRetentionDays is how long delivered notifications are kept
+	notificationRetentionDays = 90
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+	ErrUnknownEventType     = errors.New("unknown notification event type")
+	ErrUnknownChannel       = errors.New("unknown notification channel")
+)
+
+// NotificationPreferences is a user's language and per-event channel choices
+type NotificationPreferences struct {
+	Locale string            `json:"locale"`
+	Events []EventPreference `json:"events"`
+}
+
+// EventPreference is the channels a user wants an event type on
+type EventPreference struct {
+	EventType string          `json:"event_type"`
+	Channels  map[string]bool `json:"channels"`
+}
+
+// NotificationService renders notifications into the outbox according to
+// user preferences and delivers them over the configured channels, retrying
+// failures with exponential backoff
+type NotificationService struct {
+	repo     *repository.NotificationRepository
+	users    *repository.UserRepository
+	channels map[string]notify.Channel
+	wake     chan struct{}
+}
+
+// NewNotificationService creates a new NotificationService delivering over
+// the given channels. Channels without an implementation are never used.
+func NewNotificationService(repo *repository.NotificationRepository, users *repository.UserRepository, channels ...notify.Channel) *NotificationService {
+	s := &NotificationService{
+		repo:     repo,
+		users:    users,
+		channels: make(map[string]notify.Channel),
+		wake:     make(chan struct{}, 1),
+	}
+	for _, channel := range channels {
+		s.channels[channel.Name()] = channel
+	}
+	return s
+}
+
+// Notify queues an event for a user on every channel they have enabled,
+// logging failures rather than failing the caller
+func (s *NotificationService) Notify(userID uuid.UUID, eventType string, data any) {
+	if err := s.enqueue(userID, eventType, data); err != nil {
+		log.Printf("notify: failed to queue %s for user %s: %v", eventType, userID, err)
+	}
+}
+
+// Run delivers queued notifications until the context is cancelled
+func (s *NotificationService) Run(ctx context.Context) {
+	ticker := time.NewTicker(notificationPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Deliver(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("notify: delivery failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Deliver sends every due notification and returns how many were delivered
+func (s *NotificationService) Deliver(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		batch, err := s.repo.Lease(time.Now(), notificationLease, notificationBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		recipients := make(map[uuid.UUID]notify.Recipient)
+		for i := range batch {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			ok, err := s.send(ctx, &batch[i], recipients)
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+
+		if len(batch) < notificationBatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// Inbox retrieves a user's in-app notifications
+func (s *NotificationService) Inbox(userID uuid.UUID, unreadOnly bool, page, limit int) ([]models.Notification, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListInbox(userID, unreadOnly, page, limit)
+}
+
+// UnreadCount counts a user's unread in-app notifications
+func (s *NotificationService) UnreadCount(userID uuid.UUID) (int64, error) {
+	return s.repo.CountUnread(userID)
+}
+
+// MarkRead marks one of a user's in-app notifications as read
+func (s *NotificationService) MarkRead(id, userID uuid.UUID) error {
+	found, err := s.repo.MarkRead(id, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead marks all of a user's in-app notifications as read
+func (s *NotificationService) MarkAllRead(userID uuid.UUID) error {
+	return s.repo.MarkAllRead(userID, time.Now())
+}
+
+// Preferences retrieves a user's language and the effective channel choices
+// for every event type, filling in defaults where none were set
+func (s *NotificationService) Preferences(userID uuid.UUID) (*NotificationPreferences, error) {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.enabledChannels(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := &NotificationPreferences{Locale: notify.NormalizeLocale(user.Locale)}
+	for _, eventType := range notify.EventTypes() {
+		event := EventPreference{EventType: eventType, Channels: make(map[string]bool)}
+		for _, channel := range notify.Channels {
+			event.Channels[channel] = enabled(eventType, channel)
+		}
+		preferences.Events = append(preferences.Events, event)
+	}
+	return preferences, nil
+}
+
+// UpdatePreferences changes a user's language and channel choices. Event
+// types and channels left out are not changed.
+func (s *NotificationService) UpdatePreferences(userID uuid.UUID, input *NotificationPreferences) (*NotificationPreferences, error) {
+	if input.Locale != "" {
+		locale := notify.NormalizeLocale(input.Locale)
+		if locale != input.Locale {
+			return nil, fmt.Errorf("locale must be one of %v", notify.Locales)
+		}
+		if err := s.users.UpdateLocale(userID, locale); err != nil {
+			return nil, err
+		}
+	}
+
+	var preferences []models.NotificationPreference
+	for _, event := range input.Events {
+		if !notify.Supported(event.EventType) {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownEventType, event.EventType)
+		}
+		for channel, on := range event.Channels {
+			if !knownChannel(channel) {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownChannel, channel)
+			}
+			preferences = append(preferences, models.NotificationPreference{
+				UserID:    userID,
+				EventType: event.EventType,
+				Channel:   channel,
+				Enabled:   on,
+			})
+		}
+	}
+	if err := s.repo.SavePreferences(preferences); err != nil {
+		return nil, err
+	}
+	return s.Preferences(userID)
+}
+
+// Outbox retrieves outbox entries for administrators, optionally by status
+func (s *NotificationService) Outbox(status models.NotificationStatus, page, limit int) ([]models.Notification, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListByStatus(status, page, limit)
+}
+
+// Retry queues a failed notification for another round of delivery attempts
+func (s *NotificationService) Retry(id uuid.UUID) error {
+	found, err := s.repo.Retry(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !found {
+		return ErrNotificationNotFound
+	}
+	s.signal()
+	return nil
+}
+
+// PurgeOld removes delivered and failed notifications past the retention period
+func (s *NotificationService) PurgeOld() (int64, error) {
+	return s.repo.DeleteBefore(time.Now().AddDate(0, 0, -notificationRetentionDays))
+}
+
+// enqueue renders an event in the user's language and stores one outbox
+// entry per enabled channel
+func (s *NotificationService) enqueue(userID uuid.UUID, eventType string, data any) error {
+	if !notify.Supported(eventType) {
+		return ErrUnknownEventType
+	}
+
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return err
+	}
+	enabled, err := s.enabledChannels(userID)
+	if err != nil {
+		return err
+	}
+
+	locale := notify.NormalizeLocale(user.Locale)
+	subject, body, err := notify.Render(eventType, locale, data)
+	if err != nil {
+		return err
+	}
+	payload, err := toPayload(data)
+	if err != nil {
+		return err
+	}
+
+	recipient := recipientFor(user)
+	now := time.Now()
+	var notifications []models.Notification
+	for _, channel := range notify.Channels {
+		if _, ok := s.channels[channel]; !ok || !enabled(eventType, channel) {
+			continue
+		}
+		if recipient.Address(channel) == "" {
+			continue
+		}
+		notifications = append(notifications, models.Notification{
+			UserID:        userID,
+			EventType:     eventType,
+			Channel:       channel,
+			Locale:        locale,
+			Subject:       subject,
+			Body:          body,
+			Payload:       payload,
+			Status:        models.NotificationStatusPending,
+			NextAttemptAt: now,
+		})
+	}
+
+	if err := s.repo.CreateBatch(notifications); err != nil {
+		return err
+	}
+	if len(notifications) > 0 {
+		s.signal()
+	}
+	return nil
+}
+
+// send makes one delivery attempt and records the outcome. It only returns
+// an error when the outcome cannot be stored.
+func (s *NotificationService) send(ctx context.Context, notification *models.Notification, recipients map[uuid.UUID]notify.Recipient) (bool, error) {
+	attempts := notification.Attempts + 1
+
+	channel, ok := s.channels[notification.Channel]
+	if !ok {
+		return false, s.repo.MarkFailed(notification.ID, attempts, ErrUnknownChannel.Error())
+	}
+
+	recipient, ok := recipients[notification.UserID]
+	if !ok {
+		user, err := s.users.GetByID(notification.UserID)
+		if err != nil {
+			return false, s.repo.MarkFailed(notification.ID, attempts, "recipient no longer exists")
+		}
+		recipient = recipientFor(user)
+		recipients[notification.UserID] = recipient
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, notificationSendTimeout)
+	err := channel.Send(sendCtx, notify.Message{
+		ID:        notification.ID,
+		EventType: notification.EventType,
+		To:        recipient,
+		Subject:   notification.Subject,
+		Body:      notification.Body,
+	})
+	cancel()
+
+	switch {
+	case err == nil:
+		return true, s.repo.MarkSent(notification.ID, attempts, time.Now())
+	case errors.Is(err, notify.ErrNoAddress) || attempts >= maxNotificationAttempts:
+		log.Printf("notify: giving up on %s notification %s after %d attempts: %v", notification.Channel, notification.ID, attempts, err)
+		return false, s.repo.MarkFailed(notification.ID, attempts, err.Error())
+	default:
+		return false, s.repo.MarkRetry(notification.ID, attempts, time.Now().Add(notificationBackoff(attempts)), err.Error())
+	}
+}
+
+// enabledChannels returns a lookup of the user's effective channel choices
+func (s *NotificationService) enabledChannels(userID uuid.UUID) (func(eventType, channel string) bool, error) {
+	preferences, err := s.repo.Preferences(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[string]bool, len(preferences))
+	for _, preference := range preferences {
+		set[preference.EventType+"/"+preference.Channel] = preference.Enabled
+	}
+	return func(eventType, channel string) bool {
+		if on, ok := set[eventType+"/"+channel]; ok {
+			return on
+		}
+		return notify.DefaultEnabled(eventType, channel)
+	}, nil
+}
+
+// signal wakes the dispatcher without blocking
+func (s *NotificationService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// notificationBackoff returns the delay before the next attempt: the base
+// delay doubled per failed attempt, capped, with up to 10% jitter so retries
+// from a provider outage do not arrive all at once
+func notificationBackoff(attempts int) time.Duration {
+	delay := notificationMaxBackoff
+	if attempts <= 16 {
+		delay = min(notificationBaseBackoff<<(attempts-1), notificationMaxBackoff)
+	}
+	return delay + rand.N(delay/10+1)
+}
+
+// recipientFor builds the delivery address of a user
+func recipientFor(user *models.User) notify.Recipient {
+	recipient := notify.Recipient{UserID: user.ID, Name: user.FirstName, Phone: user.Phone}
+	if user.HasEmail() {
+		recipient.Email = user.Email
+	}
+	return recipient
+}
+
+// toPayload converts event data into the JSON object stored with a notification
+func toPayload(data any) (map[string]any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, err
+	}
+	return payload, nil
+}
+
+// knownChannel reports whether a channel name is valid
+func knownChannel(channel string) bool {
+	for _, c := range notify.Channels {
+		if c == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,519 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrNotOrganizationMember = errors.New("you are not a member of this organization")
+	ErrOrganizationAdminOnly = errors.New("only organization admins can do this")
+	ErrMemberNotFound        = errors.New("member not found")
+	ErrAlreadyMember         = errors.New("user is already a member of this organization")
+	ErrLastOrganizationAdmin = errors.New("an organization needs at least one admin")
+	ErrNotInInventory        = errors.New("item is not in this organization's inventory")
+)
+
+// clockTime matches "HH:MM" on a 24-hour clock
+var clockTime = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+// PublicOrganization is an organisation as the public sees it
+type PublicOrganization struct {
+	*models.Organization
+	OpenNow bool `json:"open_now"`
+}
+
+// CustodyUpdate is a change to where an organisation holds an item
+type CustodyUpdate struct {
+	Status          models.CustodyStatus `json:"status"`
+	StorageLocation *string              `json:"storage_location"`
+	Notes           *string              `json:"notes"`
+}
+
+// OrganizationService manages organisations, their staff and the items they hold
+type OrganizationService struct {
+	repo      *repository.OrganizationRepository
+	users     *repository.UserRepository
+	items     *ItemService
+	locations *LocationService
+}
+
+// NewOrganizationService creates a new OrganizationService
+func NewOrganizationService(repo *repository.OrganizationRepository, users *repository.UserRepository, items *ItemService, locations *LocationService) *OrganizationService {
+	return &OrganizationService{repo: repo, users: users, items: items, locations: locations}
+}
+
+// Create registers an organisation with its creator as the first admin. New
+// organisations are unverified until a platform administrator checks them.
+func (s *OrganizationService) Create(organization *models.Organization, creatorID uuid.UUID) error {
+	if err := s.prepare(organization); err != nil {
+		return err
+	}
+	organization.CreatedByID = creatorID
+	organization.Verified = false
+	organization.VerifiedAt = nil
+
+	founder := &models.OrganizationMember{UserID: creatorID, Role: models.OrganizationRoleAdmin, AddedByID: creatorID}
+	return s.repo.Create(organization, founder)
+}
+
+// List retrieves organisations for the public directory
+func (s *OrganizationService) List(filter repository.OrganizationFilter, page, limit int) ([]PublicOrganization, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	if filter.CountyID != "" {
+		itemFilter := repository.ItemFilter{CountyID: filter.CountyID}
+		s.locations.NormalizeFilter(&itemFilter)
+		filter.CountyID = itemFilter.CountyID
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+
+	organizations, total, err := s.repo.List(filter, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	now := time.Now()
+	public := make([]PublicOrganization, len(organizations))
+	for i := range organizations {
+		public[i] = PublicOrganization{Organization: &organizations[i], OpenNow: organizations[i].OpenAt(now)}
+	}
+	return public, total, nil
+}
+
+// GetByID retrieves an organisation's public profile
+func (s *OrganizationService) GetByID(id uuid.UUID) (*PublicOrganization, error) {
+	organization, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return &PublicOrganization{Organization: organization, OpenNow: organization.OpenAt(time.Now())}, nil
+}
+
+// Update changes an organisation's profile. Only its admins may do this, and
+// the verified badge is not changed.
+func (s *OrganizationService) Update(id, actorID uuid.UUID, isAdmin bool, input *models.Organization) (*models.Organization, error) {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return nil, err
+	}
+	organization, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	organization.Name = input.Name
+	organization.Type = input.Type
+	organization.Description = input.Description
+	organization.Phone = input.Phone
+	organization.Email = input.Email
+	organization.Location = input.Location
+	organization.CountyID = input.CountyID
+	organization.SubCountyID = input.SubCountyID
+	organization.Latitude = input.Latitude
+	organization.Longitude = input.Longitude
+	organization.OpeningHours = input.OpeningHours
+	if err := s.prepare(organization); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(organization); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+// SetVerified grants or withdraws an organisation's verified badge
+func (s *OrganizationService) SetVerified(id uuid.UUID, verified bool) (*models.Organization, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+
+	var at *time.Time
+	if verified {
+		now := time.Now()
+		at = &now
+	}
+	if err := s.repo.SetVerified(id, verified, at); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByID(id)
+}
+
+// Memberships retrieves the organisations a user belongs to and their role in each
+func (s *OrganizationService) Memberships(userID uuid.UUID) ([]models.OrganizationMember, error) {
+	return s.repo.Memberships(userID)
+}
+
+// Members retrieves an organisation's staff. Any member may see them.
+func (s *OrganizationService) Members(id, actorID uuid.UUID, isAdmin bool) ([]models.OrganizationMember, error) {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return nil, err
+	}
+	members, err := s.repo.ListMembers(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range members {
+		if members[i].User != nil {
+			members[i].User.Password = ""
+		}
+	}
+	return members, nil
+}
+
+// AddMember adds a registered user to an organisation by email address
+func (s *OrganizationService) AddMember(id, actorID uuid.UUID, isAdmin bool, email string, role models.OrganizationRole) (*models.OrganizationMember, error) {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return nil, err
+	}
+	if err := validateOrganizationRole(role); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil, errors.New("no account uses that email address")
+	}
+	if _, err := s.repo.GetMember(id, user.ID); err == nil {
+		return nil, ErrAlreadyMember
+	}
+
+	member := &models.OrganizationMember{OrganizationID: id, UserID: user.ID, Role: role, AddedByID: actorID}
+	if err := s.repo.AddMember(member); err != nil {
+		return nil, err
+	}
+	user.Password = ""
+	member.User = user
+	return member, nil
+}
+
+// UpdateMemberRole changes a member's role, keeping at least one admin
+func (s *OrganizationService) UpdateMemberRole(id, userID, actorID uuid.UUID, isAdmin bool, role models.OrganizationRole) error {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return err
+	}
+	if err := validateOrganizationRole(role); err != nil {
+		return err
+	}
+
+	member, err := s.repo.GetMember(id, userID)
+	if err != nil {
+		return ErrMemberNotFound
+	}
+	if member.Role == models.OrganizationRoleAdmin && role != models.OrganizationRoleAdmin {
+		if err := s.keepAnAdmin(id); err != nil {
+			return err
+		}
+	}
+	return s.repo.UpdateMemberRole(id, userID, role)
+}
+
+// RemoveMember removes a member, keeping at least one admin. Members may
+// also leave on their own.
+func (s *OrganizationService) RemoveMember(id, userID, actorID uuid.UUID, isAdmin bool) error {
+	if userID != actorID {
+		if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+			return err
+		}
+	}
+
+	member, err := s.repo.GetMember(id, userID)
+	if err != nil {
+		return ErrMemberNotFound
+	}
+	if member.Role == models.OrganizationRoleAdmin {
+		if err := s.keepAnAdmin(id); err != nil {
+			return err
+		}
+	}
+	return s.repo.RemoveMember(id, userID)
+}
+
+// PostItem reports an item on behalf of an organisation and records that the
+// organisation holds it. The item defaults to found, with the organisation's
+// location and phone number when none are given.
+func (s *OrganizationService) PostItem(id, actorID uuid.UUID, isAdmin bool, item *models.Item, storageLocation string) error {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return err
+	}
+	organization, err := s.repo.GetByID(id)
+	if err != nil {
+		return ErrOrganizationNotFound
+	}
+
+	organizationItem(organization, actorID, item, storageLocation)
+	return s.items.Create(item)
+}
+
+// Inventory retrieves the items an organisation posted or holds, with their
+// custody records. Any member may see it.
+func (s *OrganizationService) Inventory(id, actorID uuid.UUID, isAdmin bool, status models.CustodyStatus, page, limit int) ([]models.Item, int64, error) {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.Inventory(id, status, page, limit)
+}
+
+// UpdateItem edits an item the organisation posted. Only admins may do this,
+// whoever on the staff posted it. Items it only holds stay their reporters'.
+func (s *OrganizationService) UpdateItem(id, itemID, actorID uuid.UUID, isAdmin bool, item *models.Item) error {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return err
+	}
+	if _, err := s.inventoryItem(id, itemID); err != nil {
+		return err
+	}
+	item.ID = itemID
+	return s.items.Update(item)
+}
+
+// RemoveItem deletes an item the organisation posted. Only admins may do this.
+func (s *OrganizationService) RemoveItem(id, itemID, actorID uuid.UUID, isAdmin bool) error {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleAdmin); err != nil {
+		return err
+	}
+	if _, err := s.inventoryItem(id, itemID); err != nil {
+		return err
+	}
+	return s.items.Delete(itemID)
+}
+
+// UpdateCustody records a change to where an organisation holds an item or
+// that it has let the item go. Any member may do this.
+func (s *OrganizationService) UpdateCustody(id, itemID, actorID uuid.UUID, isAdmin bool, update CustodyUpdate) (*models.ItemCustody, error) {
+	if err := s.requireRole(id, actorID, isAdmin, models.OrganizationRoleStaff); err != nil {
+		return nil, err
+	}
+	item, err := s.heldItem(id, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	custody, err := s.repo.GetCustody(itemID)
+	if err != nil {
+		// Items the organisation posted before it had them in hand
+		custody = &models.ItemCustody{ItemID: item.ID, OrganizationID: id, Status: models.CustodyStatusHeld, ReceivedByID: actorID, ReceivedAt: time.Now()}
+	} else if custody.OrganizationID != id {
+		return nil, ErrNotInInventory
+	}
+
+	if update.StorageLocation != nil {
+		custody.StorageLocation = strings.TrimSpace(*update.StorageLocation)
+	}
+	if update.Notes != nil {
+		custody.Notes = strings.TrimSpace(*update.Notes)
+	}
+	switch update.Status {
+	case "":
+	case models.CustodyStatusHeld:
+		custody.Status = update.Status
+		custody.ReleasedByID, custody.ReleasedAt = nil, nil
+	case models.CustodyStatusReleased, models.CustodyStatusDisposed:
+		if custody.Status != update.Status {
+			now := time.Now()
+			custody.Status = update.Status
+			custody.ReleasedByID, custody.ReleasedAt = &actorID, &now
+		}
+	default:
+		return nil, fmt.Errorf("status must be %s, %s or %s", models.CustodyStatusHeld, models.CustodyStatusReleased, models.CustodyStatusDisposed)
+	}
+
+	if err := s.repo.SaveCustody(custody); err != nil {
+		return nil, err
+	}
+	return custody, nil
+}
+
+// Role returns a user's role in an organisation, or ErrNotOrganizationMember
+func (s *OrganizationService) Role(id, userID uuid.UUID) (models.OrganizationRole, error) {
+	member, err := s.repo.GetMember(id, userID)
+	if err != nil {
+		return "", ErrNotOrganizationMember
+	}
+	return member.Role, nil
+}
+
+// requireRole checks that the actor belongs to the organisation with at
+// least the given role. Platform administrators may act as an admin of any
+// organisation.
+func (s *OrganizationService) requireRole(id, actorID uuid.UUID, isAdmin bool, role models.OrganizationRole) error {
+	if isAdmin {
+		if _, err := s.repo.GetByID(id); err != nil {
+			return ErrOrganizationNotFound
+		}
+		return nil
+	}
+
+	actual, err := s.Role(id, actorID)
+	if err != nil {
+		if _, err := s.repo.GetByID(id); err != nil {
+			return ErrOrganizationNotFound
+		}
+		return err
+	}
+	if role == models.OrganizationRoleAdmin && actual != models.OrganizationRoleAdmin {
+		return ErrOrganizationAdminOnly
+	}
+	return nil
+}
+
+// organizationItem fills in an item posted on behalf of an organisation: it
+// is found by default, uses the organisation's phone and office location
+// unless given others, and a found item is logged as held in its custody
+func organizationItem(organization *models.Organization, actorID uuid.UUID, item *models.Item, storageLocation string) {
+	item.UserID = actorID
+	item.OrganizationID = &organization.ID
+	item.Organization = nil
+	item.ExternalReference = strings.TrimSpace(item.ExternalReference)
+	if item.Status == "" {
+		item.Status = models.ItemStatusFound
+	}
+	if item.Contact == "" {
+		item.Contact = organization.Phone
+	}
+	if item.Location == "" && item.CountyID == "" && item.SubCountyID == "" && item.WardID == "" {
+		item.Location = organization.Location
+		item.CountyID, item.SubCountyID = organization.CountyID, organization.SubCountyID
+	}
+	if item.Status == models.ItemStatusFound {
+		item.Custody = &models.ItemCustody{
+			OrganizationID:  organization.ID,
+			Status:          models.CustodyStatusHeld,
+			StorageLocation: strings.TrimSpace(storageLocation),
+			ReceivedByID:    actorID,
+			ReceivedAt:      time.Now(),
+		}
+	} else {
+		item.Custody = nil
+	}
+}
+
+// inventoryItem loads an item the organisation posted
+func (s *OrganizationService) inventoryItem(id, itemID uuid.UUID) (*models.Item, error) {
+	item, err := s.items.GetByID(itemID)
+	if err != nil || item.OrganizationID == nil || *item.OrganizationID != id {
+		return nil, ErrNotInInventory
+	}
+	return item, nil
+}
+
+// heldItem loads an item the organisation posted or has custody of
+func (s *OrganizationService) heldItem(id, itemID uuid.UUID) (*models.Item, error) {
+	if item, err := s.inventoryItem(id, itemID); err == nil {
+		return item, nil
+	}
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		return nil, ErrNotInInventory
+	}
+	if custody, err := s.repo.GetCustody(itemID); err == nil && custody.OrganizationID == id {
+		return item, nil
+	}
+	return nil, ErrNotInInventory
+}
+
+// keepAnAdmin fails if the organisation has only one admin left
+func (s *OrganizationService) keepAnAdmin(id uuid.UUID) error {
+	admins, err := s.repo.CountAdmins(id)
+	if err != nil {
+		return err
+	}
+	if admins <= 1 {
+		return ErrLastOrganizationAdmin
+	}
+	return nil
+}
+
+// prepare validates and normalises an organisation's profile
+func (s *OrganizationService) prepare(organization *models.Organization) error {
+	organization.Name = strings.TrimSpace(organization.Name)
+	if organization.Name == "" {
+		return errors.New("name is required")
+	}
+
+	switch organization.Type {
+	case models.OrganizationTypePolice, models.OrganizationTypeCampus, models.OrganizationTypeTransport,
+		models.OrganizationTypeAirport, models.OrganizationTypeOther:
+	default:
+		return fmt.Errorf("type must be one of %s, %s, %s, %s or %s", models.OrganizationTypePolice,
+			models.OrganizationTypeCampus, models.OrganizationTypeTransport, models.OrganizationTypeAirport, models.OrganizationTypeOther)
+	}
+
+	countyID, subCountyID, err := normalizePlace(s.locations, organization.Location, organization.CountyID,
+		organization.SubCountyID, organization.Latitude, organization.Longitude)
+	if err != nil {
+		return err
+	}
+	organization.CountyID, organization.SubCountyID = countyID, subCountyID
+
+	return validateOpeningHours(organization.OpeningHours)
+}
+
+// normalizePlace validates an office's coordinates and resolves its county
+// and sub-county the same way as an item's
+func normalizePlace(locations *LocationService, location, countyID, subCountyID string, lat, lng *float64) (string, string, error) {
+	if (lat == nil) != (lng == nil) {
+		return "", "", errors.New("latitude and longitude must be provided together")
+	}
+	if lat != nil {
+		if err := validateCoordinates(*lat, *lng); err != nil {
+			return "", "", err
+		}
+	}
+
+	// The gazetteer works on items, so resolve the place as one
+	place := &models.Item{Location: location, CountyID: countyID, SubCountyID: subCountyID}
+	if err := locations.NormalizeItem(place); err != nil {
+		return "", "", err
+	}
+	return place.CountyID, place.SubCountyID, nil
+}
+
+// validateOpeningHours checks and normalises opening hours in place
+func validateOpeningHours(openingHours []models.OpeningHours) error {
+	for i := range openingHours {
+		hours := &openingHours[i]
+		hours.Day = strings.ToLower(strings.TrimSpace(hours.Day))
+		if len(hours.Day) > 3 {
+			hours.Day = hours.Day[:3]
+		}
+		if !slices.Contains(models.Weekdays, hours.Day) {
+			return fmt.Errorf("opening hours day must be one of %v", models.Weekdays)
+		}
+		if !clockTime.MatchString(hours.Opens) || !clockTime.MatchString(hours.Closes) {
+			return errors.New("opening hours must be given as HH:MM")
+		}
+		if hours.Opens >= hours.Closes {
+			return errors.New("opening hours must close after they open")
+		}
+	}
+	return nil
+}
+
+// validateOrganizationRole checks that a role exists
+func validateOrganizationRole(role models.OrganizationRole) error {
+	switch role {
+	case models.OrganizationRoleAdmin, models.OrganizationRoleStaff:
+		return nil
+	default:
+		return fmt.Errorf("role must be %s or %s", models.OrganizationRoleAdmin, models.OrganizationRoleStaff)
+	}
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/util/qrcode"
+	"strings"
+	"time"
+)
+
+const (
+	// recoveryCodeLength is the number of characters in a tag code
+	recoveryCodeLength = 8
+	// recoveryCodeAlphabet leaves out characters that are easy to misread on a sticker
+	recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	// maxReportsPerTagHour throttles reports through a single tag
+	maxReportsPerTagHour = 5
+	// maxTagsPerSheet bounds the number of tags on one printable sheet
+	maxTagsPerSheet = 60
+)
+
+// assetCategoryPaths maps asset types to the category of the found report
+var assetCategoryPaths = map[models.AssetType]string{
+	models.AssetTypePhone:   "electronics/phone",
+	models.AssetTypeLaptop:  "electronics/laptop",
+	models.AssetTypeTablet:  "electronics/tablet",
+	models.AssetTypeBicycle: "vehicles-bicycles/bicycle",
+}
+
+var (
+	ErrRecoveryTagNotFound = errors.New("recovery tag not found")
+	ErrRecoveryTagInactive = errors.New("this tag is no longer active")
+	ErrTooManyReports      = errors.New("too many reports for this tag, please try again later")
+	ErrReportNotFound      = errors.New("report not found")
+)
+
+// PublicRecoveryTag is what a finder sees after scanning a tag
+type PublicRecoveryTag struct {
+	Code      string           `json:"code"`
+	Label     string           `json:"label"`
+	AssetType models.AssetType `json:"asset_type,omitempty"`
+}
+
+// FinderReport is what a finder submits after scanning a tag
+type FinderReport struct {
+	Message       string
+	Location      string
+	FinderName    string
+	FinderContact string
+}
+
+// RecoveryReportHook is called after a finder reports a tag
+type RecoveryReportHook func(tag *models.RecoveryTag, report *models.RecoveryReport)
+
+// RecoveryTagService manages QR recovery tags and the reports finders send through them
+type RecoveryTagService struct {
+	repo        *repository.RecoveryTagRepository
+	assets      *AssetService
+	items       *ItemService
+	categories  *CategoryService
+	baseURL     string
+	afterReport []RecoveryReportHook
+}
+
+// NewRecoveryTagService creates a new RecoveryTagService. Scan URLs are built
+// from baseURL, the public site where finders land.
+func NewRecoveryTagService(repo *repository.RecoveryTagRepository, assets *AssetService, items *ItemService, categories *CategoryService, baseURL string) *RecoveryTagService {
+	return &RecoveryTagService{
+		repo:       repo,
+		assets:     assets,
+		items:      items,
+		categories: categories,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// AfterReport registers a hook that runs after a finder reports a tag
+func (s *RecoveryTagService) AfterReport(hook RecoveryReportHook) {
+	s.afterReport = append(s.afterReport, hook)
+}
+
+// Create issues a new tag for a user, optionally linked to one of their assets
+func (s *RecoveryTagService) Create(userID uuid.UUID, assetID *uuid.UUID, label string) (*models.RecoveryTag, error) {
+	tag := &models.RecoveryTag{UserID: userID, Label: strings.TrimSpace(label), Active: true}
+	if assetID != nil {
+		asset, err := s.assets.GetOwned(*assetID, userID)
+		if err != nil {
+			return nil, err
+		}
+		tag.AssetID = &asset.ID
+		tag.Asset = asset
+		if tag.Label == "" {
+			tag.Label = strings.TrimSpace(asset.Brand + " " + asset.ModelName)
+		}
+	}
+
+	code, err := randomCode(recoveryCodeLength)
+	if err != nil {
+		return nil, err
+	}
+	tag.Code = code
+
+	if err := s.repo.Create(tag); err != nil {
+		return nil, err
+	}
+	return tag, nil
+}
+
+// List retrieves a user's tags
+func (s *RecoveryTagService) List(userID uuid.UUID) ([]models.RecoveryTag, error) {
+	return s.repo.ListByUser(userID, nil)
+}
+
+// GetOwned retrieves a tag owned by the user
+func (s *RecoveryTagService) GetOwned(id, userID uuid.UUID) (*models.RecoveryTag, error) {
+	tag, err := s.repo.GetByID(id)
+	if err != nil || tag.UserID != userID {
+		return nil, ErrRecoveryTagNotFound
+	}
+	return tag, nil
+}
+
+// Deactivate stops a tag from accepting reports, e.g. when the sticker is lost
+func (s *RecoveryTagService) Deactivate(id, userID uuid.UUID) error {
+	tag, err := s.GetOwned(id, userID)
+	if err != nil {
+		return err
+	}
+	tag.Active = false
+	return s.repo.Update(tag)
+}
+
+// ScanURL returns the URL encoded in a tag's QR code
+func (s *RecoveryTagService) ScanURL(tag *models.RecoveryTag) string {
+	return s.baseURL + "/recover/" + tag.Code
+}
+
+// QRCode renders a tag as a PNG or SVG image
+func (s *RecoveryTagService) QRCode(id, userID uuid.UUID, format string, size int) ([]byte, string, error) {
+	tag, err := s.GetOwned(id, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	switch format {
+	case "svg":
+		data, err := qrcode.SVG(s.ScanURL(tag))
+		return data, "image/svg+xml", err
+	case "png", "":
+		if size < 128 || size > 2048 {
+			size = 512
+		}
+		data, err := qrcode.PNG(s.ScanURL(tag), size)
+		return data, "image/png", err
+	default:
+		return nil, "", fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// Sheet renders a printable PDF of a user's active tags, or of the given tags
+func (s *RecoveryTagService) Sheet(userID uuid.UUID, ids []uuid.UUID) ([]byte, error) {
+	tags, err := s.repo.ListByUser(userID, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]qrcode.Label, 0, len(tags))
+	for _, tag := range tags {
+		if !tag.Active {
+			continue
+		}
+		labels = append(labels, qrcode.Label{
+			Content: s.ScanURL(&tag),
+			Title:   truncate(tagTitle(&tag), 32),
+			Caption: "If found, scan to return - " + tag.Code,
+		})
+	}
+	if len(labels) > maxTagsPerSheet {
+		return nil, fmt.Errorf("a sheet can hold at most %d tags", maxTagsPerSheet)
+	}
+
+	return qrcode.Sheet(labels)
+}
+
+// Lookup returns the public view of a scanned tag. Nothing about the owner is revealed.
+func (s *RecoveryTagService) Lookup(code string) (*PublicRecoveryTag, error) {
+	tag, err := s.activeTag(code)
+	if err != nil {
+		return nil, err
+	}
+
+	public := &PublicRecoveryTag{Code: tag.Code, Label: tag.Label}
+	if tag.Asset != nil {
+		public.AssetType = tag.Asset.Type
+	}
+	return public, nil
+}
+
+// Report handles a finder scanning a tag. It leaves the finder's message for
+// the owner with a pre-filled found item as an unlisted draft, which only the
+// owner can publish. The owner's contact details are never shown to the finder.
+func (s *RecoveryTagService) Report(code string, input FinderReport) (*models.RecoveryReport, error) {
+	tag, err := s.activeTag(code)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	recent, err := s.repo.CountReportsSince(tag.ID, now.Add(-time.Hour))
+	if err != nil {
+		return nil, err
+	}
+	if recent >= maxReportsPerTagHour {
+		return nil, ErrTooManyReports
+	}
+	if err := s.repo.RecordScan(tag.ID, now); err != nil {
+		return nil, err
+	}
+
+	item := &models.Item{
+		Title:       "Found: " + tagTitle(tag),
+		Description: strings.TrimSpace(input.Message),
+		Status:      models.ItemStatusFound,
+		Location:    strings.TrimSpace(input.Location),
+		Date:        now,
+		UserID:      tag.UserID,
+	}
+	if tag.Asset != nil {
+		if path, ok := assetCategoryPaths[tag.Asset.Type]; ok {
+			if category, err := s.categories.GetByPath(path); err == nil {
+				item.CategoryID = &category.ID
+			}
+		}
+	}
+	if err := s.items.CreateDraft(item, models.ItemSourceRecoveryTag); err != nil {
+		return nil, err
+	}
+
+	report := &models.RecoveryReport{
+		TagID:         tag.ID,
+		OwnerID:       tag.UserID,
+		ItemID:        item.ID,
+		Message:       item.Description,
+		Location:      item.Location,
+		FinderName:    strings.TrimSpace(input.FinderName),
+		FinderContact: strings.TrimSpace(input.FinderContact),
+	}
+	if err := s.repo.CreateReport(report); err != nil {
+		return nil, err
+	}
+	for _, hook := range s.afterReport {
+		hook(tag, report)
+	}
+	return report, nil
+}
+
+// ListReports retrieves the reports finders sent to an owner
+func (s *RecoveryTagService) ListReports(ownerID uuid.UUID) ([]models.RecoveryReport, error) {
+	return s.repo.ListReports(ownerID)
+}
+
+// MarkReportRead records that the owner has read a report
+func (s *RecoveryTagService) MarkReportRead(id, ownerID uuid.UUID) error {
+	report, err := s.repo.GetReport(id)
+	if err != nil || report.OwnerID != ownerID {
+		return ErrReportNotFound
+	}
+	return s.repo.MarkReportRead(id, time.Now())
+}
+
+// PublishReport lists the draft found item filed with a finder's report.
+// Only the tag's owner can publish it.
+func (s *RecoveryTagService) PublishReport(id, ownerID uuid.UUID) (*models.Item, error) {
+	report, err := s.repo.GetReport(id)
+	if err != nil || report.OwnerID != ownerID {
+		return nil, ErrReportNotFound
+	}
+	item, err := s.items.GetByID(report.ItemID)
+	if err != nil {
+		return nil, ErrItemNotFound
+	}
+	if item.UserID != ownerID {
+		return nil, ErrReportNotFound
+	}
+
+	if err := s.items.PublishChecked(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// activeTag loads a tag by its printed code and checks it accepts reports
+func (s *RecoveryTagService) activeTag(code string) (*models.RecoveryTag, error) {
+	tag, err := s.repo.GetByCode(strings.ToUpper(strings.TrimSpace(code)))
+	if err != nil {
+		return nil, ErrRecoveryTagNotFound
+	}
+	if !tag.Active {
+		return nil, ErrRecoveryTagInactive
+	}
+	return tag, nil
+}
+
+// tagTitle describes the tagged belonging for the sheet and the found report
+func tagTitle(tag *models.RecoveryTag) string {
+	if tag.Label != "" {
+		return tag.Label
+	}
+	if tag.Asset != nil {
+		return string(tag.Asset.Type)
+	}
+	return "belonging"
+}
+
+// truncate shortens text to at most n runes
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+// randomCode generates a random code of the given length from the
+// unambiguous code alphabet
+func randomCode(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)]
+	}
+	return string(buf), nil
+}
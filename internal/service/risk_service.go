@@ -0,0 +1,307 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/sms"
+	"strings"
+	"time"
+)
+
+const (
+	// RiskMediumScore and RiskHighScore are where risk levels start; high-risk
+	// items and claims go to the review queue
+	RiskMediumScore = 30
+	RiskHighScore   = 60
+	maxRiskScore    = 100
+
+	// newAccountAge and youngAccountAge are how old an account must be before
+	// its age stops counting against it
+	newAccountAge   = 24 * time.Hour
+	youngAccountAge = 7 * 24 * time.Hour
+	// claimBurstLimit claims within claimBurstWindow is a burst
+	claimBurstWindow = time.Hour
+	claimBurstLimit  = 3
+	// claimSpreadLimit different items claimed within claimSpreadWindow
+	// suggests someone claiming whatever is posted
+	claimSpreadWindow = 7 * 24 * time.Hour
+	claimSpreadLimit  = 4
+	// itemBurstLimit items within itemBurstWindow is a burst
+	itemBurstWindow = time.Hour
+	itemBurstLimit  = 5
+	// sharedNumberLimit other accounts using the same phone number is suspicious
+	sharedNumberLimit = 2
+
+	// flaggedLookback is how far back an open or confirmed review throttles its actor
+	flaggedLookback = 30 * 24 * time.Hour
+	// throttleWindow and throttledActions limit what flagged actors can post
+	throttleWindow   = 24 * time.Hour
+	throttledActions = 2
+)
+
+var (
+	ErrThrottled          = errors.New("your account is limited while our moderators review recent activity; try again later")
+	ErrRiskReviewNotFound = errors.New("risk review not found")
+)
+
+// scamPhrases are wording used to ask for money before returning an item,
+// in English and Swahili
+var scamPhrases = []string{
+	"send money", "send me money", "send cash", "send ksh", "send kes", "send sh",
+	"pay ksh", "pay kes", "pay a fee", "processing fee", "registration fee", "clearance fee",
+	"facilitation fee", "release fee", "transport fee first", "before i release", "before releasing",
+	"mpesa to", "m-pesa to", "till number", "paybill", "pay bill number", "western union",
+	"tuma pesa", "nitumie pesa", "tuma ksh", "ada ya", "lipa kwa", "lipa ada",
+}
+
+// RiskService scores items and claims for signs of scams, queues high-risk
+// ones for moderators and throttles actors under review
+type RiskService struct {
+	repo  *repository.RiskRepository
+	users *repository.UserRepository
+}
+
+// NewRiskService creates a new RiskService
+func NewRiskService(repo *repository.RiskRepository, users *repository.UserRepository) *RiskService {
+	return &RiskService{repo: repo, users: users}
+}
+
+// RiskLevel names the band a score falls in
+func RiskLevel(score int) string {
+	switch {
+	case score >= RiskHighScore:
+		return "high"
+	case score >= RiskMediumScore:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// CheckItem throttles people under review before they post another item.
+// It is registered as an item check. Organisation items are not throttled.
+func (s *RiskService) CheckItem(item *models.Item) error {
+	if item.OrganizationID != nil {
+		return nil
+	}
+	return s.throttle(item.UserID, s.repo.CountItemsSince)
+}
+
+// CheckClaim throttles people under review before they file another claim.
+// It is registered as a claim check.
+func (s *RiskService) CheckClaim(claim *models.Claim) error {
+	return s.throttle(claim.ClaimerID, s.repo.CountClaimsSince)
+}
+
+// ItemChanged scores a new or edited item and queues it when it is high
+// risk. It is registered as an item hook. Organisation items are not scored,
+// nor are items a finder filed through a recovery tag, whose text the owner
+// did not write.
+func (s *RiskService) ItemChanged(item *models.Item) {
+	if item.OrganizationID != nil || item.Source == models.ItemSourceRecoveryTag {
+		return
+	}
+	signals := s.itemSignals(item)
+	score := riskScore(signals)
+	if err := s.repo.SetItemRisk(item.ID, score, signals); err != nil {
+		log.Printf("risk: failed to store score of item %s: %v", item.ID, err)
+		return
+	}
+	item.RiskScore, item.RiskSignals = score, signals
+	s.queue(models.RiskSubjectItem, item.ID, item.UserID, score, signals)
+}
+
+// ClaimChanged scores a new claim and queues it when it is high risk. It is
+// registered as a claim hook.
+func (s *RiskService) ClaimChanged(claim *models.Claim) {
+	if claim.Status != models.ClaimStatusPending {
+		return
+	}
+	signals := s.claimSignals(claim)
+	score := riskScore(signals)
+	if err := s.repo.SetClaimRisk(claim.ID, score, signals); err != nil {
+		log.Printf("risk: failed to store score of claim %s: %v", claim.ID, err)
+		return
+	}
+	claim.RiskScore, claim.RiskSignals = score, signals
+	s.queue(models.RiskSubjectClaim, claim.ID, claim.ClaimerID, score, signals)
+}
+
+// Reviews retrieves the review queue
+func (s *RiskService) Reviews(filter repository.RiskReviewFilter, page, limit int) ([]models.RiskReview, int64, error) {
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListReviews(filter, page, limit)
+}
+
+// Decide records a moderator's decision on a review. Clearing it lifts the
+// actor's throttle; confirming it keeps them throttled.
+func (s *RiskService) Decide(id, moderatorID uuid.UUID, status models.RiskReviewStatus, note string) (*models.RiskReview, error) {
+	if status != models.RiskReviewCleared && status != models.RiskReviewConfirmed {
+		return nil, errors.New("decision must be cleared or confirmed")
+	}
+	review, err := s.repo.GetReview(id)
+	if err != nil {
+		return nil, ErrRiskReviewNotFound
+	}
+
+	now := time.Now()
+	review.Status, review.Note = status, strings.TrimSpace(note)
+	review.ReviewedByID, review.ReviewedAt = &moderatorID, &now
+	if err := s.repo.UpdateReview(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// throttle refuses an action when the actor is under review and has
+// already acted throttledActions times within throttleWindow
+func (s *RiskService) throttle(actorID uuid.UUID, count func(uuid.UUID, time.Time) (int64, error)) error {
+	now := time.Now()
+	flagged, err := s.repo.IsFlagged(actorID, now.Add(-flaggedLookback))
+	if err != nil || !flagged {
+		return err
+	}
+	recent, err := count(actorID, now.Add(-throttleWindow))
+	if err != nil {
+		return err
+	}
+	if recent >= throttledActions {
+		return ErrThrottled
+	}
+	return nil
+}
+
+// queue adds a high-risk item or claim to the review queue, or updates the
+// score of one already waiting
+func (s *RiskService) queue(subject models.RiskSubject, subjectID, actorID uuid.UUID, score int, signals []models.RiskSignal) {
+	if score < RiskHighScore {
+		return
+	}
+
+	if review, err := s.repo.OpenReviewFor(subject, subjectID); err == nil {
+		review.Score, review.Signals = score, signals
+		if err := s.repo.UpdateReview(review); err != nil {
+			log.Printf("risk: failed to update review %s: %v", review.ID, err)
+		}
+		return
+	}
+
+	review := &models.RiskReview{
+		SubjectType: subject,
+		SubjectID:   subjectID,
+		ActorID:     actorID,
+		Score:       score,
+		Signals:     signals,
+		Status:      models.RiskReviewOpen,
+	}
+	if err := s.repo.CreateReview(review); err != nil {
+		log.Printf("risk: failed to queue %s %s: %v", subject, subjectID, err)
+	}
+}
+
+// itemSignals collects the signals for an item
+func (s *RiskService) itemSignals(item *models.Item) []models.RiskSignal {
+	signals := s.actorSignals(item.UserID)
+
+	if posted, err := s.repo.CountItemsSince(item.UserID, time.Now().Add(-itemBurstWindow)); err == nil && posted >= itemBurstLimit {
+		signals = append(signals, models.RiskSignal{Code: "item_velocity", Points: 15, Detail: fmt.Sprintf("%d items in the last hour", posted)})
+	}
+	signals = append(signals, textSignals(item.Title, item.Description, item.Contact)...)
+	signals = append(signals, s.numberSignals(item.UserID, item.Contact+" "+item.Description)...)
+	return signals
+}
+
+// claimSignals collects the signals for a claim
+func (s *RiskService) claimSignals(claim *models.Claim) []models.RiskSignal {
+	signals := s.actorSignals(claim.ClaimerID)
+	now := time.Now()
+
+	if filed, err := s.repo.CountClaimsSince(claim.ClaimerID, now.Add(-claimBurstWindow)); err == nil && filed >= claimBurstLimit {
+		signals = append(signals, models.RiskSignal{Code: "claim_velocity", Points: 25, Detail: fmt.Sprintf("%d claims in the last hour", filed)})
+	}
+	if items, err := s.repo.CountClaimedItemsSince(claim.ClaimerID, now.Add(-claimSpreadWindow)); err == nil && items >= claimSpreadLimit {
+		signals = append(signals, models.RiskSignal{Code: "claims_on_many_items", Points: 25, Detail: fmt.Sprintf("claims on %d different items this week", items)})
+	}
+	signals = append(signals, textSignals(claim.Description)...)
+	signals = append(signals, s.numberSignals(claim.ClaimerID, claim.Description)...)
+	return signals
+}
+
+// actorSignals collects the signals about the account itself
+func (s *RiskService) actorSignals(userID uuid.UUID) []models.RiskSignal {
+	user, err := s.users.GetByID(userID)
+	if err != nil {
+		return nil
+	}
+
+	var signals []models.RiskSignal
+	switch age := time.Since(user.CreatedAt); {
+	case age < newAccountAge:
+		signals = append(signals, models.RiskSignal{Code: "new_account", Points: 20, Detail: "account created in the last day"})
+	case age < youngAccountAge:
+		signals = append(signals, models.RiskSignal{Code: "young_account", Points: 10, Detail: "account created in the last week"})
+	}
+	if user.Phone != "" {
+		if others, err := s.repo.CountAccountsWithPhone(user.Phone, user.ID); err == nil && others >= sharedNumberLimit {
+			signals = append(signals, models.RiskSignal{Code: "phone_shared_by_accounts", Points: 20, Detail: fmt.Sprintf("phone number registered to %d other accounts", others)})
+		}
+	}
+	return signals
+}
+
+// numberSignals flags phone numbers in free text that other accounts also use
+func (s *RiskService) numberSignals(userID uuid.UUID, text string) []models.RiskSignal {
+	for _, match := range phonePattern.FindAllString(text, 5) {
+		phone, ok := sms.NormalizePhone(match)
+		if !ok {
+			continue
+		}
+		others, err := s.repo.CountAccountsUsingNumber(phone[len(phone)-9:], userID)
+		if err == nil && others >= sharedNumberLimit {
+			return []models.RiskSignal{{Code: "phone_reused", Points: 20, Detail: fmt.Sprintf("%s is used by %d other accounts", phone, others)}}
+		}
+	}
+	return nil
+}
+
+// textSignals flags wording that asks for money
+func textSignals(texts ...string) []models.RiskSignal {
+	text := strings.ToLower(strings.Join(texts, " "))
+	text = strings.Join(strings.Fields(text), " ")
+
+	var found []string
+	for _, phrase := range scamPhrases {
+		if strings.Contains(text, phrase) {
+			found = append(found, phrase)
+		}
+	}
+	if len(found) == 0 {
+		return nil
+	}
+	points := 30 + 10*(len(found)-1)
+	if points > 50 {
+		points = 50
+	}
+	return []models.RiskSignal{{Code: "payment_request", Points: points, Detail: `asks for money: "` + strings.Join(found, `", "`) + `"`}}
+}
+
+// riskScore adds up signals, capped at maxRiskScore
+func riskScore(signals []models.RiskSignal) int {
+	score := 0
+	for _, signal := range signals {
+		score += signal.Points
+	}
+	if score > maxRiskScore {
+		score = maxRiskScore
+	}
+	return score
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"strings"
+	"time"
+)
+
+const (
+	// maxSavedSearches bounds how many active saved searches a user can have
+	maxSavedSearches = 20
+	// maxSavedSearchDays bounds how long a saved search can run before it must be renewed
+	maxSavedSearchDays = 90
+	// savedSearchGraceDays is how long expired searches are kept so they can be renewed
+	savedSearchGraceDays = 30
+	// savedSearchQueueSize bounds the number of items waiting to be evaluated
+	savedSearchQueueSize = 1000
+)
+
+var (
+	ErrSavedSearchNotFound  = errors.New("saved search not found")
+	ErrTooManySavedSearches = fmt.Errorf("you can have at most %d active saved searches", maxSavedSearches)
+)
+
+// SavedSearchService manages saved searches and alerts users when new or
+// updated items match them
+type SavedSearchService struct {
+	repo       *repository.SavedSearchRepository
+	items      *ItemService
+	events     *EventService
+	expiryDays int
+	queue      chan uuid.UUID
+}
+
+// NewSavedSearchService creates a new SavedSearchService. Searches expire
+// after expiryDays unless renewed.
+func NewSavedSearchService(repo *repository.SavedSearchRepository, items *ItemService, events *EventService, expiryDays int) *SavedSearchService {
+	if expiryDays <= 0 || expiryDays > maxSavedSearchDays {
+		expiryDays = maxSavedSearchDays
+	}
+	return &SavedSearchService{
+		repo:       repo,
+		items:      items,
+		events:     events,
+		expiryDays: expiryDays,
+		queue:      make(chan uuid.UUID, savedSearchQueueSize),
+	}
+}
+
+// Create saves a new search for a user
+func (s *SavedSearchService) Create(search *models.SavedSearch, expiresInDays int) error {
+	count, err := s.repo.CountActiveByUser(search.UserID, time.Now())
+	if err != nil {
+		return err
+	}
+	if count >= maxSavedSearches {
+		return ErrTooManySavedSearches
+	}
+
+	if err := s.prepare(search, expiresInDays); err != nil {
+		return err
+	}
+	return s.repo.Create(search)
+}
+
+// ListForUser retrieves a user's saved searches
+func (s *SavedSearchService) ListForUser(userID uuid.UUID) ([]models.SavedSearch, error) {
+	return s.repo.ListByUser(userID)
+}
+
+// GetOwned retrieves a saved search owned by the user
+func (s *SavedSearchService) GetOwned(id, userID uuid.UUID) (*models.SavedSearch, error) {
+	search, err := s.repo.GetByID(id)
+	if err != nil || search.UserID != userID {
+		return nil, ErrSavedSearchNotFound
+	}
+	return search, nil
+}
+
+// Update replaces a saved search's criteria and renews its expiry
+func (s *SavedSearchService) Update(id uuid.UUID, input *models.SavedSearch, expiresInDays int) (*models.SavedSearch, error) {
+	search, err := s.GetOwned(id, input.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	search.Name = input.Name
+	search.Keywords = input.Keywords
+	search.CategoryPath = input.CategoryPath
+	search.CountyID = input.CountyID
+	search.Status = input.Status
+	search.DateFrom = input.DateFrom
+	search.DateTo = input.DateTo
+	search.Frequency = input.Frequency
+	if err := s.prepare(search, expiresInDays); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(search); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
+
+// Delete removes a saved search
+func (s *SavedSearchService) Delete(id, userID uuid.UUID) error {
+	if _, err := s.GetOwned(id, userID); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// Hits retrieves the items that matched a saved search
+func (s *SavedSearchService) Hits(id, userID uuid.UUID, page, limit int) ([]models.SavedSearchHit, int64, error) {
+	if _, err := s.GetOwned(id, userID); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListHits(id, page, limit)
+}
+
+// Enqueue schedules an item to be evaluated against saved searches. It is
+// registered as an item hook and never blocks the request.
+func (s *SavedSearchService) Enqueue(item *models.Item) {
+	select {
+	case s.queue <- item.ID:
+	default:
+		log.Printf("saved search queue full, skipping item %s", item.ID)
+	}
+}
+
+// Run evaluates queued items until the context is cancelled
+func (s *SavedSearchService) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case itemID := <-s.queue:
+			if err := s.evaluate(itemID); err != nil {
+				log.Printf("failed to evaluate saved searches for item %s: %v", itemID, err)
+			}
+		}
+	}
+}
+
+// SendDigests sends the daily digest for every daily search with new hits
+func (s *SavedSearchService) SendDigests(ctx context.Context) (int, error) {
+	now := time.Now()
+	searches, err := s.repo.PendingDigests(now.Add(-24*time.Hour), now)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i := range searches {
+		if ctx.Err() != nil {
+			return sent, ctx.Err()
+		}
+
+		hits, err := s.repo.UnnotifiedHits(searches[i].ID)
+		if err != nil {
+			return sent, err
+		}
+
+		var items []models.Item
+		var hitIDs []uuid.UUID
+		for _, hit := range hits {
+			hitIDs = append(hitIDs, hit.ID)
+			if hit.Item != nil {
+				items = append(items, *hit.Item)
+			}
+		}
+		if len(items) > 0 {
+			s.events.SavedSearchMatched(&searches[i], items, true)
+		}
+		if err := s.repo.MarkNotified(searches[i].ID, hitIDs, now); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
+
+// PurgeExpired removes searches that expired more than the grace period ago
+func (s *SavedSearchService) PurgeExpired() (int64, error) {
+	return s.repo.DeleteExpiredBefore(time.Now().AddDate(0, 0, -savedSearchGraceDays))
+}
+
+// evaluate records hits for an item and sends instant alerts
+func (s *SavedSearchService) evaluate(itemID uuid.UUID) error {
+	item, err := s.items.GetByID(itemID)
+	if err != nil {
+		// The item was deleted before it could be evaluated
+		return nil
+	}
+
+	categoryPath := ""
+	if item.Category != nil {
+		categoryPath = item.Category.Path
+	}
+
+	now := time.Now()
+	searches, err := s.repo.Candidates(item, categoryPath, now)
+	if err != nil {
+		return err
+	}
+
+	text := itemSearchText(item)
+	for i := range searches {
+		search := &searches[i]
+		if !keywordsMatch(search.Keywords, text) {
+			continue
+		}
+
+		hit := &models.SavedSearchHit{SearchID: search.ID, ItemID: item.ID, UserID: search.UserID}
+		created, err := s.repo.CreateHit(hit)
+		if err != nil {
+			return err
+		}
+		if !created || search.Frequency != models.AlertFrequencyInstant {
+			continue
+		}
+
+		s.events.SavedSearchMatched(search, []models.Item{*item}, false)
+		if err := s.repo.MarkNotified(search.ID, []uuid.UUID{hit.ID}, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// prepare validates and normalises a saved search and sets its expiry
+func (s *SavedSearchService) prepare(search *models.SavedSearch, expiresInDays int) error {
+	search.Keywords = strings.TrimSpace(search.Keywords)
+
+	filter := repository.ItemFilter{CountyID: search.CountyID, CategoryPath: search.CategoryPath}
+	s.items.NormalizeFilter(&filter)
+	search.CountyID, search.CategoryPath = filter.CountyID, filter.CategoryPath
+
+	if search.Keywords == "" && search.CategoryPath == "" && search.CountyID == "" && search.Status == "" {
+		return errors.New("a saved search needs keywords, a category, a county or a status")
+	}
+
+	switch search.Status {
+	case "", models.ItemStatusLost, models.ItemStatusFound:
+	default:
+		return fmt.Errorf("status must be %s or %s", models.ItemStatusLost, models.ItemStatusFound)
+	}
+
+	switch search.Frequency {
+	case "":
+		search.Frequency = models.AlertFrequencyInstant
+	case models.AlertFrequencyInstant, models.AlertFrequencyDaily:
+	default:
+		return fmt.Errorf("frequency must be %s or %s", models.AlertFrequencyInstant, models.AlertFrequencyDaily)
+	}
+
+	if search.DateFrom != nil && search.DateTo != nil && search.DateTo.Before(*search.DateFrom) {
+		return errors.New("date_to must not be before date_from")
+	}
+
+	if expiresInDays <= 0 || expiresInDays > maxSavedSearchDays {
+		expiresInDays = s.expiryDays
+	}
+	search.ExpiresAt = time.Now().AddDate(0, 0, expiresInDays)
+	return nil
+}
+
+// itemSearchText collects the words of an item that keywords are matched
+// against. Private attributes are left out so a saved search can't find an
+// item by its serial number.
+func itemSearchText(item *models.Item) map[string]bool {
+	words := wordSet(item.Title + " " + item.Description + " " + item.Location)
+	for _, tag := range item.Tags {
+		words[tag.Name] = true
+		for word := range wordSet(tag.Name) {
+			words[word] = true
+		}
+	}
+	private := make(map[string]bool, len(item.PrivateAttributes))
+	for _, key := range item.PrivateAttributes {
+		private[key] = true
+	}
+	for key, value := range item.Attributes {
+		if private[key] {
+			continue
+		}
+		for word := range wordSet(fmt.Sprint(value)) {
+			words[word] = true
+		}
+	}
+	return words
+}
+
+// keywordsMatch reports whether every keyword, or its canonical tag, appears in the item's words
+func keywordsMatch(keywords string, words map[string]bool) bool {
+	for keyword := range wordSet(keywords) {
+		if !words[keyword] && !words[normalizeTag(keyword)] {
+			return false
+		}
+	}
+	return true
+}
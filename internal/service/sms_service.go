@@ -0,0 +1,362 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/notify"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/sms"
+	"strings"
+	"time"
+)
+
+// minSMSLocationScore is the lowest gazetteer score accepted for a one-word place
+const minSMSLocationScore = 0.85
+
+// smsStatusWords maps the first word of a report text to the item status.
+// Swahili words also set the reply language for new reporters.
+var smsStatusWords = map[string]models.ItemStatus{
+	"LOST":  models.ItemStatusLost,
+	"POTEA": models.ItemStatusLost,
+	"FOUND": models.ItemStatusFound,
+	"OKOTA": models.ItemStatusFound,
+}
+
+// smsCategoryWords maps the item word of a report text to a category path
+var smsCategoryWords = map[string]string{
+	"ID":           "documents/national-id",
+	"KITAMBULISHO": "documents/national-id",
+	"PASSPORT":     "documents/passport",
+	"DL":           "documents/driving-licence",
+	"LICENCE":      "documents/driving-licence",
+	"LICENSE":      "documents/driving-licence",
+	"ATM":          "documents/bank-card",
+	"CARD":         "documents/bank-card",
+	"CERT":         "documents/certificate",
+	"CERTIFICATE":  "documents/certificate",
+	"PHONE":        "electronics/phone",
+	"SIMU":         "electronics/phone",
+	"LAPTOP":       "electronics/laptop",
+	"TABLET":       "electronics/tablet",
+	"WALLET":       "bags-wallets/wallet",
+	"POCHI":        "bags-wallets/wallet",
+	"BAG":          "bags-wallets",
+	"MKOBA":        "bags-wallets",
+	"KEYS":         "keys",
+	"FUNGUO":       "keys",
+	"BIKE":         "vehicles-bicycles/bicycle",
+	"BAISKELI":     "vehicles-bicycles/bicycle",
+	"OTHER":        "other",
+}
+
+// smsConnectors are dropped from between the details and the place
+var smsConnectors = map[string]bool{"AT": true, "IN": true, "NEAR": true, "KWA": true, "KARIBU": true}
+
+// smsReplies holds the reply texts in each supported language
+var smsReplies = map[string]map[string]string{
+	notify.LocaleEnglish: {
+		"usage":     "To report, text LOST or FOUND, the item and the place, e.g. LOST ID John Kamau Nairobi or FOUND PHONE Tecno Thika. Items: ID, PASSPORT, DL, ATM, PHONE, LAPTOP, WALLET, BAG, KEYS, BIKE, OTHER.",
+		"name":      "Please include the name on the document, e.g. LOST ID John Kamau Nairobi.",
+		"draft":     "Ref %s: your report of %s is saved. Reply DETAILS %s followed by colour, marks or the exact place to add more, then CONFIRM %s to publish it.",
+		"details":   "Ref %s updated. Reply CONFIRM %s to publish it.",
+		"confirmed": "Ref %s is now published. We will text you if we find a match. Reply STATUS %s any time.",
+		"published": "Ref %s is already published.",
+		"status":    "Ref %s: %s - %s.",
+		"cancelled": "Ref %s has been removed.",
+		"no_cancel": "Ref %s is published. Only drafts can be cancelled by text; remove it in the app.",
+		"not_found": "We could not find ref %s for your number.",
+		"failed":    "Sorry, we could not save your report. Please try again later.",
+		"draft_st":  "draft, reply CONFIRM to publish",
+	},
+	notify.LocaleSwahili: {
+		"usage":     "Kuripoti, tuma POTEA au OKOTA, kitu na mahali, mfano POTEA ID John Kamau Nairobi au OKOTA SIMU Tecno Thika. Vitu: ID, PASSPORT, DL, ATM, SIMU, LAPTOP, POCHI, MKOBA, FUNGUO, BAISKELI, OTHER.",
+		"name":      "Tafadhali weka jina lililo kwenye hati, mfano POTEA ID John Kamau Nairobi.",
+		"draft":     "Kumb %s: ripoti yako ya %s imehifadhiwa. Jibu DETAILS %s ukifuatisha rangi, alama au mahali kamili, kisha CONFIRM %s kuichapisha.",
+		"details":   "Kumb %s imesasishwa. Jibu CONFIRM %s kuichapisha.",
+		"confirmed": "Kumb %s imechapishwa. Tutakutumia ujumbe tukipata inayolingana. Jibu STATUS %s wakati wowote.",
+		"published": "Kumb %s tayari imechapishwa.",
+		"status":    "Kumb %s: %s - %s.",
+		"cancelled": "Kumb %s imeondolewa.",
+		"no_cancel": "Kumb %s imechapishwa. Rasimu pekee zinaweza kughairiwa kwa SMS; iondoe kwenye programu.",
+		"not_found": "Hatukupata kumb %s kwa nambari yako.",
+		"failed":    "Samahani, hatukuweza kuhifadhi ripoti yako. Tafadhali jaribu tena baadaye.",
+		"draft_st":  "rasimu, jibu CONFIRM kuichapisha",
+	},
+}
+
+// smsReport is a report parsed from a text message
+type smsReport struct {
+	Status       models.ItemStatus
+	CategoryPath string
+	Details      string
+	Location     string
+	Swahili      bool
+}
+
+// SMSService lets people report and follow up on items by text message
+type SMSService struct {
+	provider   sms.Provider
+	users      *repository.UserRepository
+	items      *ItemService
+	categories *CategoryService
+	locations  *LocationService
+}
+
+// NewSMSService creates a new SMSService replying through provider
+func NewSMSService(provider sms.Provider, users *repository.UserRepository, items *ItemService, categories *CategoryService, locations *LocationService) *SMSService {
+	return &SMSService{provider: provider, users: users, items: items, categories: categories, locations: locations}
+}
+
+// HandleInbound processes a text from a user and texts back the reply, which
+// is also returned. Reports become draft items under an account for the
+// sender's number; follow-up commands quote the reference code:
+//
+//	LOST ID John Kamau Nairobi
+//	DETAILS K7M2Q9P green cover, lost near the stage
+//	CONFIRM K7M2Q9P
+//	STATUS K7M2Q9P
+//	CANCEL K7M2Q9P
+func (s *SMSService) HandleInbound(ctx context.Context, msg sms.InboundMessage) (string, error) {
+	phone, ok := sms.NormalizePhone(msg.From)
+	if !ok {
+		return "", fmt.Errorf("unsupported sender %q", msg.From)
+	}
+
+	words := strings.Fields(msg.Text)
+	command := ""
+	if len(words) > 0 {
+		command = strings.ToUpper(words[0])
+	}
+
+	var reply string
+	var err error
+	switch command {
+	case "DETAILS", "CONFIRM", "STATUS", "CANCEL":
+		reply, err = s.followUp(phone, command, words[1:])
+	default:
+		reply, err = s.report(phone, msg)
+	}
+	if err != nil {
+		log.Printf("sms: failed to handle text from %s: %v", phone, err)
+	}
+
+	if sendErr := s.provider.Send(ctx, phone, reply); sendErr != nil {
+		return reply, fmt.Errorf("failed to send reply: %w", sendErr)
+	}
+	return reply, nil
+}
+
+// report turns a report text into a draft item
+func (s *SMSService) report(phone string, msg sms.InboundMessage) (string, error) {
+	parsed, ok := s.parseReport(msg.Text)
+	locale := notify.LocaleEnglish
+	if parsed != nil && parsed.Swahili {
+		locale = notify.LocaleSwahili
+	}
+	user, err := s.phoneUser(phone, locale)
+	if err != nil {
+		return smsReply(locale, "failed"), err
+	}
+	locale = notify.NormalizeLocale(user.Locale)
+
+	if !ok {
+		return smsReply(locale, "usage"), nil
+	}
+
+	category, err := s.categories.GetByPath(parsed.CategoryPath)
+	if err != nil {
+		return smsReply(locale, "failed"), fmt.Errorf("category %q: %w", parsed.CategoryPath, err)
+	}
+
+	isDocument := strings.HasPrefix(category.Path, "documents/")
+	if isDocument && parsed.Details == "" {
+		return smsReply(locale, "name"), nil
+	}
+
+	title := category.Name
+	if parsed.Details != "" {
+		title += " - " + parsed.Details
+	}
+	item := &models.Item{
+		Title:      truncate(title, 120),
+		CategoryID: &category.ID,
+		Status:     parsed.Status,
+		Location:   parsed.Location,
+		Date:       smsDate(msg),
+		UserID:     user.ID,
+		Contact:    phone,
+	}
+	if isDocument {
+		item.Attributes = map[string]any{"name": parsed.Details}
+	}
+	if err := s.items.CreateDraft(item, models.ItemSourceSMS); err != nil {
+		return smsReply(locale, "failed"), err
+	}
+
+	ref := *item.ReferenceCode
+	return fmt.Sprintf(smsReply(locale, "draft"), ref, strings.ToLower(category.Name), ref, ref), nil
+}
+
+// followUp handles a command about an earlier report
+func (s *SMSService) followUp(phone, command string, args []string) (string, error) {
+	user, err := s.phoneUser(phone, notify.LocaleEnglish)
+	if err != nil {
+		return smsReply(notify.LocaleEnglish, "failed"), err
+	}
+	locale := notify.NormalizeLocale(user.Locale)
+
+	if len(args) == 0 {
+		return smsReply(locale, "usage"), nil
+	}
+	ref := strings.ToUpper(args[0])
+	item, err := s.items.GetByReference(ref)
+	if err != nil || item.UserID != user.ID {
+		return fmt.Sprintf(smsReply(locale, "not_found"), ref), nil
+	}
+
+	switch command {
+	case "DETAILS":
+		details := strings.TrimSpace(strings.Join(args[1:], " "))
+		if details == "" {
+			return fmt.Sprintf(smsReply(locale, "details"), ref, ref), nil
+		}
+		if item.Description != "" {
+			details = item.Description + "\n" + details
+		}
+		item.Description = details
+		if err := s.items.Update(item); err != nil {
+			return smsReply(locale, "failed"), err
+		}
+		if !item.Draft {
+			return fmt.Sprintf(smsReply(locale, "published"), ref), nil
+		}
+		return fmt.Sprintf(smsReply(locale, "details"), ref, ref), nil
+
+	case "CONFIRM":
+		if !item.Draft {
+			return fmt.Sprintf(smsReply(locale, "published"), ref), nil
+		}
+		if err := s.items.PublishChecked(item); err != nil {
+			return smsReply(locale, "failed"), err
+		}
+		return fmt.Sprintf(smsReply(locale, "confirmed"), ref, ref), nil
+
+	case "CANCEL":
+		// A text only proves the number, so published reports are removed in the app
+		if !item.Draft {
+			return fmt.Sprintf(smsReply(locale, "no_cancel"), ref), nil
+		}
+		if err := s.items.Delete(item.ID); err != nil {
+			return smsReply(locale, "failed"), err
+		}
+		return fmt.Sprintf(smsReply(locale, "cancelled"), ref), nil
+
+	default:
+		status := string(item.Status)
+		if item.Draft {
+			status = smsReply(locale, "draft_st")
+		}
+		return fmt.Sprintf(smsReply(locale, "status"), ref, item.Title, status), nil
+	}
+}
+
+// parseReport reads "<LOST|FOUND> <ITEM> [details] [place]". The place is the
+// longest run of trailing words that names a county, sub-county or ward; if
+// none does, the last word is taken as the place.
+func (s *SMSService) parseReport(text string) (*smsReport, bool) {
+	words := strings.Fields(text)
+	if len(words) < 2 {
+		return nil, false
+	}
+
+	status, ok := smsStatusWords[strings.ToUpper(words[0])]
+	if !ok {
+		return nil, false
+	}
+	report := &smsReport{Status: status, Swahili: strings.EqualFold(words[0], "POTEA") || strings.EqualFold(words[0], "OKOTA")}
+
+	path, ok := smsCategoryWords[strings.ToUpper(words[1])]
+	if !ok {
+		return report, false
+	}
+	report.CategoryPath = path
+
+	rest := words[2:]
+	placeWords := 0
+	for size := min(3, len(rest)); size > 0; size-- {
+		if s.isPlace(rest[len(rest)-size:]) {
+			placeWords = size
+			break
+		}
+	}
+	if placeWords == 0 && len(rest) >= 2 {
+		// An unknown last word is a landmark, e.g. "Mombasa CBD" or "Gikomba market"
+		placeWords = 1
+		if len(rest) >= 3 && s.isPlace(rest[len(rest)-2:len(rest)-1]) {
+			placeWords = 2
+		}
+	}
+
+	report.Location = strings.Join(rest[len(rest)-placeWords:], " ")
+	details := rest[:len(rest)-placeWords]
+	if n := len(details); n > 0 && smsConnectors[strings.ToUpper(details[n-1])] {
+		details = details[:n-1]
+	}
+	report.Details = strings.Join(details, " ")
+	return report, true
+}
+
+// isPlace reports whether words name a county, sub-county or ward
+func (s *SMSService) isPlace(words []string) bool {
+	phrase := strings.Join(words, " ")
+	match, ok := s.locations.Resolve(phrase)
+	if !ok {
+		return false
+	}
+	return strings.EqualFold(match.Name, phrase) || (len(words) == 1 && match.Score >= minSMSLocationScore)
+}
+
+// phoneUser finds the account for a phone number, creating one for first-time
+// reporters in the given language
+func (s *SMSService) phoneUser(phone, locale string) (*models.User, error) {
+	return findOrCreatePhoneUser(s.users, phone, locale)
+}
+
+// findOrCreatePhoneUser finds the account for a phone number, creating one
+// for people who report by SMS or USSD without signing up
+func findOrCreatePhoneUser(users *repository.UserRepository, phone, locale string) (*models.User, error) {
+	user, err := users.GetByPhone(phone)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user = models.NewPhoneUser(phone)
+	user.Locale = locale
+	if err := users.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// smsReply returns a reply text in the user's language
+func smsReply(locale, key string) string {
+	if text, ok := smsReplies[locale][key]; ok {
+		return text
+	}
+	return smsReplies[notify.LocaleEnglish][key]
+}
+
+// smsDate returns the time a text was sent, or now if unknown
+func smsDate(msg sms.InboundMessage) time.Time {
+	if msg.Date.IsZero() {
+		return time.Now()
+	}
+	return msg.Date
+}
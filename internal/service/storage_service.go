@@ -2,8 +2,12 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"io"
 	"lostnfound-api/internal/models"
 	"lostnfound-api/internal/repository"
 	"lostnfound-api/internal/util/storage"
@@ -35,10 +39,12 @@ func (s *StorageService) UploadItemImage(ctx context.Context, itemID uuid.UUID,
 	contentType := getContentTypeFromFileName(filename)
 
 	// Define the object path in GCS
-	objectName := fmt.Sprintf("items/%d/%s", itemID, filename)
+	objectName := itemObjectPrefix(itemID) + filename
 
-	// Upload file to Google Cloud Storage
-	url, err := s.storage.UploadFile(ctx, objectName, file, contentType)
+	// Upload file to Google Cloud Storage, hashing it on the way so the
+	// same photo can be recognised on another report
+	hash := sha256.New()
+	url, err := s.storage.UploadFile(ctx, objectName, io.TeeReader(file, hash), contentType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
@@ -47,6 +53,7 @@ func (s *StorageService) UploadItemImage(ctx context.Context, itemID uuid.UUID,
 	image := &models.Image{
 		URL:    url,
 		ItemID: itemID,
+		Hash:   hex.EncodeToString(hash.Sum(nil)),
 	}
 
 	if err := s.imageRepo.Create(image); err != nil {
@@ -58,6 +65,56 @@ func (s *StorageService) UploadItemImage(ctx context.Context, itemID uuid.UUID,
 	return image, nil
 }
 
+// UploadMessageAttachment uploads a file attached to a conversation message
+// and returns its URL
+func (s *StorageService) UploadMessageAttachment(ctx context.Context, conversationID uuid.UUID, file multipart.File, fileHeader *multipart.FileHeader) (string, error) {
+	filename := generateUniqueFilename(fileHeader.Filename)
+	contentType := getContentTypeFromFileName(filename)
+	if contentType == "application/octet-stream" {
+		return "", errors.New("attachments must be images or PDF documents")
+	}
+
+	objectName := fmt.Sprintf("messages/%s/%s", conversationID, filename)
+	url, err := s.storage.UploadFile(ctx, objectName, file, contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	return url, nil
+}
+
+// UploadCustodyEvidence uploads a signature or photo recorded when an item
+// changes hands and returns its URL
+func (s *StorageService) UploadCustodyEvidence(ctx context.Context, itemID uuid.UUID, file multipart.File, fileHeader *multipart.FileHeader) (string, error) {
+	filename := generateUniqueFilename(fileHeader.Filename)
+	contentType := getContentTypeFromFileName(filename)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", errors.New("signatures and photos must be images")
+	}
+
+	objectName := fmt.Sprintf("custody/%s/%s", itemID, filename)
+	url, err := s.storage.UploadFile(ctx, objectName, file, contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	return url, nil
+}
+
+// UploadImportImage uploads an image from a bulk import bundle and returns its URL
+func (s *StorageService) UploadImportImage(ctx context.Context, organizationID uuid.UUID, name string, content io.Reader) (string, error) {
+	filename := generateUniqueFilename(name)
+	contentType := getContentTypeFromFileName(filename)
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("%s is not an image", name)
+	}
+
+	objectName := fmt.Sprintf("imports/%s/%s", organizationID, filename)
+	url, err := s.storage.UploadFile(ctx, objectName, content, contentType)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload file: %w", err)
+	}
+	return url, nil
+}
+
 // DeleteItemImage deletes an image from storage and database
 func (s *StorageService) DeleteItemImage(ctx context.Context, imageID uint) error {
 	// Fetch image record
@@ -66,18 +123,9 @@ func (s *StorageService) DeleteItemImage(ctx context.Context, imageID uint) erro
 		return fmt.Errorf("image not found: %w", err)
 	}
 
-	// Extract object name from URL
-	// URL format: https://storage.googleapis.com/bucket-name/object-name
-	urlParts := strings.Split(image.URL, "/")
-	if len(urlParts) < 5 {
-		return fmt.Errorf("invalid image URL format")
-	}
-
-	objectName := strings.Join(urlParts[4:], "/")
-
 	// Delete file from Google Cloud Storage
-	if err := s.storage.DeleteFile(ctx, objectName); err != nil {
-		return fmt.Errorf("failed to delete file from storage: %w", err)
+	if err := s.DeleteItemUpload(ctx, image.ItemID, image.URL); err != nil {
+		return err
 	}
 
 	// Delete record from database
@@ -88,6 +136,71 @@ func (s *StorageService) DeleteItemImage(ctx context.Context, imageID uint) erro
 	return nil
 }
 
+// DeleteItemUpload deletes a file uploaded for an item, given its public URL.
+// Only objects in this bucket under the item's own folder are deleted, and
+// only when no other item still uses the URL; anything else was not uploaded
+// for this item and is left alone.
+func (s *StorageService) DeleteItemUpload(ctx context.Context, itemID uuid.UUID, url string) error {
+	objectName, ok := strings.CutPrefix(url, s.storage.GetPublicURL(""))
+	if !ok || !strings.HasPrefix(objectName, itemObjectPrefix(itemID)) {
+		return nil
+	}
+	shared, err := s.imageRepo.SharedWithOtherItems(url, itemID)
+	if err != nil {
+		return fmt.Errorf("failed to check image references: %w", err)
+	}
+	if shared {
+		return nil
+	}
+
+	if err := s.storage.DeleteFile(ctx, objectName); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete file from storage: %w", err)
+	}
+	return nil
+}
+
+// DeleteFileByURL deletes a stored object given its public URL.
+// Objects that no longer exist are treated as already deleted.
+func (s *StorageService) DeleteFileByURL(ctx context.Context, url string) error {
+	objectName, err := objectNameFromURL(url)
+	if err != nil {
+		return err
+	}
+
+	if err := s.storage.DeleteFile(ctx, objectName); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete file from storage: %w", err)
+	}
+
+	return nil
+}
+
+// UploadExport stores an export file privately and returns its object name
+func (s *StorageService) UploadExport(ctx context.Context, jobID uuid.UUID, filename string, content io.Reader, contentType string) (string, error) {
+	objectName := fmt.Sprintf("exports/%s/%s", jobID, filename)
+	if err := s.storage.UploadPrivateFile(ctx, objectName, content, contentType); err != nil {
+		return "", fmt.Errorf("failed to upload export: %w", err)
+	}
+	return objectName, nil
+}
+
+// ExportDownloadURL generates a short-lived link for downloading an export
+func (s *StorageService) ExportDownloadURL(objectName, filename string) (string, error) {
+	url, err := s.storage.GenerateDownloadURL(objectName, filename, exportLinkLifetime)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate download link: %w", err)
+	}
+	return url, nil
+}
+
+// DeleteExport deletes an export file. Files that no longer exist are
+// treated as already deleted.
+func (s *StorageService) DeleteExport(ctx context.Context, objectName string) error {
+	if err := s.storage.DeleteFile(ctx, objectName); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("failed to delete export: %w", err)
+	}
+	return nil
+}
+
 // GenerateSignedUploadURL generates a signed URL for direct file upload
 func (s *StorageService) GenerateSignedUploadURL(ctx context.Context, itemID uint, filename string) (string, string, error) {
 	// Generate a unique filename
@@ -119,6 +232,22 @@ func generateUniqueFilename(originalFilename string) string {
 	return fmt.Sprintf("%s%s", uuid.New().String(), extension)
 }
 
+// itemObjectPrefix is the folder an item's uploaded images are stored in
+func itemObjectPrefix(itemID uuid.UUID) string {
+	return fmt.Sprintf("items/%d/", itemID)
+}
+
+// objectNameFromURL extracts the object name from a public storage URL
+// URL format: https://storage.googleapis.com/bucket-name/object-name
+func objectNameFromURL(url string) (string, error) {
+	urlParts := strings.Split(url, "/")
+	if len(urlParts) < 5 {
+		return "", fmt.Errorf("invalid image URL format")
+	}
+
+	return strings.Join(urlParts[4:], "/"), nil
+}
+
 // getContentTypeFromFileName determines the content type based on file extension
 func getContentTypeFromFileName(filename string) string {
 	extension := strings.ToLower(filepath.Ext(filename))
@@ -134,6 +263,8 @@ func getContentTypeFromFileName(filename string) string {
 		return "image/webp"
 	case ".heif", ".heic":
 		return "image/heif"
+	case ".pdf":
+		return "application/pdf"
 	default:
 		return "application/octet-stream"
 	}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxTagLength bounds the length of a normalised tag
+	maxTagLength = 32
+	// maxItemTags bounds how many tags an item can carry
+	maxItemTags = 20
+	// maxSuggestedTags is how many tags are suggested for a new item
+	maxSuggestedTags = 5
+)
+
+// tagSynonyms maps common variants, including Swahili and Sheng terms, to a canonical tag
+var tagSynonyms = map[string]string{
+	"cellphone":       "phone",
+	"cell-phone":      "phone",
+	"mobile":          "phone",
+	"mobile-phone":    "phone",
+	"smartphone":      "phone",
+	"simu":            "phone",
+	"phones":          "phone",
+	"id":              "national-id",
+	"id-card":         "national-id",
+	"identity-card":   "national-id",
+	"kitambulisho":    "national-id",
+	"licence":         "driving-licence",
+	"license":         "driving-licence",
+	"driving-license": "driving-licence",
+	"dl":              "driving-licence",
+	"purse":           "wallet",
+	"wallets":         "wallet",
+	"pochi":           "wallet",
+	"keys":            "key",
+	"funguo":          "key",
+	"bags":            "bag",
+	"mkoba":           "bag",
+	"rucksack":        "backpack",
+	"laptops":         "laptop",
+	"notebook":        "laptop",
+	"atm":             "bank-card",
+	"atm-card":        "bank-card",
+	"debit-card":      "bank-card",
+	"kadi":            "bank-card",
+	"spectacles":      "glasses",
+	"specs":           "glasses",
+	"miwani":          "glasses",
+	"earphones":       "headphones",
+	"earpods":         "headphones",
+	"airpods":         "headphones",
+	"mat":             "matatu",
+	"boda":            "boda-boda",
+	"bodaboda":        "boda-boda",
+}
+
+// tagStopwords are common English and Swahili words that make poor tags
+var tagStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "was": true, "were": true, "has": true,
+	"have": true, "had": true, "lost": true, "found": true, "near": true, "from": true, "this": true,
+	"that": true, "along": true, "into": true, "item": true, "please": true, "contact": true, "call": true,
+	"reward": true, "color": true, "colour": true, "its": true, "his": true, "her": true, "their": true,
+	"who": true, "which": true, "while": true, "when": true, "where": true, "today": true, "yesterday": true,
+	"morning": true, "evening": true, "night": true, "around": true, "some": true, "one": true, "two": true,
+	"name": true, "names": true, "owner": true, "kwa": true, "na": true, "ya": true, "wa": true,
+	"katika": true, "nime": true, "nimepoteza": true, "imepotea": true, "nimeokota": true, "tafadhali": true,
+}
+
+var ErrTooManyTags = errors.New("too many tags on item")
+
+// TagService manages item tags
+type TagService struct {
+	repo     *repository.TagRepository
+	itemRepo *repository.ItemRepository
+}
+
+// NewTagService creates a new TagService
+func NewTagService(repo *repository.TagRepository, itemRepo *repository.ItemRepository) *TagService {
+	return &TagService{repo: repo, itemRepo: itemRepo}
+}
+
+// NormalizeTags lowercases, slugifies, synonym-merges and deduplicates tag names
+func NormalizeTags(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	normalized := make([]string, 0, len(names))
+	for _, name := range names {
+		tag := normalizeTag(name)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// normalizeTag returns the canonical form of a single tag
+func normalizeTag(name string) string {
+	tag := slugify(name)
+	if len(tag) > maxTagLength {
+		tag = strings.Trim(tag[:maxTagLength], "-")
+	}
+	if canonical, ok := tagSynonyms[tag]; ok {
+		return canonical
+	}
+	return tag
+}
+
+// Resolve turns tag names into stored tags, creating new ones as needed
+func (s *TagService) Resolve(names []string) ([]models.Tag, error) {
+	names = NormalizeTags(names)
+	if len(names) > maxItemTags {
+		return nil, ErrTooManyTags
+	}
+	return s.repo.FindOrCreate(names)
+}
+
+// AddToItem attaches tags to an item and returns the item's full tag list
+func (s *TagService) AddToItem(itemID uuid.UUID, names []string) ([]models.Tag, error) {
+	item, err := s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, errors.New("item not found")
+	}
+
+	tags, err := s.Resolve(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(item.Tags)+len(tags) > maxItemTags {
+		return nil, ErrTooManyTags
+	}
+
+	if err := s.repo.AddToItem(itemID, tags); err != nil {
+		return nil, err
+	}
+
+	item, err = s.itemRepo.GetByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+	return item.Tags, nil
+}
+
+// RemoveFromItem detaches a tag from an item
+func (s *TagService) RemoveFromItem(itemID uuid.UUID, name string) error {
+	return s.repo.RemoveFromItem(itemID, normalizeTag(name))
+}
+
+// Popular returns the most used tags
+func (s *TagService) Popular(limit int) ([]repository.TagCount, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.repo.Popular(limit)
+}
+
+// Suggest extracts likely tags from an item's title and description.
+// Title words count double, and words that are already in use as tags are
+// preferred over new ones.
+func (s *TagService) Suggest(item *models.Item) ([]string, error) {
+	scores := map[string]int{}
+	addWords := func(text string, weight int) {
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '\'')
+		})
+		for i := 0; i < len(words); i++ {
+			// Two-word phrases such as "id card" can map to a tag on their own
+			if i+1 < len(words) {
+				if tag, ok := tagSynonyms[words[i]+"-"+words[i+1]]; ok {
+					scores[tag] += weight
+					i++
+					continue
+				}
+			}
+			word := words[i]
+			if tag, ok := tagSynonyms[word]; ok {
+				scores[tag] += weight
+				continue
+			}
+			if len(word) < 3 || tagStopwords[word] || isNumeric(word) {
+				continue
+			}
+			scores[normalizeTag(word)] += weight
+		}
+	}
+	addWords(item.Title, 2)
+	addWords(item.Description, 1)
+
+	candidates := make([]string, 0, len(scores))
+	for tag := range scores {
+		if tag != "" {
+			candidates = append(candidates, tag)
+		}
+	}
+
+	existing, err := s.repo.FindExisting(candidates)
+	if err != nil {
+		return nil, err
+	}
+	for tag := range existing {
+		scores[tag] += 2
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if scores[candidates[i]] != scores[candidates[j]] {
+			return scores[candidates[i]] > scores[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > maxSuggestedTags {
+		candidates = candidates[:maxSuggestedTags]
+	}
+	return candidates, nil
+}
+
+// isNumeric reports whether a word is made only of digits
+func isNumeric(word string) bool {
+	for _, r := range word {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return word != ""
+}
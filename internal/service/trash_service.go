@@ -0,0 +1,121 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"time"
+)
+
+// purgeBatchSize bounds how many items a single purge run removes
+const purgeBatchSize = 100
+
+var (
+	ErrDeletedItemNotFound  = errors.New("deleted item not found")
+	ErrRestoreWindowExpired = errors.New("restore window has expired")
+)
+
+// TrashService manages soft deleted items until they are purged
+type TrashService struct {
+	repo      *repository.ItemRepository
+	storage   *StorageService
+	retention time.Duration
+}
+
+// NewTrashService creates a new TrashService that keeps deleted items for retentionDays
+func NewTrashService(repo *repository.ItemRepository, storage *StorageService, retentionDays int) *TrashService {
+	return &TrashService{
+		repo:      repo,
+		storage:   storage,
+		retention: time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+// RetentionDays returns the number of days a deleted item is kept
+func (s *TrashService) RetentionDays() int {
+	return int(s.retention / (24 * time.Hour))
+}
+
+// PurgeAt returns when a deleted item becomes eligible for purging
+func (s *TrashService) PurgeAt(item *models.Item) time.Time {
+	return item.DeletedAt.Time.Add(s.retention)
+}
+
+// GetDeleted retrieves a soft deleted item by ID
+func (s *TrashService) GetDeleted(id uuid.UUID) (*models.Item, error) {
+	item, err := s.repo.GetDeletedByID(id)
+	if err != nil {
+		return nil, ErrDeletedItemNotFound
+	}
+	return item, nil
+}
+
+// ListDeleted retrieves soft deleted items
+func (s *TrashService) ListDeleted(page, limit int) ([]models.Item, int64, error) {
+	// Default pagination values
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+
+	return s.repo.ListDeleted(page, limit)
+}
+
+// Restore brings a soft deleted item back. Unless enforceWindow is false,
+// the item must still be inside its retention window.
+func (s *TrashService) Restore(id uuid.UUID, enforceWindow bool) (*models.Item, error) {
+	item, err := s.GetDeleted(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if enforceWindow && time.Now().After(s.PurgeAt(item)) {
+		return nil, ErrRestoreWindowExpired
+	}
+
+	if err := s.repo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+// PurgeExpired permanently removes items whose retention window has passed,
+// along with their stored images. Items whose images cannot be removed are
+// left in place so the next run can retry them.
+func (s *TrashService) PurgeExpired(ctx context.Context) (int, error) {
+	items, err := s.repo.ListDeletedBefore(time.Now().Add(-s.retention), purgeBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list expired items: %w", err)
+	}
+
+	purged := 0
+	for _, item := range items {
+		if err := s.purge(ctx, &item); err != nil {
+			log.Printf("failed to purge item %s: %v", item.ID, err)
+			continue
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// purge removes the images uploaded for an item and then the item itself.
+// Images it shares with other items, or that were never uploaded for it,
+// are kept.
+func (s *TrashService) purge(ctx context.Context, item *models.Item) error {
+	for _, image := range item.Images {
+		if err := s.storage.DeleteItemUpload(ctx, item.ID, image.URL); err != nil {
+			return err
+		}
+	}
+
+	return s.repo.Purge(item.ID)
+}
@@ -0,0 +1,446 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/notify"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/sms"
+	"lostnfound-api/internal/ussd"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// maxUSSDSearchResults bounds the documents listed on one screen
+	maxUSSDSearchResults = 3
+	// minUSSDSearchLength is the shortest name that can be searched
+	minUSSDSearchLength = 3
+)
+
+// USSD menu states
+const (
+	ussdStateMain           = "main"
+	ussdStateReportCategory = "report_category"
+	ussdStateReportDetails  = "report_details"
+	ussdStateReportPlace    = "report_place"
+	ussdStateReportConfirm  = "report_confirm"
+	ussdStateStatus         = "status"
+	ussdStateSearch         = "search"
+	ussdStateLanguage       = "language"
+)
+
+// ussdCategory is an item type offered in the report menu
+type ussdCategory struct {
+	path  string
+	names map[string]string
+}
+
+// ussdCategories are the item types offered in the report menu, in order
+var ussdCategories = []ussdCategory{
+	{"documents/national-id", map[string]string{notify.LocaleEnglish: "National ID", notify.LocaleSwahili: "Kitambulisho"}},
+	{"documents/passport", map[string]string{notify.LocaleEnglish: "Passport", notify.LocaleSwahili: "Pasipoti"}},
+	{"documents/driving-licence", map[string]string{notify.LocaleEnglish: "Driving licence", notify.LocaleSwahili: "Leseni ya udereva"}},
+	{"documents/bank-card", map[string]string{notify.LocaleEnglish: "ATM card", notify.LocaleSwahili: "Kadi ya benki"}},
+	{"electronics/phone", map[string]string{notify.LocaleEnglish: "Phone", notify.LocaleSwahili: "Simu"}},
+	{"bags-wallets/wallet", map[string]string{notify.LocaleEnglish: "Wallet", notify.LocaleSwahili: "Pochi"}},
+	{"bags-wallets", map[string]string{notify.LocaleEnglish: "Bag", notify.LocaleSwahili: "Mkoba"}},
+	{"keys", map[string]string{notify.LocaleEnglish: "Keys", notify.LocaleSwahili: "Funguo"}},
+	{"other", map[string]string{notify.LocaleEnglish: "Other", notify.LocaleSwahili: "Kingine"}},
+}
+
+// ussdTexts holds the menu texts in each supported language
+var ussdTexts = map[string]map[string]string{
+	notify.LocaleEnglish: {
+		"main":           "Lost & Found Kenya\n1. Report lost item\n2. Report found item\n3. Check report status\n4. Search documents by name\n5. Language / Lugha",
+		"category_lost":  "What did you lose?",
+		"category_found": "What did you find?",
+		"details_doc":    "Enter the name on the document",
+		"details":        "Describe it (brand, colour, marks)",
+		"place":          "Where? (town or area)",
+		"confirm":        "%s: %s, %s\n1. Submit\n2. Cancel",
+		"lost":           "Lost",
+		"found":          "Found",
+		"saved":          "Saved. Your reference is %s. We will SMS you if we find a match.",
+		"cancelled":      "Report cancelled.",
+		"status_prompt":  "Enter your reference code",
+		"status":         "Ref %s: %s. Status: %s.",
+		"not_found":      "No report %s on this number.",
+		"search_prompt":  "Enter the name on the document",
+		"search_short":   "Enter at least 3 letters of the name",
+		"search_none":    "No found documents match %s yet. Please try again later.",
+		"search_results": "Found documents:\n%sQuote the ref to claim in the app or at a collection point.",
+		"language":       "Choose language\n1. English\n2. Kiswahili",
+		"language_set":   "Language set to English.",
+		"invalid":        "Invalid choice.",
+		"back":           "0. Back",
+		"failed":         "Sorry, something went wrong. Please try again.",
+		"unsupported":    "This service is only available on Kenyan mobile numbers.",
+		"item_lost":      "lost",
+		"item_found":     "found",
+		"item_claimed":   "claimed",
+		"item_returned":  "returned",
+		"item_draft":     "draft",
+	},
+	notify.LocaleSwahili: {
+		"main":           "Lost & Found Kenya\n1. Ripoti kilichopotea\n2. Ripoti kilichookotwa\n3. Hali ya ripoti\n4. Tafuta hati kwa jina\n5. Language / Lugha",
+		"category_lost":  "Umepoteza nini?",
+		"category_found": "Umeokota nini?",
+		"details_doc":    "Weka jina lililo kwenye hati",
+		"details":        "Kieleze (aina, rangi, alama)",
+		"place":          "Wapi? (mji au eneo)",
+		"confirm":        "%s: %s, %s\n1. Tuma\n2. Ghairi",
+		"lost":           "Kimepotea",
+		"found":          "Kimeokotwa",
+		"saved":          "Imehifadhiwa. Kumbukumbu yako ni %s. Tutakutumia SMS tukipata inayolingana.",
+		"cancelled":      "Ripoti imeghairiwa.",
+		"status_prompt":  "Weka nambari ya kumbukumbu",
+		"status":         "Kumb %s: %s. Hali: %s.",
+		"not_found":      "Hakuna ripoti %s kwa nambari hii.",
+		"search_prompt":  "Weka jina lililo kwenye hati",
+		"search_short":   "Weka angalau herufi 3 za jina",
+		"search_none":    "Hakuna hati iliyookotwa yenye jina %s bado. Tafadhali jaribu tena baadaye.",
+		"search_results": "Hati zilizookotwa:\n%sTaja kumb kudai kupitia programu au kituo cha kuchukulia.",
+		"language":       "Chagua lugha\n1. English\n2. Kiswahili",
+		"language_set":   "Lugha imewekwa Kiswahili.",
+		"invalid":        "Chaguo si sahihi.",
+		"back":           "0. Rudi",
+		"failed":         "Samahani, kuna hitilafu. Tafadhali jaribu tena.",
+		"unsupported":    "Huduma hii inapatikana kwa nambari za simu za Kenya pekee.",
+		"item_lost":      "kimepotea",
+		"item_found":     "kimeokotwa",
+		"item_claimed":   "kimedaiwa",
+		"item_returned":  "kimerudishwa",
+		"item_draft":     "rasimu",
+	},
+}
+
+// USSDRequest is a gateway callback for one step of a USSD session. Text
+// holds every input of the session so far, separated by "*".
+type USSDRequest struct {
+	SessionID   string
+	ServiceCode string
+	PhoneNumber string
+	Text        string
+}
+
+// USSDService runs the USSD menus for reporting items, checking a report's
+// status and searching found documents
+type USSDService struct {
+	store      ussd.Store
+	provider   sms.Provider
+	users      *repository.UserRepository
+	items      *ItemService
+	categories *CategoryService
+}
+
+// NewUSSDService creates a new USSDService keeping sessions in store. The SMS
+// provider texts reporters their reference code.
+func NewUSSDService(store ussd.Store, provider sms.Provider, users *repository.UserRepository, items *ItemService, categories *CategoryService) *USSDService {
+	return &USSDService{store: store, provider: provider, users: users, items: items, categories: categories}
+}
+
+// Handle answers a gateway callback with the next screen, prefixed with CON
+// when the session continues or END when it is over
+func (s *USSDService) Handle(ctx context.Context, req USSDRequest) string {
+	phone, ok := sms.NormalizePhone(req.PhoneNumber)
+	if !ok {
+		return ussd.End + ussdText(notify.LocaleEnglish, "unsupported")
+	}
+
+	session, err := s.store.Get(ctx, req.SessionID)
+	if err != nil {
+		log.Printf("ussd: failed to load session %s: %v", req.SessionID, err)
+		return ussd.End + ussdText(notify.LocaleEnglish, "failed")
+	}
+	// A session belongs to the phone that opened it
+	if session != nil && session.Phone != phone {
+		log.Printf("ussd: session %s continued from another number", req.SessionID)
+		return ussd.End + ussdText(session.Data["locale"], "failed")
+	}
+	if session == nil {
+		session = &ussd.Session{ID: req.SessionID, Phone: phone, State: ussdStateMain, Data: map[string]string{}}
+		session.Data["locale"] = notify.LocaleEnglish
+		if user, err := s.users.GetByPhone(phone); err == nil {
+			session.Data["locale"] = notify.NormalizeLocale(user.Locale)
+		}
+	}
+
+	var inputs []string
+	if req.Text != "" {
+		inputs = strings.Split(req.Text, "*")
+	}
+
+	// Normally one new input arrives per request; a repeated request gets the
+	// current screen again
+	screen := ussd.Continue + s.render(session, "")
+	for session.Inputs < len(inputs) {
+		input := strings.TrimSpace(inputs[session.Inputs])
+		session.Inputs++
+		screen = s.step(ctx, session, input)
+		if strings.HasPrefix(screen, ussd.End) {
+			if err := s.store.Delete(ctx, session.ID); err != nil {
+				log.Printf("ussd: failed to delete session %s: %v", session.ID, err)
+			}
+			return screen
+		}
+	}
+
+	if err := s.store.Save(ctx, session); err != nil {
+		log.Printf("ussd: failed to save session %s: %v", session.ID, err)
+		return ussd.End + ussdText(session.Data["locale"], "failed")
+	}
+	return screen
+}
+
+// step applies one input to the session and returns the next screen
+func (s *USSDService) step(ctx context.Context, session *ussd.Session, input string) string {
+	locale := session.Data["locale"]
+	next := func(state string) string {
+		session.State = state
+		return ussd.Continue + s.render(session, "")
+	}
+	invalid := func() string {
+		return ussd.Continue + s.render(session, ussdText(locale, "invalid")+"\n")
+	}
+
+	if input == "0" && session.State != ussdStateMain {
+		return next(ussdStateMain)
+	}
+
+	switch session.State {
+	case ussdStateMain:
+		switch input {
+		case "1":
+			session.Data["status"] = string(models.ItemStatusLost)
+			return next(ussdStateReportCategory)
+		case "2":
+			session.Data["status"] = string(models.ItemStatusFound)
+			return next(ussdStateReportCategory)
+		case "3":
+			return next(ussdStateStatus)
+		case "4":
+			return next(ussdStateSearch)
+		case "5":
+			return next(ussdStateLanguage)
+		}
+		return invalid()
+
+	case ussdStateReportCategory:
+		n, err := strconv.Atoi(input)
+		if err != nil || n < 1 || n > len(ussdCategories) {
+			return invalid()
+		}
+		session.Data["category"] = strconv.Itoa(n - 1)
+		return next(ussdStateReportDetails)
+
+	case ussdStateReportDetails:
+		if input == "" {
+			return invalid()
+		}
+		session.Data["details"] = input
+		return next(ussdStateReportPlace)
+
+	case ussdStateReportPlace:
+		if input == "" {
+			return invalid()
+		}
+		session.Data["place"] = input
+		return next(ussdStateReportConfirm)
+
+	case ussdStateReportConfirm:
+		switch input {
+		case "1":
+			return ussd.End + s.submit(ctx, session)
+		case "2":
+			return ussd.End + ussdText(locale, "cancelled")
+		}
+		return invalid()
+
+	case ussdStateStatus:
+		return ussd.End + s.status(session.Phone, locale, input)
+
+	case ussdStateSearch:
+		if len([]rune(input)) < minUSSDSearchLength {
+			return ussd.Continue + ussdText(locale, "search_short")
+		}
+		return ussd.End + s.search(locale, input)
+
+	case ussdStateLanguage:
+		switch input {
+		case "1":
+			locale = notify.LocaleEnglish
+		case "2":
+			locale = notify.LocaleSwahili
+		default:
+			return invalid()
+		}
+		user, err := findOrCreatePhoneUser(s.users, session.Phone, locale)
+		if err == nil {
+			err = s.users.UpdateLocale(user.ID, locale)
+		}
+		if err != nil {
+			log.Printf("ussd: failed to set language for %s: %v", session.Phone, err)
+			return ussd.End + ussdText(locale, "failed")
+		}
+		return ussd.End + ussdText(locale, "language_set")
+	}
+
+	return next(ussdStateMain)
+}
+
+// render returns the screen for the session's current state, after prefix
+func (s *USSDService) render(session *ussd.Session, prefix string) string {
+	locale := session.Data["locale"]
+	back := "\n" + ussdText(locale, "back")
+
+	switch session.State {
+	case ussdStateReportCategory:
+		var b strings.Builder
+		b.WriteString(ussdText(locale, "category_"+session.Data["status"]))
+		for i, category := range ussdCategories {
+			fmt.Fprintf(&b, "\n%d. %s", i+1, category.name(locale))
+		}
+		return prefix + b.String() + back
+	case ussdStateReportDetails:
+		if s.isDocument(session) {
+			return prefix + ussdText(locale, "details_doc") + back
+		}
+		return prefix + ussdText(locale, "details") + back
+	case ussdStateReportPlace:
+		return prefix + ussdText(locale, "place") + back
+	case ussdStateReportConfirm:
+		category := s.category(session)
+		title := ussdText(locale, session.Data["status"]) + " " + category.name(locale)
+		return prefix + fmt.Sprintf(ussdText(locale, "confirm"), title, session.Data["details"], session.Data["place"])
+	case ussdStateStatus:
+		return prefix + ussdText(locale, "status_prompt") + back
+	case ussdStateSearch:
+		return prefix + ussdText(locale, "search_prompt") + back
+	case ussdStateLanguage:
+		return prefix + ussdText(locale, "language")
+	default:
+		return prefix + ussdText(locale, "main")
+	}
+}
+
+// submit files the report described by the session and texts the reference
+func (s *USSDService) submit(ctx context.Context, session *ussd.Session) string {
+	locale := session.Data["locale"]
+	failed := ussdText(locale, "failed")
+
+	user, err := findOrCreatePhoneUser(s.users, session.Phone, locale)
+	if err != nil {
+		log.Printf("ussd: failed to find account for %s: %v", session.Phone, err)
+		return failed
+	}
+	category, err := s.categories.GetByPath(s.category(session).path)
+	if err != nil {
+		log.Printf("ussd: category %q: %v", s.category(session).path, err)
+		return failed
+	}
+
+	details := session.Data["details"]
+	item := &models.Item{
+		Title:      truncate(category.Name+" - "+details, 120),
+		CategoryID: &category.ID,
+		Status:     models.ItemStatus(session.Data["status"]),
+		Location:   session.Data["place"],
+		Date:       time.Now(),
+		UserID:     user.ID,
+		Contact:    session.Phone,
+	}
+	if s.isDocument(session) {
+		item.Attributes = map[string]any{"name": details}
+	} else {
+		item.Description = details
+	}
+	if err := s.items.CreateFrom(item, models.ItemSourceUSSD); err != nil {
+		log.Printf("ussd: failed to save report from %s: %v", session.Phone, err)
+		return failed
+	}
+
+	reply := fmt.Sprintf(ussdText(locale, "saved"), *item.ReferenceCode)
+	if err := s.provider.Send(ctx, session.Phone, reply); err != nil {
+		log.Printf("ussd: failed to text reference to %s: %v", session.Phone, err)
+	}
+	return reply
+}
+
+// status describes one of the caller's reports
+func (s *USSDService) status(phone, locale, ref string) string {
+	ref = strings.ToUpper(ref)
+	user, err := s.users.GetByPhone(phone)
+	if err != nil {
+		return fmt.Sprintf(ussdText(locale, "not_found"), ref)
+	}
+	item, err := s.items.GetByReference(ref)
+	if err != nil || item.UserID != user.ID {
+		return fmt.Sprintf(ussdText(locale, "not_found"), ref)
+	}
+
+	status := ussdText(locale, "item_"+string(item.Status))
+	if item.Draft {
+		status = ussdText(locale, "item_draft")
+	}
+	return fmt.Sprintf(ussdText(locale, "status"), ref, truncate(item.Title, 60), status)
+}
+
+// search lists found documents bearing a name
+func (s *USSDService) search(locale, name string) string {
+	items, err := s.items.FoundDocumentsByName(name, maxUSSDSearchResults)
+	if err != nil {
+		log.Printf("ussd: document search failed: %v", err)
+		return ussdText(locale, "failed")
+	}
+	if len(items) == 0 {
+		return fmt.Sprintf(ussdText(locale, "search_none"), name)
+	}
+
+	var b strings.Builder
+	for i, item := range items {
+		place := item.Location
+		if place == "" {
+			place = item.Date.Format("2 Jan")
+		}
+		ref := ""
+		if item.ReferenceCode != nil {
+			ref = *item.ReferenceCode
+		}
+		fmt.Fprintf(&b, "%d. %s, %s (Ref %s)\n", i+1, truncate(item.Title, 40), truncate(place, 20), ref)
+	}
+	return fmt.Sprintf(ussdText(locale, "search_results"), b.String())
+}
+
+// category returns the item type chosen in the session
+func (s *USSDService) category(session *ussd.Session) ussdCategory {
+	n, err := strconv.Atoi(session.Data["category"])
+	if err != nil || n < 0 || n >= len(ussdCategories) {
+		return ussdCategories[len(ussdCategories)-1]
+	}
+	return ussdCategories[n]
+}
+
+// isDocument reports whether the session is reporting a document
+func (s *USSDService) isDocument(session *ussd.Session) bool {
+	return strings.HasPrefix(s.category(session).path, "documents/")
+}
+
+// name returns the item type's name in a language
+func (c ussdCategory) name(locale string) string {
+	if name, ok := c.names[locale]; ok {
+		return name
+	}
+	return c.names[notify.LocaleEnglish]
+}
+
+// ussdText returns a menu text in a language
+func ussdText(locale, key string) string {
+	if text, ok := ussdTexts[locale][key]; ok {
+		return text
+	}
+	return ussdTexts[notify.LocaleEnglish][key]
+}
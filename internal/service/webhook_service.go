@@ -0,0 +1,568 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"log"
+	"lostnfound-api/internal/models"
+	"lostnfound-api/internal/repository"
+	"lostnfound-api/internal/webhook"
+	"math/rand/v2"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// webhookBatchSize bounds how many deliveries are leased at once
+	webhookBatchSize = 50
+	// webhookLease is how long a leased delivery is hidden from other dispatchers
+	webhookLease = 5 * time.Minute
+	// webhookTimeout bounds a single delivery attempt
+	webhookTimeout = 10 * time.Second
+	// maxWebhookAttempts is how many times a delivery is tried before giving up,
+	// which with the backoff below covers a receiver being down for about a day
+	maxWebhookAttempts = 12
+	// webhookBaseBackoff is the delay before the first retry; it doubles per attempt
+	webhookBaseBackoff = time.Minute
+	// webhookMaxBackoff caps the delay between retries
+	webhookMaxBackoff = 12 * time.Hour
+	// webhookPollInterval is how often the dispatcher checks for due retries
+	webhookPollInterval = 15 * time.Second
+	// webhookRetentionDays is how long the delivery log is kept
+	webhookRetentionDays = 30
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook subscription not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+)
+
+// webhookEnvelope is the body posted to subscribers
+type webhookEnvelope struct {
+	ID        uuid.UUID `json:"id"`
+	Type      string    `json:"type"`
+	CreatedAt time.Time `json:"created_at"`
+	Data      any       `json:"data"`
+}
+
+// webhookItem is how an item appears in webhook payloads. Reporter contact
+// details are left out and coordinates are rounded as for other users.
+type webhookItem struct {
+	ID            uuid.UUID         `json:"id"`
+	ReferenceCode string            `json:"reference_code,omitempty"`
+	Title         string            `json:"title"`
+	Description   string            `json:"description"`
+	Status        models.ItemStatus `json:"status"`
+	Category      string            `json:"category,omitempty"`
+	CountyID      string            `json:"county_id,omitempty"`
+	SubCountyID   string            `json:"sub_county_id,omitempty"`
+	Location      string            `json:"location,omitempty"`
+	Latitude      *float64          `json:"latitude,omitempty"`
+	Longitude     *float64          `json:"longitude,omitempty"`
+	Date          time.Time         `json:"date"`
+	Source        models.ItemSource `json:"source"`
+	IsResolved    bool              `json:"is_resolved"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// webhookClaim is the payload of a claim.status_changed webhook
+type webhookClaim struct {
+	ClaimID   uuid.UUID          `json:"claim_id"`
+	Status    models.ClaimStatus `json:"status"`
+	DecidedAt *time.Time         `json:"decided_at,omitempty"`
+	Item      webhookItem        `json:"item"`
+}
+
+// webhookMatch is the payload of an item.match webhook
+type webhookMatch struct {
+	Item        webhookItem `json:"item"`
+	MatchedItem webhookItem `json:"matched_item"`
+	Score       float64     `json:"score"`
+	Reasons     []string    `json:"reasons"`
+}
+
+// WebhookService manages partner webhook subscriptions and delivers signed
+// events to them, retrying failures with exponential backoff
+type WebhookService struct {
+	repo   *repository.WebhookRepository
+	items  *ItemService
+	client *webhook.Client
+	wake   chan struct{}
+}
+
+// NewWebhookService creates a new WebhookService
+func NewWebhookService(repo *repository.WebhookRepository, items *ItemService) *WebhookService {
+	return &WebhookService{
+		repo:   repo,
+		items:  items,
+		client: webhook.NewClient(webhookTimeout),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Create validates and saves a new subscription. A signing secret is
+// generated unless one is given.
+func (s *WebhookService) Create(subscription *models.WebhookSubscription) error {
+	if err := s.prepare(subscription); err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+	subscription.Active = true
+	return s.repo.Create(subscription)
+}
+
+// List retrieves all subscriptions
+func (s *WebhookService) List() ([]models.WebhookSubscription, error) {
+	return s.repo.List()
+}
+
+// GetByID retrieves a subscription
+func (s *WebhookService) GetByID(id uuid.UUID) (*models.WebhookSubscription, error) {
+	subscription, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, ErrWebhookNotFound
+	}
+	return subscription, nil
+}
+
+// Update changes a subscription's URL, events, filters and whether it is active
+func (s *WebhookService) Update(id uuid.UUID, input *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	subscription, err := s.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription.Name = input.Name
+	subscription.URL = input.URL
+	subscription.EventTypes = input.EventTypes
+	subscription.CountyIDs = input.CountyIDs
+	subscription.CategoryPaths = input.CategoryPaths
+	subscription.Active = input.Active
+	if err := s.prepare(subscription); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(subscription); err != nil {
+		return nil, err
+	}
+	return subscription, nil
+}
+
+// RotateSecret replaces a subscription's signing secret and returns the new one
+func (s *WebhookService) RotateSecret(id uuid.UUID) (string, error) {
+	subscription, err := s.GetByID(id)
+	if err != nil {
+		return "", err
+	}
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return "", err
+	}
+	subscription.Secret = secret
+	if err := s.repo.Update(subscription); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+// Delete removes a subscription
+func (s *WebhookService) Delete(id uuid.UUID) error {
+	if _, err := s.GetByID(id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// Deliveries retrieves a subscription's delivery log, optionally by status
+func (s *WebhookService) Deliveries(id uuid.UUID, status models.WebhookDeliveryStatus, page, limit int) ([]models.WebhookDelivery, int64, error) {
+	if _, err := s.GetByID(id); err != nil {
+		return nil, 0, err
+	}
+	if page <= 0 {
+		page = 1
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 10
+	}
+	return s.repo.ListDeliveries(id, status, page, limit)
+}
+
+// Redeliver queues a new delivery of a logged event to its subscription. The
+// receiver sees the same event ID, so it can ignore events it already has.
+func (s *WebhookService) Redeliver(deliveryID uuid.UUID) (*models.WebhookDelivery, error) {
+	original, err := s.repo.GetDelivery(deliveryID)
+	if err != nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+	if _, err := s.GetByID(original.SubscriptionID); err != nil {
+		return nil, err
+	}
+
+	delivery := models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Body:           original.Body,
+		Status:         models.WebhookDeliveryPending,
+		NextAttemptAt:  time.Now(),
+		RedeliveryOf:   &original.ID,
+	}
+	deliveries := []models.WebhookDelivery{delivery}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return nil, err
+	}
+	s.signal()
+	return &deliveries[0], nil
+}
+
+// ItemCreated queues item.created webhooks
+func (s *WebhookService) ItemCreated(item *models.Item) {
+	s.itemEvent(webhook.EventItemCreated, item.ID)
+}
+
+// ItemUpdated queues item.updated webhooks
+func (s *WebhookService) ItemUpdated(item *models.Item) {
+	s.itemEvent(webhook.EventItemUpdated, item.ID)
+}
+
+// ClaimChanged queues claim.status_changed webhooks for the claimed item's
+// county and category
+func (s *WebhookService) ClaimChanged(claim *models.Claim) {
+	s.queue(webhook.EventClaimStatusChanged, func() (any, []*models.Item, error) {
+		item, err := s.items.GetByID(claim.ItemID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return webhookClaim{
+			ClaimID:   claim.ID,
+			Status:    claim.Status,
+			DecidedAt: claim.DecidedAt,
+			Item:      toWebhookItem(item),
+		}, []*models.Item{item}, nil
+	})
+}
+
+// MatchFound queues item.match webhooks. Subscriptions interested in either
+// item receive the match.
+func (s *WebhookService) MatchFound(itemID uuid.UUID, match Match) {
+	s.queue(webhook.EventItemMatch, func() (any, []*models.Item, error) {
+		item, err := s.items.GetByID(itemID)
+		if err != nil {
+			return nil, nil, err
+		}
+		matched, err := s.items.GetByID(match.Item.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return webhookMatch{
+			Item:        toWebhookItem(item),
+			MatchedItem: toWebhookItem(matched),
+			Score:       match.Score,
+			Reasons:     match.Reasons,
+		}, []*models.Item{item, matched}, nil
+	})
+}
+
+// Run delivers queued webhooks until the context is cancelled
+func (s *WebhookService) Run(ctx context.Context) {
+	ticker := time.NewTicker(webhookPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.Deliver(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhooks: delivery failed: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// Deliver sends every due webhook and returns how many were delivered
+func (s *WebhookService) Deliver(ctx context.Context) (int, error) {
+	delivered := 0
+	for {
+		batch, err := s.repo.LeaseDeliveries(time.Now(), webhookLease, webhookBatchSize)
+		if err != nil {
+			return delivered, err
+		}
+
+		subscriptions := make(map[uuid.UUID]*models.WebhookSubscription)
+		for i := range batch {
+			if ctx.Err() != nil {
+				return delivered, ctx.Err()
+			}
+			ok, err := s.send(ctx, &batch[i], subscriptions)
+			if err != nil {
+				return delivered, err
+			}
+			if ok {
+				delivered++
+			}
+		}
+
+		if len(batch) < webhookBatchSize {
+			return delivered, nil
+		}
+	}
+}
+
+// PurgeOld removes finished deliveries past the retention period
+func (s *WebhookService) PurgeOld() (int64, error) {
+	return s.repo.DeleteDeliveriesBefore(time.Now().AddDate(0, 0, -webhookRetentionDays))
+}
+
+// itemEvent queues an event whose payload is the item itself
+func (s *WebhookService) itemEvent(eventType string, itemID uuid.UUID) {
+	s.queue(eventType, func() (any, []*models.Item, error) {
+		item, err := s.items.GetByID(itemID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return toWebhookItem(item), []*models.Item{item}, nil
+	})
+}
+
+// queue stores a delivery of an event for every active subscription that
+// wants it, logging failures rather than failing the caller. The payload is
+// only built when some subscription takes the event type.
+func (s *WebhookService) queue(eventType string, build func() (any, []*models.Item, error)) {
+	if err := s.enqueue(eventType, build); err != nil {
+		log.Printf("webhooks: failed to queue %s: %v", eventType, err)
+	}
+}
+
+// enqueue does the work of queue
+func (s *WebhookService) enqueue(eventType string, build func() (any, []*models.Item, error)) error {
+	subscriptions, err := s.repo.ListActive()
+	if err != nil {
+		return err
+	}
+	var interested []models.WebhookSubscription
+	for _, subscription := range subscriptions {
+		if slices.Contains(subscription.EventTypes, eventType) {
+			interested = append(interested, subscription)
+		}
+	}
+	if len(interested) == 0 {
+		return nil
+	}
+
+	data, items, err := build()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	envelope := webhookEnvelope{ID: uuid.New(), Type: eventType, CreatedAt: now.UTC(), Data: data}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	var deliveries []models.WebhookDelivery
+	for i := range interested {
+		if !wantsAny(&interested[i], eventType, items) {
+			continue
+		}
+		deliveries = append(deliveries, models.WebhookDelivery{
+			SubscriptionID: interested[i].ID,
+			EventID:        envelope.ID,
+			EventType:      eventType,
+			Body:           string(body),
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		})
+	}
+
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+	if len(deliveries) > 0 {
+		s.signal()
+	}
+	return nil
+}
+
+// send makes one delivery attempt and records the outcome. It only returns
+// an error when the outcome cannot be stored.
+func (s *WebhookService) send(ctx context.Context, delivery *models.WebhookDelivery, subscriptions map[uuid.UUID]*models.WebhookSubscription) (bool, error) {
+	delivery.Attempts++
+
+	subscription, ok := subscriptions[delivery.SubscriptionID]
+	if !ok {
+		if found, err := s.repo.GetByID(delivery.SubscriptionID); err == nil {
+			subscription = found
+		}
+		subscriptions[delivery.SubscriptionID] = subscription
+	}
+	if subscription == nil || !subscription.Active {
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = "subscription is no longer active"
+		return false, s.repo.RecordAttempt(delivery)
+	}
+
+	sendCtx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	result, err := s.client.Send(sendCtx, webhook.Delivery{
+		ID:        delivery.ID.String(),
+		EventType: delivery.EventType,
+		URL:       subscription.URL,
+		Secret:    subscription.Secret,
+		Body:      []byte(delivery.Body),
+	})
+	cancel()
+
+	if result != nil {
+		delivery.ResponseStatus = result.StatusCode
+		delivery.ResponseBody = result.Body
+		delivery.DurationMs = result.Duration.Milliseconds()
+	}
+
+	now := time.Now()
+	switch {
+	case err == nil:
+		delivery.Status = models.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+		return true, s.repo.RecordAttempt(delivery)
+	case delivery.Attempts >= maxWebhookAttempts:
+		log.Printf("webhooks: giving up on delivery %s to %s after %d attempts: %v", delivery.ID, subscription.Name, delivery.Attempts, err)
+		delivery.Status = models.WebhookDeliveryFailed
+		delivery.LastError = err.Error()
+		return false, s.repo.RecordAttempt(delivery)
+	default:
+		delivery.NextAttemptAt = now.Add(webhookBackoff(delivery.Attempts))
+		delivery.LastError = err.Error()
+		return false, s.repo.RecordAttempt(delivery)
+	}
+}
+
+// prepare validates and normalises a subscription
+func (s *WebhookService) prepare(subscription *models.WebhookSubscription) error {
+	subscription.Name = strings.TrimSpace(subscription.Name)
+	if subscription.Name == "" {
+		return errors.New("name is required")
+	}
+
+	subscription.URL = strings.TrimSpace(subscription.URL)
+	target, err := url.Parse(subscription.URL)
+	if err != nil || target.Host == "" || (target.Scheme != "https" && target.Scheme != "http") {
+		return errors.New("url must be an absolute http or https URL")
+	}
+
+	if len(subscription.EventTypes) == 0 {
+		return fmt.Errorf("event_types must include at least one of %v", webhook.EventTypes)
+	}
+	eventTypes := make([]string, 0, len(subscription.EventTypes))
+	for _, eventType := range subscription.EventTypes {
+		if !webhook.Supported(eventType) {
+			return fmt.Errorf("unknown event type %q, expected one of %v", eventType, webhook.EventTypes)
+		}
+		eventTypes = appendUnique(eventTypes, eventType)
+	}
+	subscription.EventTypes = eventTypes
+
+	counties := make([]string, 0, len(subscription.CountyIDs))
+	for _, county := range subscription.CountyIDs {
+		filter := repository.ItemFilter{CountyID: county}
+		s.items.NormalizeFilter(&filter)
+		if filter.CountyID == "" {
+			continue
+		}
+		counties = appendUnique(counties, filter.CountyID)
+	}
+	subscription.CountyIDs = counties
+
+	categories := make([]string, 0, len(subscription.CategoryPaths))
+	for _, category := range subscription.CategoryPaths {
+		filter := repository.ItemFilter{CategoryPath: category}
+		s.items.NormalizeFilter(&filter)
+		if filter.CategoryPath == "" {
+			continue
+		}
+		categories = appendUnique(categories, filter.CategoryPath)
+	}
+	subscription.CategoryPaths = categories
+	return nil
+}
+
+// signal wakes the dispatcher without blocking
+func (s *WebhookService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// wantsAny reports whether a subscription wants an event about any of the items
+func wantsAny(subscription *models.WebhookSubscription, eventType string, items []*models.Item) bool {
+	for _, item := range items {
+		categoryPath := ""
+		if item.Category != nil {
+			categoryPath = item.Category.Path
+		}
+		if subscription.Wants(eventType, item.CountyID, categoryPath) {
+			return true
+		}
+	}
+	return false
+}
+
+// toWebhookItem converts an item to its webhook payload
+func toWebhookItem(item *models.Item) webhookItem {
+	item.RoundCoordinates(publicCoordinateDecimals)
+	payload := webhookItem{
+		ID:          item.ID,
+		Title:       item.Title,
+		Description: item.Description,
+		Status:      item.Status,
+		CountyID:    item.CountyID,
+		SubCountyID: item.SubCountyID,
+		Location:    item.Location,
+		Latitude:    item.Latitude,
+		Longitude:   item.Longitude,
+		Date:        item.Date,
+		Source:      item.Source,
+		IsResolved:  item.IsResolved,
+		UpdatedAt:   item.UpdatedAt,
+	}
+	if item.ReferenceCode != nil {
+		payload.ReferenceCode = *item.ReferenceCode
+	}
+	if item.Category != nil {
+		payload.Category = item.Category.Path
+	}
+	return payload
+}
+
+// appendUnique appends a value unless it is already present
+func appendUnique(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
+
+// webhookBackoff returns the delay before the next attempt: the base delay
+// doubled per failed attempt, capped, with up to 10% jitter
+func webhookBackoff(attempts int) time.Duration {
+	delay := webhookMaxBackoff
+	if attempts <= 16 {
+		delay = min(webhookBaseBackoff<<(attempts-1), webhookMaxBackoff)
+	}
+	return delay + rand.N(delay/10+1)
+}
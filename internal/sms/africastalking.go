@@ -0,0 +1,120 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Default Africa's Talking API hosts
+const (
+	AfricasTalkingLiveURL    = "https://api.africastalking.com"
+	AfricasTalkingSandboxURL = "https://api.sandbox.africastalking.com"
+)
+
+// AfricasTalking sends messages through the Africa's Talking bulk SMS API
+type AfricasTalking struct {
+	baseURL  string
+	username string
+	apiKey   string
+	senderID string
+	client   *http.Client
+}
+
+// NewAfricasTalking creates an Africa's Talking provider. An empty senderID
+// uses the account's default shortcode.
+func NewAfricasTalking(baseURL, username, apiKey, senderID string) *AfricasTalking {
+	return &AfricasTalking{
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		username: username,
+		apiKey:   apiKey,
+		senderID: senderID,
+		client:   &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// sendResponse is the body returned by the messaging endpoint
+type sendResponse struct {
+	SMSMessageData struct {
+		Message    string `json:"Message"`
+		Recipients []struct {
+			StatusCode int    `json:"statusCode"`
+			Number     string `json:"number"`
+			Status     string `json:"status"`
+			MessageID  string `json:"messageId"`
+		} `json:"Recipients"`
+	} `json:"SMSMessageData"`
+}
+
+// Send delivers a message
+func (p *AfricasTalking) Send(ctx context.Context, to, message string) error {
+	form := url.Values{}
+	form.Set("username", p.username)
+	form.Set("to", to)
+	form.Set("message", message)
+	if p.senderID != "" {
+		form.Set("from", p.senderID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/version1/messaging", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("apiKey", p.apiKey)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sms provider returned %s", resp.Status)
+	}
+
+	var body sendResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode sms provider response: %w", err)
+	}
+	if len(body.SMSMessageData.Recipients) == 0 {
+		return fmt.Errorf("%w: %s", ErrRejected, body.SMSMessageData.Message)
+	}
+	for _, recipient := range body.SMSMessageData.Recipients {
+		// 100 Processed, 101 Sent, 102 Queued
+		if recipient.StatusCode < 100 || recipient.StatusCode > 102 {
+			return fmt.Errorf("%w: %s (%s)", ErrRejected, recipient.Status, recipient.Number)
+		}
+	}
+	return nil
+}
+
+// ParseInbound reads an Africa's Talking incoming message callback
+func ParseInbound(form url.Values) (InboundMessage, error) {
+	msg := InboundMessage{
+		ID:          form.Get("id"),
+		LinkID:      form.Get("linkId"),
+		From:        form.Get("from"),
+		To:          form.Get("to"),
+		Text:        form.Get("text"),
+		NetworkCode: form.Get("networkCode"),
+	}
+	if msg.From == "" {
+		return msg, fmt.Errorf("missing sender")
+	}
+	msg.Date = time.Now()
+	if date := form.Get("date"); date != "" {
+		for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05.000"} {
+			if t, err := time.Parse(layout, date); err == nil {
+				msg.Date = t
+				break
+			}
+		}
+	}
+	return msg, nil
+}
@@ -0,0 +1,103 @@
+package sms
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SentMessage is a message accepted by the FakeServer
+type SentMessage struct {
+	ID      string    `json:"id"`
+	From    string    `json:"from,omitempty"`
+	To      string    `json:"to"`
+	Message string    `json:"message"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// FakeServer imitates the Africa's Talking messaging endpoint so the API can
+// be run and tested without a provider account. Messages are kept in memory
+// and listed at GET /messages.
+type FakeServer struct {
+	mu       sync.Mutex
+	messages []SentMessage
+	apiKey   string
+}
+
+// NewFakeServer creates a FakeServer. A non-empty apiKey must be sent by clients.
+func NewFakeServer(apiKey string) *FakeServer {
+	return &FakeServer{apiKey: apiKey}
+}
+
+// Messages returns the messages sent so far
+func (s *FakeServer) Messages() []SentMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]SentMessage(nil), s.messages...)
+}
+
+// ServeHTTP handles the messaging endpoint and the message listing
+func (s *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/version1/messaging":
+		s.send(w, r)
+	case r.Method == http.MethodGet && r.URL.Path == "/messages":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Messages())
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// send records a message and answers like the real API
+func (s *FakeServer) send(w http.ResponseWriter, r *http.Request) {
+	if s.apiKey != "" && r.Header.Get("apiKey") != s.apiKey {
+		http.Error(w, "The supplied authentication is invalid", http.StatusUnauthorized)
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	type recipient struct {
+		StatusCode int    `json:"statusCode"`
+		Number     string `json:"number"`
+		Status     string `json:"status"`
+		Cost       string `json:"cost"`
+		MessageID  string `json:"messageId"`
+	}
+	var recipients []recipient
+
+	s.mu.Lock()
+	for _, to := range strings.Split(r.PostForm.Get("to"), ",") {
+		to = strings.TrimSpace(to)
+		if to == "" {
+			continue
+		}
+		id := fmt.Sprintf("ATXid_fake%06d", len(s.messages)+1)
+		s.messages = append(s.messages, SentMessage{
+			ID:      id,
+			From:    r.PostForm.Get("from"),
+			To:      to,
+			Message: r.PostForm.Get("message"),
+			SentAt:  time.Now(),
+		})
+		log.Printf("fake sms to %s: %s", to, r.PostForm.Get("message"))
+		recipients = append(recipients, recipient{StatusCode: 101, Number: to, Status: "Success", Cost: "KES 0.0000", MessageID: id})
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]any{
+		"SMSMessageData": map[string]any{
+			"Message":    fmt.Sprintf("Sent to %d/%d Total Cost: KES 0", len(recipients), len(recipients)),
+			"Recipients": recipients,
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// ErrRejected is returned when the provider refuses a message
+var ErrRejected = errors.New("sms rejected by provider")
+
+// Provider sends text messages
+type Provider interface {
+	// Send delivers a message to one phone number in international format
+	Send(ctx context.Context, to, message string) error
+}
+
+// InboundMessage is a text message received from a user
+type InboundMessage struct {
+	ID          string
+	LinkID      string
+	From        string
+	To          string
+	Text        string
+	Date        time.Time
+	NetworkCode string
+}
+
+// LogProvider is a stand-in that writes messages to the application log
+type LogProvider struct{}
+
+// Send logs the message
+func (LogProvider) Send(ctx context.Context, to, message string) error {
+	log.Printf("sms to %s: %s", to, message)
+	return nil
+}
+
+// NormalizePhone converts a Kenyan mobile number such as "0712 345678" or
+// "254712345678" to international format ("+254712345678"). It reports
+// false for anything that is not a Kenyan mobile number.
+func NormalizePhone(phone string) (string, bool) {
+	digits := make([]byte, 0, len(phone))
+	for i := 0; i < len(phone); i++ {
+		if phone[i] >= '0' && phone[i] <= '9' {
+			digits = append(digits, phone[i])
+		}
+	}
+
+	number := string(digits)
+	switch {
+	case len(number) == 12 && number[:3] == "254":
+		number = number[3:]
+	case len(number) == 10 && number[0] == '0':
+		number = number[1:]
+	}
+	if len(number) != 9 || (number[0] != '7' && number[0] != '1') {
+		return "", false
+	}
+	return "+254" + number, true
+}
@@ -0,0 +1,73 @@
+package ussd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// redisKeyPrefix namespaces session keys
+const redisKeyPrefix = "ussd:session:"
+
+// RedisStore keeps sessions in Redis so any API instance can serve any step
+// of a dialogue. Sessions expire on their own after SessionTTL.
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to Redis
+func NewRedisStore(url string) (*RedisStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Get returns a session, or nil if it does not exist
+func (s *RedisStore) Get(ctx context.Context, id string) (*Session, error) {
+	raw, err := s.client.Get(ctx, redisKeyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session Session
+	if err := json.Unmarshal(raw, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Save stores a session and renews its expiry
+func (s *RedisStore) Save(ctx context.Context, session *Session) error {
+	session.UpdatedAt = time.Now()
+	raw, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, redisKeyPrefix+session.ID, raw, SessionTTL).Err()
+}
+
+// Delete removes a session
+func (s *RedisStore) Delete(ctx context.Context, id string) error {
+	return s.client.Del(ctx, redisKeyPrefix+id).Err()
+}
+
+// Close closes the Redis connection
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
@@ -0,0 +1,36 @@
+package ussd
+
+import (
+	"context"
+	"time"
+)
+
+// SessionTTL is how long an idle session is kept. Networks end USSD sessions
+// after a few minutes of inactivity.
+const SessionTTL = 5 * time.Minute
+
+// Session is the state of one USSD dialogue
+type Session struct {
+	ID        string            `json:"id"`
+	Phone     string            `json:"phone"`
+	State     string            `json:"state"`
+	Data      map[string]string `json:"data"`
+	Inputs    int               `json:"inputs"` // number of inputs already handled
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// Store keeps sessions between the requests of a dialogue
+type Store interface {
+	// Get returns a session, or nil if it does not exist or has expired
+	Get(ctx context.Context, id string) (*Session, error)
+	// Save creates or replaces a session
+	Save(ctx context.Context, session *Session) error
+	// Delete removes a session
+	Delete(ctx context.Context, id string) error
+}
+
+// Response prefixes understood by USSD gateways
+const (
+	Continue = "CON "
+	End      = "END "
+)
@@ -0,0 +1,111 @@
+package qrcode
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/go-pdf/fpdf"
+	qr "github.com/skip2/go-qrcode"
+	"strings"
+)
+
+// Label is a single tag printed on a sheet
+type Label struct {
+	Content string // text encoded in the QR code, usually a URL
+	Title   string // printed under the code
+	Caption string // smaller second line, e.g. the tag code
+}
+
+// PNG renders content as a PNG QR code of the given width in pixels
+func PNG(content string, size int) ([]byte, error) {
+	return qr.Encode(content, qr.Medium, size)
+}
+
+// SVG renders content as a scalable SVG QR code
+func SVG(content string) ([]byte, error) {
+	code, err := qr.New(content, qr.Medium)
+	if err != nil {
+		return nil, err
+	}
+	bitmap := code.Bitmap()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" shape-rendering="crispEdges">`, len(bitmap), len(bitmap))
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/><path fill="#000" d="`, len(bitmap), len(bitmap))
+	for y, row := range bitmap {
+		for x, set := range row {
+			if set {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	b.WriteString(`"/></svg>`)
+	return []byte(b.String()), nil
+}
+
+// Sheet layout on A4 paper, in millimetres
+const (
+	sheetColumns = 3
+	sheetRows    = 5
+	cellWidth    = 63
+	cellHeight   = 55
+	marginLeft   = 10.5
+	marginTop    = 11
+	codeSize     = 36
+)
+
+// Sheet renders labels as a printable A4 PDF of cut-out stickers. Codes are
+// drawn as vector squares so they stay sharp at any print resolution.
+func Sheet(labels []Label) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.SetDrawColor(200, 200, 200)
+
+	perPage := sheetColumns * sheetRows
+	for i, label := range labels {
+		if i%perPage == 0 {
+			pdf.AddPage()
+		}
+		col := i % sheetColumns
+		row := (i % perPage) / sheetColumns
+		x := marginLeft + float64(col)*cellWidth
+		y := marginTop + float64(row)*cellHeight
+
+		// Dashed cut guide around each sticker
+		pdf.SetDashPattern([]float64{1, 1}, 0)
+		pdf.Rect(x, y, cellWidth, cellHeight, "D")
+		pdf.SetDashPattern([]float64{}, 0)
+
+		code, err := qr.New(label.Content, qr.Medium)
+		if err != nil {
+			return nil, err
+		}
+		bitmap := code.Bitmap()
+		module := codeSize / float64(len(bitmap))
+		codeX := x + (cellWidth-codeSize)/2
+		codeY := y + 3
+		pdf.SetFillColor(0, 0, 0)
+		for by, bits := range bitmap {
+			for bx, set := range bits {
+				if set {
+					pdf.Rect(codeX+float64(bx)*module, codeY+float64(by)*module, module, module, "F")
+				}
+			}
+		}
+
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetXY(x, codeY+codeSize+1)
+		pdf.CellFormat(cellWidth, 5, pdf.UnicodeTranslatorFromDescriptor("")(label.Title), "", 2, "C", false, 0, "")
+		pdf.SetFont("Helvetica", "", 7)
+		pdf.CellFormat(cellWidth, 4, label.Caption, "", 0, "C", false, 0, "")
+	}
+
+	if len(labels) == 0 {
+		pdf.AddPage()
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,226 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// excelEpoch is day zero of the date serial numbers spreadsheets store dates as
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// ReadCSV reads every record of a CSV file. Rows may have different lengths.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 && len(rows[0]) > 0 {
+		// Spreadsheet programs often save CSV with a byte order mark
+		rows[0][0] = strings.TrimPrefix(rows[0][0], "\uFEFF")
+	}
+	return rows, nil
+}
+
+// ReadXLSX reads the rows of the first worksheet in an XLSX workbook. Cells
+// are returned as they are stored, so dates come back as serial numbers; see
+// ParseDate.
+func ReadXLSX(data []byte) ([][]string, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, errors.New("not a valid XLSX file")
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	sheet, err := firstSheetPath(files)
+	if err != nil {
+		return nil, err
+	}
+	var shared []string
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = readSharedStrings(file); err != nil {
+			return nil, err
+		}
+	}
+	file, ok := files[sheet]
+	if !ok {
+		return nil, fmt.Errorf("worksheet %s is missing", sheet)
+	}
+	return readSheet(file, shared)
+}
+
+// ParseDate reads a date cell written as YYYY-MM-DD, DD/MM/YYYY or a
+// spreadsheet date serial number
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{"2006-01-02", time.RFC3339, "2006-01-02 15:04", "02/01/2006"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 && serial < 100000 {
+		return excelEpoch.Add(time.Duration(serial * float64(24*time.Hour))).Truncate(time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a date; use YYYY-MM-DD", value)
+}
+
+// firstSheetPath finds the worksheet listed first in the workbook
+func firstSheetPath(files map[string]*zip.File) (string, error) {
+	var workbook struct {
+		Sheets []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var rels struct {
+		Relationships []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if err := decodeFile(files["xl/workbook.xml"], &workbook); err != nil || len(workbook.Sheets) == 0 {
+		return "", errors.New("the workbook has no worksheets")
+	}
+	if err := decodeFile(files["xl/_rels/workbook.xml.rels"], &rels); err != nil {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	for _, rel := range rels.Relationships {
+		if rel.ID != workbook.Sheets[0].ID {
+			continue
+		}
+		if strings.HasPrefix(rel.Target, "/") {
+			return strings.TrimPrefix(rel.Target, "/"), nil
+		}
+		return path.Join("xl", rel.Target), nil
+	}
+	return "xl/worksheets/sheet1.xml", nil
+}
+
+// readSharedStrings reads the workbook's shared string table
+func readSharedStrings(file *zip.File) ([]string, error) {
+	var table struct {
+		Items []richText `xml:"si"`
+	}
+	if err := decodeFile(file, &table); err != nil {
+		return nil, err
+	}
+	shared := make([]string, len(table.Items))
+	for i, item := range table.Items {
+		shared[i] = item.String()
+	}
+	return shared, nil
+}
+
+// richText is a string that may be split into differently formatted runs
+type richText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String joins the text of every run
+func (r richText) String() string {
+	var text strings.Builder
+	text.WriteString(r.Text)
+	for _, run := range r.Runs {
+		text.WriteString(run.Text)
+	}
+	return text.String()
+}
+
+// readSheet reads a worksheet's cells into rows, keeping empty cells in place
+func readSheet(file *zip.File, shared []string) ([][]string, error) {
+	var sheet struct {
+		Rows []struct {
+			Number int `xml:"r,attr"`
+			Cells  []struct {
+				Ref    string   `xml:"r,attr"`
+				Type   string   `xml:"t,attr"`
+				Value  string   `xml:"v"`
+				Inline richText `xml:"is"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeFile(file, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows [][]string
+	for i, row := range sheet.Rows {
+		number := row.Number
+		if number == 0 {
+			number = i + 1
+		}
+		for len(rows) < number-1 {
+			rows = append(rows, nil)
+		}
+
+		var cells []string
+		for j, cell := range row.Cells {
+			column := j
+			if cell.Ref != "" {
+				column = columnIndex(cell.Ref)
+			}
+			for len(cells) <= column {
+				cells = append(cells, "")
+			}
+
+			switch cell.Type {
+			case "s":
+				index, err := strconv.Atoi(cell.Value)
+				if err != nil || index < 0 || index >= len(shared) {
+					return nil, fmt.Errorf("cell %s refers to a missing string", cell.Ref)
+				}
+				cells[column] = shared[index]
+			case "inlineStr":
+				cells[column] = cell.Inline.String()
+			case "b":
+				cells[column] = map[string]string{"1": "TRUE", "0": "FALSE"}[cell.Value]
+			default:
+				cells[column] = cell.Value
+			}
+		}
+		rows = append(rows, cells)
+	}
+	return rows, nil
+}
+
+// columnIndex converts the letters of a cell reference such as "AB12" to a
+// zero-based column number
+func columnIndex(ref string) int {
+	index := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		index = index*26 + int(r-'A'+1)
+	}
+	return index - 1
+}
+
+// decodeFile unmarshals an XML file from the workbook archive
+func decodeFile(file *zip.File, v any) error {
+	if file == nil {
+		return errors.New("missing file")
+	}
+	reader, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return xml.NewDecoder(reader).Decode(v)
+}
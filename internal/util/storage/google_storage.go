@@ -8,9 +8,13 @@ import (
 
 	"google.golang.org/api/option"
 	"lostnfound-api/internal/config"
+	"net/url"
 	"time"
 )
 
+// ErrObjectNotExist is returned when the requested object does not exist in the bucket
+var ErrObjectNotExist = storage.ErrObjectNotExist
+
 // GoogleCloudStorage implements file storage using Google Cloud Storage
 type GoogleCloudStorage struct {
 	client     *storage.Client
@@ -73,6 +77,40 @@ func (g *GoogleCloudStorage) UploadFile(ctx context.Context, objectName string,
 	return url, nil
 }
 
+// UploadPrivateFile uploads a file that can only be read through a signed URL
+func (g *GoogleCloudStorage) UploadPrivateFile(ctx context.Context, objectName string, content io.Reader, contentType string) error {
+	w := g.client.Bucket(g.bucketName).Object(objectName).NewWriter(ctx)
+	w.ContentType = contentType
+
+	if _, err := io.Copy(w, content); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("io.Copy: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("Writer.Close: %w", err)
+	}
+	return nil
+}
+
+// GenerateDownloadURL generates a signed URL for downloading a private file
+// under the given file name
+func (g *GoogleCloudStorage) GenerateDownloadURL(objectName, filename string, expires time.Duration) (string, error) {
+	opts := &storage.SignedURLOptions{
+		Scheme:  storage.SigningSchemeV4,
+		Method:  "GET",
+		Expires: time.Now().Add(expires),
+		QueryParameters: url.Values{
+			"response-content-disposition": {fmt.Sprintf("attachment; filename=%q", filename)},
+		},
+	}
+
+	signed, err := g.client.Bucket(g.bucketName).SignedURL(objectName, opts)
+	if err != nil {
+		return "", fmt.Errorf("storage.SignedURL: %w", err)
+	}
+	return signed, nil
+}
+
 // GenerateSignedURL generates a signed URL for uploading a file directly
 func (g *GoogleCloudStorage) GenerateSignedURL(ctx context.Context, objectName string, contentType string) (string, error) {
 	opts := &storage.SignedURLOptions{
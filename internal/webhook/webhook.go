@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event types partners can subscribe to
+const (
+	EventItemCreated        = "item.created"
+	EventItemUpdated        = "item.updated"
+	EventClaimStatusChanged = "claim.status_changed"
+	EventItemMatch          = "item.match"
+)
+
+// EventTypes lists every event type in a stable order
+var EventTypes = []string{EventItemCreated, EventItemUpdated, EventClaimStatusChanged, EventItemMatch}
+
+// Request headers sent with every delivery
+const (
+	HeaderEvent     = "X-LostNFound-Event"
+	HeaderDelivery  = "X-LostNFound-Delivery"
+	HeaderSignature = "X-LostNFound-Signature"
+)
+
+// SignatureTolerance is how old a signed timestamp receivers should accept
+const SignatureTolerance = 5 * time.Minute
+
+// maxResponseBody bounds how much of a receiver's response is kept
+const maxResponseBody = 1024
+
+// Supported reports whether an event type can be subscribed to
+func Supported(eventType string) bool {
+	return slices.Contains(EventTypes, eventType)
+}
+
+// NewSecret generates a random signing secret
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
+
+// Sign returns the signature header value for a body sent at the given time:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Including the
+// timestamp lets receivers reject replayed deliveries.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	t := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + t + ",v1=" + digest(secret, t, body)
+}
+
+// Verify checks a signature header against a body, rejecting signatures
+// older than the tolerance. Receivers written in Go can use it directly.
+func Verify(secret, header string, body []byte, now time.Time) bool {
+	var t, v1 string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			t = value
+		case "v1":
+			v1 = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(t, 10, 64)
+	if err != nil || v1 == "" {
+		return false
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(v1), []byte(digest(secret, t, body)))
+}
+
+// digest computes the hex HMAC of a timestamp and body
+func digest(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Delivery is one signed POST of an event to a subscriber
+type Delivery struct {
+	ID        string
+	EventType string
+	URL       string
+	Secret    string
+	Body      []byte
+}
+
+// Result is the receiver's answer to a delivery
+type Result struct {
+	StatusCode int
+	Body       string
+	Duration   time.Duration
+}
+
+// Client sends deliveries over HTTP
+type Client struct {
+	http      *http.Client
+	userAgent string
+}
+
+// NewClient creates a new Client whose requests time out after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		http: &http.Client{
+			Timeout: timeout,
+			// Redirects could send the signed payload somewhere unexpected
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		userAgent: "LostNFound-Webhooks/1.0",
+	}
+}
+
+// Send posts a delivery and returns the receiver's response. Any status
+// outside 2xx is returned as an error along with the result.
+func (c *Client) Send(ctx context.Context, delivery Delivery) (*Result, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, time.Now(), delivery.Body))
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	result := &Result{StatusCode: resp.StatusCode, Body: string(body), Duration: time.Since(start)}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return result, fmt.Errorf("receiver responded with status %d", resp.StatusCode)
+	}
+	return result, nil
+}